@@ -0,0 +1,6 @@
+package ceiling
+
+type Ceiling struct {
+	CPU    string
+	Memory string
+}
@@ -0,0 +1,7 @@
+package certs
+
+type Certs struct {
+	Provision            string
+	TTL                  string
+	VersionBundleVersion string
+}
@@ -0,0 +1,6 @@
+package cloudconfig
+
+type CloudConfig struct {
+	Compress string
+	Secret   string
+}
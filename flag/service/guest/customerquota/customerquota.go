@@ -0,0 +1,9 @@
+package customerquota
+
+import (
+	"github.com/giantswarm/kvm-operator/flag/service/guest/customerquota/configmap"
+)
+
+type CustomerQuota struct {
+	ConfigMap configmap.ConfigMap
+}
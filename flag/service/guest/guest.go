@@ -1,7 +1,27 @@
 package guest
 
-import "github.com/giantswarm/kvm-operator/flag/service/guest/update"
+import (
+	"github.com/giantswarm/kvm-operator/flag/service/guest/ceiling"
+	"github.com/giantswarm/kvm-operator/flag/service/guest/certs"
+	"github.com/giantswarm/kvm-operator/flag/service/guest/cloudconfig"
+	"github.com/giantswarm/kvm-operator/flag/service/guest/customerquota"
+	"github.com/giantswarm/kvm-operator/flag/service/guest/imageprepull"
+	"github.com/giantswarm/kvm-operator/flag/service/guest/networkpolicy"
+	"github.com/giantswarm/kvm-operator/flag/service/guest/scale"
+	"github.com/giantswarm/kvm-operator/flag/service/guest/scaledown"
+	"github.com/giantswarm/kvm-operator/flag/service/guest/security"
+	"github.com/giantswarm/kvm-operator/flag/service/guest/update"
+)
 
 type Guest struct {
-	Update update.Update
+	Ceiling       ceiling.Ceiling
+	Certs         certs.Certs
+	CloudConfig   cloudconfig.CloudConfig
+	CustomerQuota customerquota.CustomerQuota
+	ImagePrePull  imageprepull.ImagePrePull
+	NetworkPolicy networkpolicy.NetworkPolicy
+	Scale         scale.Scale
+	ScaleDown     scaledown.ScaleDown
+	Security      security.Security
+	Update        update.Update
 }
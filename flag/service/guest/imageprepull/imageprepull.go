@@ -0,0 +1,7 @@
+package imageprepull
+
+type ImagePrePull struct {
+	BaseURL        string
+	Namespace      string
+	ServiceAccount string
+}
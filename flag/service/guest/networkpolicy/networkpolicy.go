@@ -0,0 +1,8 @@
+package networkpolicy
+
+type NetworkPolicy struct {
+	DNSNamespace               string
+	IngressControllerNamespace string
+	OperatorNamespace          string
+	PodCIDR                    string
+}
@@ -0,0 +1,5 @@
+package scale
+
+type Scale struct {
+	Enabled string
+}
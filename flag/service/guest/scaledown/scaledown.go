@@ -0,0 +1,6 @@
+package scaledown
+
+type ScaleDown struct {
+	MaxWorkers string
+	Policy     string
+}
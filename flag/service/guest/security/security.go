@@ -0,0 +1,5 @@
+package security
+
+type Security struct {
+	AppArmorProfile string
+}
@@ -2,4 +2,5 @@ package update
 
 type Update struct {
 	Enabled string
+	Surge   string
 }
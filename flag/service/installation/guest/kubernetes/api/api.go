@@ -1,9 +1,11 @@
 package api
 
 import (
+	"github.com/giantswarm/kvm-operator/flag/service/installation/guest/kubernetes/api/audit"
 	"github.com/giantswarm/kvm-operator/flag/service/installation/guest/kubernetes/api/auth"
 )
 
 type API struct {
-	Auth auth.Auth
+	Audit audit.Audit
+	Auth  auth.Auth
 }
@@ -0,0 +1,6 @@
+package audit
+
+type Audit struct {
+	HostVolume string
+	Policy     string
+}
@@ -0,0 +1,6 @@
+package pullsecret
+
+type PullSecret struct {
+	Name      string
+	Namespace string
+}
@@ -0,0 +1,10 @@
+package registry
+
+import (
+	"github.com/giantswarm/kvm-operator/flag/service/registry/pullsecret"
+)
+
+type Registry struct {
+	Mirror     string
+	PullSecret pullsecret.PullSecret
+}
@@ -4,10 +4,12 @@ import (
 	"github.com/giantswarm/kvm-operator/flag/service/guest"
 	"github.com/giantswarm/kvm-operator/flag/service/installation"
 	"github.com/giantswarm/kvm-operator/flag/service/kubernetes"
+	"github.com/giantswarm/kvm-operator/flag/service/registry"
 )
 
 type Service struct {
 	Guest        guest.Guest
 	Installation installation.Installation
 	Kubernetes   kubernetes.Kubernetes
+	Registry     registry.Registry
 }
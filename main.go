@@ -99,12 +99,35 @@ func main() {
 
 	daemonCommand := newCommand.DaemonCommand().CobraCommand()
 
+	daemonCommand.PersistentFlags().Bool(f.Service.Installation.Guest.Kubernetes.API.Audit.HostVolume, false, "Whether guest cluster API server audit logs are stored on a dedicated host volume of the master pods.")
+	daemonCommand.PersistentFlags().String(f.Service.Installation.Guest.Kubernetes.API.Audit.Policy, "", "Audit policy of guest cluster API servers. Defaults to logging all requests at the Metadata level.")
 	daemonCommand.PersistentFlags().String(f.Service.Installation.Guest.Kubernetes.API.Auth.Provider.OIDC.ClientID, "", "OIDC authorization provider ClientID.")
 	daemonCommand.PersistentFlags().String(f.Service.Installation.Guest.Kubernetes.API.Auth.Provider.OIDC.IssuerURL, "", "OIDC authorization provider IssuerURL.")
 	daemonCommand.PersistentFlags().String(f.Service.Installation.Guest.Kubernetes.API.Auth.Provider.OIDC.UsernameClaim, "", "OIDC authorization provider UsernameClaim.")
 	daemonCommand.PersistentFlags().String(f.Service.Installation.Guest.Kubernetes.API.Auth.Provider.OIDC.GroupsClaim, "", "OIDC authorization provider GroupsClaim.")
 
+	daemonCommand.PersistentFlags().String(f.Service.Guest.Ceiling.CPU, "", "Maximum number of CPUs the VMs of a single guest cluster may request in total. Guest clusters requesting more are held. No ceiling is applied when empty.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.Ceiling.Memory, "", "Maximum memory the VMs of a single guest cluster may request in total, including the overhead of QEMU, e.g. 256Gi. Guest clusters requesting more are held. No ceiling is applied when empty.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Guest.Certs.Provision, false, "Whether CertConfigs are created for guest clusters, so cert-operator issues their certificates.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.Certs.TTL, "4320h", "Time to live of the certificates issued for guest clusters.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.Certs.VersionBundleVersion, "0.1.0", "Version bundle version of the cert-operator issuing the certificates of guest clusters.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Guest.CloudConfig.Compress, false, "Whether the cloud-configs of guest cluster nodes are gzip compressed before they are base64 encoded. Requires a k8s-kvm image decoding the user data according to the CLOUD_CONFIG_ENCODING environment variable. Existing nodes are updated when this changes.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Guest.CloudConfig.Secret, false, "Whether the cloud-configs of guest cluster nodes are stored in secrets instead of config maps. Existing nodes switch to secrets on their next update.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.CustomerQuota.ConfigMap.Name, "", "Name of the config map defining the quotas of customers. Each key is a customer ID and its value the YAML encoded quota of the customer's guest clusters in total, e.g. cpus, memory, disk and clusters. Customer quotas are not enforced when empty.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.CustomerQuota.ConfigMap.Namespace, "giantswarm", "Namespace of the config map defining the quotas of customers.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.ImagePrePull.BaseURL, "https://stable.release.core-os.net/amd64-usr", "Location the Container Linux PXE images of guest clusters and their digests are downloaded from, e.g. a mirror of the Container Linux release server. Images are expected at <base URL>/<version>/.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.ImagePrePull.Namespace, "giantswarm", "Namespace of the daemon sets pre-pulling the Container Linux images of guest clusters. There is one daemon set per Container Linux version in use.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.ImagePrePull.ServiceAccount, "kvm-operator-image-prepull", "Service account of the pods pre-pulling the Container Linux images of guest clusters. It has to be allowed to use host path volumes.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.NetworkPolicy.DNSNamespace, "kube-system", "Namespace running the DNS of the host cluster, which guest cluster namespaces are allowed to resolve names with.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.NetworkPolicy.IngressControllerNamespace, "kube-system", "Namespace running the ingress controller of the host cluster, which is allowed to reach the master and worker services of guest clusters.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.NetworkPolicy.OperatorNamespace, "giantswarm", "Namespace running the operators of the host cluster, which guest cluster namespaces are allowed to exchange traffic with.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.NetworkPolicy.PodCIDR, "", "Pod network of the host cluster. Pods in guest cluster namespaces are allowed to reach addresses outside of it, e.g. the Kubernetes API. They are only allowed to reach the DNS, ingress controller and operator namespaces when empty.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Guest.Scale.Enabled, false, "Whether the status and scale subresources of the KVMConfig CRD are enabled, so guest cluster workers can be scaled using the scale subresource. Once enabled, the status of KVMConfigs can only be written using the status subresource. Disabling this later on does not disable the subresources again.")
+	daemonCommand.PersistentFlags().Int(f.Service.Guest.ScaleDown.MaxWorkers, 1, "Maximum number of guest cluster workers removed per reconciliation on scale down.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.ScaleDown.Policy, "newest", "Policy choosing the guest cluster workers removed on scale down of node pools and of workers added using the scale subresource. One of newest, oldest or least-loaded. Workers defined in the custom object spec are not subject to the policy, they are only removed when removed from the spec.")
+	daemonCommand.PersistentFlags().String(f.Service.Guest.Security.AppArmorProfile, "", "AppArmor profile of the unprivileged containers of guest cluster VM pods, e.g. runtime/default. No AppArmor profiles are set when empty.")
 	daemonCommand.PersistentFlags().Bool(f.Service.Guest.Update.Enabled, false, "Whether updates of guest cluster nodes are allowed to be processed upon reconciliation.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Guest.Update.Surge, false, "Whether guest cluster workers are replaced on updates, so a new worker is ready before the old one is drained and removed.")
 
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.Address, "http://127.0.0.1:6443", "Address used to connect to Kubernetes. When empty in-cluster config is created.")
 	daemonCommand.PersistentFlags().Bool(f.Service.Kubernetes.InCluster, false, "Whether to use the in-cluster config to authenticate with Kubernetes.")
@@ -112,5 +135,9 @@ func main() {
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.TLS.CrtFile, "", "Certificate file path to use to authenticate with Kubernetes.")
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.TLS.KeyFile, "", "Key file path to use to authenticate with Kubernetes.")
 
+	daemonCommand.PersistentFlags().String(f.Service.Registry.Mirror, "", "Registry all images generated by the operator are pulled from instead of their upstream registries. Used for installations without internet access.")
+	daemonCommand.PersistentFlags().String(f.Service.Registry.PullSecret.Name, "", "Name of the image pull secret propagated into the namespaces of guest clusters.")
+	daemonCommand.PersistentFlags().String(f.Service.Registry.PullSecret.Namespace, "", "Namespace of the image pull secret propagated into the namespaces of guest clusters.")
+
 	newCommand.CobraCommand().Execute()
 }
@@ -1,8 +1,11 @@
 package controller
 
 import (
+	"context"
+	"fmt"
 	"time"
 
+	"github.com/cenkalti/backoff"
 	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/apiextensions/pkg/clientset/versioned"
 	"github.com/giantswarm/certs"
@@ -12,13 +15,17 @@ import (
 	"github.com/giantswarm/operatorkit/controller"
 	"github.com/giantswarm/operatorkit/informer"
 	"github.com/giantswarm/randomkeys"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v10"
 	"github.com/giantswarm/kvm-operator/service/controller/v11"
 	"github.com/giantswarm/kvm-operator/service/controller/v12"
 	v12cloudconfig "github.com/giantswarm/kvm-operator/service/controller/v12/cloudconfig"
+	"github.com/giantswarm/kvm-operator/service/controller/v13"
+	v13cloudconfig "github.com/giantswarm/kvm-operator/service/controller/v13/cloudconfig"
 	"github.com/giantswarm/kvm-operator/service/controller/v2"
 	"github.com/giantswarm/kvm-operator/service/controller/v3"
 	"github.com/giantswarm/kvm-operator/service/controller/v4"
@@ -35,9 +42,81 @@ type ClusterConfig struct {
 	K8sExtClient apiextensionsclient.Interface
 	Logger       micrologger.Logger
 
-	GuestUpdateEnabled bool
-	OIDC               ClusterConfigOIDC
-	ProjectName        string
+	Audit                    ClusterConfigAudit
+	GuestCeiling             ClusterConfigGuestCeiling
+	GuestCerts               ClusterConfigGuestCerts
+	GuestCloudConfigCompress bool
+	GuestCloudConfigSecret   bool
+	GuestCustomerQuota       ClusterConfigGuestCustomerQuota
+	GuestImagePrePull        ClusterConfigGuestImagePrePull
+	GuestNetworkPolicy       ClusterConfigGuestNetworkPolicy
+	GuestScaleDown           ClusterConfigGuestScaleDown
+	GuestScaleEnabled        bool
+	GuestSecurity            ClusterConfigGuestSecurity
+	GuestUpdateEnabled       bool
+	GuestUpdateSurge         bool
+	OIDC                     ClusterConfigOIDC
+	ProjectName              string
+	Registry                 ClusterConfigRegistry
+}
+
+// ClusterConfigAudit represents the installation wide configuration of the
+// audit logs of guest cluster API servers.
+type ClusterConfigAudit struct {
+	HostVolume bool
+	Policy     string
+}
+
+// ClusterConfigGuestCeiling represents the maximum resources the VMs of a
+// single guest cluster may request in total.
+type ClusterConfigGuestCeiling struct {
+	CPU    string
+	Memory string
+}
+
+// ClusterConfigGuestCerts represents the configuration of how certificates of
+// guest clusters are provisioned.
+type ClusterConfigGuestCerts struct {
+	Provision            bool
+	TTL                  string
+	VersionBundleVersion string
+}
+
+// ClusterConfigGuestCustomerQuota represents the configuration of the quotas
+// limiting the resources of all guest clusters of a customer.
+type ClusterConfigGuestCustomerQuota struct {
+	ConfigMapName      string
+	ConfigMapNamespace string
+}
+
+// ClusterConfigGuestImagePrePull represents the configuration of the daemon
+// sets pre-pulling the Container Linux images of guest clusters.
+type ClusterConfigGuestImagePrePull struct {
+	BaseURL        string
+	Namespace      string
+	ServiceAccount string
+}
+
+// ClusterConfigGuestScaleDown represents the configuration of how guest
+// cluster workers are removed on scale down.
+type ClusterConfigGuestScaleDown struct {
+	MaxWorkers int
+	Policy     string
+}
+
+// ClusterConfigGuestNetworkPolicy represents the configuration of the network
+// policies isolating guest cluster namespaces.
+type ClusterConfigGuestNetworkPolicy struct {
+	DNSNamespace               string
+	IngressControllerNamespace string
+	OperatorNamespace          string
+	PodCIDR                    string
+}
+
+// ClusterConfigGuestSecurity represents the configuration of the security
+// profiles of guest cluster VM pods.
+type ClusterConfigGuestSecurity struct {
+	AppArmorProfile string
 }
 
 // ClusterConfigOIDC represents the configuration of the OIDC authorization
@@ -49,8 +128,45 @@ type ClusterConfigOIDC struct {
 	GroupsClaim   string
 }
 
+// ClusterConfigRegistry represents the configuration of the registry images
+// are pulled from.
+type ClusterConfigRegistry struct {
+	Mirror              string
+	PullSecretName      string
+	PullSecretNamespace string
+}
+
+// crdSubresourcesPatch enables the status and scale subresources of the
+// KVMConfig CRD. The scale subresource maps .spec.replicas to the number of
+// guest cluster workers not belonging to a node pool and reports the number of
+// available ones in .status.replicas.
+//
+// Note that enabling the status subresource changes who can write .status.
+// Updates of the custom object itself ignore any changes of .status from then
+// on, while writes to the /status endpoint ignore anything but .status. Only
+// clients allowed to write the kvmconfigs/status resource, like the operator,
+// can change the status. Clients which used to write the status along with
+// the spec have to write it using the /status endpoint instead.
+const crdSubresourcesPatch = `{
+  "spec": {
+    "subresources": {
+      "status": {},
+      "scale": {
+        "specReplicasPath": ".spec.replicas",
+        "statusReplicasPath": ".status.replicas"
+      }
+    }
+  }
+}`
+
 type Cluster struct {
 	*controller.Controller
+
+	crd          *apiextensionsv1beta1.CustomResourceDefinition
+	crdClient    *k8scrdclient.CRDClient
+	k8sExtClient apiextensionsclient.Interface
+	logger       micrologger.Logger
+	scaleEnabled bool
 }
 
 func NewCluster(config ClusterConfig) (*Cluster, error) {
@@ -323,6 +439,58 @@ func NewCluster(config ClusterConfig) (*Cluster, error) {
 		}
 	}
 
+	var resourceSetV13 *controller.ResourceSet
+	{
+		c := v13.ClusterResourceSetConfig{
+			CertsSearcher:      certsSearcher,
+			G8sClient:          config.G8sClient,
+			K8sClient:          config.K8sClient,
+			Logger:             config.Logger,
+			RandomkeysSearcher: randomkeysSearcher,
+
+			Audit: v13cloudconfig.AuditConfig{
+				HostVolume: config.Audit.HostVolume,
+				Policy:     config.Audit.Policy,
+			},
+			GuestCeilingCPU:                              config.GuestCeiling.CPU,
+			GuestCeilingMemory:                           config.GuestCeiling.Memory,
+			GuestCertsProvision:                          config.GuestCerts.Provision,
+			GuestCertsTTL:                                config.GuestCerts.TTL,
+			GuestCertsVersionBundleVersion:               config.GuestCerts.VersionBundleVersion,
+			GuestCloudConfigCompress:                     config.GuestCloudConfigCompress,
+			GuestCloudConfigSecret:                       config.GuestCloudConfigSecret,
+			GuestAppArmorProfile:                         config.GuestSecurity.AppArmorProfile,
+			GuestNetworkPolicyDNSNamespace:               config.GuestNetworkPolicy.DNSNamespace,
+			GuestNetworkPolicyIngressControllerNamespace: config.GuestNetworkPolicy.IngressControllerNamespace,
+			GuestNetworkPolicyOperatorNamespace:          config.GuestNetworkPolicy.OperatorNamespace,
+			GuestNetworkPolicyPodCIDR:                    config.GuestNetworkPolicy.PodCIDR,
+			GuestCustomerQuotaConfigMapName:              config.GuestCustomerQuota.ConfigMapName,
+			GuestCustomerQuotaConfigMapNamespace:         config.GuestCustomerQuota.ConfigMapNamespace,
+			GuestImagePrePullBaseURL:                     config.GuestImagePrePull.BaseURL,
+			GuestImagePrePullNamespace:                   config.GuestImagePrePull.Namespace,
+			GuestImagePrePullServiceAccount:              config.GuestImagePrePull.ServiceAccount,
+			GuestUpdateEnabled:                           config.GuestUpdateEnabled,
+			GuestUpdateSurge:                             config.GuestUpdateSurge,
+			ProjectName:                                  config.ProjectName,
+			OIDC: v13cloudconfig.OIDCConfig{
+				ClientID:      config.OIDC.ClientID,
+				IssuerURL:     config.OIDC.IssuerURL,
+				UsernameClaim: config.OIDC.UsernameClaim,
+				GroupsClaim:   config.OIDC.GroupsClaim,
+			},
+			RegistryMirror:              config.Registry.Mirror,
+			RegistryPullSecretName:      config.Registry.PullSecretName,
+			RegistryPullSecretNamespace: config.Registry.PullSecretNamespace,
+			ScaleDownMaxWorkers:         config.GuestScaleDown.MaxWorkers,
+			ScaleDownPolicy:             config.GuestScaleDown.Policy,
+		}
+
+		resourceSetV13, err = v13.NewClusterResourceSet(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var resourceRouter *controller.ResourceRouter
 	{
 		c := controller.ResourceRouterConfig{
@@ -340,6 +508,7 @@ func NewCluster(config ClusterConfig) (*Cluster, error) {
 				resourceSetV10,
 				resourceSetV11,
 				resourceSetV12,
+				resourceSetV13,
 			},
 		}
 
@@ -349,10 +518,12 @@ func NewCluster(config ClusterConfig) (*Cluster, error) {
 		}
 	}
 
+	crd := v1alpha1.NewKVMConfigCRD()
+
 	var operatorkitController *controller.Controller
 	{
 		c := controller.Config{
-			CRD:            v1alpha1.NewKVMConfigCRD(),
+			CRD:            crd,
 			CRDClient:      crdClient,
 			Informer:       newInformer,
 			Logger:         config.Logger,
@@ -370,7 +541,56 @@ func NewCluster(config ClusterConfig) (*Cluster, error) {
 
 	c := &Cluster{
 		Controller: operatorkitController,
+
+		crd:          crd,
+		crdClient:    crdClient,
+		k8sExtClient: config.K8sExtClient,
+		logger:       config.Logger,
+		scaleEnabled: config.GuestScaleEnabled,
 	}
 
 	return c, nil
 }
+
+// Boot enables the subresources of the KVMConfig CRD in case scaling is
+// enabled and boots the controller afterwards. The vendored CRD types do not
+// support subresources yet, which is why they are enabled by patching the CRD
+// once it got created. See crdSubresourcesPatch for how this changes writing
+// the status of KVMConfigs.
+func (c *Cluster) Boot() {
+	ctx := context.Background()
+
+	if !c.scaleEnabled {
+		c.logger.LogCtx(ctx, "level", "debug", "message", "not enabling CRD subresources: scaling is disabled")
+		c.Controller.Boot()
+		return
+	}
+
+	operation := func() error {
+		err := c.crdClient.EnsureCreated(ctx, c.crd, controller.DefaultBackOffFactory()())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		_, err = c.k8sExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Patch(c.crd.GetName(), types.MergePatchType, []byte(crdSubresourcesPatch))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	notifier := func(err error, d time.Duration) {
+		c.logger.LogCtx(ctx, "level", "warning", "message", "retrying enabling CRD subresources due to error", "stack", fmt.Sprintf("%#v", err))
+	}
+
+	err := backoff.RetryNotify(operation, controller.DefaultBackOffFactory()(), notifier)
+	if err != nil {
+		// Subresources are not supported by all Kubernetes versions. The
+		// controller works without them, only scaling via the scale subresource
+		// is not available then.
+		c.logger.LogCtx(ctx, "level", "error", "message", "failed enabling CRD subresources", "stack", fmt.Sprintf("%#v", err))
+	}
+
+	c.Controller.Boot()
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/giantswarm/apiextensions/pkg/clientset/versioned"
+	"github.com/giantswarm/certs"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/giantswarm/operatorkit/controller"
@@ -15,6 +16,7 @@ import (
 	"github.com/giantswarm/kvm-operator/service/controller/v11"
 	"github.com/giantswarm/kvm-operator/service/controller/v11/key"
 	"github.com/giantswarm/kvm-operator/service/controller/v12"
+	"github.com/giantswarm/kvm-operator/service/controller/v13"
 )
 
 type DrainerConfig struct {
@@ -91,6 +93,21 @@ func NewDrainer(config DrainerConfig) (*Drainer, error) {
 func newDrainerResourceRouter(config DrainerConfig) (*controller.ResourceRouter, error) {
 	var err error
 
+	var certsSearcher certs.Interface
+	{
+		c := certs.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+
+			WatchTimeout: 5 * time.Second,
+		}
+
+		certsSearcher, err = certs.NewSearcher(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var resourceSetV11 *controller.ResourceSet
 	{
 		c := v11.DrainerResourceSetConfig{
@@ -123,6 +140,23 @@ func newDrainerResourceRouter(config DrainerConfig) (*controller.ResourceRouter,
 		}
 	}
 
+	var resourceSetV13 *controller.ResourceSet
+	{
+		c := v13.DrainerResourceSetConfig{
+			CertsSearcher: certsSearcher,
+			G8sClient:     config.G8sClient,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+
+			ProjectName: config.ProjectName,
+		}
+
+		resourceSetV13, err = v13.NewDrainerResourceSet(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var resourceRouter *controller.ResourceRouter
 	{
 		c := controller.ResourceRouterConfig{
@@ -131,6 +165,7 @@ func newDrainerResourceRouter(config DrainerConfig) (*controller.ResourceRouter,
 			ResourceSets: []*controller.ResourceSet{
 				resourceSetV11,
 				resourceSetV12,
+				resourceSetV13,
 			},
 		}
 
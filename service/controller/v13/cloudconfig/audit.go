@@ -0,0 +1,174 @@
+package cloudconfig
+
+import (
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	k8scloudconfig "github.com/giantswarm/k8scloudconfig/v_3_2_5"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// auditPolicyPath is the path of the audit policy the master template
+	// configures the API server with. Extension files are written after the
+	// files of the template, so writing the same path replaces the default
+	// policy of the template.
+	auditPolicyPath = "/etc/kubernetes/manifests/audit-policy.yml"
+
+	auditPolicyAPIVersion = "audit.k8s.io/v1beta1"
+	auditPolicyKind       = "Policy"
+
+	// auditHostVolumeUnit mounts the audit log share of the VM onto the
+	// directory the API server writes its audit logs to. The k8s-kvm container
+	// shares its dedicated audit log host volume with the VM, see the
+	// deployment resource. This makes the audit logs available on the host,
+	// where they can be shipped without access to the guest cluster. The API
+	// server rotates the audit logs itself, as configured by the master
+	// template.
+	auditHostVolumeUnit = `[Unit]
+Description=Mount for API server audit log volume
+Before=k8s-kubelet.service
+
+[Mount]
+What=auditlogshare
+Where=/var/log/apiserver
+Options=trans=virtio,version=9p2000.L,cache=mmap
+Type=9p
+
+[Install]
+WantedBy=multi-user.target
+`
+	auditHostVolumeUnitName = "var-log-apiserver.mount"
+)
+
+var (
+	auditLevels = []string{
+		"None",
+		"Metadata",
+		"Request",
+		"RequestResponse",
+	}
+)
+
+// AuditConfig represents the installation wide configuration of the audit
+// logs of guest cluster API servers. Policy replaces the default audit policy
+// of the master template, if not empty.
+type AuditConfig struct {
+	HostVolume bool
+	Policy     string
+}
+
+// auditPolicy is the part of an audit policy being validated before it is put
+// into the cloud-config of masters.
+type auditPolicy struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Rules      []struct {
+		Level string `json:"level"`
+	} `json:"rules"`
+}
+
+// newAuditAssets returns the files and units of the master cloud-config
+// configuring the audit logs of the given guest cluster. Settings of the
+// custom object take precedence over the installation wide audit
+// configuration.
+func (c *CloudConfig) newAuditAssets(customObject v1alpha1.KVMConfig) (extraAssets, error) {
+	var assets extraAssets
+
+	audit, err := key.AuditFromAnnotation(customObject)
+	if err != nil {
+		return extraAssets{}, microerror.Mask(err)
+	}
+
+	policy := c.audit.Policy
+	if audit.PolicyConfigMap != "" {
+		m, err := c.k8sClient.CoreV1().ConfigMaps(audit.Namespace).Get(audit.PolicyConfigMap, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return extraAssets{}, microerror.Maskf(notFoundError, "audit policy config map '%s/%s'", audit.Namespace, audit.PolicyConfigMap)
+		} else if err != nil {
+			return extraAssets{}, microerror.Mask(err)
+		}
+
+		policy = m.Data[key.AuditPolicyConfigMapKey]
+
+		err = validateAuditPolicy(policy)
+		if err != nil {
+			return extraAssets{}, microerror.Maskf(invalidAuditPolicyError, "audit policy config map '%s/%s': %s", audit.Namespace, audit.PolicyConfigMap, err)
+		}
+	}
+
+	if policy != "" {
+		f := k8scloudconfig.FileAsset{
+			Metadata: k8scloudconfig.FileMetadata{
+				Path:        auditPolicyPath,
+				Owner:       extraFileOwner,
+				Permissions: extraFilePermissions,
+			},
+			// The policy is not rendered as template, because it is not meant to
+			// contain any.
+			Content: strings.Split(strings.TrimSuffix(policy, "\n"), "\n"),
+		}
+		assets.Files = append(assets.Files, f)
+	}
+
+	hostVolume, err := key.AuditHostVolume(customObject, c.audit.HostVolume)
+	if err != nil {
+		return extraAssets{}, microerror.Mask(err)
+	}
+
+	if hostVolume {
+		u := k8scloudconfig.UnitAsset{
+			Metadata: k8scloudconfig.UnitMetadata{
+				Name:    auditHostVolumeUnitName,
+				Enable:  true,
+				Command: "start",
+			},
+			Content: strings.Split(auditHostVolumeUnit, "\n"),
+		}
+		assets.Units = append(assets.Units, u)
+	}
+
+	return assets, nil
+}
+
+// validateAuditPolicy ensures the given audit policy is one the API server of
+// guest clusters accepts, so an invalid policy cannot break the API server.
+func validateAuditPolicy(policy string) error {
+	var p auditPolicy
+	err := yaml.Unmarshal([]byte(policy), &p)
+	if err != nil {
+		return microerror.Maskf(invalidAuditPolicyError, "audit policy must be YAML or JSON: %s", err)
+	}
+
+	if p.APIVersion != auditPolicyAPIVersion {
+		return microerror.Maskf(invalidAuditPolicyError, "apiVersion '%s' of audit policy must be '%s'", p.APIVersion, auditPolicyAPIVersion)
+	}
+	if p.Kind != auditPolicyKind {
+		return microerror.Maskf(invalidAuditPolicyError, "kind '%s' of audit policy must be '%s'", p.Kind, auditPolicyKind)
+	}
+	if len(p.Rules) == 0 {
+		return microerror.Maskf(invalidAuditPolicyError, "audit policy must define rules")
+	}
+	for i, r := range p.Rules {
+		if !containsString(auditLevels, r.Level) {
+			return microerror.Maskf(invalidAuditPolicyError, "level '%s' of audit policy rule %d must be one of %s", r.Level, i, strings.Join(auditLevels, ", "))
+		}
+	}
+
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,223 @@
+package cloudconfig
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	testAuditPolicy = `apiVersion: audit.k8s.io/v1beta1
+kind: Policy
+rules:
+- level: None
+  users: ["system:kube-proxy"]
+- level: Metadata
+`
+	testCustomerAuditPolicy = `apiVersion: audit.k8s.io/v1beta1
+kind: Policy
+rules:
+- level: RequestResponse
+`
+)
+
+func Test_CloudConfig_newAuditAssets(t *testing.T) {
+	testCases := []struct {
+		Audit           AuditConfig
+		Annotation      string
+		Data            map[string]string
+		ExpectedPolicy  []string
+		ExpectedUnits   []string
+		ExpectedErrorFn func(error) bool
+	}{
+		// Test 0 ensures the default policy of the master template is kept
+		// without any configuration.
+		{
+			Audit:      AuditConfig{},
+			Annotation: "",
+		},
+
+		// Test 1 ensures the installation wide policy replaces the default
+		// policy of the master template.
+		{
+			Audit:      AuditConfig{Policy: testAuditPolicy},
+			Annotation: "",
+			ExpectedPolicy: []string{
+				"apiVersion: audit.k8s.io/v1beta1",
+				"kind: Policy",
+				"rules:",
+				"- level: None",
+				`  users: ["system:kube-proxy"]`,
+				"- level: Metadata",
+			},
+		},
+
+		// Test 2 ensures the policy of the referenced config map takes
+		// precedence over the installation wide policy.
+		{
+			Audit:      AuditConfig{Policy: testAuditPolicy},
+			Annotation: `{"policyConfigMap": "audit"}`,
+			Data: map[string]string{
+				key.AuditPolicyConfigMapKey: testCustomerAuditPolicy,
+			},
+			ExpectedPolicy: []string{
+				"apiVersion: audit.k8s.io/v1beta1",
+				"kind: Policy",
+				"rules:",
+				"- level: RequestResponse",
+			},
+		},
+
+		// Test 3 ensures audit logs are stored on the host volume as configured
+		// installation wide.
+		{
+			Audit:         AuditConfig{HostVolume: true},
+			Annotation:    "",
+			ExpectedUnits: []string{auditHostVolumeUnitName},
+		},
+
+		// Test 4 ensures the annotation disables storing audit logs on the host
+		// volume.
+		{
+			Audit:      AuditConfig{HostVolume: true},
+			Annotation: `{"hostVolume": false}`,
+		},
+
+		// Test 5 ensures the annotation enables storing audit logs on the host
+		// volume.
+		{
+			Audit:         AuditConfig{},
+			Annotation:    `{"hostVolume": true}`,
+			ExpectedUnits: []string{auditHostVolumeUnitName},
+		},
+
+		// Test 6 ensures a missing config map is reported.
+		{
+			Annotation:      `{"policyConfigMap": "missing"}`,
+			ExpectedErrorFn: IsNotFound,
+		},
+
+		// Test 7 ensures a config map without policy is rejected.
+		{
+			Annotation: `{"policyConfigMap": "audit"}`,
+			Data: map[string]string{
+				"policy.yml": testCustomerAuditPolicy,
+			},
+			ExpectedErrorFn: IsInvalidAuditPolicy,
+		},
+
+		// Test 8 ensures policies of unknown kinds are rejected.
+		{
+			Annotation: `{"policyConfigMap": "audit"}`,
+			Data: map[string]string{
+				key.AuditPolicyConfigMapKey: "apiVersion: audit.k8s.io/v1beta1\nkind: Pod\nrules:\n- level: Metadata",
+			},
+			ExpectedErrorFn: IsInvalidAuditPolicy,
+		},
+
+		// Test 9 ensures policies with unknown levels are rejected.
+		{
+			Annotation: `{"policyConfigMap": "audit"}`,
+			Data: map[string]string{
+				key.AuditPolicyConfigMapKey: "apiVersion: audit.k8s.io/v1beta1\nkind: Policy\nrules:\n- level: Everything",
+			},
+			ExpectedErrorFn: IsInvalidAuditPolicy,
+		},
+
+		// Test 10 ensures an invalid annotation is rejected.
+		{
+			Annotation:      `{"namespace": "default"}`,
+			ExpectedErrorFn: key.IsInvalidAnnotation,
+		},
+
+		// Test 11 ensures config maps of other namespaces are rejected.
+		{
+			Annotation:      `{"policyConfigMap": "audit", "namespace": "kube-system"}`,
+			ExpectedErrorFn: key.IsInvalidAnnotation,
+		},
+	}
+
+	for i, tc := range testCases {
+		k8sClient := fake.NewSimpleClientset()
+		if tc.Data != nil {
+			m := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "audit",
+					Namespace: "default",
+				},
+				Data: tc.Data,
+			}
+			_, err := k8sClient.CoreV1().ConfigMaps("default").Create(m)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		c := DefaultConfig()
+		c.K8sClient = k8sClient
+		c.Logger = microloggertest.New()
+		c.Audit = tc.Audit
+		cloudConfig, err := New(c)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		customObject := v1alpha1.KVMConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Annotations: map[string]string{
+					key.AnnotationAudit: tc.Annotation,
+				},
+			},
+		}
+
+		assets, err := cloudConfig.newAuditAssets(customObject)
+		if tc.ExpectedErrorFn != nil {
+			if !tc.ExpectedErrorFn(err) {
+				t.Fatalf("case %d expected error got %#v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		var policy []string
+		for _, f := range assets.Files {
+			if f.Metadata.Path != auditPolicyPath {
+				t.Fatalf("case %d expected %#v got %#v", i, auditPolicyPath, f.Metadata.Path)
+			}
+			policy = f.Content
+		}
+		if !reflect.DeepEqual(policy, tc.ExpectedPolicy) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedPolicy, policy)
+		}
+
+		var units []string
+		for _, u := range assets.Units {
+			units = append(units, u.Metadata.Name)
+		}
+		if !reflect.DeepEqual(units, tc.ExpectedUnits) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedUnits, units)
+		}
+	}
+}
+
+func Test_CloudConfig_New_AuditPolicy(t *testing.T) {
+	c := DefaultConfig()
+	c.K8sClient = fake.NewSimpleClientset()
+	c.Logger = microloggertest.New()
+	c.Audit = AuditConfig{Policy: "kind: Policy"}
+
+	_, err := New(c)
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error got %#v", err)
+	}
+}
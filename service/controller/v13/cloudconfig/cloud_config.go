@@ -1,10 +1,18 @@
 package cloudconfig
 
 import (
-	"fmt"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"regexp"
+	"strings"
 
+	k8scloudconfig "github.com/giantswarm/k8scloudconfig/v_3_2_5"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
 const (
@@ -12,12 +20,33 @@ const (
 	FilePermission = 0700
 )
 
+// upstreamRegistries are the registries images referenced in the rendered
+// cloud configs are pulled from. Images of these registries are rewritten to
+// be pulled from the registry mirror if one is configured.
+var upstreamRegistries = []string{
+	"docker.io",
+	"gcr.io",
+	"k8s.gcr.io",
+	"quay.io",
+}
+
+// imageRegexp matches references of images of the upstream registries. The
+// registry must not be preceded by a slash, so that e.g. URLs of the upstream
+// registries in user provided files stay untouched.
+var imageRegexp = newImageRegexp(upstreamRegistries)
+
 // Config represents the configuration used to create a cloud config service.
 type Config struct {
 	// Dependencies.
-	Logger micrologger.Logger
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
 
-	OIDC OIDCConfig
+	Audit AuditConfig
+	// Compress makes the rendered cloud configs gzip compressed before they
+	// are base64 encoded. The k8s-kvm image must support decoding them.
+	Compress       bool
+	OIDC           OIDCConfig
+	RegistryMirror string
 }
 
 // DefaultConfig provides a default configuration to create a new cloud config
@@ -25,16 +54,21 @@ type Config struct {
 func DefaultConfig() Config {
 	return Config{
 		// Dependencies.
-		Logger: nil,
+		K8sClient: nil,
+		Logger:    nil,
 	}
 }
 
 // CloudConfig implements the cloud config service interface.
 type CloudConfig struct {
 	// Dependencies.
-	logger micrologger.Logger
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
 
-	k8sAPIExtraArgs []string
+	audit          AuditConfig
+	compress       bool
+	oidc           OIDCConfig
+	registryMirror string
 }
 
 // OIDCConfig represents the configuration of the OIDC authorization provider
@@ -48,32 +82,86 @@ type OIDCConfig struct {
 // New creates a new configured cloud config service.
 func New(config Config) (*CloudConfig, error) {
 	// Dependencies.
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "k8s client must not be empty")
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "logger must not be empty")
 	}
 
-	var k8sAPIExtraArgs []string
-	{
-		if config.OIDC.ClientID != "" {
-			k8sAPIExtraArgs = append(k8sAPIExtraArgs, fmt.Sprintf("--oidc-client-id=%s", config.OIDC.ClientID))
-		}
-		if config.OIDC.IssuerURL != "" {
-			k8sAPIExtraArgs = append(k8sAPIExtraArgs, fmt.Sprintf("--oidc-issuer-url=%s", config.OIDC.IssuerURL))
-		}
-		if config.OIDC.UsernameClaim != "" {
-			k8sAPIExtraArgs = append(k8sAPIExtraArgs, fmt.Sprintf("--oidc-username-claim=%s", config.OIDC.UsernameClaim))
-		}
-		if config.OIDC.GroupsClaim != "" {
-			k8sAPIExtraArgs = append(k8sAPIExtraArgs, fmt.Sprintf("--oidc-groups-claim=%s", config.OIDC.GroupsClaim))
+	if config.Audit.Policy != "" {
+		err := validateAuditPolicy(config.Audit.Policy)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "audit policy: %s", err)
 		}
 	}
 
 	newCloudConfig := &CloudConfig{
 		// Dependencies.
-		logger: config.Logger,
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
 
-		k8sAPIExtraArgs: k8sAPIExtraArgs,
+		audit:          config.Audit,
+		compress:       config.Compress,
+		oidc:           config.OIDC,
+		registryMirror: config.RegistryMirror,
 	}
 
 	return newCloudConfig, nil
 }
+
+// Encoding returns the encoding of the cloud configs rendered by this service
+// as described by key.CloudConfigEncoding.
+func (c *CloudConfig) Encoding() string {
+	return key.CloudConfigEncoding(c.compress)
+}
+
+// encode returns the rendered cloud config base64 encoded. The cloud config is
+// gzip compressed beforehand in case compression is enabled. Images are
+// rewritten to be pulled from the registry mirror, if configured.
+func (c *CloudConfig) encode(cloudConfig *k8scloudconfig.CloudConfig) (string, error) {
+	content := rewriteImages(cloudConfig.String(), c.registryMirror)
+
+	if !c.compress {
+		return base64.StdEncoding.EncodeToString([]byte(content)), nil
+	}
+
+	var b bytes.Buffer
+	{
+		w := gzip.NewWriter(&b)
+
+		_, err := w.Write([]byte(content))
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		err = w.Close()
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+	}
+
+	return base64.StdEncoding.EncodeToString(b.Bytes()), nil
+}
+
+// rewriteImages replaces the registry of all images of the upstream registries
+// referenced in the given content with the given mirror. The content is
+// returned unchanged in case the mirror is empty.
+func rewriteImages(content, mirror string) string {
+	if mirror == "" {
+		return content
+	}
+
+	return imageRegexp.ReplaceAllStringFunc(content, func(m string) string {
+		sub := imageRegexp.FindStringSubmatch(m)
+		return sub[1] + key.ImageWithRegistryMirror(sub[2], mirror)
+	})
+}
+
+func newImageRegexp(registries []string) *regexp.Regexp {
+	var quoted []string
+	for _, r := range registries {
+		quoted = append(quoted, regexp.QuoteMeta(r))
+	}
+
+	return regexp.MustCompile(`(^|[^\w./-])((?:` + strings.Join(quoted, "|") + `)/[\w./-]+)`)
+}
@@ -0,0 +1,141 @@
+package cloudconfig
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_CloudConfig_rewriteImages(t *testing.T) {
+	testCases := []struct {
+		Content         string
+		Mirror          string
+		ExpectedContent string
+	}{
+		// Test 0 ensures the content is not changed without a mirror.
+		{
+			Content:         "image: quay.io/giantswarm/hyperkube:v1.10.1",
+			Mirror:          "",
+			ExpectedContent: "image: quay.io/giantswarm/hyperkube:v1.10.1",
+		},
+
+		// Test 1 ensures images are rewritten to be pulled from the mirror.
+		{
+			Content:         "image: quay.io/giantswarm/hyperkube:v1.10.1\nExecStart=/usr/bin/docker run quay.io/coreos/etcd:v3.3.3",
+			Mirror:          "registry.example.com/",
+			ExpectedContent: "image: registry.example.com/giantswarm/hyperkube:v1.10.1\nExecStart=/usr/bin/docker run registry.example.com/coreos/etcd:v3.3.3",
+		},
+
+		// Test 2 ensures images of all upstream registries are rewritten, while
+		// URLs of the upstream registries are not.
+		{
+			Content:         "image: quay.io/example/app:1.0.0\nimage: gcr.io/google_containers/defaultbackend:1.0\nurl: https://quay.io/giantswarm/hyperkube",
+			Mirror:          "registry.example.com",
+			ExpectedContent: "image: registry.example.com/example/app:1.0.0\nimage: registry.example.com/google_containers/defaultbackend:1.0\nurl: https://quay.io/giantswarm/hyperkube",
+		},
+	}
+
+	for i, tc := range testCases {
+		content := rewriteImages(tc.Content, tc.Mirror)
+		if content != tc.ExpectedContent {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedContent, content)
+		}
+	}
+}
+
+func Test_CloudConfig_NewTemplates_RegistryMirror(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Masters: []v1alpha1.ClusterNode{
+					{ID: "m1"},
+				},
+				Workers: []v1alpha1.ClusterNode{
+					{ID: "w1"},
+				},
+			},
+			KVM: v1alpha1.KVMConfigSpecKVM{
+				Masters: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 1, Memory: "1G"},
+				},
+				Workers: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 1, Memory: "1G"},
+				},
+			},
+		},
+	}
+
+	workers, err := key.WorkerNodes(customObject)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	for _, compress := range []bool{false, true} {
+		var cloudConfig *CloudConfig
+		{
+			c := DefaultConfig()
+
+			c.K8sClient = fake.NewSimpleClientset()
+			c.Logger = microloggertest.New()
+			c.Compress = compress
+			c.RegistryMirror = "registry.example.com"
+
+			cloudConfig, err = New(c)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+		}
+
+		master, err := cloudConfig.NewMasterTemplate(customObject, certs.Cluster{}, customObject.Spec.Cluster.Masters[0], []key.EncryptionKey{{Name: "key1", Secret: "c2VjcmV0"}})
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		worker, err := cloudConfig.NewWorkerTemplate(customObject, certs.Cluster{}, workers[0])
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+
+		for _, encoded := range []string{master, worker} {
+			content := decodeCloudConfig(t, encoded, cloudConfig.Encoding())
+
+			if !strings.Contains(content, "registry.example.com/giantswarm/hyperkube") {
+				t.Fatalf("expected %#v to be rewritten", "quay.io/giantswarm/hyperkube")
+			}
+			if imageRegexp.MatchString(content) {
+				t.Fatalf("expected no images of %#v got %#v", upstreamRegistries, imageRegexp.FindString(content))
+			}
+		}
+	}
+}
+
+func decodeCloudConfig(t *testing.T, encoded, encoding string) string {
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	if encoding == key.CloudConfigEncodingBase64 {
+		return string(b)
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	return string(content)
+}
@@ -2,6 +2,7 @@ package cloudconfigtest
 
 import (
 	"github.com/giantswarm/micrologger/microloggertest"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v13/cloudconfig"
 )
@@ -9,6 +10,7 @@ import (
 func New() *cloudconfig.CloudConfig {
 	c := cloudconfig.DefaultConfig()
 
+	c.K8sClient = fake.NewSimpleClientset()
 	c.Logger = microloggertest.New()
 
 	newCloudConfig, err := cloudconfig.New(c)
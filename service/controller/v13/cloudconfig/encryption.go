@@ -0,0 +1,109 @@
+package cloudconfig
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	k8scloudconfig "github.com/giantswarm/k8scloudconfig/v_3_2_5"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/randomkeys"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// encryptionConfigPath is the path of the encryption config the master
+	// template configures the API server with. Extension files are written
+	// after the files of the template, so writing the same path replaces the
+	// encryption config of the template.
+	encryptionConfigPath = "/etc/kubernetes/encryption/k8s-encryption-config.yaml"
+
+	encryptionConfigPermissions = 0600
+
+	encryptionConfigTemplate = `kind: EncryptionConfig
+apiVersion: v1
+resources:
+  - resources:
+    - secrets
+    providers:
+    - aescbc:
+        keys:
+{{- range . }}
+        - name: {{ .Name }}
+          secret: {{ .Secret }}
+{{- end }}
+    - identity: {}`
+)
+
+// EncryptionKeys returns the keys of the encryption config of the API server
+// of the given guest cluster. The keys are read from the secret the given
+// random keys were found in, which also tracks the rotation of the keys. In
+// case the secret does not exist anymore, the given random keys are used.
+func (c *CloudConfig) EncryptionKeys(clusterID string, randomKeys randomkeys.Cluster) ([]key.EncryptionKey, error) {
+	o := metav1.ListOptions{
+		LabelSelector: key.EncryptionKeySecretSelector(clusterID),
+	}
+	list, err := c.k8sClient.CoreV1().Secrets(randomkeys.SecretNamespace).List(o)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if len(list.Items) == 0 {
+		keys := []key.EncryptionKey{
+			{
+				Name:   key.EncryptionKeyDefaultName,
+				Secret: string(randomKeys.APIServerEncryptionKey),
+			},
+		}
+
+		return keys, nil
+	}
+
+	keys, err := key.EncryptionKeysFromSecret(&list.Items[0])
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return keys, nil
+}
+
+// newEncryptionAssets returns the encryption config of the master
+// cloud-config in case the given keys differ from the single key the master
+// template renders. This way masters of guest clusters whose key has never
+// been rotated are not rolled.
+func newEncryptionAssets(keys []key.EncryptionKey) (extraAssets, error) {
+	if len(keys) == 0 {
+		return extraAssets{}, microerror.Maskf(invalidConfigError, "encryption keys must not be empty")
+	}
+	if len(keys) == 1 && keys[0].Name == key.EncryptionKeyDefaultName {
+		return extraAssets{}, nil
+	}
+
+	t, err := template.New("encryptionConfig").Parse(encryptionConfigTemplate)
+	if err != nil {
+		return extraAssets{}, microerror.Mask(err)
+	}
+
+	var b bytes.Buffer
+	err = t.Execute(&b, keys)
+	if err != nil {
+		return extraAssets{}, microerror.Mask(err)
+	}
+
+	f := k8scloudconfig.FileAsset{
+		Metadata: k8scloudconfig.FileMetadata{
+			Path:        encryptionConfigPath,
+			Owner:       extraFileOwner,
+			Permissions: encryptionConfigPermissions,
+		},
+		Content: strings.Split(b.String(), "\n"),
+	}
+
+	assets := extraAssets{
+		Files: []k8scloudconfig.FileAsset{f},
+	}
+
+	return assets, nil
+}
@@ -0,0 +1,95 @@
+package cloudconfig
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_CloudConfig_newEncryptionAssets(t *testing.T) {
+	testCases := []struct {
+		Keys            []key.EncryptionKey
+		ExpectedConfig  []string
+		ExpectedErrorFn func(error) bool
+	}{
+		// Test 0 ensures the encryption config of the master template is kept
+		// for keys never rotated.
+		{
+			Keys: []key.EncryptionKey{
+				{Name: "key1", Secret: "b2xk"},
+			},
+			ExpectedConfig: nil,
+		},
+		// Test 1 ensures all keys are rendered in the given order.
+		{
+			Keys: []key.EncryptionKey{
+				{Name: "key2", Secret: "bmV3"},
+				{Name: "key1", Secret: "b2xk"},
+			},
+			ExpectedConfig: []string{
+				"kind: EncryptionConfig",
+				"apiVersion: v1",
+				"resources:",
+				"  - resources:",
+				"    - secrets",
+				"    providers:",
+				"    - aescbc:",
+				"        keys:",
+				"        - name: key2",
+				"          secret: bmV3",
+				"        - name: key1",
+				"          secret: b2xk",
+				"    - identity: {}",
+			},
+		},
+		// Test 2 ensures a rotated key is rendered even if it is the only key.
+		{
+			Keys: []key.EncryptionKey{
+				{Name: "key2", Secret: "bmV3"},
+			},
+			ExpectedConfig: []string{
+				"kind: EncryptionConfig",
+				"apiVersion: v1",
+				"resources:",
+				"  - resources:",
+				"    - secrets",
+				"    providers:",
+				"    - aescbc:",
+				"        keys:",
+				"        - name: key2",
+				"          secret: bmV3",
+				"    - identity: {}",
+			},
+		},
+		// Test 3 ensures an error is returned in case no key is given.
+		{
+			Keys:            nil,
+			ExpectedErrorFn: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		assets, err := newEncryptionAssets(tc.Keys)
+		if tc.ExpectedErrorFn != nil {
+			if !tc.ExpectedErrorFn(err) {
+				t.Fatalf("case %d expected error got %#v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		var config []string
+		for _, f := range assets.Files {
+			if f.Metadata.Path != encryptionConfigPath {
+				t.Fatalf("case %d expected %#v got %#v", i, encryptionConfigPath, f.Metadata.Path)
+			}
+			config = f.Content
+		}
+		if !reflect.DeepEqual(config, tc.ExpectedConfig) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedConfig, config)
+		}
+	}
+}
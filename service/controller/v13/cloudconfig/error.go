@@ -15,3 +15,17 @@ var notFoundError = microerror.New("not found")
 func IsNotFound(err error) bool {
 	return microerror.Cause(err) == notFoundError
 }
+
+var invalidExtraAssetError = microerror.New("invalid extra asset")
+
+// IsInvalidExtraAsset asserts invalidExtraAssetError.
+func IsInvalidExtraAsset(err error) bool {
+	return microerror.Cause(err) == invalidExtraAssetError
+}
+
+var invalidAuditPolicyError = microerror.New("invalid audit policy")
+
+// IsInvalidAuditPolicy asserts invalidAuditPolicyError.
+func IsInvalidAuditPolicy(err error) bool {
+	return microerror.Cause(err) == invalidAuditPolicyError
+}
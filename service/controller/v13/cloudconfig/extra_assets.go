@@ -0,0 +1,214 @@
+package cloudconfig
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/ghodss/yaml"
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	k8scloudconfig "github.com/giantswarm/k8scloudconfig/v_3_2_5"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	extraFileOwner       = "root:root"
+	extraFilePermissions = 0644
+	extraUnitCommand     = "start"
+)
+
+var (
+	extraFileOwnerRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_-]*(:[a-z_][a-z0-9_-]*)?$`)
+	extraUnitNameRegexp  = regexp.MustCompile(`^[a-zA-Z0-9@._-]+\.(automount|mount|path|service|socket|target|timer)$`)
+)
+
+// extraAsset is an entry of a config map referenced using
+// key.AnnotationExtraAssets. It is YAML or JSON encoded and describes either a
+// file, in case Path is set, or a systemd unit, in case Unit is set. Content is
+// rendered as template, with the cluster and the node being available as
+// .Cluster and .Node.
+type extraAsset struct {
+	Path        string `json:"path,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Permissions string `json:"permissions,omitempty"`
+
+	Unit    string `json:"unit,omitempty"`
+	Enable  *bool  `json:"enable,omitempty"`
+	Command string `json:"command,omitempty"`
+
+	Content string `json:"content"`
+}
+
+type extraAssetParams struct {
+	Cluster v1alpha1.Cluster
+	Node    v1alpha1.ClusterNode
+}
+
+// extraAssets are the extra files and systemd units of a single node.
+type extraAssets struct {
+	Files []k8scloudconfig.FileAsset
+	Units []k8scloudconfig.UnitAsset
+}
+
+// newExtraAssets reads the config maps referenced by the given custom object
+// and returns the rendered files and units applying to nodes of the given
+// role.
+func (c *CloudConfig) newExtraAssets(customObject v1alpha1.KVMConfig, node v1alpha1.ClusterNode, role string) (extraAssets, error) {
+	var assets extraAssets
+
+	refs, err := key.ExtraAssetsRefs(customObject)
+	if err != nil {
+		return extraAssets{}, microerror.Mask(err)
+	}
+
+	params := extraAssetParams{
+		Cluster: customObject.Spec.Cluster,
+		Node:    node,
+	}
+
+	for _, r := range refs {
+		if r.Role != "" && r.Role != role {
+			continue
+		}
+
+		m, err := c.k8sClient.CoreV1().ConfigMaps(r.Namespace).Get(r.ConfigMap, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return extraAssets{}, microerror.Maskf(notFoundError, "extra assets config map '%s/%s'", r.Namespace, r.ConfigMap)
+		} else if err != nil {
+			return extraAssets{}, microerror.Mask(err)
+		}
+
+		// Config map data is a map. Entries are sorted to render the same
+		// cloud-config for the same config map.
+		var entries []string
+		for k := range m.Data {
+			entries = append(entries, k)
+		}
+		sort.Strings(entries)
+
+		for _, e := range entries {
+			id := fmt.Sprintf("%s/%s/%s", r.Namespace, r.ConfigMap, e)
+
+			var a extraAsset
+			err := yaml.Unmarshal([]byte(m.Data[e]), &a)
+			if err != nil {
+				return extraAssets{}, microerror.Maskf(invalidExtraAssetError, "extra asset '%s' must be YAML or JSON: %s", id, err)
+			}
+
+			err = a.validate(id)
+			if err != nil {
+				return extraAssets{}, microerror.Mask(err)
+			}
+
+			content, err := k8scloudconfig.RenderAssetContent(a.Content, params)
+			if err != nil {
+				return extraAssets{}, microerror.Maskf(invalidExtraAssetError, "content of extra asset '%s' must be a valid template: %s", id, err)
+			}
+
+			if a.Path != "" {
+				assets.Files = append(assets.Files, a.newFileAsset(content))
+			} else {
+				assets.Units = append(assets.Units, a.newUnitAsset(content))
+			}
+		}
+	}
+
+	return assets, nil
+}
+
+func (a extraAsset) newFileAsset(content []string) k8scloudconfig.FileAsset {
+	owner := extraFileOwner
+	if a.Owner != "" {
+		owner = a.Owner
+	}
+
+	permissions := extraFilePermissions
+	if a.Permissions != "" {
+		// The permissions are validated already.
+		p, _ := strconv.ParseInt(a.Permissions, 8, 32)
+		permissions = int(p)
+	}
+
+	f := k8scloudconfig.FileAsset{
+		Metadata: k8scloudconfig.FileMetadata{
+			Path:        a.Path,
+			Owner:       owner,
+			Permissions: permissions,
+		},
+		Content: content,
+	}
+
+	return f
+}
+
+func (a extraAsset) newUnitAsset(content []string) k8scloudconfig.UnitAsset {
+	enable := true
+	if a.Enable != nil {
+		enable = *a.Enable
+	}
+
+	command := extraUnitCommand
+	if a.Command != "" {
+		command = a.Command
+	}
+
+	u := k8scloudconfig.UnitAsset{
+		Metadata: k8scloudconfig.UnitMetadata{
+			Name:    a.Unit,
+			Enable:  enable,
+			Command: command,
+		},
+		Content: content,
+	}
+
+	return u
+}
+
+func (a extraAsset) validate(id string) error {
+	if (a.Path == "") == (a.Unit == "") {
+		return microerror.Maskf(invalidExtraAssetError, "extra asset '%s' must either define a path or a unit", id)
+	}
+	if a.Content == "" {
+		return microerror.Maskf(invalidExtraAssetError, "content of extra asset '%s' must not be empty", id)
+	}
+
+	if a.Path != "" {
+		if !path.IsAbs(a.Path) || path.Clean(a.Path) != a.Path {
+			return microerror.Maskf(invalidExtraAssetError, "path '%s' of extra asset '%s' must be absolute and clean", a.Path, id)
+		}
+		if a.Owner != "" && !extraFileOwnerRegexp.MatchString(a.Owner) {
+			return microerror.Maskf(invalidExtraAssetError, "owner '%s' of extra asset '%s' must be of the form '<user>[:<group>]'", a.Owner, id)
+		}
+		if a.Permissions != "" {
+			p, err := strconv.ParseInt(a.Permissions, 8, 32)
+			if err != nil || p < 0 || p > 0777 {
+				return microerror.Maskf(invalidExtraAssetError, "permissions '%s' of extra asset '%s' must be octal between 0000 and 0777", a.Permissions, id)
+			}
+		}
+		if a.Enable != nil || a.Command != "" {
+			return microerror.Maskf(invalidExtraAssetError, "extra asset '%s' must not define enable or command for files", id)
+		}
+	}
+
+	if a.Unit != "" {
+		if !extraUnitNameRegexp.MatchString(a.Unit) {
+			return microerror.Maskf(invalidExtraAssetError, "unit '%s' of extra asset '%s' must be a valid systemd unit name", a.Unit, id)
+		}
+		switch a.Command {
+		case "", "start", "restart", "stop":
+		default:
+			return microerror.Maskf(invalidExtraAssetError, "command '%s' of extra asset '%s' must be one of 'start', 'restart' or 'stop'", a.Command, id)
+		}
+		if a.Owner != "" || a.Permissions != "" {
+			return microerror.Maskf(invalidExtraAssetError, "extra asset '%s' must not define owner or permissions for units", id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package cloudconfig
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	k8scloudconfig "github.com/giantswarm/k8scloudconfig/v_3_2_5"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_CloudConfig_newExtraAssets(t *testing.T) {
+	testCases := []struct {
+		Annotation      string
+		Data            map[string]string
+		Role            string
+		ExpectedFiles   []k8scloudconfig.FileAsset
+		ExpectedUnits   []k8scloudconfig.UnitAsset
+		ExpectedErrorFn func(error) bool
+	}{
+		// Test 0 ensures there are no extra assets without the annotation.
+		{
+			Annotation: "",
+			Role:       key.WorkerID,
+		},
+
+		// Test 1 ensures files and units are rendered with their defaults and in
+		// the order of their config map entries.
+		{
+			Annotation: `[{"configMap": "extra"}]`,
+			Data: map[string]string{
+				"b-unit": "unit: agent.service\ncontent: |\n  [Service]\n  ExecStart=/opt/agent {{ .Node.ID }}",
+				"a-file": "path: /etc/sysctl.d/99-custom.conf\ncontent: vm.max_map_count = 262144",
+			},
+			Role: key.WorkerID,
+			ExpectedFiles: []k8scloudconfig.FileAsset{
+				{
+					Metadata: k8scloudconfig.FileMetadata{
+						Path:        "/etc/sysctl.d/99-custom.conf",
+						Owner:       "root:root",
+						Permissions: 0644,
+					},
+					Content: []string{"vm.max_map_count = 262144"},
+				},
+			},
+			ExpectedUnits: []k8scloudconfig.UnitAsset{
+				{
+					Metadata: k8scloudconfig.UnitMetadata{
+						Name:    "agent.service",
+						Enable:  true,
+						Command: "start",
+					},
+					Content: []string{"[Service]", "ExecStart=/opt/agent w1"},
+				},
+			},
+		},
+
+		// Test 2 ensures assets of another role are ignored.
+		{
+			Annotation: `[{"configMap": "extra", "role": "master"}]`,
+			Data: map[string]string{
+				"a-file": "path: /etc/custom\ncontent: foo",
+			},
+			Role: key.WorkerID,
+		},
+
+		// Test 3 ensures file permissions and owners are applied.
+		{
+			Annotation: `[{"configMap": "extra", "namespace": "default", "role": "worker"}]`,
+			Data: map[string]string{
+				"a-file": `{"path": "/opt/bin/tool", "owner": "core:core", "permissions": "0755", "content": "#!/bin/sh"}`,
+			},
+			Role: key.WorkerID,
+			ExpectedFiles: []k8scloudconfig.FileAsset{
+				{
+					Metadata: k8scloudconfig.FileMetadata{
+						Path:        "/opt/bin/tool",
+						Owner:       "core:core",
+						Permissions: 0755,
+					},
+					Content: []string{"#!/bin/sh"},
+				},
+			},
+		},
+
+		// Test 4 ensures assets defining both a path and a unit are rejected.
+		{
+			Annotation: `[{"configMap": "extra"}]`,
+			Data: map[string]string{
+				"a": "path: /etc/custom\nunit: custom.service\ncontent: foo",
+			},
+			Role:            key.WorkerID,
+			ExpectedErrorFn: IsInvalidExtraAsset,
+		},
+
+		// Test 5 ensures relative paths are rejected.
+		{
+			Annotation: `[{"configMap": "extra"}]`,
+			Data: map[string]string{
+				"a": "path: etc/../custom\ncontent: foo",
+			},
+			Role:            key.WorkerID,
+			ExpectedErrorFn: IsInvalidExtraAsset,
+		},
+
+		// Test 6 ensures invalid permissions are rejected.
+		{
+			Annotation: `[{"configMap": "extra"}]`,
+			Data: map[string]string{
+				"a": "path: /etc/custom\npermissions: \"0999\"\ncontent: foo",
+			},
+			Role:            key.WorkerID,
+			ExpectedErrorFn: IsInvalidExtraAsset,
+		},
+
+		// Test 7 ensures invalid unit names are rejected.
+		{
+			Annotation: `[{"configMap": "extra"}]`,
+			Data: map[string]string{
+				"a": "unit: custom\ncontent: foo",
+			},
+			Role:            key.WorkerID,
+			ExpectedErrorFn: IsInvalidExtraAsset,
+		},
+
+		// Test 8 ensures invalid templates are rejected.
+		{
+			Annotation: `[{"configMap": "extra"}]`,
+			Data: map[string]string{
+				"a": "path: /etc/custom\ncontent: \"{{ .Foo \"",
+			},
+			Role:            key.WorkerID,
+			ExpectedErrorFn: IsInvalidExtraAsset,
+		},
+
+		// Test 9 ensures missing config maps are reported.
+		{
+			Annotation:      `[{"configMap": "missing"}]`,
+			Role:            key.WorkerID,
+			ExpectedErrorFn: IsNotFound,
+		},
+
+		// Test 10 ensures invalid roles are rejected.
+		{
+			Annotation:      `[{"configMap": "extra", "role": "all"}]`,
+			Role:            key.WorkerID,
+			ExpectedErrorFn: key.IsInvalidAnnotation,
+		},
+
+		// Test 11 ensures config maps of other namespaces are rejected.
+		{
+			Annotation:      `[{"configMap": "extra", "namespace": "kube-system"}]`,
+			Role:            key.WorkerID,
+			ExpectedErrorFn: key.IsInvalidAnnotation,
+		},
+	}
+
+	for i, tc := range testCases {
+		k8sClient := fake.NewSimpleClientset()
+		if tc.Data != nil {
+			m := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "extra",
+					Namespace: "default",
+				},
+				Data: tc.Data,
+			}
+			_, err := k8sClient.CoreV1().ConfigMaps("default").Create(m)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		c := DefaultConfig()
+		c.K8sClient = k8sClient
+		c.Logger = microloggertest.New()
+		cloudConfig, err := New(c)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		customObject := v1alpha1.KVMConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Annotations: map[string]string{
+					key.AnnotationExtraAssets: tc.Annotation,
+				},
+			},
+			Spec: v1alpha1.KVMConfigSpec{
+				Cluster: v1alpha1.Cluster{
+					ID: "al9qy",
+				},
+			},
+		}
+
+		assets, err := cloudConfig.newExtraAssets(customObject, v1alpha1.ClusterNode{ID: "w1"}, tc.Role)
+		if tc.ExpectedErrorFn != nil {
+			if !tc.ExpectedErrorFn(err) {
+				t.Fatalf("case %d expected error got %#v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		if !reflect.DeepEqual(assets.Files, tc.ExpectedFiles) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedFiles, assets.Files)
+		}
+		if !reflect.DeepEqual(assets.Units, tc.ExpectedUnits) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedUnits, assets.Units)
+		}
+	}
+}
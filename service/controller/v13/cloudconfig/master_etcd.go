@@ -0,0 +1,174 @@
+package cloudconfig
+
+import (
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	k8scloudconfig "github.com/giantswarm/k8scloudconfig/v_3_2_5"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	etcdInitialClusterStateExisting = "existing"
+	etcdInitialClusterStateNew      = "new"
+)
+
+// etcdDropIn overrides the etcd3 unit of the master template for guest
+// clusters running multiple masters. The member advertises the IP of the
+// master VM to its peers and joins the etcd cluster using the initial cluster
+// written by etcdInitialClusterScript.
+const etcdDropIn = `[Service]
+EnvironmentFile=-/etc/etcd-initial-cluster
+ExecStartPre=/opt/bin/etcd-initial-cluster
+ExecStart=
+ExecStart=/usr/bin/docker run \
+    -v /etc/ssl/certs/ca-certificates.crt:/etc/ssl/certs/ca-certificates.crt \
+    -v /etc/kubernetes/ssl/etcd/:/etc/etcd \
+    -v /var/lib/etcd/:/var/lib/etcd  \
+    --net=host  \
+    --name $NAME \
+    $IMAGE \
+    etcd \
+    --name {{ .MemberName }} \
+    --trusted-ca-file /etc/etcd/server-ca.pem \
+    --cert-file /etc/etcd/server-crt.pem \
+    --key-file /etc/etcd/server-key.pem\
+    --client-cert-auth=true \
+    --peer-trusted-ca-file /etc/etcd/server-ca.pem \
+    --peer-cert-file /etc/etcd/server-crt.pem \
+    --peer-key-file /etc/etcd/server-key.pem \
+    --peer-client-cert-auth=true \
+    --advertise-client-urls=https://{{ .Domain }}:{{ .Port }} \
+    --initial-advertise-peer-urls=https://${DEFAULT_IPV4}:{{ .PeerPort }} \
+    --listen-client-urls=https://0.0.0.0:{{ .ClientPort }} \
+    --listen-peer-urls=https://${DEFAULT_IPV4}:{{ .PeerPort }} \
+    --initial-cluster-token k8s-etcd-cluster \
+    --initial-cluster ${ETCD_INITIAL_CLUSTER} \
+    --initial-cluster-state {{ .InitialClusterState }} \
+    --data-dir=/var/lib/etcd \
+    --enable-v2
+`
+
+// etcdInitialClusterScript writes the initial cluster the etcd member of the
+// master needs to bootstrap or join the etcd cluster. The bootstrap member
+// starts a new etcd cluster on its own. All other masters wait for
+// kvm-operator to add them to the existing etcd cluster before they join it.
+// Members having data already ignore the initial cluster.
+const etcdInitialClusterScript = `#!/bin/bash
+set -o errexit -o nounset -o pipefail
+
+source /etc/network-environment
+
+PEER_URL="https://${DEFAULT_IPV4}:{{ .PeerPort }}"
+ENV_FILE=/etc/etcd-initial-cluster
+
+if [ "{{ .InitialClusterState }}" = "new" ] || [ -d /var/lib/etcd/member ]; then
+  echo "ETCD_INITIAL_CLUSTER={{ .MemberName }}=${PEER_URL}" > ${ENV_FILE}
+  exit 0
+fi
+
+etcdctl() {
+  /usr/bin/docker run --rm --net=host \
+    -v /etc/kubernetes/ssl/etcd/:/etc/etcd \
+    -e ETCDCTL_API=3 \
+    {{ .Image }} \
+    etcdctl \
+    --endpoints=https://{{ .Domain }}:{{ .Port }} \
+    --cacert=/etc/etcd/server-ca.pem \
+    --cert=/etc/etcd/server-crt.pem \
+    --key=/etc/etcd/server-key.pem \
+    "$@"
+}
+
+until members=$(etcdctl member list) && echo "${members}" | grep -q "${PEER_URL}"; do
+  echo "Waiting for etcd member ${PEER_URL} to be added to the etcd cluster"
+  sleep 5
+done
+
+# Members are listed as "ID, STATUS, NAME, PEER URLS, CLIENT URLS". Members
+# not started yet, like this one, have no name.
+initial=$(echo "${members}" | awk -F', ' -v name={{ .MemberName }} -v peer="${PEER_URL}" '{ n = $3; if ($4 == peer) n = name; printf "%s%s=%s", sep, n, $4; sep = "," }')
+
+echo "ETCD_INITIAL_CLUSTER=${initial}" > ${ENV_FILE}
+`
+
+// etcdImage is the etcd image of the master template.
+const etcdImage = "quay.io/coreos/etcd:v3.3.3"
+
+type etcdParams struct {
+	ClientPort          int
+	Domain              string
+	Image               string
+	InitialClusterState string
+	MemberName          string
+	PeerPort            int
+	Port                int
+}
+
+// newEtcdFilesMeta returns the files configuring the etcd member of the given
+// master in case the guest cluster runs multiple masters. Guest clusters
+// running a single master use the etcd3 unit of the master template as it is.
+func newEtcdFilesMeta(customObject v1alpha1.KVMConfig, node v1alpha1.ClusterNode) []k8scloudconfig.FileMetadata {
+	masters := customObject.Spec.Cluster.Masters
+	if len(masters) <= 1 {
+		return nil
+	}
+
+	filesMeta := []k8scloudconfig.FileMetadata{
+		{
+			AssetContent: etcdDropIn,
+			Path:         "/etc/systemd/system/etcd3.service.d/20-multi-master.conf",
+			Owner:        FileOwner,
+			Permissions:  0644,
+		},
+		{
+			AssetContent: etcdInitialClusterScript,
+			Path:         "/opt/bin/etcd-initial-cluster",
+			Owner:        FileOwner,
+			Permissions:  FilePermission,
+		},
+	}
+
+	return filesMeta
+}
+
+// newEtcdParams returns the parameters of the etcd files of the given master.
+// Only the given bootstrap member starts a new etcd cluster. All other masters
+// join the existing one.
+func newEtcdParams(customObject v1alpha1.KVMConfig, node v1alpha1.ClusterNode, bootstrapMember string) etcdParams {
+	state := etcdInitialClusterStateExisting
+	if bootstrapMember != "" && bootstrapMember == node.ID {
+		state = etcdInitialClusterStateNew
+	}
+
+	p := etcdParams{
+		ClientPort:          key.EtcdPort,
+		Domain:              customObject.Spec.Cluster.Etcd.Domain,
+		Image:               etcdImage,
+		InitialClusterState: state,
+		MemberName:          key.EtcdMemberName(node),
+		PeerPort:            key.EtcdPeerPort,
+		// The etcd domain is served by the ingress controller of the host
+		// cluster. This is the default etcd port of the master template.
+		Port: 443,
+	}
+
+	return p
+}
+
+// etcdBootstrapMember returns the ID of the master bootstrapping the etcd
+// cluster of the given guest cluster, as persisted on its namespace using
+// key.AnnotationEtcdBootstrapMember. It is empty in case the namespace does
+// not exist or lacks the annotation.
+func (c *CloudConfig) etcdBootstrapMember(customObject v1alpha1.KVMConfig) (string, error) {
+	n, err := c.k8sClient.CoreV1().Namespaces().Get(key.ClusterNamespace(customObject), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return n.GetAnnotations()[key.AnnotationEtcdBootstrapMember], nil
+}
@@ -0,0 +1,105 @@
+package cloudconfig
+
+import (
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_CloudConfig_newEtcdParams_InitialClusterState(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Masters: []v1alpha1.ClusterNode{
+					{
+						ID: "5xchu",
+					},
+					{
+						ID: "p7jqk",
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		Namespace     *corev1.Namespace
+		Node          string
+		ExpectedState string
+	}{
+		// Test 0 ensures the bootstrap member starts a new etcd cluster.
+		{
+			Namespace:     newEtcdBootstrapNamespace("5xchu"),
+			Node:          "5xchu",
+			ExpectedState: etcdInitialClusterStateNew,
+		},
+
+		// Test 1 ensures other masters join the existing etcd cluster.
+		{
+			Namespace:     newEtcdBootstrapNamespace("5xchu"),
+			Node:          "p7jqk",
+			ExpectedState: etcdInitialClusterStateExisting,
+		},
+
+		// Test 2 ensures the first master joins the existing etcd cluster in case
+		// it is not the bootstrap member, e.g. because the bootstrap member got
+		// replaced.
+		{
+			Namespace:     newEtcdBootstrapNamespace("wq3fz"),
+			Node:          "5xchu",
+			ExpectedState: etcdInitialClusterStateExisting,
+		},
+
+		// Test 3 ensures all masters join the existing etcd cluster in case the
+		// namespace does not define the bootstrap member.
+		{
+			Namespace:     newEtcdBootstrapNamespace(""),
+			Node:          "5xchu",
+			ExpectedState: etcdInitialClusterStateExisting,
+		},
+	}
+
+	for i, tc := range testCases {
+		k8sClient := fake.NewSimpleClientset(tc.Namespace)
+
+		c := DefaultConfig()
+		c.K8sClient = k8sClient
+		c.Logger = microloggertest.New()
+		cloudConfig, err := New(c)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		bootstrapMember, err := cloudConfig.etcdBootstrapMember(customObject)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		params := newEtcdParams(customObject, v1alpha1.ClusterNode{ID: tc.Node}, bootstrapMember)
+		if params.InitialClusterState != tc.ExpectedState {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedState, params.InitialClusterState)
+		}
+	}
+}
+
+func newEtcdBootstrapNamespace(member string) *corev1.Namespace {
+	n := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "al9qy",
+		},
+	}
+	if member != "" {
+		n.Annotations = map[string]string{
+			key.AnnotationEtcdBootstrapMember: member,
+		}
+	}
+
+	return n
+}
@@ -5,23 +5,69 @@ import (
 	"github.com/giantswarm/certs"
 	k8scloudconfig "github.com/giantswarm/k8scloudconfig/v_3_2_5"
 	"github.com/giantswarm/microerror"
-	"github.com/giantswarm/randomkeys"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
-// NewMasterTemplate generates a new worker cloud config template and returns it
-// as a base64 encoded string.
-func (c *CloudConfig) NewMasterTemplate(customObject v1alpha1.KVMConfig, certs certs.Cluster, node v1alpha1.ClusterNode, randomKeys randomkeys.Cluster) (string, error) {
+// NewMasterTemplate generates a new master cloud config template and returns it
+// encoded as described by CloudConfig.Encoding. The API server encrypts
+// secrets using the first of the given encryption keys.
+func (c *CloudConfig) NewMasterTemplate(customObject v1alpha1.KVMConfig, certs certs.Cluster, node v1alpha1.ClusterNode, encryptionKeys []key.EncryptionKey) (string, error) {
 	var err error
 
+	audit, err := c.newAuditAssets(customObject)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	encryption, err := newEncryptionAssets(encryptionKeys)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	extra, err := c.newExtraAssets(customObject, node, key.MasterID)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	hyperkubeArgs, err := key.HyperkubeExtraArgsFromAnnotation(customObject)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	oidcArgs, err := c.newOIDCArgs(customObject)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	oidc, err := newOIDCAssets(customObject)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	etcdBootstrapMember, err := c.etcdBootstrapMember(customObject)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
 	var params k8scloudconfig.Params
 	{
-		params.APIServerEncryptionKey = string(randomKeys.APIServerEncryptionKey)
+		params.APIServerEncryptionKey = encryptionKeys[0].Secret
 		params.Cluster = customObject.Spec.Cluster
 		params.Extension = &masterExtension{
-			certs: certs,
+			audit:               audit,
+			certs:               certs,
+			customObject:        customObject,
+			encryption:          encryption,
+			etcdBootstrapMember: etcdBootstrapMember,
+			extra:               extra,
+			node:                node,
+			oidc:                oidc,
 		}
 		params.Node = node
-		params.Hyperkube.Apiserver.Pod.CommandExtraArgs = c.k8sAPIExtraArgs
+		params.Hyperkube.Apiserver.Pod.CommandExtraArgs = append(oidcArgs, hyperkubeArgs.APIServer...)
+		params.Hyperkube.ControllerManager.Pod.CommandExtraArgs = hyperkubeArgs.ControllerManager
+		params.Hyperkube.Kubelet.Docker.CommandExtraArgs = hyperkubeArgs.Kubelet
 	}
 
 	var newCloudConfig *k8scloudconfig.CloudConfig
@@ -41,11 +87,23 @@ func (c *CloudConfig) NewMasterTemplate(customObject v1alpha1.KVMConfig, certs c
 		}
 	}
 
-	return newCloudConfig.Base64(), nil
+	encoded, err := c.encode(newCloudConfig)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return encoded, nil
 }
 
 type masterExtension struct {
-	certs certs.Cluster
+	audit               extraAssets
+	certs               certs.Cluster
+	customObject        v1alpha1.KVMConfig
+	encryption          extraAssets
+	etcdBootstrapMember string
+	extra               extraAssets
+	node                v1alpha1.ClusterNode
+	oidc                extraAssets
 }
 
 func (e *masterExtension) Files() ([]k8scloudconfig.FileAsset, error) {
@@ -61,10 +119,12 @@ func (e *masterExtension) Files() ([]k8scloudconfig.FileAsset, error) {
 		filesMeta = append(filesMeta, m)
 	}
 
+	filesMeta = append(filesMeta, newEtcdFilesMeta(e.customObject, e.node)...)
+
 	var newFiles []k8scloudconfig.FileAsset
 
 	for _, fm := range filesMeta {
-		c, err := k8scloudconfig.RenderAssetContent(fm.AssetContent, nil)
+		c, err := k8scloudconfig.RenderAssetContent(fm.AssetContent, newEtcdParams(e.customObject, e.node, e.etcdBootstrapMember))
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
@@ -77,6 +137,11 @@ func (e *masterExtension) Files() ([]k8scloudconfig.FileAsset, error) {
 		newFiles = append(newFiles, fileAsset)
 	}
 
+	newFiles = append(newFiles, e.audit.Files...)
+	newFiles = append(newFiles, e.encryption.Files...)
+	newFiles = append(newFiles, e.extra.Files...)
+	newFiles = append(newFiles, e.oidc.Files...)
+
 	return newFiles, nil
 }
 
@@ -164,6 +229,9 @@ WantedBy=multi-user.target`,
 		newUnits = append(newUnits, unitAsset)
 	}
 
+	newUnits = append(newUnits, e.audit.Units...)
+	newUnits = append(newUnits, e.extra.Units...)
+
 	return newUnits, nil
 }
 
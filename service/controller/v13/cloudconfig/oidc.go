@@ -0,0 +1,88 @@
+package cloudconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	k8scloudconfig "github.com/giantswarm/k8scloudconfig/v_3_2_5"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// oidcCAPath is the path of the certificate authority the OIDC issuer of a
+	// guest cluster is verified with. The directory is mounted into the API
+	// server pod by the master template.
+	oidcCAPath = "/etc/kubernetes/ssl/oidc-ca.pem"
+)
+
+// newOIDCArgs returns the OIDC arguments of the API server of the given guest
+// cluster. Settings of the custom object replace the installation wide OIDC
+// configuration as a whole, so that a cluster using its own issuer never gets
+// the client ID or claims of the installation's issuer.
+func (c *CloudConfig) newOIDCArgs(customObject v1alpha1.KVMConfig) ([]string, error) {
+	oidc, err := key.OIDCFromAnnotation(customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if oidc.IssuerURL == "" {
+		oidc = key.OIDC{
+			ClientID:      c.oidc.ClientID,
+			IssuerURL:     c.oidc.IssuerURL,
+			UsernameClaim: c.oidc.UsernameClaim,
+			GroupsClaim:   c.oidc.GroupsClaim,
+		}
+	}
+
+	var args []string
+	{
+		if oidc.ClientID != "" {
+			args = append(args, fmt.Sprintf("--oidc-client-id=%s", oidc.ClientID))
+		}
+		if oidc.IssuerURL != "" {
+			args = append(args, fmt.Sprintf("--oidc-issuer-url=%s", oidc.IssuerURL))
+		}
+		if oidc.UsernameClaim != "" {
+			args = append(args, fmt.Sprintf("--oidc-username-claim=%s", oidc.UsernameClaim))
+		}
+		if oidc.GroupsClaim != "" {
+			args = append(args, fmt.Sprintf("--oidc-groups-claim=%s", oidc.GroupsClaim))
+		}
+		if oidc.CA != "" {
+			args = append(args, fmt.Sprintf("--oidc-ca-file=%s", oidcCAPath))
+		}
+	}
+
+	return args, nil
+}
+
+// newOIDCAssets returns the certificate authority the OIDC issuer of the given
+// guest cluster is verified with, in case the custom object defines one.
+func newOIDCAssets(customObject v1alpha1.KVMConfig) (extraAssets, error) {
+	oidc, err := key.OIDCFromAnnotation(customObject)
+	if err != nil {
+		return extraAssets{}, microerror.Mask(err)
+	}
+
+	if oidc.CA == "" {
+		return extraAssets{}, nil
+	}
+
+	f := k8scloudconfig.FileAsset{
+		Metadata: k8scloudconfig.FileMetadata{
+			Path:        oidcCAPath,
+			Owner:       extraFileOwner,
+			Permissions: extraFilePermissions,
+		},
+		Content: strings.Split(strings.TrimSpace(oidc.CA), "\n"),
+	}
+
+	assets := extraAssets{
+		Files: []k8scloudconfig.FileAsset{f},
+	}
+
+	return assets, nil
+}
@@ -0,0 +1,233 @@
+package cloudconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_CloudConfig_newOIDCArgs(t *testing.T) {
+	testCases := []struct {
+		OIDC            OIDCConfig
+		Annotation      string
+		ExpectedArgs    []string
+		ExpectedErrorFn func(error) bool
+	}{
+		// Test 0 ensures there are no OIDC arguments without any configuration.
+		{
+			OIDC:         OIDCConfig{},
+			Annotation:   "",
+			ExpectedArgs: nil,
+		},
+
+		// Test 1 ensures the installation wide configuration is used without
+		// the annotation.
+		{
+			OIDC: OIDCConfig{
+				ClientID:      "installation",
+				IssuerURL:     "https://dex.example.com",
+				UsernameClaim: "email",
+				GroupsClaim:   "groups",
+			},
+			Annotation: "",
+			ExpectedArgs: []string{
+				"--oidc-client-id=installation",
+				"--oidc-issuer-url=https://dex.example.com",
+				"--oidc-username-claim=email",
+				"--oidc-groups-claim=groups",
+			},
+		},
+
+		// Test 2 ensures the settings of the annotation replace the installation
+		// wide configuration as a whole.
+		{
+			OIDC: OIDCConfig{
+				ClientID:      "installation",
+				IssuerURL:     "https://dex.example.com",
+				UsernameClaim: "email",
+				GroupsClaim:   "groups",
+			},
+			Annotation: `{"clientID": "customer", "issuerURL": "https://idp.customer.com/auth"}`,
+			ExpectedArgs: []string{
+				"--oidc-client-id=customer",
+				"--oidc-issuer-url=https://idp.customer.com/auth",
+			},
+		},
+
+		// Test 3 ensures the annotation configures OIDC without installation
+		// wide configuration.
+		{
+			OIDC:       OIDCConfig{},
+			Annotation: `{"clientID": "customer", "issuerURL": "https://idp.customer.com", "usernameClaim": "sub"}`,
+			ExpectedArgs: []string{
+				"--oidc-client-id=customer",
+				"--oidc-issuer-url=https://idp.customer.com",
+				"--oidc-username-claim=sub",
+			},
+		},
+
+		// Test 4 ensures invalid JSON is rejected.
+		{
+			Annotation:      `customer`,
+			ExpectedErrorFn: key.IsInvalidAnnotation,
+		},
+
+		// Test 5 ensures issuers not being served via HTTPS are rejected.
+		{
+			Annotation:      `{"clientID": "customer", "issuerURL": "http://idp.customer.com"}`,
+			ExpectedErrorFn: key.IsInvalidAnnotation,
+		},
+
+		// Test 6 ensures values breaking the API server arguments are rejected.
+		{
+			Annotation:      `{"clientID": "customer --anonymous-auth=true", "issuerURL": "https://idp.customer.com"}`,
+			ExpectedErrorFn: key.IsInvalidAnnotation,
+		},
+
+		// Test 7 ensures settings without issuer are rejected instead of being
+		// mixed with the installation wide configuration.
+		{
+			OIDC: OIDCConfig{
+				ClientID:  "installation",
+				IssuerURL: "https://dex.example.com",
+			},
+			Annotation:      `{"clientID": "customer"}`,
+			ExpectedErrorFn: key.IsInvalidAnnotation,
+		},
+
+		// Test 8 ensures the API server verifies the issuer with the CA of the
+		// annotation.
+		{
+			Annotation: newOIDCAnnotation(key.OIDC{ClientID: "customer", IssuerURL: "https://idp.customer.com", CA: testOIDCCA}),
+			ExpectedArgs: []string{
+				"--oidc-client-id=customer",
+				"--oidc-issuer-url=https://idp.customer.com",
+				"--oidc-ca-file=/etc/kubernetes/ssl/oidc-ca.pem",
+			},
+		},
+
+		// Test 9 ensures CAs not being PEM encoded certificates are rejected.
+		{
+			Annotation:      newOIDCAnnotation(key.OIDC{ClientID: "customer", IssuerURL: "https://idp.customer.com", CA: "customer"}),
+			ExpectedErrorFn: key.IsInvalidAnnotation,
+		},
+	}
+
+	for i, tc := range testCases {
+		c := DefaultConfig()
+		c.K8sClient = fake.NewSimpleClientset()
+		c.Logger = microloggertest.New()
+		c.OIDC = tc.OIDC
+		cloudConfig, err := New(c)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		customObject := v1alpha1.KVMConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					key.AnnotationOIDC: tc.Annotation,
+				},
+			},
+		}
+
+		args, err := cloudConfig.newOIDCArgs(customObject)
+		if tc.ExpectedErrorFn != nil {
+			if !tc.ExpectedErrorFn(err) {
+				t.Fatalf("case %d expected error got %#v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		if !reflect.DeepEqual(args, tc.ExpectedArgs) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedArgs, args)
+		}
+	}
+}
+
+func Test_CloudConfig_newOIDCAssets(t *testing.T) {
+	testCases := []struct {
+		Annotation    string
+		ExpectedPaths []string
+	}{
+		// Test 0 ensures no CA is written without the annotation.
+		{
+			Annotation:    "",
+			ExpectedPaths: nil,
+		},
+
+		// Test 1 ensures no CA is written in case the annotation does not
+		// define one.
+		{
+			Annotation:    `{"clientID": "customer", "issuerURL": "https://idp.customer.com"}`,
+			ExpectedPaths: nil,
+		},
+
+		// Test 2 ensures the CA of the annotation is written.
+		{
+			Annotation: newOIDCAnnotation(key.OIDC{ClientID: "customer", IssuerURL: "https://idp.customer.com", CA: testOIDCCA}),
+			ExpectedPaths: []string{
+				"/etc/kubernetes/ssl/oidc-ca.pem",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		customObject := v1alpha1.KVMConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					key.AnnotationOIDC: tc.Annotation,
+				},
+			},
+		}
+
+		assets, err := newOIDCAssets(customObject)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		var paths []string
+		for _, f := range assets.Files {
+			paths = append(paths, f.Metadata.Path)
+			if strings.Join(f.Content, "\n") != strings.TrimSpace(testOIDCCA) {
+				t.Fatalf("case %d expected %#v got %#v", i, testOIDCCA, f.Content)
+			}
+		}
+		if !reflect.DeepEqual(paths, tc.ExpectedPaths) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedPaths, paths)
+		}
+	}
+}
+
+const testOIDCCA = `-----BEGIN CERTIFICATE-----
+MIIBijCCATGgAwIBAgIUMpmNprtZbdtqsZTOxJ7VFFl+0hgwCgYIKoZIzj0EAwIw
+GzEZMBcGA1UEAwwQaWRwLmN1c3RvbWVyLmNvbTAeFw0yNjEwMTgyMjE4MzZaFw0z
+NjEwMTUyMjE4MzZaMBsxGTAXBgNVBAMMEGlkcC5jdXN0b21lci5jb20wWTATBgcq
+hkjOPQIBBggqhkjOPQMBBwNCAASZ3SqQz78KTaVUxFPOG2ciqjDbR9uKodek+loC
+rsXQHQb6K2zqTfvJEZ2NDBN6zCBnuaZGea9dUgvhM20PCfWCo1MwUTAdBgNVHQ4E
+FgQU75dizAvi7klE1zy9qI8jttkaQyMwHwYDVR0jBBgwFoAU75dizAvi7klE1zy9
+qI8jttkaQyMwDwYDVR0TAQH/BAUwAwEB/zAKBggqhkjOPQQDAgNHADBEAiBv69ex
+C+aFzgxKKIlxKggI2W0bfZtJbDvJpzBLLwyObQIgXYKxmvqBfMvPCNgeYISAYiT5
+CeorDJqhZ1Pit2LwI4A=
+-----END CERTIFICATE-----
+`
+
+func newOIDCAnnotation(oidc key.OIDC) string {
+	b, err := json.Marshal(oidc)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(b)
+}
@@ -1,24 +1,53 @@
 package cloudconfig
 
 import (
+	"fmt"
+
 	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/certs"
 	k8scloudconfig "github.com/giantswarm/k8scloudconfig/v_3_2_5"
 	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
 // NewWorkerTemplate generates a new worker cloud config template and returns it
-// as a base64 encoded string.
-func (c *CloudConfig) NewWorkerTemplate(customObject v1alpha1.KVMConfig, certs certs.Cluster, node v1alpha1.ClusterNode) (string, error) {
+// encoded as described by CloudConfig.Encoding. Labels and taints of the
+// worker's node pool are applied by the kubelet when registering the node.
+func (c *CloudConfig) NewWorkerTemplate(customObject v1alpha1.KVMConfig, certs certs.Cluster, worker key.WorkerNode) (string, error) {
 	var err error
 
+	extra, err := c.newExtraAssets(customObject, worker.Node, key.WorkerID)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	hyperkubeArgs, err := key.HyperkubeExtraArgsFromAnnotation(customObject)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
 	var params k8scloudconfig.Params
 	{
 		params.Cluster = customObject.Spec.Cluster
 		params.Extension = &workerExtension{
 			certs: certs,
+			extra: extra,
+		}
+		params.Node = worker.Node
+		params.Hyperkube.Kubelet.Docker.CommandExtraArgs = hyperkubeArgs.Kubelet
+
+		// The worker template already sets the kubelet's node labels from the
+		// cluster spec, so the labels of the node pool are appended to these.
+		if labels := key.KubeletNodeLabels(worker); labels != "" {
+			if params.Cluster.Kubernetes.Kubelet.Labels != "" {
+				labels = params.Cluster.Kubernetes.Kubelet.Labels + "," + labels
+			}
+			params.Cluster.Kubernetes.Kubelet.Labels = labels
+		}
+		if taints := key.KubeletNodeTaints(worker); taints != "" {
+			params.Hyperkube.Kubelet.Docker.CommandExtraArgs = append(params.Hyperkube.Kubelet.Docker.CommandExtraArgs, fmt.Sprintf("--register-with-taints=%s", taints))
 		}
-		params.Node = node
 	}
 
 	var newCloudConfig *k8scloudconfig.CloudConfig
@@ -38,11 +67,17 @@ func (c *CloudConfig) NewWorkerTemplate(customObject v1alpha1.KVMConfig, certs c
 		}
 	}
 
-	return newCloudConfig.Base64(), nil
+	encoded, err := c.encode(newCloudConfig)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return encoded, nil
 }
 
 type workerExtension struct {
 	certs certs.Cluster
+	extra extraAssets
 }
 
 func (e *workerExtension) Files() ([]k8scloudconfig.FileAsset, error) {
@@ -74,6 +109,8 @@ func (e *workerExtension) Files() ([]k8scloudconfig.FileAsset, error) {
 		newFiles = append(newFiles, fileAsset)
 	}
 
+	newFiles = append(newFiles, e.extra.Files...)
+
 	return newFiles, nil
 }
 
@@ -107,6 +144,8 @@ func (e *workerExtension) Units() ([]k8scloudconfig.UnitAsset, error) {
 		newUnits = append(newUnits, unitAsset)
 	}
 
+	newUnits = append(newUnits, e.extra.Units...)
+
 	return newUnits, nil
 }
 
@@ -2,7 +2,10 @@ package v13
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/apiextensions/pkg/clientset/versioned"
 	"github.com/giantswarm/certs"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
@@ -14,26 +17,71 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v13/cloudconfig"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterlister"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/etcdcluster"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/certconfig"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/cloudconfigsecret"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/clusterrolebinding"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/configmap"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/daemonset"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/deployment"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/encryptionkey"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/etcdmember"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/ingress"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/namespace"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/networkpolicy"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/pullsecret"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/pvc"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/role"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/rolebinding"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/service"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/serviceaccount"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/scaledown"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/workerscontext"
 )
 
 type ClusterResourceSetConfig struct {
 	CertsSearcher      certs.Interface
+	G8sClient          versioned.Interface
 	K8sClient          kubernetes.Interface
 	Logger             micrologger.Logger
 	RandomkeysSearcher randomkeys.Interface
 
-	OIDC               cloudconfig.OIDCConfig
-	GuestUpdateEnabled bool
-	ProjectName        string
+	Audit                          cloudconfig.AuditConfig
+	OIDC                           cloudconfig.OIDCConfig
+	GuestCeilingCPU                string
+	GuestCeilingMemory             string
+	GuestCertsProvision            bool
+	GuestCertsTTL                  string
+	GuestCertsVersionBundleVersion string
+	GuestCloudConfigCompress       bool
+	GuestCloudConfigSecret         bool
+	GuestAppArmorProfile           string
+	// GuestNetworkPolicyDNSNamespace, the ingress controller and the operator
+	// namespaces are the host cluster namespaces guest cluster namespaces are
+	// allowed to exchange traffic with.
+	GuestNetworkPolicyDNSNamespace               string
+	GuestNetworkPolicyIngressControllerNamespace string
+	GuestNetworkPolicyOperatorNamespace          string
+	GuestNetworkPolicyPodCIDR                    string
+	GuestCustomerQuotaConfigMapName              string
+	GuestCustomerQuotaConfigMapNamespace         string
+	GuestImagePrePullBaseURL                     string
+	GuestImagePrePullNamespace                   string
+	GuestImagePrePullServiceAccount              string
+	GuestUpdateEnabled                           bool
+	GuestUpdateSurge                             bool
+	ProjectName                                  string
+	RegistryMirror                               string
+	RegistryPullSecretName                       string
+	RegistryPullSecretNamespace                  string
+	ScaleDownMaxWorkers                          int
+	ScaleDownPolicy                              string
 }
 
 func NewClusterResourceSet(config ClusterResourceSetConfig) (*controller.ResourceSet, error) {
@@ -42,9 +90,13 @@ func NewClusterResourceSet(config ClusterResourceSetConfig) (*controller.Resourc
 	var cloudConfig *cloudconfig.CloudConfig
 	{
 		c := cloudconfig.Config{
-			Logger: config.Logger,
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
 
-			OIDC: config.OIDC,
+			Audit:          config.Audit,
+			Compress:       config.GuestCloudConfigCompress,
+			OIDC:           config.OIDC,
+			RegistryMirror: config.RegistryMirror,
 		}
 
 		cloudConfig, err = cloudconfig.New(c)
@@ -53,19 +105,162 @@ func NewClusterResourceSet(config ClusterResourceSetConfig) (*controller.Resourc
 		}
 	}
 
-	var clusterRoleBindingResource controller.Resource
+	var clusterStatus *clusterstatus.ClusterStatus
 	{
-		c := clusterrolebinding.Config{
-			K8sClient: config.K8sClient,
+		c := clusterstatus.Config{
+			G8sClient: config.G8sClient,
+			Logger:    config.Logger,
+		}
+
+		clusterStatus, err = clusterstatus.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var clusterLister *clusterlister.ClusterLister
+	{
+		c := clusterlister.Config{
+			G8sClient: config.G8sClient,
 			Logger:    config.Logger,
 		}
 
-		ops, err := clusterrolebinding.New(c)
+		clusterLister, err = clusterlister.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var guestClient *guestclient.GuestClient
+	{
+		c := guestclient.Config{
+			CertsSearcher: config.CertsSearcher,
+		}
+
+		guestClient, err = guestclient.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var etcdCluster *etcdcluster.EtcdCluster
+	{
+		c := etcdcluster.Config{
+			CertsSearcher: config.CertsSearcher,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+		}
+
+		etcdCluster, err = etcdcluster.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var customerQuota *customerquota.CustomerQuota
+	{
+		c := customerquota.Config{
+			ClusterLister: clusterLister,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+
+			ConfigMapName:      config.GuestCustomerQuotaConfigMapName,
+			ConfigMapNamespace: config.GuestCustomerQuotaConfigMapNamespace,
+		}
+
+		customerQuota, err = customerquota.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var quorumGuard *quorumguard.QuorumGuard
+	{
+		c := quorumguard.Config{
+			CertsSearcher: config.CertsSearcher,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+		}
+
+		quorumGuard, err = quorumguard.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var scaleDown *scaledown.ScaleDown
+	{
+		c := scaledown.Config{
+			GuestClient: guestClient,
+			K8sClient:   config.K8sClient,
+			Logger:      config.Logger,
+
+			Policy: config.ScaleDownPolicy,
+		}
+
+		scaleDown, err = scaledown.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	// The cert config resources are only used in case certificates are
+	// provisioned, since they require the cert-operator configuration.
+	var certConfigResource controller.Resource
+	if config.GuestCertsProvision {
+		c := certconfig.Config{
+			ClusterStatus: clusterStatus,
+			G8sClient:     config.G8sClient,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+
+			TTL:                  config.GuestCertsTTL,
+			VersionBundleVersion: config.GuestCertsVersionBundleVersion,
+		}
+
+		ops, err := certconfig.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		certConfigResource, err = toCRUDResource(config.Logger, ops)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var certConfigCleanupResource controller.Resource
+	if config.GuestCertsProvision {
+		c := certconfig.Config{
+			ClusterStatus: clusterStatus,
+			G8sClient:     config.G8sClient,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+
+			Cleanup:              true,
+			TTL:                  config.GuestCertsTTL,
+			VersionBundleVersion: config.GuestCertsVersionBundleVersion,
+		}
+
+		ops, err := certconfig.New(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 
-		clusterRoleBindingResource, err = toCRUDResource(config.Logger, ops)
+		certConfigCleanupResource, err = toCRUDResource(config.Logger, ops)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var clusterRoleBindingResource controller.Resource
+	{
+		c := clusterrolebinding.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+		}
+
+		clusterRoleBindingResource, err = clusterrolebinding.New(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
@@ -75,9 +270,14 @@ func NewClusterResourceSet(config ClusterResourceSetConfig) (*controller.Resourc
 	{
 		c := namespace.DefaultConfig()
 
+		c.ClusterStatus = clusterStatus
 		c.K8sClient = config.K8sClient
 		c.Logger = config.Logger
 
+		c.CPUCeiling = config.GuestCeilingCPU
+		c.MemoryCeiling = config.GuestCeilingMemory
+		c.UpdateSurge = config.GuestUpdateSurge
+
 		ops, err := namespace.New(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
@@ -89,12 +289,93 @@ func NewClusterResourceSet(config ClusterResourceSetConfig) (*controller.Resourc
 		}
 	}
 
+	var networkPolicyResource controller.Resource
+	{
+		c := networkpolicy.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+
+			DNSNamespace:               config.GuestNetworkPolicyDNSNamespace,
+			IngressControllerNamespace: config.GuestNetworkPolicyIngressControllerNamespace,
+			OperatorNamespace:          config.GuestNetworkPolicyOperatorNamespace,
+			PodCIDR:                    config.GuestNetworkPolicyPodCIDR,
+		}
+
+		ops, err := networkpolicy.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		networkPolicyResource, err = toCRUDResource(config.Logger, ops)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var pullSecretResource controller.Resource
+	{
+		c := pullsecret.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+
+			PullSecretName:      config.RegistryPullSecretName,
+			PullSecretNamespace: config.RegistryPullSecretNamespace,
+		}
+
+		ops, err := pullsecret.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		pullSecretResource, err = toCRUDResource(config.Logger, ops)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var roleResource controller.Resource
+	{
+		c := role.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+		}
+
+		ops, err := role.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		roleResource, err = toCRUDResource(config.Logger, ops)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var roleBindingResource controller.Resource
+	{
+		c := rolebinding.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+		}
+
+		ops, err := rolebinding.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		roleBindingResource, err = toCRUDResource(config.Logger, ops)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var serviceAccountResource controller.Resource
 	{
 		c := serviceaccount.DefaultConfig()
 
 		c.K8sClient = config.K8sClient
 		c.Logger = config.Logger
+		c.PullSecretName = config.RegistryPullSecretName
 
 		ops, err := serviceaccount.New(c)
 		if err != nil {
@@ -107,12 +388,74 @@ func NewClusterResourceSet(config ClusterResourceSetConfig) (*controller.Resourc
 		}
 	}
 
+	var daemonSetResource controller.Resource
+	{
+		c := daemonset.Config{
+			ClusterLister: clusterLister,
+			ClusterStatus: clusterStatus,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+
+			BaseURL:        config.GuestImagePrePullBaseURL,
+			Namespace:      config.GuestImagePrePullNamespace,
+			RegistryMirror: config.RegistryMirror,
+			ServiceAccount: config.GuestImagePrePullServiceAccount,
+		}
+
+		ops, err := daemonset.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		daemonSetResource, err = toCRUDResource(config.Logger, ops)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var encryptionKeyResource controller.Resource
+	{
+		c := encryptionkey.Config{
+			ClusterStatus: clusterStatus,
+			GuestClient:   guestClient,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+		}
+
+		encryptionKeyResource, err = encryptionkey.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var cloudConfigSecretResource controller.Resource
+	{
+		c := cloudconfigsecret.Config{
+			CertSearcher: config.CertsSearcher,
+			CloudConfig:  cloudConfig,
+			K8sClient:    config.K8sClient,
+			KeyWatcher:   config.RandomkeysSearcher,
+			Logger:       config.Logger,
+		}
+
+		ops, err := cloudconfigsecret.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		cloudConfigSecretResource, err = toCRUDResource(config.Logger, ops)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var configMapResource controller.Resource
 	{
 		c := configmap.DefaultConfig()
 
 		c.CertSearcher = config.CertsSearcher
 		c.CloudConfig = cloudConfig
+		c.CloudConfigSecret = config.GuestCloudConfigSecret
 		c.K8sClient = config.K8sClient
 		c.KeyWatcher = config.RandomkeysSearcher
 		c.Logger = config.Logger
@@ -132,8 +475,20 @@ func NewClusterResourceSet(config ClusterResourceSetConfig) (*controller.Resourc
 	{
 		c := deployment.DefaultConfig()
 
+		c.AppArmorProfile = config.GuestAppArmorProfile
+		c.AuditHostVolume = config.Audit.HostVolume
+		c.CloudConfigCompress = config.GuestCloudConfigCompress
+		c.CloudConfigSecret = config.GuestCloudConfigSecret
+		c.ClusterStatus = clusterStatus
+		c.CustomerQuota = customerQuota
+		c.G8sClient = config.G8sClient
+		c.GuestClient = guestClient
 		c.K8sClient = config.K8sClient
 		c.Logger = config.Logger
+		c.QuorumGuard = quorumGuard
+		c.RegistryMirror = config.RegistryMirror
+		c.ScaleDownMaxWorkers = config.ScaleDownMaxWorkers
+		c.UpdateSurge = config.GuestUpdateSurge
 
 		ops, err := deployment.New(c)
 		if err != nil {
@@ -146,6 +501,21 @@ func NewClusterResourceSet(config ClusterResourceSetConfig) (*controller.Resourc
 		}
 	}
 
+	var etcdMemberResource controller.Resource
+	{
+		c := etcdmember.Config{
+			EtcdCluster: etcdCluster,
+			K8sClient:   config.K8sClient,
+			Logger:      config.Logger,
+			QuorumGuard: quorumGuard,
+		}
+
+		etcdMemberResource, err = etcdmember.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var ingressResource controller.Resource
 	{
 		c := ingress.DefaultConfig()
@@ -200,15 +570,51 @@ func NewClusterResourceSet(config ClusterResourceSetConfig) (*controller.Resourc
 		}
 	}
 
-	resources := []controller.Resource{
-		clusterRoleBindingResource,
+	var resources []controller.Resource
+
+	// Certificates have to be issued before the cloud-configs of guest cluster
+	// nodes can be rendered. The cert config resource cancels the
+	// reconciliation until they are.
+	if config.GuestCertsProvision {
+		resources = append(resources, certConfigResource)
+	}
+
+	resources = append(resources,
 		namespaceResource,
+		networkPolicyResource,
+		pullSecretResource,
 		serviceAccountResource,
+		roleResource,
+		roleBindingResource,
+		// Guest clusters are migrated off their cluster role bindings only
+		// after their service accounts have been bound in their namespaces.
+		clusterRoleBindingResource,
+		daemonSetResource,
+		// The encryption key rotation changes the encryption keys the
+		// cloud-configs of masters are rendered with, so it runs before them.
+		encryptionKeyResource,
+	)
+
+	// Cloud-config secrets have to exist before the config maps of migrated
+	// nodes are deleted and before deployments mount them.
+	if config.GuestCloudConfigSecret {
+		resources = append(resources, cloudConfigSecretResource)
+	}
+
+	resources = append(resources,
 		configMapResource,
+		etcdMemberResource,
 		deploymentResource,
 		ingressResource,
 		pvcResource,
 		serviceResource,
+	)
+
+	// The cert configs of deleted guest clusters are deleted last, since
+	// deleting them makes cert-operator delete the certificates the resources
+	// before might still need.
+	if config.GuestCertsProvision {
+		resources = append(resources, certConfigCleanupResource)
 	}
 
 	{
@@ -251,6 +657,41 @@ func NewClusterResourceSet(config ClusterResourceSetConfig) (*controller.Resourc
 			updateallowedcontext.SetUpdateAllowed(ctx)
 		}
 
+		// The workers are computed once per reconciliation, because choosing the
+		// workers to be removed on scale down depends on the current state of
+		// the guest cluster. This way all resources agree on the same workers.
+		{
+			customObject, err := key.ToCustomObject(obj)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			if !key.IsDeleted(customObject) {
+				workers, err := key.WorkerNodes(customObject)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				replicas, ok, err := specReplicas(ctx, clusterLister, customObject)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+				if ok {
+					workers, err = scaleWorkerNodes(ctx, config.Logger, clusterStatus, customObject, workers, replicas)
+					if err != nil {
+						return nil, microerror.Mask(err)
+					}
+				}
+
+				workers, err = scaleDown.WorkerNodes(ctx, customObject, workers)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				ctx = workerscontext.NewContext(ctx, workers)
+			}
+		}
+
 		return ctx, nil
 	}
 
@@ -285,3 +726,61 @@ func toCRUDResource(logger micrologger.Logger, ops controller.CRUDResourceOps) (
 
 	return r, nil
 }
+
+// specReplicas returns the number of workers requested using the scale
+// subresource of the KVMConfig CRD, if any. The KVMConfig type does not define
+// replicas, which is why they are read from the raw custom objects cached by
+// the cluster lister.
+func specReplicas(ctx context.Context, clusterLister clusterlister.Interface, customObject v1alpha1.KVMConfig) (int, bool, error) {
+	clusters, err := clusterLister.List(ctx)
+	if err != nil {
+		return 0, false, microerror.Mask(err)
+	}
+
+	for _, c := range clusters {
+		if c.CustomObject.GetNamespace() != customObject.GetNamespace() || c.CustomObject.GetName() != customObject.GetName() {
+			continue
+		}
+		if c.Replicas == nil {
+			return 0, false, nil
+		}
+
+		return *c.Replicas, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// scaleWorkerNodes scales the given workers to the given replicas and reports
+// whether the replicas are valid using clusterstatus.ConditionReplicasValid.
+// Rejected replicas are returned as error, so the guest cluster is not
+// reconciled with workers the custom object does not define.
+func scaleWorkerNodes(ctx context.Context, logger micrologger.Logger, clusterStatus clusterstatus.Interface, customObject v1alpha1.KVMConfig, workers []key.WorkerNode, replicas int) ([]key.WorkerNode, error) {
+	scaled, err := key.ScaleWorkerNodes(workers, replicas)
+
+	condition := clusterstatus.Condition{
+		Type: clusterstatus.ConditionReplicasValid,
+	}
+	if key.IsInvalidSpec(err) {
+		condition.Status = clusterstatus.ConditionStatusFalse
+		condition.Reason = "ReplicasRejected"
+		condition.Message = err.Error()
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	} else {
+		condition.Status = clusterstatus.ConditionStatusTrue
+		condition.Reason = "ReplicasApplied"
+		condition.Message = fmt.Sprintf("guest cluster is scaled to %d replicas", replicas)
+	}
+
+	statusErr := clusterStatus.SetCondition(ctx, customObject, condition)
+	if statusErr != nil {
+		logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cannot set status condition '%s'", condition.Type), "stack", fmt.Sprintf("%#v", statusErr))
+	}
+
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return scaled, nil
+}
@@ -0,0 +1,195 @@
+package clusterlister
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/apiextensions/pkg/clientset/versioned"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// syncTimeout is the time to wait for the cache to be filled initially
+	// before listing guest clusters fails.
+	syncTimeout = 30 * time.Second
+)
+
+type Config struct {
+	G8sClient versioned.Interface
+	Logger    micrologger.Logger
+}
+
+// ClusterLister implements Interface. The KVMConfig custom objects of the
+// installation are watched and cached once they are listed for the first
+// time. They are cached in their raw representation, so fields the KVMConfig
+// type does not define, like the replicas of the scale subresource, are kept.
+type ClusterLister struct {
+	logger micrologger.Logger
+
+	informer cache.SharedIndexInformer
+	once     sync.Once
+	stopCh   chan struct{}
+}
+
+func New(config Config) (*ClusterLister, error) {
+	if config.G8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.G8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	l := &ClusterLister{
+		logger: config.Logger,
+
+		informer: cache.NewSharedIndexInformer(newListWatch(config.G8sClient), &unstructured.Unstructured{}, 0, cache.Indexers{}),
+		stopCh:   make(chan struct{}),
+	}
+
+	return l, nil
+}
+
+func (l *ClusterLister) List(ctx context.Context) ([]Cluster, error) {
+	l.once.Do(func() {
+		l.logger.LogCtx(ctx, "level", "debug", "message", "starting to watch KVMConfig custom objects")
+		go l.informer.Run(l.stopCh)
+	})
+
+	{
+		ctx, cancel := context.WithTimeout(ctx, syncTimeout)
+		defer cancel()
+
+		if !cache.WaitForCacheSync(ctx.Done(), l.informer.HasSynced) {
+			return nil, microerror.Maskf(notSyncedError, "KVMConfig custom objects are not cached yet")
+		}
+	}
+
+	var clusters []Cluster
+	for _, obj := range l.informer.GetStore().List() {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", &unstructured.Unstructured{}, obj)
+		}
+
+		c, err := toCluster(u)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		clusters = append(clusters, c)
+	}
+
+	return clusters, nil
+}
+
+func newListWatch(g8sClient versioned.Interface) *cache.ListWatch {
+	restClient := g8sClient.ProviderV1alpha1().RESTClient()
+
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			b, err := restClient.Get().Resource("kvmconfigs").VersionedParams(&options, metav1.ParameterCodec).DoRaw()
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			return runtime.Decode(unstructured.UnstructuredJSONScheme, b)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			stream, err := restClient.Get().Resource("kvmconfigs").VersionedParams(&options, metav1.ParameterCodec).Stream()
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			return watch.NewStreamWatcher(newWatchDecoder(stream)), nil
+		},
+	}
+
+	return lw
+}
+
+func toCluster(u *unstructured.Unstructured) (Cluster, error) {
+	b, err := u.MarshalJSON()
+	if err != nil {
+		return Cluster{}, microerror.Mask(err)
+	}
+
+	var c Cluster
+	err = json.Unmarshal(b, &c.CustomObject)
+	if err != nil {
+		return Cluster{}, microerror.Mask(err)
+	}
+
+	var raw struct {
+		Spec struct {
+			Replicas *int `json:"replicas"`
+		} `json:"spec"`
+	}
+	err = json.Unmarshal(b, &raw)
+	if err != nil {
+		return Cluster{}, microerror.Mask(err)
+	}
+	c.Replicas = raw.Spec.Replicas
+
+	return c, nil
+}
+
+// watchDecoder decodes the watch events streamed by the Kubernetes API into
+// unstructured objects. Error events are decoded into statuses, so the
+// reflector of the informer can tell why the watch ended.
+type watchDecoder struct {
+	decoder *json.Decoder
+	stream  io.ReadCloser
+}
+
+func newWatchDecoder(stream io.ReadCloser) *watchDecoder {
+	d := &watchDecoder{
+		decoder: json.NewDecoder(stream),
+		stream:  stream,
+	}
+
+	return d
+}
+
+func (d *watchDecoder) Decode() (watch.EventType, runtime.Object, error) {
+	var event struct {
+		Type   watch.EventType `json:"type"`
+		Object json.RawMessage `json:"object"`
+	}
+	err := d.decoder.Decode(&event)
+	if err != nil {
+		// The stream watcher stops silently on io.EOF, which is why errors are
+		// not masked here.
+		return "", nil, err
+	}
+
+	if event.Type == watch.Error {
+		status := &metav1.Status{}
+		err := json.Unmarshal(event.Object, status)
+		if err != nil {
+			return "", nil, microerror.Mask(err)
+		}
+
+		return event.Type, status, nil
+	}
+
+	obj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, event.Object)
+	if err != nil {
+		return "", nil, microerror.Mask(err)
+	}
+
+	return event.Type, obj, nil
+}
+
+func (d *watchDecoder) Close() {
+	d.stream.Close()
+}
@@ -0,0 +1,88 @@
+package clusterlister
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func Test_ClusterLister_watchDecoder(t *testing.T) {
+	stream := `{"type":"ADDED","object":{"apiVersion":"provider.giantswarm.io/v1alpha1","kind":"KVMConfig","metadata":{"name":"al9qy"},"spec":{"cluster":{"id":"al9qy"},"replicas":3}}}
+{"type":"DELETED","object":{"apiVersion":"provider.giantswarm.io/v1alpha1","kind":"KVMConfig","metadata":{"name":"b3kz1"},"spec":{"cluster":{"id":"b3kz1"}}}}
+{"type":"ERROR","object":{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Expired","code":410}}
+`
+
+	d := newWatchDecoder(ioutil.NopCloser(strings.NewReader(stream)))
+
+	testCases := []struct {
+		ExpectedType     watch.EventType
+		ExpectedID       string
+		ExpectedReplicas *int
+	}{
+		// Test 0 ensures added custom objects are decoded along with the
+		// replicas the KVMConfig type does not define.
+		{
+			ExpectedType:     watch.Added,
+			ExpectedID:       "al9qy",
+			ExpectedReplicas: func() *int { r := 3; return &r }(),
+		},
+
+		// Test 1 ensures custom objects not requesting replicas have none.
+		{
+			ExpectedType:     watch.Deleted,
+			ExpectedID:       "b3kz1",
+			ExpectedReplicas: nil,
+		},
+
+		// Test 2 ensures error events are decoded into statuses.
+		{
+			ExpectedType: watch.Error,
+		},
+	}
+
+	for i, tc := range testCases {
+		eventType, obj, err := d.Decode()
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if eventType != tc.ExpectedType {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedType, eventType)
+		}
+
+		if eventType == watch.Error {
+			status, ok := obj.(*metav1.Status)
+			if !ok {
+				t.Fatalf("case %d expected %T got %T", i, &metav1.Status{}, obj)
+			}
+			if status.Reason != metav1.StatusReasonExpired {
+				t.Fatalf("case %d expected %#v got %#v", i, metav1.StatusReasonExpired, status.Reason)
+			}
+			continue
+		}
+
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			t.Fatalf("case %d expected %T got %T", i, &unstructured.Unstructured{}, obj)
+		}
+		c, err := toCluster(u)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		if c.CustomObject.Spec.Cluster.ID != tc.ExpectedID {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedID, c.CustomObject.Spec.Cluster.ID)
+		}
+		if (c.Replicas == nil) != (tc.ExpectedReplicas == nil) || (c.Replicas != nil && *c.Replicas != *tc.ExpectedReplicas) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedReplicas, c.Replicas)
+		}
+	}
+
+	_, _, err := d.Decode()
+	if err == nil {
+		t.Fatalf("expected %#v got %#v", "EOF", nil)
+	}
+}
@@ -0,0 +1,23 @@
+package clusterlistertest
+
+import (
+	"context"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterlister"
+)
+
+// ClusterLister is a clusterlister.Interface implementation listing the
+// configured guest clusters, for use in tests.
+type ClusterLister struct {
+	Clusters []clusterlister.Cluster
+}
+
+func New(clusters ...clusterlister.Cluster) *ClusterLister {
+	return &ClusterLister{
+		Clusters: clusters,
+	}
+}
+
+func (l *ClusterLister) List(ctx context.Context) ([]clusterlister.Cluster, error) {
+	return l.Clusters, nil
+}
@@ -0,0 +1,24 @@
+package clusterlister
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = microerror.New("invalid config")
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var notSyncedError = microerror.New("not synced")
+
+// IsNotSynced asserts notSyncedError.
+func IsNotSynced(err error) bool {
+	return microerror.Cause(err) == notSyncedError
+}
+
+var wrongTypeError = microerror.New("wrong type")
+
+// IsWrongTypeError asserts wrongTypeError.
+func IsWrongTypeError(err error) bool {
+	return microerror.Cause(err) == wrongTypeError
+}
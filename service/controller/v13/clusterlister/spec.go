@@ -0,0 +1,24 @@
+package clusterlister
+
+import (
+	"context"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+)
+
+// Interface describes how the guest clusters of the installation are looked
+// up without querying the Kubernetes API on every reconciliation.
+type Interface interface {
+	// List returns all guest clusters of the installation, including the ones
+	// being deleted.
+	List(ctx context.Context) ([]Cluster, error)
+}
+
+// Cluster is the KVMConfig custom object of a single guest cluster.
+type Cluster struct {
+	CustomObject v1alpha1.KVMConfig
+	// Replicas is the number of workers requested using the scale subresource
+	// of the KVMConfig CRD, which the KVMConfig type does not define. It is nil
+	// in case no replicas are requested.
+	Replicas *int
+}
@@ -0,0 +1,184 @@
+package clusterstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/apiextensions/pkg/clientset/versioned"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type Config struct {
+	G8sClient versioned.Interface
+	Logger    micrologger.Logger
+}
+
+// ClusterStatus implements Interface. The KVMConfig type does not define a
+// status, so the status is read from the raw custom object and written using
+// merge patches against the status subresource.
+type ClusterStatus struct {
+	g8sClient versioned.Interface
+	logger    micrologger.Logger
+}
+
+func New(config Config) (*ClusterStatus, error) {
+	if config.G8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.G8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	s := &ClusterStatus{
+		g8sClient: config.G8sClient,
+		logger:    config.Logger,
+	}
+
+	return s, nil
+}
+
+func (s *ClusterStatus) SetCondition(ctx context.Context, customObject v1alpha1.KVMConfig, condition Condition) error {
+	status, err := s.status(customObject)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var conditions []Condition
+	{
+		var found bool
+
+		for _, c := range status.Conditions {
+			if c.Type != condition.Type {
+				conditions = append(conditions, c)
+				continue
+			}
+
+			found = true
+
+			if c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+				return nil
+			}
+			if c.Status == condition.Status {
+				condition.LastTransitionTime = c.LastTransitionTime
+			} else {
+				condition.LastTransitionTime = apismetav1.NewTime(time.Now())
+			}
+
+			conditions = append(conditions, condition)
+		}
+
+		if !found {
+			condition.LastTransitionTime = apismetav1.NewTime(time.Now())
+			conditions = append(conditions, condition)
+		}
+	}
+
+	s.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("setting status condition '%s' to '%s'", condition.Type, condition.Status))
+
+	status.Conditions = conditions
+
+	err = s.patchStatus(customObject, status)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	s.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("set status condition '%s' to '%s'", condition.Type, condition.Status))
+
+	return nil
+}
+
+func (s *ClusterStatus) SetReplicas(ctx context.Context, customObject v1alpha1.KVMConfig, replicas int) error {
+	status, err := s.status(customObject)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if status.Replicas == replicas {
+		return nil
+	}
+
+	s.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("setting status replicas to %d", replicas))
+
+	// Only the replicas are patched, so the conditions are left untouched.
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"replicas": replicas,
+		},
+	}
+
+	err = s.patch(customObject, patch)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	s.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("set status replicas to %d", replicas))
+
+	return nil
+}
+
+func (s *ClusterStatus) patchStatus(customObject v1alpha1.KVMConfig, status Status) error {
+	patch := struct {
+		Status Status `json:"status"`
+	}{
+		Status: status,
+	}
+
+	err := s.patch(customObject, patch)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// patch applies the given merge patch to the status subresource of the custom
+// object. In case the status subresource is not enabled for the CRD, the patch
+// is applied to the custom object itself.
+func (s *ClusterStatus) patch(customObject v1alpha1.KVMConfig, patch interface{}) error {
+	b, err := json.Marshal(patch)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	kvmConfigs := s.g8sClient.ProviderV1alpha1().KVMConfigs(customObject.GetNamespace())
+
+	_, err = kvmConfigs.Patch(customObject.GetName(), types.MergePatchType, b, "status")
+	if apierrors.IsNotFound(err) {
+		_, err = kvmConfigs.Patch(customObject.GetName(), types.MergePatchType, b)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (s *ClusterStatus) status(customObject v1alpha1.KVMConfig) (Status, error) {
+	b, err := s.g8sClient.ProviderV1alpha1().RESTClient().Get().
+		Namespace(customObject.GetNamespace()).
+		Resource("kvmconfigs").
+		Name(customObject.GetName()).
+		DoRaw()
+	if err != nil {
+		return Status{}, microerror.Mask(err)
+	}
+
+	var raw struct {
+		Status Status `json:"status"`
+	}
+	err = json.Unmarshal(b, &raw)
+	if err != nil {
+		return Status{}, microerror.Mask(err)
+	}
+
+	return raw.Status, nil
+}
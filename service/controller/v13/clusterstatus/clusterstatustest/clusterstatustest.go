@@ -0,0 +1,32 @@
+package clusterstatustest
+
+import (
+	"context"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+)
+
+// ClusterStatus is a clusterstatus.Interface implementation recording the
+// conditions and replicas it was asked to set, for use in tests.
+type ClusterStatus struct {
+	Conditions []clusterstatus.Condition
+	Replicas   []int
+}
+
+func New() *ClusterStatus {
+	return &ClusterStatus{}
+}
+
+func (s *ClusterStatus) SetCondition(ctx context.Context, customObject v1alpha1.KVMConfig, condition clusterstatus.Condition) error {
+	s.Conditions = append(s.Conditions, condition)
+
+	return nil
+}
+
+func (s *ClusterStatus) SetReplicas(ctx context.Context, customObject v1alpha1.KVMConfig, replicas int) error {
+	s.Replicas = append(s.Replicas, replicas)
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package clusterstatus
+
+import (
+	"context"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionCoreosImagePresent reports whether the Container Linux image
+	// requested by the guest cluster is available on all eligible hosts.
+	ConditionCoreosImagePresent = "CoreosImagePresent"
+	// ConditionCertificatesPresent reports whether all certificates of the guest
+	// cluster are issued by cert-operator.
+	ConditionCertificatesPresent = "CertificatesPresent"
+	// ConditionEncryptionKeyRotated reports the progress of the rotation of the
+	// key the API server encrypts secrets with. The reason is the phase of the
+	// rotation.
+	ConditionEncryptionKeyRotated = "EncryptionKeyRotated"
+	// ConditionHostCapacitySufficient reports whether the VM pods of the guest
+	// cluster being created or updated fit on the hosts. Deployments of the
+	// guest cluster are not created or updated while they do not.
+	ConditionHostCapacitySufficient = "HostCapacitySufficient"
+	// ConditionCustomerWithinQuota reports whether the customer of the guest
+	// cluster is within its quota. Deployments of the guest cluster are not
+	// created or updated while it is not.
+	ConditionCustomerWithinQuota = "CustomerWithinQuota"
+	// ConditionResourcesWithinCeiling reports whether the resources requested
+	// for the VMs of the guest cluster are within the configured ceiling. The
+	// guest cluster is not reconciled further while they are not.
+	ConditionResourcesWithinCeiling = "ResourcesWithinCeiling"
+	// ConditionReplicasValid reports whether the replicas requested using the
+	// scale subresource can be applied. Workers defined in the custom object
+	// spec are never removed by scaling, so fewer replicas are rejected and the
+	// guest cluster is not reconciled until they are fixed.
+	ConditionReplicasValid = "ReplicasValid"
+)
+
+const (
+	ConditionStatusFalse = "False"
+	ConditionStatusTrue  = "True"
+)
+
+// Interface describes how the status of guest clusters is reported on their
+// KVMConfig custom objects.
+type Interface interface {
+	// SetCondition ensures the given condition is reflected in the status of the
+	// given custom object. Existing conditions of the same type are replaced.
+	// The transition time of a condition is only updated when its status
+	// changes.
+	SetCondition(ctx context.Context, customObject v1alpha1.KVMConfig, condition Condition) error
+	// SetReplicas ensures the number of available workers is reflected in the
+	// status of the given custom object. The value is exposed through the scale
+	// subresource of the KVMConfig CRD.
+	SetReplicas(ctx context.Context, customObject v1alpha1.KVMConfig, replicas int) error
+}
+
+// Status is the status of a guest cluster as stored in the status of the
+// KVMConfig custom object.
+type Status struct {
+	Conditions []Condition `json:"conditions,omitempty"`
+	Replicas   int         `json:"replicas"`
+}
+
+// Condition is a single observation of the guest cluster state.
+type Condition struct {
+	LastTransitionTime apismetav1.Time `json:"lastTransitionTime"`
+	Message            string          `json:"message,omitempty"`
+	Reason             string          `json:"reason,omitempty"`
+	Status             string          `json:"status"`
+	Type               string          `json:"type"`
+}
@@ -0,0 +1,282 @@
+package customerquota
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterlister"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/metric"
+)
+
+type Config struct {
+	ClusterLister clusterlister.Interface
+	K8sClient     kubernetes.Interface
+	Logger        micrologger.Logger
+
+	// ConfigMapName and ConfigMapNamespace locate the config map defining the
+	// quotas of customers. Each key of the config map is a customer ID and its
+	// value the YAML encoded quota of the customer, e.g.
+	//
+	//     cpus: 64
+	//     memory: 256Gi
+	//     disk: 2T
+	//     clusters: 5
+	//
+	// Customer quotas are not enforced in case ConfigMapName is empty.
+	ConfigMapName      string
+	ConfigMapNamespace string
+}
+
+// CustomerQuota implements Interface. The usage of a customer is computed from
+// the specs of its guest clusters, so guest clusters count against the quota
+// as soon as they are specified, not only once their VMs run. Workers are
+// scaled to the replicas requested using the scale subresource, like the
+// deployments of the workers are. Guest clusters being deleted do not count.
+type CustomerQuota struct {
+	clusterLister clusterlister.Interface
+	k8sClient     kubernetes.Interface
+	logger        micrologger.Logger
+
+	configMapName      string
+	configMapNamespace string
+}
+
+func New(config Config) (*CustomerQuota, error) {
+	if config.ClusterLister == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ClusterLister must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.ConfigMapName != "" && config.ConfigMapNamespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ConfigMapNamespace must not be empty", config)
+	}
+
+	q := &CustomerQuota{
+		clusterLister: config.ClusterLister,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+
+		configMapName:      config.ConfigMapName,
+		configMapNamespace: config.ConfigMapNamespace,
+	}
+
+	return q, nil
+}
+
+func (q *CustomerQuota) Check(ctx context.Context, customObject v1alpha1.KVMConfig) (Decision, error) {
+	customerID := key.ClusterCustomer(customObject)
+
+	quota, err := q.findQuota(customerID)
+	if err != nil {
+		return Decision{}, microerror.Mask(err)
+	}
+	if quota == nil {
+		d := Decision{
+			Allowed: true,
+			Reason:  fmt.Sprintf("customer '%s' has no quota", customerID),
+		}
+		return d, nil
+	}
+
+	usage, err := q.computeUsage(ctx, customObject)
+	if err != nil {
+		return Decision{}, microerror.Mask(err)
+	}
+
+	report(customerID, *quota, usage)
+
+	exceeded := exceededResources(*quota, usage)
+
+	d := Decision{
+		Allowed:  len(exceeded) == 0,
+		Enforced: true,
+	}
+	if d.Allowed {
+		d.Reason = fmt.Sprintf("customer '%s' is within its quota", customerID)
+	} else {
+		d.Reason = fmt.Sprintf("customer '%s' is over quota: %s", customerID, strings.Join(exceeded, ", "))
+	}
+
+	q.logger.LogCtx(ctx, "level", "debug", "message", d.Reason)
+
+	return d, nil
+}
+
+// findQuota returns the quota of the given customer, or nil in case none is
+// defined.
+func (q *CustomerQuota) findQuota(customerID string) (*Quota, error) {
+	if q.configMapName == "" {
+		return nil, nil
+	}
+
+	m, err := q.k8sClient.CoreV1().ConfigMaps(q.configMapNamespace).Get(q.configMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	data, ok := m.Data[customerID]
+	if !ok {
+		return nil, nil
+	}
+
+	var quota Quota
+	err = yaml.Unmarshal([]byte(data), &quota)
+	if err != nil {
+		return nil, microerror.Maskf(invalidQuotaError, "quota of customer '%s' must be valid YAML: %s", customerID, err)
+	}
+
+	return &quota, nil
+}
+
+// computeUsage sums up the resources of all guest clusters of the customer of
+// the given guest cluster. The given guest cluster is taken into account as
+// given, since it may be specified differently than stored. Only its replicas
+// are looked up, since the KVMConfig type does not define them.
+func (q *CustomerQuota) computeUsage(ctx context.Context, customObject v1alpha1.KVMConfig) (Usage, error) {
+	customerID := key.ClusterCustomer(customObject)
+
+	list, err := q.clusterLister.List(ctx)
+	if err != nil {
+		return Usage{}, microerror.Mask(err)
+	}
+
+	clusters := []clusterlister.Cluster{{CustomObject: customObject}}
+	for _, c := range list {
+		if key.ClusterID(c.CustomObject) == key.ClusterID(customObject) {
+			clusters[0].Replicas = c.Replicas
+			continue
+		}
+		if key.ClusterCustomer(c.CustomObject) != customerID {
+			continue
+		}
+		if c.CustomObject.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		clusters = append(clusters, c)
+	}
+
+	var usage Usage
+	for _, c := range clusters {
+		nodes, err := clusterNodeResources(c)
+		if key.IsInvalidSpec(err) && key.ClusterID(c.CustomObject) != key.ClusterID(customObject) {
+			// Other guest clusters having an invalid spec do not block the given
+			// guest cluster. They have no VMs running their nodes anyway.
+			q.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cannot compute resources of guest cluster '%s'", key.ClusterID(c.CustomObject)), "stack", fmt.Sprintf("%#v", err))
+			continue
+		} else if err != nil {
+			return Usage{}, microerror.Mask(err)
+		}
+
+		usage.Clusters++
+		for _, n := range nodes {
+			usage.CPUs.Add(n.CPU)
+			usage.Disk.Add(n.Disk)
+			usage.Memory.Add(n.Memory)
+		}
+	}
+
+	return usage, nil
+}
+
+// clusterNodeResources returns the resources of all nodes of the given guest
+// cluster, with its workers scaled to the requested replicas. Rejected replicas
+// are not applied to the guest cluster, so they are ignored here as well.
+func clusterNodeResources(c clusterlister.Cluster) ([]key.NodeResources, error) {
+	workers, err := key.WorkerNodes(c.CustomObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if c.Replicas != nil {
+		scaled, err := key.ScaleWorkerNodes(workers, *c.Replicas)
+		if key.IsInvalidSpec(err) {
+			// fall through
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		} else {
+			workers = scaled
+		}
+	}
+
+	nodes, err := key.ClusterNodeResources(c.CustomObject, workers)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return nodes, nil
+}
+
+// exceededResources returns a description of each resource the given usage
+// exceeds the given quota with.
+func exceededResources(quota Quota, usage Usage) []string {
+	var exceeded []string
+
+	if quota.Clusters != nil && usage.Clusters > *quota.Clusters {
+		exceeded = append(exceeded, fmt.Sprintf("%d clusters exceed quota of %d", usage.Clusters, *quota.Clusters))
+	}
+	if quota.CPUs != nil && usage.CPUs.Cmp(*quota.CPUs) > 0 {
+		exceeded = append(exceeded, fmt.Sprintf("%s CPUs exceed quota of %s", usage.CPUs.String(), quota.CPUs.String()))
+	}
+	if quota.Disk != nil && usage.Disk.Cmp(*quota.Disk) > 0 {
+		exceeded = append(exceeded, fmt.Sprintf("%s disk exceeds quota of %s", usage.Disk.String(), quota.Disk.String()))
+	}
+	if quota.Memory != nil && usage.Memory.Cmp(*quota.Memory) > 0 {
+		exceeded = append(exceeded, fmt.Sprintf("%s memory exceeds quota of %s", usage.Memory.String(), quota.Memory.String()))
+	}
+
+	return exceeded
+}
+
+// report exports the given usage and quota of the given customer as metrics.
+// Resources not limited by the quota have no quota metric. CPUs are exported
+// as number of CPUs, disk and memory in bytes.
+func report(customerID string, quota Quota, usage Usage) {
+	usages := map[string]float64{
+		ResourceClusters: float64(usage.Clusters),
+		ResourceCPUs:     float64(usage.CPUs.MilliValue()) / 1000,
+		ResourceDisk:     float64(usage.Disk.Value()),
+		ResourceMemory:   float64(usage.Memory.Value()),
+	}
+
+	limits := map[string]float64{}
+	if quota.Clusters != nil {
+		limits[ResourceClusters] = float64(*quota.Clusters)
+	}
+	if quota.CPUs != nil {
+		limits[ResourceCPUs] = float64(quota.CPUs.MilliValue()) / 1000
+	}
+	if quota.Disk != nil {
+		limits[ResourceDisk] = float64(quota.Disk.Value())
+	}
+	if quota.Memory != nil {
+		limits[ResourceMemory] = float64(quota.Memory.Value())
+	}
+
+	for r, v := range usages {
+		metric.CustomerQuotaUsageGauge.WithLabelValues(customerID, r).Set(v)
+
+		l, ok := limits[r]
+		if ok {
+			metric.CustomerQuotaLimitGauge.WithLabelValues(customerID, r).Set(l)
+		} else {
+			metric.CustomerQuotaLimitGauge.DeleteLabelValues(customerID, r)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package customerquota
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterlister"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterlister/clusterlistertest"
+)
+
+func Test_CustomerQuota_Check(t *testing.T) {
+	newCluster := func(id, customerID string, cpus int, deleted bool) *v1alpha1.KVMConfig {
+		c := &v1alpha1.KVMConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      id,
+				Namespace: "default",
+			},
+			Spec: v1alpha1.KVMConfigSpec{
+				Cluster: v1alpha1.Cluster{
+					ID: id,
+					Customer: v1alpha1.ClusterCustomer{
+						ID: customerID,
+					},
+					Masters: []v1alpha1.ClusterNode{
+						{ID: "m1"},
+					},
+					Workers: []v1alpha1.ClusterNode{
+						{ID: "w1"},
+					},
+				},
+				KVM: v1alpha1.KVMConfigSpecKVM{
+					Masters: []v1alpha1.KVMConfigSpecKVMNode{
+						{CPUs: 1, Disk: 10, Memory: "1G"},
+					},
+					Workers: []v1alpha1.KVMConfigSpecKVMNode{
+						{CPUs: cpus, Disk: 20, Memory: "2G"},
+					},
+				},
+			},
+		}
+		if deleted {
+			now := apismetav1.Now()
+			c.SetDeletionTimestamp(&now)
+		}
+		return c
+	}
+
+	newConfigMap := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      "kvm-operator-customer-quotas",
+				Namespace: "giantswarm",
+			},
+			Data: data,
+		}
+	}
+
+	twoReplicas := 2
+
+	testCases := []struct {
+		ConfigMapName    string
+		K8sObjects       []runtime.Object
+		Clusters         []clusterlister.Cluster
+		CustomObject     *v1alpha1.KVMConfig
+		ExpectedAllowed  bool
+		ExpectedEnforced bool
+		ExpectedReason   string
+		ErrorMatcher     func(error) bool
+	}{
+		// Test 0 ensures customer quotas are not enforced when no config map is
+		// configured.
+		{
+			ConfigMapName:    "",
+			K8sObjects:       nil,
+			Clusters:         nil,
+			CustomObject:     newCluster("al9qy", "acme", 2, false),
+			ExpectedAllowed:  true,
+			ExpectedEnforced: false,
+			ExpectedReason:   "has no quota",
+			ErrorMatcher:     nil,
+		},
+
+		// Test 1 ensures customer quotas are not enforced when the configured
+		// config map does not exist.
+		{
+			ConfigMapName:    "kvm-operator-customer-quotas",
+			K8sObjects:       nil,
+			Clusters:         nil,
+			CustomObject:     newCluster("al9qy", "acme", 2, false),
+			ExpectedAllowed:  true,
+			ExpectedEnforced: false,
+			ExpectedReason:   "has no quota",
+			ErrorMatcher:     nil,
+		},
+
+		// Test 2 ensures customers not having a quota are not limited.
+		{
+			ConfigMapName: "kvm-operator-customer-quotas",
+			K8sObjects: []runtime.Object{
+				newConfigMap(map[string]string{"other": "clusters: 0"}),
+			},
+			Clusters:         nil,
+			CustomObject:     newCluster("al9qy", "acme", 2, false),
+			ExpectedAllowed:  true,
+			ExpectedEnforced: false,
+			ExpectedReason:   "has no quota",
+			ErrorMatcher:     nil,
+		},
+
+		// Test 3 ensures customers within their quota are allowed. Guest clusters
+		// of other customers and guest clusters being deleted do not count.
+		{
+			ConfigMapName: "kvm-operator-customer-quotas",
+			K8sObjects: []runtime.Object{
+				newConfigMap(map[string]string{"acme": "clusters: 2\ncpus: 6\nmemory: 16Gi\ndisk: 60G"}),
+			},
+			Clusters: []clusterlister.Cluster{
+				{CustomObject: *newCluster("al9qy", "acme", 2, false)},
+				{CustomObject: *newCluster("b3kz1", "acme", 2, false)},
+				{CustomObject: *newCluster("c8ma2", "acme", 8, true)},
+				{CustomObject: *newCluster("d0vx7", "other", 8, false)},
+			},
+			CustomObject:     newCluster("al9qy", "acme", 2, false),
+			ExpectedAllowed:  true,
+			ExpectedEnforced: true,
+			ExpectedReason:   "is within its quota",
+			ErrorMatcher:     nil,
+		},
+
+		// Test 4 ensures the given guest cluster counts as given, not as stored,
+		// so scaling it up beyond the quota is denied.
+		{
+			ConfigMapName: "kvm-operator-customer-quotas",
+			K8sObjects: []runtime.Object{
+				newConfigMap(map[string]string{"acme": "cpus: 6"}),
+			},
+			Clusters: []clusterlister.Cluster{
+				{CustomObject: *newCluster("al9qy", "acme", 2, false)},
+				{CustomObject: *newCluster("b3kz1", "acme", 2, false)},
+			},
+			CustomObject:     newCluster("al9qy", "acme", 4, false),
+			ExpectedAllowed:  false,
+			ExpectedEnforced: true,
+			ExpectedReason:   "8 CPUs exceed quota of 6",
+			ErrorMatcher:     nil,
+		},
+
+		// Test 5 ensures new guest clusters count against the cluster quota.
+		{
+			ConfigMapName: "kvm-operator-customer-quotas",
+			K8sObjects: []runtime.Object{
+				newConfigMap(map[string]string{"acme": "clusters: 1"}),
+			},
+			Clusters: []clusterlister.Cluster{
+				{CustomObject: *newCluster("b3kz1", "acme", 2, false)},
+			},
+			CustomObject:     newCluster("al9qy", "acme", 2, false),
+			ExpectedAllowed:  false,
+			ExpectedEnforced: true,
+			ExpectedReason:   "2 clusters exceed quota of 1",
+			ErrorMatcher:     nil,
+		},
+
+		// Test 6 ensures disks count against the disk quota.
+		{
+			ConfigMapName: "kvm-operator-customer-quotas",
+			K8sObjects: []runtime.Object{
+				newConfigMap(map[string]string{"acme": "disk: 20G"}),
+			},
+			Clusters:         nil,
+			CustomObject:     newCluster("al9qy", "acme", 2, false),
+			ExpectedAllowed:  false,
+			ExpectedEnforced: true,
+			ExpectedReason:   "30G disk exceeds quota of 20G",
+			ErrorMatcher:     nil,
+		},
+
+		// Test 7 ensures workers are scaled to the requested replicas, for the
+		// given guest cluster as well as for the other guest clusters.
+		{
+			ConfigMapName: "kvm-operator-customer-quotas",
+			K8sObjects: []runtime.Object{
+				newConfigMap(map[string]string{"acme": "cpus: 8"}),
+			},
+			Clusters: []clusterlister.Cluster{
+				{CustomObject: *newCluster("al9qy", "acme", 2, false), Replicas: &twoReplicas},
+				{CustomObject: *newCluster("b3kz1", "acme", 2, false), Replicas: &twoReplicas},
+			},
+			CustomObject:     newCluster("al9qy", "acme", 2, false),
+			ExpectedAllowed:  false,
+			ExpectedEnforced: true,
+			ExpectedReason:   "10 CPUs exceed quota of 8",
+			ErrorMatcher:     nil,
+		},
+
+		// Test 8 ensures quotas which cannot be parsed cause an error.
+		{
+			ConfigMapName: "kvm-operator-customer-quotas",
+			K8sObjects: []runtime.Object{
+				newConfigMap(map[string]string{"acme": "cpus: many"}),
+			},
+			Clusters:         nil,
+			CustomObject:     newCluster("al9qy", "acme", 2, false),
+			ExpectedAllowed:  false,
+			ExpectedEnforced: false,
+			ExpectedReason:   "",
+			ErrorMatcher:     IsInvalidQuota,
+		},
+	}
+
+	for i, tc := range testCases {
+		var q *CustomerQuota
+		{
+			c := Config{
+				ClusterLister: clusterlistertest.New(tc.Clusters...),
+				K8sClient:     fake.NewSimpleClientset(tc.K8sObjects...),
+				Logger:        microloggertest.New(),
+
+				ConfigMapName:      tc.ConfigMapName,
+				ConfigMapNamespace: "giantswarm",
+			}
+
+			var err error
+			q, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		decision, err := q.Check(context.TODO(), *tc.CustomObject)
+		if err != nil {
+			if tc.ErrorMatcher == nil || !tc.ErrorMatcher(err) {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+			continue
+		}
+		if tc.ErrorMatcher != nil {
+			t.Fatalf("case %d expected error got %#v", i, nil)
+		}
+
+		if decision.Allowed != tc.ExpectedAllowed {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedAllowed, decision.Allowed)
+		}
+		if decision.Enforced != tc.ExpectedEnforced {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedEnforced, decision.Enforced)
+		}
+		if !strings.Contains(decision.Reason, tc.ExpectedReason) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedReason, decision.Reason)
+		}
+	}
+}
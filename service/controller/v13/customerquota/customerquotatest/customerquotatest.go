@@ -0,0 +1,32 @@
+package customerquotatest
+
+import (
+	"context"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota"
+)
+
+// CustomerQuota is a customerquota.Interface implementation recording the
+// guest clusters it was asked to check and deciding as configured, for use in
+// tests. Decisions are enforced in case Exceeded is true.
+type CustomerQuota struct {
+	Checked  []string
+	Exceeded bool
+}
+
+func New() *CustomerQuota {
+	return &CustomerQuota{}
+}
+
+func (q *CustomerQuota) Check(ctx context.Context, customObject v1alpha1.KVMConfig) (customerquota.Decision, error) {
+	q.Checked = append(q.Checked, customObject.Spec.Cluster.ID)
+
+	d := customerquota.Decision{
+		Allowed:  !q.Exceeded,
+		Enforced: q.Exceeded,
+	}
+
+	return d, nil
+}
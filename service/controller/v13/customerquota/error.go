@@ -0,0 +1,17 @@
+package customerquota
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = microerror.New("invalid config")
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidQuotaError = microerror.New("invalid quota")
+
+// IsInvalidQuota asserts invalidQuotaError.
+func IsInvalidQuota(err error) bool {
+	return microerror.Cause(err) == invalidQuotaError
+}
@@ -0,0 +1,51 @@
+package customerquota
+
+import (
+	"context"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	ResourceClusters = "clusters"
+	ResourceCPUs     = "cpus"
+	ResourceDisk     = "disk"
+	ResourceMemory   = "memory"
+)
+
+// Interface describes how the resources guest clusters of a single customer
+// use in total are limited.
+type Interface interface {
+	// Check decides whether the customer of the given guest cluster is within
+	// its quota, taking the given guest cluster as specified into account. The
+	// usage of the customer and its quota are exported as metrics.
+	Check(ctx context.Context, customObject v1alpha1.KVMConfig) (Decision, error)
+}
+
+// Quota is the quota of a single customer. Resources not defined are not
+// limited. Memory includes the overhead of QEMU, like the memory requested by
+// VM pods does.
+type Quota struct {
+	Clusters *int               `json:"clusters,omitempty"`
+	CPUs     *resource.Quantity `json:"cpus,omitempty"`
+	Disk     *resource.Quantity `json:"disk,omitempty"`
+	Memory   *resource.Quantity `json:"memory,omitempty"`
+}
+
+// Usage is the total of the resources the guest clusters of a single customer
+// are specified with.
+type Usage struct {
+	Clusters int
+	CPUs     resource.Quantity
+	Disk     resource.Quantity
+	Memory   resource.Quantity
+}
+
+// Decision is the result of a customer quota check.
+type Decision struct {
+	Allowed bool
+	// Enforced is true in case a quota is defined for the customer.
+	Enforced bool
+	Reason   string
+}
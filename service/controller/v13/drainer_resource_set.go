@@ -2,6 +2,7 @@ package v13
 
 import (
 	"github.com/giantswarm/apiextensions/pkg/clientset/versioned"
+	"github.com/giantswarm/certs"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/giantswarm/operatorkit/controller"
@@ -10,14 +11,16 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/endpoint"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/resource/pod"
 )
 
 type DrainerResourceSetConfig struct {
-	G8sClient versioned.Interface
-	K8sClient kubernetes.Interface
-	Logger    micrologger.Logger
+	CertsSearcher certs.Interface
+	G8sClient     versioned.Interface
+	K8sClient     kubernetes.Interface
+	Logger        micrologger.Logger
 
 	ProjectName string
 }
@@ -42,12 +45,27 @@ func NewDrainerResourceSet(config DrainerResourceSetConfig) (*controller.Resourc
 		return false
 	}
 
+	var quorumGuard *quorumguard.QuorumGuard
+	{
+		c := quorumguard.Config{
+			CertsSearcher: config.CertsSearcher,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+		}
+
+		quorumGuard, err = quorumguard.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var podResource controller.Resource
 	{
 		c := pod.Config{
-			G8sClient: config.G8sClient,
-			K8sClient: config.K8sClient,
-			Logger:    config.Logger,
+			G8sClient:   config.G8sClient,
+			K8sClient:   config.K8sClient,
+			Logger:      config.Logger,
+			QuorumGuard: quorumGuard,
 		}
 
 		podResource, err = pod.New(c)
@@ -0,0 +1,24 @@
+package etcdcluster
+
+import "github.com/giantswarm/microerror"
+
+var executionFailedError = microerror.New("execution failed")
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
+
+var invalidConfigError = microerror.New("invalid config")
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var noEndpointsError = microerror.New("no endpoints")
+
+// IsNoEndpoints asserts noEndpointsError.
+func IsNoEndpoints(err error) bool {
+	return microerror.Cause(err) == noEndpointsError
+}
@@ -0,0 +1,206 @@
+package etcdcluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+type Config struct {
+	CertsSearcher certs.Interface
+	K8sClient     kubernetes.Interface
+	Logger        micrologger.Logger
+}
+
+// EtcdCluster implements Interface using the members API of etcd. The members
+// are reached using the endpoints of the master service of the guest cluster.
+// Requests are sent to one member after another until one succeeds, so
+// members being down do not prevent managing the membership.
+type EtcdCluster struct {
+	certsSearcher certs.Interface
+	k8sClient     kubernetes.Interface
+	logger        micrologger.Logger
+
+	// newHTTPClient, port and scheme define how members are reached. They are
+	// replaced in tests.
+	newHTTPClient func(etcdTLS certs.TLS) (*http.Client, error)
+	port          int
+	scheme        string
+}
+
+func New(config Config) (*EtcdCluster, error) {
+	if config.CertsSearcher == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CertsSearcher must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	e := &EtcdCluster{
+		certsSearcher: config.CertsSearcher,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+
+		newHTTPClient: NewHTTPClient,
+		port:          key.EtcdPort,
+		scheme:        "https",
+	}
+
+	return e, nil
+}
+
+func (e *EtcdCluster) AddMember(ctx context.Context, clusterID string, peerURL string) error {
+	in := memberRequest{
+		PeerURLs: []string{peerURL},
+	}
+
+	err := e.do(ctx, clusterID, http.MethodPost, "/v2/members", in, nil, http.StatusCreated)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (e *EtcdCluster) Members(ctx context.Context, clusterID string) ([]Member, error) {
+	var out membersResponse
+
+	err := e.do(ctx, clusterID, http.MethodGet, "/v2/members", nil, &out, http.StatusOK)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return out.Members, nil
+}
+
+func (e *EtcdCluster) RemoveMember(ctx context.Context, clusterID string, memberID string) error {
+	err := e.do(ctx, clusterID, http.MethodDelete, fmt.Sprintf("/v2/members/%s", memberID), nil, nil, http.StatusNoContent)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (e *EtcdCluster) UpdateMember(ctx context.Context, clusterID string, memberID string, peerURL string) error {
+	in := memberRequest{
+		PeerURLs: []string{peerURL},
+	}
+
+	err := e.do(ctx, clusterID, http.MethodPut, fmt.Sprintf("/v2/members/%s", memberID), in, nil, http.StatusNoContent)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (e *EtcdCluster) do(ctx context.Context, clusterID, method, path string, in, out interface{}, expected int) error {
+	var ips []string
+	{
+		endpoints, err := e.k8sClient.CoreV1().Endpoints(clusterID).Get(key.MasterID, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return microerror.Maskf(noEndpointsError, "master service of guest cluster '%s' has no endpoints", clusterID)
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, s := range endpoints.Subsets {
+			for _, a := range s.Addresses {
+				ips = append(ips, a.IP)
+			}
+		}
+
+		if len(ips) == 0 {
+			return microerror.Maskf(noEndpointsError, "master service of guest cluster '%s' has no endpoints", clusterID)
+		}
+	}
+
+	var client *http.Client
+	{
+		cluster, err := e.certsSearcher.SearchCluster(clusterID)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		client, err = e.newHTTPClient(cluster.EtcdServer)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	var err error
+	for _, ip := range ips {
+		err = e.request(ctx, client, fmt.Sprintf("%s://%s:%d%s", e.scheme, ip, e.port, path), method, in, out, expected)
+		if err != nil {
+			e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("cannot %s '%s' of etcd member '%s'", method, path, ip), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		return nil
+	}
+
+	return microerror.Mask(err)
+}
+
+func (e *EtcdCluster) request(ctx context.Context, client *http.Client, url, method string, in, out interface{}, expected int) error {
+	var body bytes.Buffer
+	if in != nil {
+		err := json.NewEncoder(&body).Encode(in)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, url, &body)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	req = req.WithContext(ctx)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != expected {
+		b, _ := ioutil.ReadAll(res.Body)
+		return microerror.Maskf(executionFailedError, "expected status code %d got %d: %s", expected, res.StatusCode, bytes.TrimSpace(b))
+	}
+
+	if out != nil {
+		err := json.NewDecoder(res.Body).Decode(out)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+type memberRequest struct {
+	PeerURLs []string `json:"peerURLs"`
+}
+
+type membersResponse struct {
+	Members []Member `json:"members"`
+}
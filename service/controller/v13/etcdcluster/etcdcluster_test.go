@@ -0,0 +1,161 @@
+package etcdcluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/certs/certstest"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_EtcdCluster_Members(t *testing.T) {
+	members := []Member{
+		{
+			ClientURLs: []string{"https://etcd.al9qy.example.com:2379"},
+			ID:         "8e9e05c52164694d",
+			Name:       "etcd0",
+			PeerURLs:   []string{"https://10.0.0.1:2380"},
+		},
+		{
+			ID:       "91bc3c398fb3c146",
+			PeerURLs: []string{"https://10.0.0.2:2380"},
+		},
+	}
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/members":
+			json.NewEncoder(w).Encode(membersResponse{Members: members})
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/members":
+			var in memberRequest
+			json.NewDecoder(r.Body).Decode(&in)
+			if !reflect.DeepEqual(in.PeerURLs, []string{"https://10.0.0.3:2380"}) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/members/91bc3c398fb3c146":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/members/8e9e05c52164694d":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      key.MasterID,
+			Namespace: "al9qy",
+		},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{
+					{IP: "127.0.0.1"},
+				},
+			},
+		},
+	}
+
+	var etcdCluster *EtcdCluster
+	{
+		c := Config{
+			CertsSearcher: certstest.NewSearcher(),
+			K8sClient:     fake.NewSimpleClientset(endpoints),
+			Logger:        microloggertest.New(),
+		}
+
+		etcdCluster, err = New(c)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+
+		etcdCluster.newHTTPClient = func(etcdTLS certs.TLS) (*http.Client, error) {
+			return http.DefaultClient, nil
+		}
+		etcdCluster.port = port
+		etcdCluster.scheme = "http"
+	}
+
+	result, err := etcdCluster.Members(context.TODO(), "al9qy")
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	if !reflect.DeepEqual(result, members) {
+		t.Fatalf("expected %#v got %#v", members, result)
+	}
+	if result[0].IsStarted() != true || result[1].IsStarted() != false {
+		t.Fatalf("expected first member to be started and second member not to be started")
+	}
+
+	err = etcdCluster.AddMember(context.TODO(), "al9qy", "https://10.0.0.3:2380")
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	err = etcdCluster.RemoveMember(context.TODO(), "al9qy", "91bc3c398fb3c146")
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	err = etcdCluster.UpdateMember(context.TODO(), "al9qy", "8e9e05c52164694d", "https://10.0.0.1:2380")
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	err = etcdCluster.RemoveMember(context.TODO(), "al9qy", "unknown")
+	if !IsExecutionFailed(err) {
+		t.Fatalf("expected %#v got %#v", "execution failed error", err)
+	}
+
+	expected := []string{
+		"GET /v2/members",
+		"POST /v2/members",
+		"DELETE /v2/members/91bc3c398fb3c146",
+		"PUT /v2/members/8e9e05c52164694d",
+		"DELETE /v2/members/unknown",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Fatalf("expected %#v got %#v", expected, requests)
+	}
+}
+
+func Test_EtcdCluster_NoEndpoints(t *testing.T) {
+	c := Config{
+		CertsSearcher: certstest.NewSearcher(),
+		K8sClient:     fake.NewSimpleClientset(),
+		Logger:        microloggertest.New(),
+	}
+
+	etcdCluster, err := New(c)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	_, err = etcdCluster.Members(context.TODO(), "al9qy")
+	if !IsNoEndpoints(err) {
+		t.Fatalf("expected %#v got %#v", "no endpoints error", err)
+	}
+}
@@ -0,0 +1,56 @@
+package etcdclustertest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/etcdcluster"
+)
+
+// EtcdCluster is an etcdcluster.Interface implementation keeping its members
+// in memory, for use in tests. Added members are not started.
+type EtcdCluster struct {
+	List []etcdcluster.Member
+}
+
+func New(members ...etcdcluster.Member) *EtcdCluster {
+	return &EtcdCluster{
+		List: members,
+	}
+}
+
+func (e *EtcdCluster) AddMember(ctx context.Context, clusterID string, peerURL string) error {
+	m := etcdcluster.Member{
+		ID:       fmt.Sprintf("%x", len(e.List)+1),
+		PeerURLs: []string{peerURL},
+	}
+	e.List = append(e.List, m)
+
+	return nil
+}
+
+func (e *EtcdCluster) Members(ctx context.Context, clusterID string) ([]etcdcluster.Member, error) {
+	return e.List, nil
+}
+
+func (e *EtcdCluster) RemoveMember(ctx context.Context, clusterID string, memberID string) error {
+	var members []etcdcluster.Member
+	for _, m := range e.List {
+		if m.ID != memberID {
+			members = append(members, m)
+		}
+	}
+	e.List = members
+
+	return nil
+}
+
+func (e *EtcdCluster) UpdateMember(ctx context.Context, clusterID string, memberID string, peerURL string) error {
+	for i, m := range e.List {
+		if m.ID == memberID {
+			e.List[i].PeerURLs = []string{peerURL}
+		}
+	}
+
+	return nil
+}
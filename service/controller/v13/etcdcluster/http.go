@@ -0,0 +1,70 @@
+package etcdcluster
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"time"
+
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// timeout is the time to wait for responses of a single etcd member.
+	timeout = 5 * time.Second
+)
+
+// NewHTTPClient returns a client authenticating against etcd members using
+// the given etcd certificates of a guest cluster. The certificates of the
+// members are verified against the CA of the guest cluster. Their host names
+// are not verified, because members are addressed by the IPs of the master
+// VMs, which are not part of the certificates.
+func NewHTTPClient(etcdTLS certs.TLS) (*http.Client, error) {
+	cert, err := tls.X509KeyPair(etcdTLS.Crt, etcdTLS.Key)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(etcdTLS.CA)
+
+	verify := func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return microerror.Maskf(executionFailedError, "etcd member did not present certificates")
+		}
+
+		var chain []*x509.Certificate
+		for _, r := range rawCerts {
+			c, err := x509.ParseCertificate(r)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			chain = append(chain, c)
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, c := range chain[1:] {
+			intermediates.AddCert(c)
+		}
+
+		_, err := chain[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				Certificates:          []tls.Certificate{cert},
+				InsecureSkipVerify:    true,
+				VerifyPeerCertificate: verify,
+			},
+		},
+	}
+
+	return client, nil
+}
@@ -0,0 +1,36 @@
+package etcdcluster
+
+import (
+	"context"
+)
+
+// Interface describes how the membership of the etcd cluster formed by the
+// masters of a guest cluster is managed.
+type Interface interface {
+	// AddMember announces a new member having the given peer URL. The member
+	// is started afterwards and joins the etcd cluster using the announced
+	// peer URL.
+	AddMember(ctx context.Context, clusterID string, peerURL string) error
+	// Members returns the members of the etcd cluster, including members being
+	// announced but not started yet.
+	Members(ctx context.Context, clusterID string) ([]Member, error)
+	// RemoveMember removes the member having the given ID. The removed member
+	// stops serving right away.
+	RemoveMember(ctx context.Context, clusterID string, memberID string) error
+	// UpdateMember changes the peer URL of the member having the given ID.
+	UpdateMember(ctx context.Context, clusterID string, memberID string, peerURL string) error
+}
+
+// Member is an etcd member as returned by the members API of etcd.
+type Member struct {
+	ClientURLs []string `json:"clientURLs"`
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	PeerURLs   []string `json:"peerURLs"`
+}
+
+// IsStarted returns true in case the member started and published its name.
+// Members being announced but not started yet have no name.
+func (m Member) IsStarted() bool {
+	return m.Name != ""
+}
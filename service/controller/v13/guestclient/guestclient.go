@@ -0,0 +1,66 @@
+package guestclient
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/microerror"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// timeout is the time to wait for responses of guest cluster APIs.
+	timeout = 10 * time.Second
+)
+
+type Config struct {
+	CertsSearcher certs.Interface
+}
+
+// GuestClient implements Interface. Clients authenticate using the node
+// operator certificates of the guest cluster, which grant the permissions
+// needed to inspect and drain guest cluster nodes.
+type GuestClient struct {
+	certsSearcher certs.Interface
+}
+
+func New(config Config) (*GuestClient, error) {
+	if config.CertsSearcher == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CertsSearcher must not be empty", config)
+	}
+
+	g := &GuestClient{
+		certsSearcher: config.CertsSearcher,
+	}
+
+	return g, nil
+}
+
+func (g *GuestClient) NewK8sClient(customObject v1alpha1.KVMConfig) (kubernetes.Interface, error) {
+	tls, err := g.certsSearcher.SearchDraining(key.ClusterID(customObject))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	c := &rest.Config{
+		Host: fmt.Sprintf("https://%s", key.ClusterAPIEndpoint(customObject)),
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData:   tls.NodeOperator.CA,
+			CertData: tls.NodeOperator.Crt,
+			KeyData:  tls.NodeOperator.Key,
+		},
+		Timeout: timeout,
+	}
+
+	k8sClient, err := kubernetes.NewForConfig(c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return k8sClient, nil
+}
@@ -0,0 +1,22 @@
+package guestclienttest
+
+import (
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// GuestClient is a guestclient.Interface implementation returning the given
+// Kubernetes client for every guest cluster, for use in tests.
+type GuestClient struct {
+	k8sClient kubernetes.Interface
+}
+
+func New(k8sClient kubernetes.Interface) *GuestClient {
+	return &GuestClient{
+		k8sClient: k8sClient,
+	}
+}
+
+func (g *GuestClient) NewK8sClient(customObject v1alpha1.KVMConfig) (kubernetes.Interface, error) {
+	return g.k8sClient, nil
+}
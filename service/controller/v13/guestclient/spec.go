@@ -0,0 +1,14 @@
+package guestclient
+
+import (
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Interface describes how clients for the Kubernetes API of guest clusters are
+// created.
+type Interface interface {
+	// NewK8sClient returns a Kubernetes client for the API of the guest cluster
+	// defined by the given custom object.
+	NewK8sClient(customObject v1alpha1.KVMConfig) (kubernetes.Interface, error)
+}
@@ -0,0 +1,78 @@
+package key
+
+import (
+	"encoding/json"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// AnnotationAudit defines the audit logging of the API server of a guest
+	// cluster as JSON encoded Audit. Settings not given fall back to the
+	// installation wide audit configuration.
+	AnnotationAudit = "kvm-operator.giantswarm.io/audit"
+
+	// AuditPolicyConfigMapKey is the key of the audit policy in the config map
+	// referenced by Audit.
+	AuditPolicyConfigMapKey = "policy.yaml"
+)
+
+// Audit represents the per cluster audit logging of a guest cluster's API
+// server. PolicyConfigMap references a config map holding the audit policy
+// using AuditPolicyConfigMapKey. Namespace defaults to the namespace of the
+// custom object and must be either that or the cluster namespace. HostVolume
+// defines whether audit logs are stored on a dedicated host volume of the
+// master pods.
+type Audit struct {
+	PolicyConfigMap string `json:"policyConfigMap,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	HostVolume      *bool  `json:"hostVolume,omitempty"`
+}
+
+// AuditFromAnnotation returns the audit settings defined in the custom object
+// using AnnotationAudit. The zero value is returned in case the annotation is
+// not set.
+func AuditFromAnnotation(customObject v1alpha1.KVMConfig) (Audit, error) {
+	v, ok := customObject.GetAnnotations()[AnnotationAudit]
+	if !ok || v == "" {
+		return Audit{}, nil
+	}
+
+	var audit Audit
+	err := json.Unmarshal([]byte(v), &audit)
+	if err != nil {
+		return Audit{}, microerror.Maskf(invalidAnnotationError, "annotation '%s' must be JSON: %s", AnnotationAudit, err)
+	}
+
+	if audit.PolicyConfigMap == "" && audit.Namespace != "" {
+		return Audit{}, microerror.Maskf(invalidAnnotationError, "namespace '%s' of annotation '%s' requires a policy config map", audit.Namespace, AnnotationAudit)
+	}
+	if audit.PolicyConfigMap != "" {
+		if audit.Namespace == "" {
+			audit.Namespace = customObject.GetNamespace()
+		}
+		if audit.Namespace != customObject.GetNamespace() && audit.Namespace != ClusterNamespace(customObject) {
+			return Audit{}, microerror.Maskf(invalidAnnotationError, "namespace '%s' of annotation '%s' must be the namespace of the custom object or the cluster namespace", audit.Namespace, AnnotationAudit)
+		}
+	}
+
+	return audit, nil
+}
+
+// AuditHostVolume returns whether the audit logs of the given guest cluster's
+// API server are stored on a dedicated host volume of the master pods. The
+// setting of the custom object takes precedence over the given installation
+// wide setting.
+func AuditHostVolume(customObject v1alpha1.KVMConfig, installation bool) (bool, error) {
+	audit, err := AuditFromAnnotation(customObject)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if audit.HostVolume != nil {
+		return *audit.HostVolume, nil
+	}
+
+	return installation, nil
+}
@@ -0,0 +1,79 @@
+package key
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/microerror"
+)
+
+// CertsComponents are the components of guest clusters having certificates.
+var CertsComponents = []string{
+	"api-server",
+	"calico-client",
+	"calico-etcd-client",
+	"etcd-server",
+	"service-account",
+	"worker",
+}
+
+// MasterCertsHash returns a hash of the certificates rendered into the
+// cloud-configs of masters.
+func MasterCertsHash(cluster certs.Cluster) string {
+	return certsHash(certs.NewFilesClusterMaster(cluster))
+}
+
+// WorkerCertsHash returns a hash of the certificates rendered into the
+// cloud-configs of workers.
+func WorkerCertsHash(cluster certs.Cluster) string {
+	return certsHash(certs.NewFilesClusterWorker(cluster))
+}
+
+func certsHash(files certs.Files) string {
+	h := sha256.New()
+	for _, f := range files {
+		h.Write([]byte(f.AbsolutePath))
+		h.Write(f.Data)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// CertsExpiry returns the expiry of the certificates of the given cluster
+// mapped by their component. Components without certificate are omitted.
+func CertsExpiry(cluster certs.Cluster) (map[string]time.Time, error) {
+	crts := [][]byte{
+		cluster.APIServer.Crt,
+		cluster.CalicoClient.Crt,
+		cluster.CalicoEtcdClient.Crt,
+		cluster.EtcdServer.Crt,
+		cluster.ServiceAccount.Crt,
+		cluster.Worker.Crt,
+	}
+
+	expiry := map[string]time.Time{}
+	for i, crt := range crts {
+		c := CertsComponents[i]
+
+		if len(crt) == 0 {
+			continue
+		}
+
+		b, _ := pem.Decode(crt)
+		if b == nil {
+			return nil, microerror.Maskf(invalidCertificateError, "certificate of component '%s' must be PEM encoded", c)
+		}
+		x, err := x509.ParseCertificate(b.Bytes)
+		if err != nil {
+			return nil, microerror.Maskf(invalidCertificateError, "certificate of component '%s' must be a X.509 certificate: %s", c, err)
+		}
+
+		expiry[c] = x.NotAfter
+	}
+
+	return expiry, nil
+}
@@ -0,0 +1,106 @@
+package key
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/certs"
+)
+
+func Test_CertsExpiry(t *testing.T) {
+	notAfter := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	crt := func() []byte {
+		k, err := rsa.GenerateKey(rand.Reader, 1024)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "api.al9qy.k8s.gigantic.io"},
+			NotBefore:    notAfter.Add(-24 * time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &k.PublicKey, k)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}()
+
+	testCases := []struct {
+		Cluster         certs.Cluster
+		ExpectedExpiry  map[string]time.Time
+		ExpectedErrorFn func(error) bool
+	}{
+		// Test 0 ensures components without certificate are omitted.
+		{
+			Cluster: certs.Cluster{
+				APIServer: certs.TLS{Crt: crt},
+				Worker:    certs.TLS{Crt: crt},
+			},
+			ExpectedExpiry: map[string]time.Time{
+				"api-server": notAfter,
+				"worker":     notAfter,
+			},
+		},
+
+		// Test 1 ensures certificates not being PEM encoded are rejected.
+		{
+			Cluster: certs.Cluster{
+				EtcdServer: certs.TLS{Crt: []byte("foo")},
+			},
+			ExpectedErrorFn: IsInvalidCertificate,
+		},
+
+		// Test 2 ensures PEM blocks not being certificates are rejected.
+		{
+			Cluster: certs.Cluster{
+				EtcdServer: certs.TLS{Crt: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("foo")})},
+			},
+			ExpectedErrorFn: IsInvalidCertificate,
+		},
+	}
+
+	for i, tc := range testCases {
+		expiry, err := CertsExpiry(tc.Cluster)
+		if tc.ExpectedErrorFn != nil {
+			if !tc.ExpectedErrorFn(err) {
+				t.Fatalf("case %d expected error got %#v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		if len(expiry) != len(tc.ExpectedExpiry) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedExpiry, expiry)
+		}
+		for c, e := range tc.ExpectedExpiry {
+			if !expiry[c].Equal(e) {
+				t.Fatalf("case %d expected %#v got %#v", i, e, expiry[c])
+			}
+		}
+	}
+}
+
+func Test_CertsHash(t *testing.T) {
+	a := certs.Cluster{Worker: certs.TLS{Crt: []byte("a")}}
+	b := certs.Cluster{Worker: certs.TLS{Crt: []byte("b")}}
+
+	if WorkerCertsHash(a) == WorkerCertsHash(b) {
+		t.Fatalf("expected worker certificates hash to change")
+	}
+	// Masters do not get the worker certificates.
+	if MasterCertsHash(a) != MasterCertsHash(b) {
+		t.Fatalf("expected master certificates hash not to change")
+	}
+}
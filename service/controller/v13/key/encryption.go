@@ -0,0 +1,162 @@
+package key
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/randomkeys"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// AnnotationEncryptionKeyRotation requests the rotation of the key the API
+	// server of a guest cluster encrypts secrets with. The value is an arbitrary
+	// rotation ID. Setting a value different from the ID of the last rotation
+	// starts a new rotation.
+	AnnotationEncryptionKeyRotation = "kvm-operator.giantswarm.io/encryption-key-rotation"
+)
+
+// Annotations below are put on the secret holding the encryption key of a
+// guest cluster and track the rotation of the key.
+const (
+	// AnnotationEncryptionKeyName is the name of the encryption key in the
+	// encryption config of the API server. Secrets are stored together with the
+	// name of the key they are encrypted with, so keys keep their names.
+	// EncryptionKeyDefaultName is assumed in case the annotation is not set.
+	AnnotationEncryptionKeyName = "kvm-operator.giantswarm.io/encryption-key-name"
+	// AnnotationEncryptionKeySecondaryName is the name of the encryption key
+	// stored using EncryptionKeySecondaryDataKey.
+	AnnotationEncryptionKeySecondaryName = "kvm-operator.giantswarm.io/encryption-key-secondary-name"
+	// AnnotationEncryptionKeyRotationID is the ID of the current or last
+	// rotation as requested using AnnotationEncryptionKeyRotation.
+	AnnotationEncryptionKeyRotationID = "kvm-operator.giantswarm.io/encryption-key-rotation-id"
+	// AnnotationEncryptionKeyRotationPhase is the phase of the current or last
+	// rotation. See the EncryptionKeyRotationPhase constants.
+	AnnotationEncryptionKeyRotationPhase = "kvm-operator.giantswarm.io/encryption-key-rotation-phase"
+)
+
+const (
+	// AnnotationEncryptionKeysHash is put on the config maps and secrets holding
+	// the cloud-configs of masters and on the pod templates of master
+	// deployments. Its value is the hash of the encryption keys rendered into
+	// the cloud-config, which allows to tell when all masters run with the
+	// encryption keys of the current rotation phase.
+	AnnotationEncryptionKeysHash = "kvm-operator.giantswarm.io/encryption-keys-hash"
+)
+
+const (
+	// EncryptionKeyDefaultName is the name of the encryption key of guest
+	// clusters whose key has never been rotated.
+	EncryptionKeyDefaultName = "key1"
+	// EncryptionKeySecondaryDataKey is the key of the secondary encryption key
+	// in the secret holding the encryption key of a guest cluster. The API
+	// server decrypts secrets using the secondary key but does not encrypt with
+	// it.
+	EncryptionKeySecondaryDataKey = "encryption-secondary"
+)
+
+// The phases of an encryption key rotation are reported as reasons of the
+// clusterstatus.ConditionEncryptionKeyRotated condition.
+const (
+	// EncryptionKeyRotationPhaseAddingKey rolls the masters with the new key as
+	// secondary key, so all masters are able to decrypt secrets before any of
+	// them encrypts with the new key. Only guest clusters running multiple
+	// masters go through this phase.
+	EncryptionKeyRotationPhaseAddingKey = "AddingKey"
+	// EncryptionKeyRotationPhasePromotingKey rolls the masters with the new key
+	// as primary key and the old key as secondary key.
+	EncryptionKeyRotationPhasePromotingKey = "PromotingKey"
+	// EncryptionKeyRotationPhaseRewritingSecrets rewrites all secrets of the
+	// guest cluster, so they are encrypted using the new key.
+	EncryptionKeyRotationPhaseRewritingSecrets = "RewritingSecrets"
+	// EncryptionKeyRotationPhaseRemovingKey rolls the masters without the old
+	// key.
+	EncryptionKeyRotationPhaseRemovingKey = "RemovingKey"
+	// EncryptionKeyRotationPhaseCompleted marks the rotation as done.
+	EncryptionKeyRotationPhaseCompleted = "Completed"
+)
+
+// EncryptionKey is a key of the encryption config of the API server of a
+// guest cluster.
+type EncryptionKey struct {
+	Name   string
+	Secret string
+}
+
+// EncryptionKeyRotation returns the rotation ID requested using
+// AnnotationEncryptionKeyRotation.
+func EncryptionKeyRotation(customObject v1alpha1.KVMConfig) string {
+	return customObject.GetAnnotations()[AnnotationEncryptionKeyRotation]
+}
+
+// EncryptionKeySecretSelector returns the label selector matching the secret
+// holding the encryption key of the given guest cluster, as used by the
+// randomkeys searcher.
+func EncryptionKeySecretSelector(clusterID string) string {
+	return fmt.Sprintf("%s=%s, %s=%s", randomkeys.RandomKeyLabel, randomkeys.EncryptionKey, randomkeys.ClusterIDLabel, clusterID)
+}
+
+// EncryptionKeysFromSecret returns the encryption keys stored in the given
+// secret. The primary key, which the API server encrypts with, comes first.
+func EncryptionKeysFromSecret(secret *corev1.Secret) ([]EncryptionKey, error) {
+	primary, ok := secret.Data[string(randomkeys.EncryptionKey)]
+	if !ok || len(primary) == 0 {
+		return nil, microerror.Maskf(invalidSecretError, "secret '%s/%s' must contain key '%s'", secret.Namespace, secret.Name, randomkeys.EncryptionKey)
+	}
+
+	name := secret.GetAnnotations()[AnnotationEncryptionKeyName]
+	if name == "" {
+		name = EncryptionKeyDefaultName
+	}
+
+	keys := []EncryptionKey{
+		{
+			Name:   name,
+			Secret: string(primary),
+		},
+	}
+
+	secondary, ok := secret.Data[EncryptionKeySecondaryDataKey]
+	if ok && len(secondary) != 0 {
+		name := secret.GetAnnotations()[AnnotationEncryptionKeySecondaryName]
+		if name == "" || name == keys[0].Name {
+			return nil, microerror.Maskf(invalidSecretError, "secret '%s/%s' must name the secondary key differently than the primary key using annotation '%s'", secret.Namespace, secret.Name, AnnotationEncryptionKeySecondaryName)
+		}
+
+		keys = append(keys, EncryptionKey{
+			Name:   name,
+			Secret: string(secondary),
+		})
+	}
+
+	return keys, nil
+}
+
+// EncryptionKeysHash returns a hash of the given encryption keys. The order
+// of the keys matters, since the API server encrypts with the first key.
+func EncryptionKeysHash(keys []EncryptionKey) string {
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k.Name))
+		h.Write([]byte(k.Secret))
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// NextEncryptionKeyName returns the name of a key following the given keys.
+// Names are of the form key<n>, like EncryptionKeyDefaultName.
+func NextEncryptionKeyName(keys []EncryptionKey) string {
+	var max int
+	for _, k := range keys {
+		var n int
+		_, err := fmt.Sscanf(k.Name, "key%d", &n)
+		if err == nil && n > max {
+			max = n
+		}
+	}
+
+	return fmt.Sprintf("key%d", max+1)
+}
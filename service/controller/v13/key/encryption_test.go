@@ -0,0 +1,112 @@
+package key
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_EncryptionKeysFromSecret(t *testing.T) {
+	testCases := []struct {
+		Annotations     map[string]string
+		Data            map[string][]byte
+		ExpectedKeys    []EncryptionKey
+		ExpectedErrorFn func(error) bool
+	}{
+		// Test 0 ensures keys never rotated are named like in the master
+		// template.
+		{
+			Data: map[string][]byte{
+				"encryption": []byte("b2xk"),
+			},
+			ExpectedKeys: []EncryptionKey{
+				{Name: "key1", Secret: "b2xk"},
+			},
+		},
+		// Test 1 ensures the secondary key follows the primary key.
+		{
+			Annotations: map[string]string{
+				AnnotationEncryptionKeyName:          "key2",
+				AnnotationEncryptionKeySecondaryName: "key1",
+			},
+			Data: map[string][]byte{
+				"encryption":           []byte("bmV3"),
+				"encryption-secondary": []byte("b2xk"),
+			},
+			ExpectedKeys: []EncryptionKey{
+				{Name: "key2", Secret: "bmV3"},
+				{Name: "key1", Secret: "b2xk"},
+			},
+		},
+		// Test 2 ensures an error is returned in case the primary key is missing.
+		{
+			Data:            map[string][]byte{},
+			ExpectedErrorFn: IsInvalidSecret,
+		},
+		// Test 3 ensures an error is returned in case both keys have the same
+		// name.
+		{
+			Data: map[string][]byte{
+				"encryption":           []byte("bmV3"),
+				"encryption-secondary": []byte("b2xk"),
+			},
+			ExpectedErrorFn: IsInvalidSecret,
+		},
+	}
+
+	for i, tc := range testCases {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: tc.Annotations,
+			},
+			Data: tc.Data,
+		}
+
+		keys, err := EncryptionKeysFromSecret(secret)
+		if tc.ExpectedErrorFn != nil {
+			if !tc.ExpectedErrorFn(err) {
+				t.Fatalf("case %d expected error got %#v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		if !reflect.DeepEqual(keys, tc.ExpectedKeys) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedKeys, keys)
+		}
+	}
+}
+
+func Test_NextEncryptionKeyName(t *testing.T) {
+	testCases := []struct {
+		Keys         []EncryptionKey
+		ExpectedName string
+	}{
+		// Test 0 ensures the key following the default key is named key2.
+		{
+			Keys:         []EncryptionKey{{Name: "key1"}},
+			ExpectedName: "key2",
+		},
+		// Test 1 ensures the highest number of all keys is used.
+		{
+			Keys:         []EncryptionKey{{Name: "key3"}, {Name: "key7"}},
+			ExpectedName: "key8",
+		},
+		// Test 2 ensures names not following the scheme are ignored.
+		{
+			Keys:         []EncryptionKey{{Name: "custom"}},
+			ExpectedName: "key1",
+		},
+	}
+
+	for i, tc := range testCases {
+		name := NextEncryptionKeyName(tc.Keys)
+		if name != tc.ExpectedName {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedName, name)
+		}
+	}
+}
@@ -2,6 +2,13 @@ package key
 
 import "github.com/giantswarm/microerror"
 
+var invalidAnnotationError = microerror.New("invalid annotation")
+
+// IsInvalidAnnotation asserts invalidAnnotationError.
+func IsInvalidAnnotation(err error) bool {
+	return microerror.Cause(err) == invalidAnnotationError
+}
+
 var missingAnnotationError = microerror.New("missing annotation")
 
 func IsMissingAnnotationError(err error) bool {
@@ -14,3 +21,24 @@ var wrongTypeError = microerror.New("wrong type")
 func IsWrongTypeError(err error) bool {
 	return microerror.Cause(err) == wrongTypeError
 }
+
+var invalidCertificateError = microerror.New("invalid certificate")
+
+// IsInvalidCertificate asserts invalidCertificateError.
+func IsInvalidCertificate(err error) bool {
+	return microerror.Cause(err) == invalidCertificateError
+}
+
+var invalidSecretError = microerror.New("invalid secret")
+
+// IsInvalidSecret asserts invalidSecretError.
+func IsInvalidSecret(err error) bool {
+	return microerror.Cause(err) == invalidSecretError
+}
+
+var invalidSpecError = microerror.New("invalid spec")
+
+// IsInvalidSpec asserts invalidSpecError.
+func IsInvalidSpec(err error) bool {
+	return microerror.Cause(err) == invalidSpecError
+}
@@ -0,0 +1,60 @@
+package key
+
+import (
+	"encoding/json"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// AnnotationExtraAssets references config maps holding extra files and
+	// systemd units put into the cloud-configs of guest cluster nodes. It is a
+	// JSON encoded list of ExtraAssetsRef.
+	AnnotationExtraAssets = "kvm-operator.giantswarm.io/extra-assets"
+)
+
+// ExtraAssetsRef references a config map holding extra files and systemd
+// units. Namespace defaults to the namespace of the custom object, which is
+// also the only namespace config maps may be referenced from. Role
+// restricts the assets to either masters or workers. Empty Role applies the
+// assets to all nodes.
+type ExtraAssetsRef struct {
+	ConfigMap string `json:"configMap"`
+	Namespace string `json:"namespace,omitempty"`
+	Role      string `json:"role,omitempty"`
+}
+
+// ExtraAssetsRefs returns the validated config map references defined in the
+// custom object using AnnotationExtraAssets.
+func ExtraAssetsRefs(customObject v1alpha1.KVMConfig) ([]ExtraAssetsRef, error) {
+	v, ok := customObject.GetAnnotations()[AnnotationExtraAssets]
+	if !ok || v == "" {
+		return nil, nil
+	}
+
+	var refs []ExtraAssetsRef
+	err := json.Unmarshal([]byte(v), &refs)
+	if err != nil {
+		return nil, microerror.Maskf(invalidAnnotationError, "annotation '%s' must be a JSON list of config map references: %s", AnnotationExtraAssets, err)
+	}
+
+	for i, r := range refs {
+		if r.ConfigMap == "" {
+			return nil, microerror.Maskf(invalidAnnotationError, "config map of extra assets reference %d must not be empty", i)
+		}
+		switch r.Role {
+		case "", MasterID, WorkerID:
+		default:
+			return nil, microerror.Maskf(invalidAnnotationError, "role '%s' of extra assets config map '%s' must be one of '%s' or '%s'", r.Role, r.ConfigMap, MasterID, WorkerID)
+		}
+
+		if r.Namespace == "" {
+			refs[i].Namespace = customObject.GetNamespace()
+		} else if r.Namespace != customObject.GetNamespace() {
+			return nil, microerror.Maskf(invalidAnnotationError, "namespace '%s' of extra assets config map '%s' must be the namespace '%s' of the custom object", r.Namespace, r.ConfigMap, customObject.GetNamespace())
+		}
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,197 @@
+package key
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// AnnotationHyperkubeExtraArgs defines extra arguments, feature gates and
+	// admission plugins of the Kubernetes components of a guest cluster as JSON
+	// encoded HyperkubeExtraArgs. Only allowlisted arguments, feature gates and
+	// admission plugins are accepted.
+	AnnotationHyperkubeExtraArgs = "kvm-operator.giantswarm.io/hyperkube-extra-args"
+)
+
+// The allowlists below control which settings can be changed per guest
+// cluster. Arguments already set by the cloud-config templates are not
+// allowlisted, because the template values would take precedence.
+var (
+	allowedAPIServerArgs = []string{
+		"default-not-ready-toleration-seconds",
+		"default-unreachable-toleration-seconds",
+		"event-ttl",
+		"max-mutating-requests-inflight",
+		"max-requests-inflight",
+		"min-request-timeout",
+		"request-timeout",
+		"target-ram-mb",
+		"watch-cache-sizes",
+	}
+	allowedControllerManagerArgs = []string{
+		"concurrent-deployment-syncs",
+		"concurrent-replicaset-syncs",
+		"horizontal-pod-autoscaler-downscale-delay",
+		"horizontal-pod-autoscaler-sync-period",
+		"horizontal-pod-autoscaler-upscale-delay",
+		"kube-api-burst",
+		"kube-api-qps",
+		"node-monitor-grace-period",
+		"pod-eviction-timeout",
+	}
+	allowedKubeletArgs = []string{
+		"event-burst",
+		"event-qps",
+		"image-gc-high-threshold",
+		"image-gc-low-threshold",
+		"max-pods",
+		"pods-per-core",
+		"registry-burst",
+		"registry-qps",
+		"serialize-image-pulls",
+	}
+	// allowedFeatureGates only lists gates being fully effective when set on
+	// the API server, the controller manager and the kubelets. Gates like
+	// TaintNodesByCondition or VolumeScheduling also need the scheduler, which
+	// feature gates are not applied to.
+	allowedFeatureGates = []string{
+		"CPUManager",
+		"CustomPodDNS",
+		"ExpandInUsePersistentVolumes",
+		"HugePages",
+		"PodShareProcessNamespace",
+		"TaintBasedEvictions",
+	}
+	allowedAdmissionPlugins = []string{
+		"AlwaysPullImages",
+		"DenyEscalatingExec",
+		"ExtendedResourceToleration",
+		"PodNodeSelector",
+		"PodTolerationRestriction",
+	}
+)
+
+var (
+	// hyperkubeArgValueRegexp restricts argument values to characters being
+	// safe in the pod manifests and the kubelet shell command line of the
+	// cloud-config templates.
+	hyperkubeArgValueRegexp = regexp.MustCompile(`^[A-Za-z0-9._:/=,%+-]+$`)
+)
+
+// HyperkubeExtraArgs are the per cluster settings of the Kubernetes
+// components of a guest cluster. Arguments are given without leading dashes.
+// Feature gates are applied to the API server, the controller manager and the
+// kubelets. Admission plugins are enabled in addition to the ones of the
+// cloud-config templates.
+type HyperkubeExtraArgs struct {
+	APIServer         map[string]string `json:"apiServer,omitempty"`
+	ControllerManager map[string]string `json:"controllerManager,omitempty"`
+	Kubelet           map[string]string `json:"kubelet,omitempty"`
+	FeatureGates      map[string]bool   `json:"featureGates,omitempty"`
+	AdmissionPlugins  []string          `json:"admissionPlugins,omitempty"`
+}
+
+// HyperkubeArgs are the command line arguments rendered from
+// HyperkubeExtraArgs.
+type HyperkubeArgs struct {
+	APIServer         []string
+	ControllerManager []string
+	Kubelet           []string
+}
+
+// HyperkubeExtraArgsFromAnnotation returns the validated command line
+// arguments defined in the custom object using AnnotationHyperkubeExtraArgs.
+// Arguments are sorted, so the rendered cloud-configs are stable.
+func HyperkubeExtraArgsFromAnnotation(customObject v1alpha1.KVMConfig) (HyperkubeArgs, error) {
+	v, ok := customObject.GetAnnotations()[AnnotationHyperkubeExtraArgs]
+	if !ok || v == "" {
+		return HyperkubeArgs{}, nil
+	}
+
+	var extraArgs HyperkubeExtraArgs
+	err := json.Unmarshal([]byte(v), &extraArgs)
+	if err != nil {
+		return HyperkubeArgs{}, microerror.Maskf(invalidAnnotationError, "annotation '%s' must be JSON: %s", AnnotationHyperkubeExtraArgs, err)
+	}
+
+	var args HyperkubeArgs
+
+	args.APIServer, err = newHyperkubeArgs("API server", extraArgs.APIServer, allowedAPIServerArgs)
+	if err != nil {
+		return HyperkubeArgs{}, microerror.Mask(err)
+	}
+	args.ControllerManager, err = newHyperkubeArgs("controller manager", extraArgs.ControllerManager, allowedControllerManagerArgs)
+	if err != nil {
+		return HyperkubeArgs{}, microerror.Mask(err)
+	}
+	args.Kubelet, err = newHyperkubeArgs("kubelet", extraArgs.Kubelet, allowedKubeletArgs)
+	if err != nil {
+		return HyperkubeArgs{}, microerror.Mask(err)
+	}
+
+	if len(extraArgs.FeatureGates) > 0 {
+		var gates []string
+		for g, enabled := range extraArgs.FeatureGates {
+			if !containsString(allowedFeatureGates, g) {
+				return HyperkubeArgs{}, microerror.Maskf(invalidAnnotationError, "feature gate '%s' is not allowed", g)
+			}
+			gates = append(gates, fmt.Sprintf("%s=%t", g, enabled))
+		}
+		sort.Strings(gates)
+
+		// The feature gates of the cloud-config templates are set after the
+		// extra arguments and are merged with these.
+		a := fmt.Sprintf("--feature-gates=%s", strings.Join(gates, ","))
+		args.APIServer = append(args.APIServer, a)
+		args.ControllerManager = append(args.ControllerManager, a)
+		args.Kubelet = append(args.Kubelet, a)
+	}
+
+	if len(extraArgs.AdmissionPlugins) > 0 {
+		for _, p := range extraArgs.AdmissionPlugins {
+			if !containsString(allowedAdmissionPlugins, p) {
+				return HyperkubeArgs{}, microerror.Maskf(invalidAnnotationError, "admission plugin '%s' is not allowed", p)
+			}
+		}
+
+		// The admission plugins of the cloud-config template are set after the
+		// extra arguments and are appended to these.
+		args.APIServer = append(args.APIServer, fmt.Sprintf("--admission-control=%s", strings.Join(extraArgs.AdmissionPlugins, ",")))
+	}
+
+	return args, nil
+}
+
+func newHyperkubeArgs(component string, extraArgs map[string]string, allowed []string) ([]string, error) {
+	var args []string
+
+	for k, v := range extraArgs {
+		if !containsString(allowed, k) {
+			return nil, microerror.Maskf(invalidAnnotationError, "%s argument '%s' is not allowed", component, k)
+		}
+		if !hyperkubeArgValueRegexp.MatchString(v) {
+			return nil, microerror.Maskf(invalidAnnotationError, "value '%s' of %s argument '%s' must match '%s'", v, component, k, hyperkubeArgValueRegexp.String())
+		}
+
+		args = append(args, fmt.Sprintf("--%s=%s", k, v))
+	}
+	sort.Strings(args)
+
+	return args, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
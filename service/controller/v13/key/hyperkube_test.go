@@ -0,0 +1,123 @@
+package key
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_HyperkubeExtraArgsFromAnnotation(t *testing.T) {
+	testCases := []struct {
+		Annotation      string
+		ExpectedArgs    HyperkubeArgs
+		ExpectedErrorFn func(error) bool
+	}{
+		// Test 0 ensures no arguments are returned in case the annotation is
+		// not set.
+		{
+			Annotation:   "",
+			ExpectedArgs: HyperkubeArgs{},
+		},
+
+		// Test 1 ensures allowlisted arguments are rendered sorted per component.
+		{
+			Annotation: `{
+				"apiServer": {"max-requests-inflight": "800", "event-ttl": "2h"},
+				"controllerManager": {"pod-eviction-timeout": "2m"},
+				"kubelet": {"max-pods": "200"}
+			}`,
+			ExpectedArgs: HyperkubeArgs{
+				APIServer:         []string{"--event-ttl=2h", "--max-requests-inflight=800"},
+				ControllerManager: []string{"--pod-eviction-timeout=2m"},
+				Kubelet:           []string{"--max-pods=200"},
+			},
+		},
+
+		// Test 2 ensures feature gates are applied to all components and
+		// admission plugins to the API server only.
+		{
+			Annotation: `{
+				"featureGates": {"TaintBasedEvictions": true, "CustomPodDNS": false},
+				"admissionPlugins": ["PodNodeSelector", "AlwaysPullImages"]
+			}`,
+			ExpectedArgs: HyperkubeArgs{
+				APIServer: []string{
+					"--feature-gates=CustomPodDNS=false,TaintBasedEvictions=true",
+					"--admission-control=PodNodeSelector,AlwaysPullImages",
+				},
+				ControllerManager: []string{"--feature-gates=CustomPodDNS=false,TaintBasedEvictions=true"},
+				Kubelet:           []string{"--feature-gates=CustomPodDNS=false,TaintBasedEvictions=true"},
+			},
+		},
+
+		// Test 3 ensures invalid JSON is rejected.
+		{
+			Annotation:      `["max-pods=200"]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 4 ensures arguments not being allowlisted are rejected.
+		{
+			Annotation:      `{"apiServer": {"authorization-mode": "AlwaysAllow"}}`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 5 ensures arguments allowlisted for another component are
+		// rejected.
+		{
+			Annotation:      `{"kubelet": {"pod-eviction-timeout": "2m"}}`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 6 ensures values breaking the kubelet command line are rejected.
+		{
+			Annotation:      `{"kubelet": {"max-pods": "200 --anonymous-auth=true"}}`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 7 ensures feature gates not being allowlisted are rejected.
+		{
+			Annotation:      `{"featureGates": {"DynamicKubeletConfig": true}}`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 8 ensures admission plugins not being allowlisted are rejected.
+		{
+			Annotation:      `{"admissionPlugins": ["AlwaysAdmit"]}`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 9 ensures feature gates needing the scheduler are rejected.
+		{
+			Annotation:      `{"featureGates": {"VolumeScheduling": true}}`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+	}
+
+	for i, tc := range testCases {
+		customObject := v1alpha1.KVMConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Annotations: map[string]string{
+					AnnotationHyperkubeExtraArgs: tc.Annotation,
+				},
+			},
+		}
+
+		args, err := HyperkubeExtraArgsFromAnnotation(customObject)
+		if tc.ExpectedErrorFn != nil {
+			if !tc.ExpectedErrorFn(err) {
+				t.Fatalf("case %d expected error got %#v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		if !reflect.DeepEqual(args, tc.ExpectedArgs) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedArgs, args)
+		}
+	}
+}
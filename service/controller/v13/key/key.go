@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -15,9 +16,30 @@ import (
 )
 
 const (
+	ImagePrePullID   = "image-prepull"
 	MasterID         = "master"
 	NodeControllerID = "node-controller"
 	WorkerID         = "worker"
+	// CloudConfigEncodingBase64 is the default encoding of the user data
+	// generated for guest cluster nodes. The rendered cloud-config is only
+	// base64 encoded.
+	CloudConfigEncodingBase64 = "base64"
+	// CloudConfigEncodingGzip is the encoding of the user data in case
+	// cloud-config compression is enabled. The rendered cloud-config is gzip
+	// compressed and base64 encoded, which keeps it small enough for config
+	// maps and secrets. It requires a k8s-kvm image decoding the user data
+	// according to the CLOUD_CONFIG_ENCODING environment variable.
+	CloudConfigEncodingGzip = "gzip+base64"
+	// CloudConfigMaxSize is the maximum size of the user data in bytes. Config
+	// maps and secrets are limited to 1 MiB in total, some of which is
+	// reserved for their metadata.
+	CloudConfigMaxSize = 1024*1024 - 64*1024
+	// CloudConfigUserDataKey is the key of the cloud-config in the data of the
+	// config maps mounted into the k8s-kvm container.
+	CloudConfigUserDataKey = "user_data"
+	// K8SKVMContainerName is the name of the container running the VM of a
+	// guest cluster node.
+	K8SKVMContainerName = "k8s-kvm"
 	// portBase is a baseline for computing the port for liveness probes.
 	portBase = 23000
 	// HealthEndpoint is http path for liveness probe.
@@ -35,10 +57,24 @@ const (
 	// SuccessThreshold is SuccessThreshold param in liveness probe config
 	SuccessThreshold = 1
 
+	// EtcdPort is the port etcd members of guest clusters serve clients on.
+	EtcdPort = 2379
+	// EtcdPeerPort is the port etcd members of guest clusters talk to each
+	// other on.
+	EtcdPeerPort = 2380
+
 	FlannelEnvPathPrefix = "/run/flannel"
 	CoreosImageDir       = "/var/lib/coreos-kvm-images"
-	CoreosVersion        = "1688.5.3"
-
+	// DefaultCoreosVersion is the Container Linux version guest cluster nodes
+	// are booted with, unless the custom object requests a specific version
+	// using AnnotationCoreosVersion.
+	DefaultCoreosVersion = "1688.5.3"
+
+	// ImagePrePullDockerImage is the image of the pods downloading Container
+	// Linux images. Its busybox provides wget and sha512sum, which the
+	// download script relies on. The same image is used by the guest cluster
+	// cloud-configs.
+	ImagePrePullDockerImage   = "alpine:3.6"
 	K8SEndpointUpdaterDocker  = "quay.io/giantswarm/k8s-endpoint-updater:df982fc73b71e60fc70a7444c068b52441ddb30e"
 	K8SKVMDockerImage         = "quay.io/giantswarm/k8s-kvm:16a61cf7fab82df299a1e921bb42e4f6402a8307"
 	K8SKVMHealthDocker        = "quay.io/giantswarm/k8s-kvm-health:ddf211dfed52086ade32ab8c45e44eb0273319ef"
@@ -58,12 +94,76 @@ const (
 	AnnotationService       = "endpoint.kvm.giantswarm.io/service"
 	AnnotationPodDrained    = "endpoint.kvm.giantswarm.io/drained"
 	AnnotationVersionBundle = "kvm-operator.giantswarm.io/version-bundle"
+	// AnnotationReplaces is put on worker deployments created to replace an
+	// existing worker deployment when updating workers in surge mode. Its value
+	// is the name of the replaced deployment.
+	AnnotationReplaces = "kvm-operator.giantswarm.io/replaces"
+	// AnnotationEtcdMember is put on master deployments of guest clusters
+	// running multiple masters. It is "True" once the master joined the etcd
+	// cluster and "False" once it was removed from it.
+	AnnotationEtcdMember = "kvm-operator.giantswarm.io/etcd-member"
+	// AnnotationEtcdBootstrapMember is put on the namespace of a guest cluster
+	// when it is created. Its value is the ID of the master bootstrapping the
+	// etcd cluster. All other masters join the existing etcd cluster, so do
+	// all masters of guest clusters whose namespace lacks the annotation.
+	AnnotationEtcdBootstrapMember = "kvm-operator.giantswarm.io/etcd-bootstrap-member"
+	// AnnotationVMSpecHash is put on master and worker deployments. Its value
+	// is a hash of the environment and the resources of the k8s-kvm container,
+	// which define the VM of the guest cluster node.
+	AnnotationVMSpecHash = "kvm-operator.giantswarm.io/vm-spec-hash"
+	// AnnotationCloudConfigHash is put on the pod templates of master and
+	// worker deployments. Its value is a hash of the cloud-config the VM of the
+	// guest cluster node boots with.
+	AnnotationCloudConfigHash = "kvm-operator.giantswarm.io/cloud-config-hash"
+	// AnnotationCloudConfigEncoding is put on the config maps and secrets
+	// holding cloud-configs. In case cloud-configs are compressed it is put on
+	// the pod templates of master and worker deployments as well. It tells the
+	// VM side how the user data is encoded.
+	AnnotationCloudConfigEncoding = "kvm-operator.giantswarm.io/cloud-config-encoding"
+	// AnnotationCertsHash is put on the config maps and secrets holding
+	// cloud-configs as well as on the pod templates of master and worker
+	// deployments. Its value is a hash of the certificates rendered into the
+	// cloud-config of the guest cluster node.
+	AnnotationCertsHash = "kvm-operator.giantswarm.io/certs-hash"
+)
+
+const (
+	// LabelCloudConfig is put on the secrets holding the cloud-configs of guest
+	// cluster nodes, so they can be told apart from other secrets in the
+	// cluster namespace.
+	LabelCloudConfig = "kvm-operator.giantswarm.io/cloud-config"
+	// LabelCertConfig is put on the CertConfig custom objects created for guest
+	// clusters, so they can be told apart from the ones managed by other
+	// operators.
+	LabelCertConfig = "kvm-operator.giantswarm.io/cert-config"
+	// LabelNamespace is put on the host cluster namespaces guest cluster
+	// namespaces are allowed to exchange traffic with. Its value is the name of
+	// the namespace, so network policies can select namespaces by name.
+	LabelNamespace = "kvm-operator.giantswarm.io/namespace"
+	// LabelCoreosVersion and LabelVersionBundle are put on the image pre-pull
+	// daemon sets, which are shared by all guest clusters of a version bundle
+	// booting the same Container Linux version.
+	LabelCoreosVersion = "kvm-operator.giantswarm.io/coreos-version"
+	LabelVersionBundle = "kvm-operator.giantswarm.io/version-bundle"
+	// LabelReplacement is put on the pods and into the selector of worker
+	// deployments replacing others in surge mode. Its value is the name of the
+	// replacement, so the pods of a worker and its replacement are told apart
+	// by their labels.
+	LabelReplacement = "kvm-operator.giantswarm.io/replacement"
 )
 
 const (
 	VersionBundleVersionAnnotation = "giantswarm.io/version-bundle-version"
 )
 
+// Annotations below are read from the KVMConfig custom object and allow to
+// configure guest clusters individually.
+const (
+	// AnnotationCoreosVersion overrides the Container Linux version guest
+	// cluster nodes are booted with.
+	AnnotationCoreosVersion = "kvm-operator.giantswarm.io/coreos-version"
+)
+
 const (
 	PodWatcherLabel = "kvm-operator.giantswarm.io/pod-watcher"
 )
@@ -72,6 +172,10 @@ const (
 	PodDeletionGracePeriod = 5 * time.Minute
 )
 
+var (
+	coreosVersionRegexp = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+$`)
+)
+
 func ClusterAPIEndpoint(customObject v1alpha1.KVMConfig) string {
 	return customObject.Spec.Cluster.Kubernetes.API.Domain
 }
@@ -121,6 +225,41 @@ func ConfigMapName(customObject v1alpha1.KVMConfig, node v1alpha1.ClusterNode, p
 	return fmt.Sprintf("%s-%s-%s", prefix, ClusterID(customObject), node.ID)
 }
 
+// CloudConfigEncoding returns the encoding of the user data generated for guest
+// cluster nodes, depending on whether cloud-config compression is enabled.
+func CloudConfigEncoding(compress bool) string {
+	if compress {
+		return CloudConfigEncodingGzip
+	}
+
+	return CloudConfigEncodingBase64
+}
+
+// CloudConfigSecretName returns the name of the secret holding the
+// cloud-config of the given node. It equals the name of the config map used
+// before, so that the volumes of existing deployments can be switched to
+// secrets without changing their names.
+func CloudConfigSecretName(customObject v1alpha1.KVMConfig, node v1alpha1.ClusterNode, prefix string) string {
+	return ConfigMapName(customObject, node, prefix)
+}
+
+// CoreosVersion returns the Container Linux version guest cluster nodes are
+// booted with. The version can be overridden per cluster by annotating the
+// custom object with AnnotationCoreosVersion. The version is validated since
+// it is used to construct download URLs and file paths on the host.
+func CoreosVersion(customObject v1alpha1.KVMConfig) (string, error) {
+	v, ok := customObject.GetAnnotations()[AnnotationCoreosVersion]
+	if !ok || v == "" {
+		return DefaultCoreosVersion, nil
+	}
+
+	if !coreosVersionRegexp.MatchString(v) {
+		return "", microerror.Maskf(invalidAnnotationError, "annotation '%s' must be of the form '<major>.<minor>.<patch>', got '%s'", AnnotationCoreosVersion, v)
+	}
+
+	return v, nil
+}
+
 func CPUQuantity(n v1alpha1.KVMConfigSpecKVMNode) (resource.Quantity, error) {
 	cpu := strconv.Itoa(n.CPUs)
 	q, err := resource.ParseQuantity(cpu)
@@ -134,10 +273,42 @@ func DeploymentName(prefix string, nodeID string) string {
 	return fmt.Sprintf("%s-%s", prefix, nodeID)
 }
 
+// DiskQuantity returns the size of the disk of the given node. Disks are sized
+// in gigabytes, like the DISK environment variable of the k8s-kvm container.
+func DiskQuantity(n v1alpha1.KVMConfigSpecKVMNode) (resource.Quantity, error) {
+	q, err := resource.ParseQuantity(fmt.Sprintf("%.0fG", n.Disk))
+	if err != nil {
+		return resource.Quantity{}, microerror.Mask(err)
+	}
+	return q, nil
+}
+
+// EndpointUpdaterImage returns the image of the endpoint updater container.
+// The image configured in the custom object takes precedence over the default.
+func EndpointUpdaterImage(customObject v1alpha1.KVMConfig) string {
+	if customObject.Spec.KVM.EndpointUpdater.Docker.Image != "" {
+		return customObject.Spec.KVM.EndpointUpdater.Docker.Image
+	}
+
+	return K8SEndpointUpdaterDocker
+}
+
 func EtcdPVCName(clusterID string, vmNumber string) string {
 	return fmt.Sprintf("%s-%s-%s", "pvc-master-etcd", clusterID, vmNumber)
 }
 
+// EtcdMemberName returns the name of the etcd member running on the given
+// master node.
+func EtcdMemberName(node v1alpha1.ClusterNode) string {
+	return fmt.Sprintf("etcd-%s", node.ID)
+}
+
+// EtcdPeerURL returns the URL the etcd member running on the master VM having
+// the given IP is reached on by other members.
+func EtcdPeerURL(ip string) string {
+	return fmt.Sprintf("https://%s:%d", ip, EtcdPeerPort)
+}
+
 func NetworkEnvFilePath(customObject v1alpha1.KVMConfig) string {
 	return fmt.Sprintf("%s/networks/%s.env", FlannelEnvPathPrefix, NetworkBridgeName(customObject))
 }
@@ -146,6 +317,34 @@ func HealthListenAddress(customObject v1alpha1.KVMConfig) string {
 	return "http://" + ProbeHost + ":" + strconv.Itoa(int(LivenessPort(customObject)))
 }
 
+// ImageWithRegistryMirror replaces the registry of the given image with the
+// given mirror. Images without an explicit registry are considered to be
+// hosted on the Docker Hub and get the mirror prepended. The image is returned
+// unchanged in case the mirror is empty.
+func ImageWithRegistryMirror(image, mirror string) string {
+	if mirror == "" {
+		return image
+	}
+
+	mirror = strings.TrimSuffix(mirror, "/")
+
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return mirror + "/" + parts[1]
+	}
+
+	return mirror + "/" + image
+}
+
+// ImagePrePullName returns the name of the image pre-pull daemon set of the
+// given version bundle and Container Linux version.
+func ImagePrePullName(versionBundleVersion, coreosVersion string) string {
+	v := strings.Replace(versionBundleVersion, ".", "-", -1)
+	c := strings.Replace(coreosVersion, ".", "-", -1)
+
+	return fmt.Sprintf("%s-%s-%s", ImagePrePullID, v, c)
+}
+
 func IsDeleted(customObject v1alpha1.KVMConfig) bool {
 	return customObject.GetDeletionTimestamp() != nil
 }
@@ -180,14 +379,36 @@ func IsPodDraind(pod *corev1.Pod) (bool, error) {
 	return b, nil
 }
 
+// K8SKVMImage returns the image of the k8s-kvm container running the guest
+// cluster VMs. The image configured in the custom object takes precedence over
+// the default.
+func K8SKVMImage(customObject v1alpha1.KVMConfig) string {
+	if customObject.Spec.KVM.K8sKVM.Docker.Image != "" {
+		return customObject.Spec.KVM.K8sKVM.Docker.Image
+	}
+
+	return K8SKVMDockerImage
+}
+
 func LivenessPort(customObject v1alpha1.KVMConfig) int32 {
 	return int32(portBase + customObject.Spec.KVM.Network.Flannel.VNI)
 }
 
+func LimitRangeName(customObject v1alpha1.KVMConfig) string {
+	return ClusterID(customObject)
+}
+
 func MasterHostPathVolumeDir(clusterID string, vmNumber string) string {
 	return filepath.Join("/home/core/volumes", clusterID, "k8s-master-vm"+vmNumber)
 }
 
+// MasterAuditLogHostPathDir returns the host directory the API server of the
+// given master node stores its audit logs in. It is kept apart from the etcd
+// data of the master.
+func MasterAuditLogHostPathDir(clusterID string, nodeID string) string {
+	return filepath.Join("/home/core/volumes", clusterID, "k8s-master-"+nodeID+"-audit-log")
+}
+
 // MemoryQuantity returns a resource.Quantity that represents the memory to be used by the nodes.
 // It adds the memory from the node definition parameter to the additional memory calculated on the node role
 func MemoryQuantityMaster(n v1alpha1.KVMConfigSpecKVMNode) (resource.Quantity, error) {
@@ -271,6 +492,20 @@ func NetworkNTPBlock(servers []net.IP) string {
 	return ntpBlock
 }
 
+// NodeControllerImage returns the image of the node controller. The image
+// configured in the custom object takes precedence over the default.
+func NodeControllerImage(customObject v1alpha1.KVMConfig) string {
+	if customObject.Spec.KVM.NodeController.Docker.Image != "" {
+		return customObject.Spec.KVM.NodeController.Docker.Image
+	}
+
+	return NodeControllerDockerImage
+}
+
+func NetworkPolicyName(customObject v1alpha1.KVMConfig) string {
+	return ClusterID(customObject)
+}
+
 func PVCNames(customObject v1alpha1.KVMConfig) []string {
 	var names []string
 
@@ -281,6 +516,22 @@ func PVCNames(customObject v1alpha1.KVMConfig) []string {
 	return names
 }
 
+func ResourceQuotaName(customObject v1alpha1.KVMConfig) string {
+	return ClusterID(customObject)
+}
+
+func RoleBindingName(customObject v1alpha1.KVMConfig) string {
+	return ClusterID(customObject)
+}
+
+func RoleBindingPSPName(customObject v1alpha1.KVMConfig) string {
+	return ClusterID(customObject) + "-psp"
+}
+
+func RoleName(customObject v1alpha1.KVMConfig) string {
+	return ClusterID(customObject)
+}
+
 func ServiceAccountName(customObject v1alpha1.KVMConfig) string {
 	return ClusterID(customObject)
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func Test_ClusterID(t *testing.T) {
@@ -58,3 +59,108 @@ DNS=8.8.4.4`
 		t.Fatal("expected", expected, "got", dnsServers)
 	}
 }
+
+func Test_CoreosVersion(t *testing.T) {
+	testCases := []struct {
+		Annotations     map[string]string
+		ExpectedVersion string
+		ErrorMatcher    func(error) bool
+	}{
+		{
+			Annotations:     nil,
+			ExpectedVersion: DefaultCoreosVersion,
+		},
+		{
+			Annotations: map[string]string{
+				AnnotationCoreosVersion: "1745.7.0",
+			},
+			ExpectedVersion: "1745.7.0",
+		},
+		{
+			Annotations: map[string]string{
+				AnnotationCoreosVersion: "stable",
+			},
+			ErrorMatcher: IsInvalidAnnotation,
+		},
+	}
+
+	for i, tc := range testCases {
+		customObject := v1alpha1.KVMConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Annotations: tc.Annotations,
+			},
+		}
+
+		version, err := CoreosVersion(customObject)
+		if tc.ErrorMatcher != nil {
+			if !tc.ErrorMatcher(err) {
+				t.Fatalf("case %d expected error got %#v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if version != tc.ExpectedVersion {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedVersion, version)
+		}
+	}
+}
+
+func Test_ImageWithRegistryMirror(t *testing.T) {
+	testCases := []struct {
+		Image         string
+		Mirror        string
+		ExpectedImage string
+	}{
+		{
+			Image:         "quay.io/giantswarm/k8s-kvm:16a61cf",
+			Mirror:        "",
+			ExpectedImage: "quay.io/giantswarm/k8s-kvm:16a61cf",
+		},
+		{
+			Image:         "quay.io/giantswarm/k8s-kvm:16a61cf",
+			Mirror:        "registry.example.com",
+			ExpectedImage: "registry.example.com/giantswarm/k8s-kvm:16a61cf",
+		},
+		{
+			Image:         "quay.io/giantswarm/k8s-kvm:16a61cf",
+			Mirror:        "registry.example.com:5000/",
+			ExpectedImage: "registry.example.com:5000/giantswarm/k8s-kvm:16a61cf",
+		},
+		{
+			Image:         "localhost/giantswarm/k8s-kvm:16a61cf",
+			Mirror:        "registry.example.com",
+			ExpectedImage: "registry.example.com/giantswarm/k8s-kvm:16a61cf",
+		},
+		{
+			Image:         "giantswarm/k8s-kvm:16a61cf",
+			Mirror:        "registry.example.com",
+			ExpectedImage: "registry.example.com/giantswarm/k8s-kvm:16a61cf",
+		},
+		{
+			Image:         "busybox",
+			Mirror:        "registry.example.com",
+			ExpectedImage: "registry.example.com/busybox",
+		},
+	}
+
+	for i, tc := range testCases {
+		image := ImageWithRegistryMirror(tc.Image, tc.Mirror)
+		if image != tc.ExpectedImage {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedImage, image)
+		}
+	}
+}
+
+func Test_K8SKVMImage(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{}
+	if K8SKVMImage(customObject) != K8SKVMDockerImage {
+		t.Fatalf("expected %#v got %#v", K8SKVMDockerImage, K8SKVMImage(customObject))
+	}
+
+	customObject.Spec.KVM.K8sKVM.Docker.Image = "registry.example.com/giantswarm/k8s-kvm:latest"
+	if K8SKVMImage(customObject) != "registry.example.com/giantswarm/k8s-kvm:latest" {
+		t.Fatalf("expected %#v got %#v", "registry.example.com/giantswarm/k8s-kvm:latest", K8SKVMImage(customObject))
+	}
+}
@@ -0,0 +1,310 @@
+package key
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// AnnotationNodePools defines the worker node pools of a guest cluster as
+	// JSON encoded list of NodePool. Node pool workers are added to the workers
+	// defined in the custom object spec.
+	AnnotationNodePools = "kvm-operator.giantswarm.io/node-pools"
+
+	// NodePoolLabel is the label put on worker deployments and guest cluster
+	// nodes to identify the node pool they belong to.
+	NodePoolLabel = "giantswarm.io/node-pool"
+
+	// ScaledNodePoolName is the name of the implicit node pool holding the
+	// workers added using the scale subresource of the KVMConfig CRD. It must
+	// not be used by node pools defined in AnnotationNodePools.
+	ScaledNodePoolName = "scaled"
+
+	// DefaultWorkerCPUs, DefaultWorkerDisk and DefaultWorkerMemory define the
+	// size of workers added using the scale subresource in case there is no
+	// existing worker to clone the size from.
+	DefaultWorkerCPUs   = 2
+	DefaultWorkerDisk   = 20
+	DefaultWorkerMemory = "4G"
+)
+
+var (
+	nodePoolNameRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,18}[a-z0-9])?$`)
+
+	// reservedNodeLabelDomains are the domains of label and taint keys which
+	// are reserved for Kubernetes. They must not be used by node pools, since
+	// they control e.g. the role of the node.
+	reservedNodeLabelDomains = []string{
+		"kubernetes.io",
+		"k8s.io",
+	}
+)
+
+// NodePool is a named group of workers sharing the same machine size, labels
+// and taints. Adding or removing capacity is done by changing Replicas.
+type NodePool struct {
+	Name     string                        `json:"name"`
+	Replicas int                           `json:"replicas"`
+	Size     v1alpha1.KVMConfigSpecKVMNode `json:"size"`
+	Labels   map[string]string             `json:"labels,omitempty"`
+	Taints   []corev1.Taint                `json:"taints,omitempty"`
+}
+
+// WorkerNode describes a single guest cluster worker, either defined in the
+// custom object spec or generated from a node pool.
+type WorkerNode struct {
+	Node   v1alpha1.ClusterNode
+	Size   v1alpha1.KVMConfigSpecKVMNode
+	Pool   string
+	Labels map[string]string
+	Taints []corev1.Taint
+}
+
+// NodePools returns the validated node pools defined in the custom object
+// using AnnotationNodePools.
+func NodePools(customObject v1alpha1.KVMConfig) ([]NodePool, error) {
+	v, ok := customObject.GetAnnotations()[AnnotationNodePools]
+	if !ok || v == "" {
+		return nil, nil
+	}
+
+	var pools []NodePool
+	err := json.Unmarshal([]byte(v), &pools)
+	if err != nil {
+		return nil, microerror.Maskf(invalidAnnotationError, "annotation '%s' must be a JSON list of node pools: %s", AnnotationNodePools, err)
+	}
+
+	names := map[string]bool{}
+	for _, p := range pools {
+		if !nodePoolNameRegexp.MatchString(p.Name) {
+			return nil, microerror.Maskf(invalidAnnotationError, "node pool name '%s' must be a lower case DNS label of at most 20 characters", p.Name)
+		}
+		if p.Name == ScaledNodePoolName {
+			return nil, microerror.Maskf(invalidAnnotationError, "node pool name '%s' is reserved", p.Name)
+		}
+		if names[p.Name] {
+			return nil, microerror.Maskf(invalidAnnotationError, "node pool name '%s' must be unique", p.Name)
+		}
+		names[p.Name] = true
+
+		if p.Replicas < 0 {
+			return nil, microerror.Maskf(invalidAnnotationError, "replicas of node pool '%s' must not be negative", p.Name)
+		}
+		if p.Size.CPUs <= 0 {
+			return nil, microerror.Maskf(invalidAnnotationError, "CPUs of node pool '%s' must be positive", p.Name)
+		}
+		if p.Size.Memory == "" {
+			return nil, microerror.Maskf(invalidAnnotationError, "memory of node pool '%s' must not be empty", p.Name)
+		}
+		if p.Size.Disk <= 0 {
+			return nil, microerror.Maskf(invalidAnnotationError, "disk of node pool '%s' must be positive", p.Name)
+		}
+
+		for k, v := range p.Labels {
+			err := validateNodeLabel(p.Name, k, v)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		for _, t := range p.Taints {
+			err := validateNodeLabel(p.Name, t.Key, t.Value)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			switch t.Effect {
+			case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+			default:
+				return nil, microerror.Maskf(invalidAnnotationError, "taint effect '%s' of node pool '%s' is not supported", t.Effect, p.Name)
+			}
+		}
+	}
+
+	return pools, nil
+}
+
+// validateNodeLabel returns an error in case the given key and value cannot be
+// used as label or taint of the nodes of the given node pool. The kubelet
+// refuses to register nodes with invalid labels or taints, which would leave
+// the workers unable to join.
+func validateNodeLabel(pool, k, v string) error {
+	if errs := validation.IsQualifiedName(k); len(errs) != 0 {
+		return microerror.Maskf(invalidAnnotationError, "key '%s' of node pool '%s' is invalid: %s", k, pool, strings.Join(errs, "; "))
+	}
+	if errs := validation.IsValidLabelValue(v); len(errs) != 0 {
+		return microerror.Maskf(invalidAnnotationError, "value '%s' of key '%s' of node pool '%s' is invalid: %s", v, k, pool, strings.Join(errs, "; "))
+	}
+
+	if i := strings.Index(k, "/"); i != -1 {
+		prefix := k[:i]
+		for _, d := range reservedNodeLabelDomains {
+			if prefix == d || strings.HasSuffix(prefix, "."+d) {
+				return microerror.Maskf(invalidAnnotationError, "key '%s' of node pool '%s' must not use the reserved prefix '%s/'", k, pool, d)
+			}
+		}
+	}
+
+	return nil
+}
+
+// NodePoolNodeID returns the ID of the worker with the given index within the
+// given node pool. The ID is stable, so the same worker keeps its deployment,
+// config map and guest cluster node across reconciliations.
+func NodePoolNodeID(pool string, index int) string {
+	return fmt.Sprintf("%s-%d", pool, index)
+}
+
+// WorkerNodes returns all workers of the guest cluster. These are the workers
+// defined in the custom object spec followed by the workers generated from the
+// node pools.
+func WorkerNodes(customObject v1alpha1.KVMConfig) ([]WorkerNode, error) {
+	var workers []WorkerNode
+
+	for i, n := range customObject.Spec.Cluster.Workers {
+		w := WorkerNode{
+			Node: n,
+		}
+		if i < len(customObject.Spec.KVM.Workers) {
+			w.Size = customObject.Spec.KVM.Workers[i]
+		}
+
+		workers = append(workers, w)
+	}
+
+	pools, err := NodePools(customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	ids := map[string]bool{}
+	for _, w := range workers {
+		ids[w.Node.ID] = true
+	}
+
+	for _, p := range pools {
+		for i := 0; i < p.Replicas; i++ {
+			id := NodePoolNodeID(p.Name, i)
+			if ids[id] {
+				return nil, microerror.Maskf(invalidAnnotationError, "ID '%s' of node pool worker conflicts with an existing worker", id)
+			}
+			ids[id] = true
+
+			w := WorkerNode{
+				Node: v1alpha1.ClusterNode{
+					ID: id,
+				},
+				Size:   p.Size,
+				Pool:   p.Name,
+				Labels: p.Labels,
+				Taints: p.Taints,
+			}
+
+			workers = append(workers, w)
+		}
+	}
+
+	return workers, nil
+}
+
+// ScaleWorkerNodes returns the given workers scaled to the given number of
+// replicas. The replicas only cover the workers defined in the custom object
+// spec and the ones of the implicit ScaledNodePoolName node pool, as told by
+// IsScaledWorkerNode. Workers of node pools defined in AnnotationNodePools are
+// always kept, since they are scaled using the replicas of their pool. Scaled
+// workers exceeding the replicas are removed from the end of the list. Missing
+// workers are added to the implicit ScaledNodePoolName node pool and clone the
+// size of the first existing worker, or use the default worker size in case
+// there is none. Workers defined in the custom object spec are never removed,
+// so replicas below their number are rejected with invalidSpecError.
+func ScaleWorkerNodes(workers []WorkerNode, replicas int) ([]WorkerNode, error) {
+	var scaled []WorkerNode
+	var pooled []WorkerNode
+	var specWorkers int
+	for _, w := range workers {
+		if IsScaledWorkerNode(w.Pool) {
+			scaled = append(scaled, w)
+		} else {
+			pooled = append(pooled, w)
+		}
+		if w.Pool == "" {
+			specWorkers++
+		}
+	}
+
+	if replicas < specWorkers {
+		return nil, microerror.Maskf(invalidSpecError, "replicas %d must not be less than the %d workers defined in the custom object spec", replicas, specWorkers)
+	}
+
+	if replicas <= len(scaled) {
+		return append(scaled[:replicas:replicas], pooled...), nil
+	}
+
+	size := v1alpha1.KVMConfigSpecKVMNode{
+		CPUs:   DefaultWorkerCPUs,
+		Disk:   DefaultWorkerDisk,
+		Memory: DefaultWorkerMemory,
+	}
+	if len(workers) > 0 {
+		size = workers[0].Size
+	}
+
+	for i := 0; len(scaled) < replicas; i++ {
+		w := WorkerNode{
+			Node: v1alpha1.ClusterNode{
+				ID: NodePoolNodeID(ScaledNodePoolName, i),
+			},
+			Size: size,
+			Pool: ScaledNodePoolName,
+		}
+
+		scaled = append(scaled, w)
+	}
+
+	return append(scaled, pooled...), nil
+}
+
+// IsScaledWorkerNode returns true in case workers of the given node pool are
+// scaled using the scale subresource of the KVMConfig CRD. These are the
+// workers defined in the custom object spec, which do not belong to any node
+// pool, and the ones of the implicit ScaledNodePoolName node pool.
+func IsScaledWorkerNode(pool string) bool {
+	return pool == "" || pool == ScaledNodePoolName
+}
+
+// KubeletNodeLabels returns the value of the kubelet --node-labels flag for the
+// given worker. Workers of node pools are labelled with NodePoolLabel in
+// addition to the labels of their pool.
+func KubeletNodeLabels(worker WorkerNode) string {
+	if worker.Pool == "" {
+		return ""
+	}
+
+	labels := []string{
+		fmt.Sprintf("%s=%s", NodePoolLabel, worker.Pool),
+	}
+	for k, v := range worker.Labels {
+		labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(labels)
+
+	return strings.Join(labels, ",")
+}
+
+// KubeletNodeTaints returns the value of the kubelet --register-with-taints
+// flag for the given worker.
+func KubeletNodeTaints(worker WorkerNode) string {
+	var taints []string
+	for _, t := range worker.Taints {
+		taints = append(taints, fmt.Sprintf("%s=%s:%s", t.Key, t.Value, t.Effect))
+	}
+
+	return strings.Join(taints, ",")
+}
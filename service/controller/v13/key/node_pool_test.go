@@ -0,0 +1,281 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_WorkerNodes(t *testing.T) {
+	testCases := []struct {
+		Annotation      string
+		ExpectedIDs     []string
+		ExpectedLabels  []string
+		ExpectedTaints  []string
+		ExpectedErrorFn func(error) bool
+	}{
+		// Test 0 ensures the workers of the spec are returned in case no node
+		// pools are defined.
+		{
+			Annotation:     "",
+			ExpectedIDs:    []string{"5xchu"},
+			ExpectedLabels: []string{""},
+			ExpectedTaints: []string{""},
+		},
+
+		// Test 1 ensures node pools are expanded into workers with stable IDs.
+		{
+			Annotation: `[
+				{"name": "default", "replicas": 2, "size": {"cpus": 2, "disk": 20, "memory": "4G"}},
+				{"name": "gpu", "replicas": 1, "size": {"cpus": 8, "disk": 50, "memory": "16G"}, "labels": {"gpu": "true"}, "taints": [{"key": "gpu", "value": "true", "effect": "NoSchedule"}]}
+			]`,
+			ExpectedIDs: []string{"5xchu", "default-0", "default-1", "gpu-0"},
+			ExpectedLabels: []string{
+				"",
+				"giantswarm.io/node-pool=default",
+				"giantswarm.io/node-pool=default",
+				"giantswarm.io/node-pool=gpu,gpu=true",
+			},
+			ExpectedTaints: []string{"", "", "", "gpu=true:NoSchedule"},
+		},
+
+		// Test 2 ensures a node pool without replicas does not add workers.
+		{
+			Annotation:     `[{"name": "default", "replicas": 0, "size": {"cpus": 2, "disk": 20, "memory": "4G"}}]`,
+			ExpectedIDs:    []string{"5xchu"},
+			ExpectedLabels: []string{""},
+			ExpectedTaints: []string{""},
+		},
+
+		// Test 3 ensures invalid JSON is rejected.
+		{
+			Annotation:      `{"name": "default"}`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 4 ensures invalid node pool names are rejected.
+		{
+			Annotation:      `[{"name": "Default_Pool", "replicas": 1, "size": {"cpus": 2, "disk": 20, "memory": "4G"}}]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 5 ensures duplicated node pool names are rejected.
+		{
+			Annotation: `[
+				{"name": "default", "replicas": 1, "size": {"cpus": 2, "disk": 20, "memory": "4G"}},
+				{"name": "default", "replicas": 1, "size": {"cpus": 2, "disk": 20, "memory": "4G"}}
+			]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 6 ensures node pools without size are rejected.
+		{
+			Annotation:      `[{"name": "default", "replicas": 1}]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 7 ensures unsupported taint effects are rejected.
+		{
+			Annotation:      `[{"name": "default", "replicas": 1, "size": {"cpus": 2, "disk": 20, "memory": "4G"}, "taints": [{"key": "gpu", "effect": "Maybe"}]}]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 8 ensures invalid label keys are rejected.
+		{
+			Annotation:      `[{"name": "default", "replicas": 1, "size": {"cpus": 2, "disk": 20, "memory": "4G"}, "labels": {"gpu,role": "true"}}]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 9 ensures invalid label values are rejected.
+		{
+			Annotation:      `[{"name": "default", "replicas": 1, "size": {"cpus": 2, "disk": 20, "memory": "4G"}, "labels": {"gpu": "true,role=master"}}]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 10 ensures label keys using reserved prefixes are rejected.
+		{
+			Annotation:      `[{"name": "default", "replicas": 1, "size": {"cpus": 2, "disk": 20, "memory": "4G"}, "labels": {"node-role.kubernetes.io/master": ""}}]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 11 ensures invalid taint keys are rejected.
+		{
+			Annotation:      `[{"name": "default", "replicas": 1, "size": {"cpus": 2, "disk": 20, "memory": "4G"}, "taints": [{"key": "gpu=true:NoSchedule,x", "effect": "NoSchedule"}]}]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 12 ensures invalid taint values are rejected.
+		{
+			Annotation:      `[{"name": "default", "replicas": 1, "size": {"cpus": 2, "disk": 20, "memory": "4G"}, "taints": [{"key": "gpu", "value": "a b", "effect": "NoSchedule"}]}]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+
+		// Test 13 ensures taint keys using reserved prefixes are rejected.
+		{
+			Annotation:      `[{"name": "default", "replicas": 1, "size": {"cpus": 2, "disk": 20, "memory": "4G"}, "taints": [{"key": "k8s.io/gpu", "effect": "NoSchedule"}]}]`,
+			ExpectedErrorFn: IsInvalidAnnotation,
+		},
+	}
+
+	for i, tc := range testCases {
+		customObject := v1alpha1.KVMConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Annotations: map[string]string{
+					AnnotationNodePools: tc.Annotation,
+				},
+			},
+			Spec: v1alpha1.KVMConfigSpec{
+				Cluster: v1alpha1.Cluster{
+					Workers: []v1alpha1.ClusterNode{
+						{
+							ID: "5xchu",
+						},
+					},
+				},
+				KVM: v1alpha1.KVMConfigSpecKVM{
+					Workers: []v1alpha1.KVMConfigSpecKVMNode{
+						{
+							CPUs:   4,
+							Disk:   30,
+							Memory: "8G",
+						},
+					},
+				},
+			},
+		}
+
+		workers, err := WorkerNodes(customObject)
+		if tc.ExpectedErrorFn != nil {
+			if !tc.ExpectedErrorFn(err) {
+				t.Fatalf("case %d expected error got %#v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		if len(workers) != len(tc.ExpectedIDs) {
+			t.Fatalf("case %d expected %d workers got %d", i, len(tc.ExpectedIDs), len(workers))
+		}
+		for j, w := range workers {
+			if w.Node.ID != tc.ExpectedIDs[j] {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedIDs[j], w.Node.ID)
+			}
+			if KubeletNodeLabels(w) != tc.ExpectedLabels[j] {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedLabels[j], KubeletNodeLabels(w))
+			}
+			if KubeletNodeTaints(w) != tc.ExpectedTaints[j] {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedTaints[j], KubeletNodeTaints(w))
+			}
+		}
+	}
+}
+
+func Test_ScaleWorkerNodes(t *testing.T) {
+	existing := []WorkerNode{
+		{
+			Node: v1alpha1.ClusterNode{ID: "5xchu"},
+			Size: v1alpha1.KVMConfigSpecKVMNode{CPUs: 4, Disk: 30, Memory: "8G"},
+		},
+		{
+			Node: v1alpha1.ClusterNode{ID: "default-0"},
+			Size: v1alpha1.KVMConfigSpecKVMNode{CPUs: 2, Disk: 20, Memory: "4G"},
+			Pool: "default",
+		},
+	}
+
+	testCases := []struct {
+		Workers       []WorkerNode
+		Replicas      int
+		ExpectedIDs   []string
+		ExpectedSizes []v1alpha1.KVMConfigSpecKVMNode
+		ErrorMatcher  func(error) bool
+	}{
+		// Test 0 ensures workers are kept as they are in case the replicas match.
+		{
+			Workers:     existing,
+			Replicas:    1,
+			ExpectedIDs: []string{"5xchu", "default-0"},
+			ExpectedSizes: []v1alpha1.KVMConfigSpecKVMNode{
+				{CPUs: 4, Disk: 30, Memory: "8G"},
+				{CPUs: 2, Disk: 20, Memory: "4G"},
+			},
+		},
+
+		// Test 1 ensures replicas below the number of workers defined in the
+		// custom object spec are rejected instead of removing spec workers.
+		{
+			Workers:      existing,
+			Replicas:     0,
+			ErrorMatcher: IsInvalidSpec,
+		},
+
+		// Test 2 ensures added workers clone the size of the first worker and do
+		// not count the node pool workers.
+		{
+			Workers:     existing,
+			Replicas:    3,
+			ExpectedIDs: []string{"5xchu", "scaled-0", "scaled-1", "default-0"},
+			ExpectedSizes: []v1alpha1.KVMConfigSpecKVMNode{
+				{CPUs: 4, Disk: 30, Memory: "8G"},
+				{CPUs: 4, Disk: 30, Memory: "8G"},
+				{CPUs: 4, Disk: 30, Memory: "8G"},
+				{CPUs: 2, Disk: 20, Memory: "4G"},
+			},
+		},
+
+		// Test 3 ensures added workers use the default size in case there is no
+		// worker to clone.
+		{
+			Workers:     nil,
+			Replicas:    1,
+			ExpectedIDs: []string{"scaled-0"},
+			ExpectedSizes: []v1alpha1.KVMConfigSpecKVMNode{
+				{CPUs: DefaultWorkerCPUs, Disk: DefaultWorkerDisk, Memory: DefaultWorkerMemory},
+			},
+		},
+
+		// Test 4 ensures workers of the implicit scaled node pool are removed from
+		// the end, while spec and node pool workers are kept.
+		{
+			Workers: append(existing, WorkerNode{
+				Node: v1alpha1.ClusterNode{ID: "scaled-0"},
+				Size: v1alpha1.KVMConfigSpecKVMNode{CPUs: 4, Disk: 30, Memory: "8G"},
+				Pool: ScaledNodePoolName,
+			}),
+			Replicas:    1,
+			ExpectedIDs: []string{"5xchu", "default-0"},
+			ExpectedSizes: []v1alpha1.KVMConfigSpecKVMNode{
+				{CPUs: 4, Disk: 30, Memory: "8G"},
+				{CPUs: 2, Disk: 20, Memory: "4G"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		workers, err := ScaleWorkerNodes(tc.Workers, tc.Replicas)
+		if err != nil {
+			if tc.ErrorMatcher == nil || !tc.ErrorMatcher(err) {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+			continue
+		}
+		if tc.ErrorMatcher != nil {
+			t.Fatalf("case %d expected error got %#v", i, nil)
+		}
+
+		if len(workers) != len(tc.ExpectedIDs) {
+			t.Fatalf("case %d expected %d workers got %d", i, len(tc.ExpectedIDs), len(workers))
+		}
+		for j, w := range workers {
+			if w.Node.ID != tc.ExpectedIDs[j] {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedIDs[j], w.Node.ID)
+			}
+			if w.Size != tc.ExpectedSizes[j] {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedSizes[j], w.Size)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package key
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/url"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// AnnotationOIDC defines the OIDC settings of the API server of a guest
+	// cluster as JSON encoded OIDC. The settings replace the installation wide
+	// OIDC configuration as a whole.
+	AnnotationOIDC = "kvm-operator.giantswarm.io/oidc"
+)
+
+// OIDC represents the per cluster OIDC settings of a guest cluster's API
+// server. IssuerURL and ClientID are required. CA is the PEM encoded
+// certificate authority the issuer is verified with. Empty CA verifies the
+// issuer with the host's root certificate authorities.
+type OIDC struct {
+	ClientID      string `json:"clientID,omitempty"`
+	IssuerURL     string `json:"issuerURL,omitempty"`
+	UsernameClaim string `json:"usernameClaim,omitempty"`
+	GroupsClaim   string `json:"groupsClaim,omitempty"`
+	CA            string `json:"ca,omitempty"`
+}
+
+// OIDCFromAnnotation returns the validated OIDC settings defined in the custom
+// object using AnnotationOIDC. The zero value is returned in case the
+// annotation is not set.
+func OIDCFromAnnotation(customObject v1alpha1.KVMConfig) (OIDC, error) {
+	v, ok := customObject.GetAnnotations()[AnnotationOIDC]
+	if !ok || v == "" {
+		return OIDC{}, nil
+	}
+
+	var oidc OIDC
+	err := json.Unmarshal([]byte(v), &oidc)
+	if err != nil {
+		return OIDC{}, microerror.Maskf(invalidAnnotationError, "annotation '%s' must be JSON: %s", AnnotationOIDC, err)
+	}
+
+	if oidc.IssuerURL == "" {
+		return OIDC{}, microerror.Maskf(invalidAnnotationError, "issuer URL of annotation '%s' must not be empty", AnnotationOIDC)
+	}
+	if oidc.ClientID == "" {
+		return OIDC{}, microerror.Maskf(invalidAnnotationError, "client ID of annotation '%s' must not be empty", AnnotationOIDC)
+	}
+
+	{
+		// The API server only accepts issuers served via HTTPS.
+		u, err := url.Parse(oidc.IssuerURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return OIDC{}, microerror.Maskf(invalidAnnotationError, "issuer URL '%s' must be a HTTPS URL", oidc.IssuerURL)
+		}
+	}
+
+	if oidc.CA != "" {
+		b, _ := pem.Decode([]byte(oidc.CA))
+		if b == nil || b.Type != "CERTIFICATE" {
+			return OIDC{}, microerror.Maskf(invalidAnnotationError, "CA of annotation '%s' must be a PEM encoded certificate", AnnotationOIDC)
+		}
+		_, err := x509.ParseCertificate(b.Bytes)
+		if err != nil {
+			return OIDC{}, microerror.Maskf(invalidAnnotationError, "CA of annotation '%s' must be a PEM encoded certificate: %s", AnnotationOIDC, err)
+		}
+	}
+
+	values := map[string]string{
+		"client ID":      oidc.ClientID,
+		"issuer URL":     oidc.IssuerURL,
+		"username claim": oidc.UsernameClaim,
+		"groups claim":   oidc.GroupsClaim,
+	}
+	for name, value := range values {
+		if value != "" && !hyperkubeArgValueRegexp.MatchString(value) {
+			return OIDC{}, microerror.Maskf(invalidAnnotationError, "%s '%s' must match '%s'", name, value, hyperkubeArgValueRegexp.String())
+		}
+	}
+
+	return oidc, nil
+}
@@ -0,0 +1,92 @@
+package key
+
+import (
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// NodeResources are the resources the VM pod of a guest cluster node requests
+// for its VM. Memory includes the overhead of QEMU. Disk is the size of the
+// disk of the VM, which is not part of the resources of the pod.
+type NodeResources struct {
+	ID     string
+	Role   string
+	CPU    resource.Quantity
+	Disk   resource.Quantity
+	Memory resource.Quantity
+}
+
+// ClusterNodeResources returns the resources requested for the VMs of all
+// masters and the given workers of the guest cluster, as the master and worker
+// deployments request them.
+func ClusterNodeResources(customObject v1alpha1.KVMConfig, workers []WorkerNode) ([]NodeResources, error) {
+	var nodes []NodeResources
+
+	for i, m := range customObject.Spec.Cluster.Masters {
+		if i >= len(customObject.Spec.KVM.Masters) {
+			return nil, microerror.Maskf(invalidSpecError, "master '%s' must have a size defined in the KVM spec", m.ID)
+		}
+		size := customObject.Spec.KVM.Masters[i]
+
+		cpu, err := CPUQuantity(size)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		disk, err := DiskQuantity(size)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		memory, err := MemoryQuantityMaster(size)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		nodes = append(nodes, NodeResources{
+			ID:     m.ID,
+			Role:   MasterID,
+			CPU:    cpu,
+			Disk:   disk,
+			Memory: memory,
+		})
+	}
+
+	for _, w := range workers {
+		cpu, err := CPUQuantity(w.Size)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		disk, err := DiskQuantity(w.Size)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		memory, err := MemoryQuantityWorker(w.Size)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		nodes = append(nodes, NodeResources{
+			ID:     w.Node.ID,
+			Role:   WorkerID,
+			CPU:    cpu,
+			Disk:   disk,
+			Memory: memory,
+		})
+	}
+
+	return nodes, nil
+}
+
+// SumNodeResources returns the CPU and memory requested by the given nodes in
+// total.
+func SumNodeResources(nodes []NodeResources) (resource.Quantity, resource.Quantity) {
+	cpu := resource.Quantity{}
+	memory := resource.Quantity{}
+
+	for _, n := range nodes {
+		cpu.Add(n.CPU)
+		memory.Add(n.Memory)
+	}
+
+	return cpu, memory
+}
@@ -0,0 +1,270 @@
+package quorumguard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/etcdcluster"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/metric"
+)
+
+const (
+	// eventSource is the component emitting the events of quorum guard
+	// decisions.
+	eventSource = "kvm-operator"
+)
+
+const (
+	reasonAllowed = "QuorumGuardAllowed"
+	reasonDenied  = "QuorumGuardDenied"
+)
+
+type Config struct {
+	CertsSearcher certs.Interface
+	K8sClient     kubernetes.Interface
+	Logger        micrologger.Logger
+}
+
+// QuorumGuard implements Interface. The etcd members of a guest cluster are
+// the master VMs, which are exposed by the endpoints of the master service.
+// The number of members is the number of master deployments, so members being
+// down already are accounted for.
+type QuorumGuard struct {
+	certsSearcher certs.Interface
+	k8sClient     kubernetes.Interface
+	logger        micrologger.Logger
+
+	// memberHealth checks the health of the etcd member having the given IP. It
+	// is replaced in tests.
+	memberHealth func(ip string, tls certs.TLS) (bool, error)
+}
+
+func New(config Config) (*QuorumGuard, error) {
+	if config.CertsSearcher == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CertsSearcher must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	q := &QuorumGuard{
+		certsSearcher: config.CertsSearcher,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+
+		memberHealth: memberHealth,
+	}
+
+	return q, nil
+}
+
+func (q *QuorumGuard) Check(ctx context.Context, request Request) (Decision, error) {
+	decision, err := q.decide(ctx, request)
+	if err != nil {
+		return Decision{}, microerror.Mask(err)
+	}
+
+	q.report(ctx, request, decision)
+
+	return decision, nil
+}
+
+func (q *QuorumGuard) decide(ctx context.Context, request Request) (Decision, error) {
+	var members int
+	{
+		o := metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("app=%s", key.MasterID),
+		}
+		list, err := q.k8sClient.Extensions().Deployments(request.ClusterID).List(o)
+		if err != nil {
+			return Decision{}, microerror.Mask(err)
+		}
+
+		var deleted int
+		for _, d := range list.Items {
+			if d.GetDeletionTimestamp() != nil {
+				deleted++
+			}
+		}
+
+		members = len(list.Items)
+
+		if members <= 1 {
+			d := Decision{
+				Allowed: true,
+				Members: members,
+				Reason:  "guest cluster has a single etcd member only",
+			}
+			return d, nil
+		}
+		if deleted == members {
+			d := Decision{
+				Allowed: true,
+				Members: members,
+				Reason:  "guest cluster is being deleted",
+			}
+			return d, nil
+		}
+	}
+
+	var healthy []string
+	{
+		endpoints, err := q.k8sClient.CoreV1().Endpoints(request.ClusterID).Get(key.MasterID, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return Decision{}, microerror.Mask(err)
+		}
+
+		var ips []string
+		if endpoints != nil {
+			for _, s := range endpoints.Subsets {
+				for _, a := range s.Addresses {
+					ips = append(ips, a.IP)
+				}
+			}
+		}
+
+		if len(ips) > 0 {
+			cluster, err := q.certsSearcher.SearchCluster(request.ClusterID)
+			if err != nil {
+				return Decision{}, microerror.Mask(err)
+			}
+
+			for _, ip := range ips {
+				ok, err := q.memberHealth(ip, cluster.EtcdServer)
+				if err != nil {
+					q.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("cannot check health of etcd member '%s'", ip), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+				if ok {
+					healthy = append(healthy, ip)
+				}
+			}
+		}
+	}
+
+	// The master being disrupted only lowers the number of healthy members in
+	// case it is healthy itself. Without knowing its IP we assume it is.
+	remaining := len(healthy)
+	if request.MasterIP == "" || containsString(healthy, request.MasterIP) {
+		remaining--
+	}
+	// Removing a member shrinks the etcd cluster, which lowers the quorum the
+	// remaining members have to keep.
+	size := members
+	if request.Action == ActionRemove {
+		size--
+	}
+	quorum := size/2 + 1
+
+	d := Decision{
+		Allowed: remaining >= quorum,
+		Healthy: len(healthy),
+		Members: members,
+	}
+	if d.Allowed {
+		d.Reason = fmt.Sprintf("%d of %d etcd members stay healthy, quorum is %d", remaining, size, quorum)
+	} else {
+		d.Reason = fmt.Sprintf("only %d of %d etcd members would stay healthy, quorum is %d", remaining, size, quorum)
+	}
+
+	return d, nil
+}
+
+// report logs the given decision, emits it as event on the object of the given
+// request and counts it in the etcd quorum guard metric. Failing to emit the
+// event does not affect the decision, which is why errors are only logged.
+func (q *QuorumGuard) report(ctx context.Context, request Request, decision Decision) {
+	label := DecisionDenied
+	reason := reasonDenied
+	eventType := corev1.EventTypeWarning
+	if decision.Allowed {
+		label = DecisionAllowed
+		reason = reasonAllowed
+		eventType = corev1.EventTypeNormal
+	}
+
+	message := fmt.Sprintf("etcd quorum guard %s %s of %s '%s': %s", label, request.Action, request.Object.Kind, request.Object.Name, decision.Reason)
+
+	q.logger.LogCtx(ctx, "level", "debug", "message", message)
+
+	metric.EtcdQuorumGuardDecisionCounter.WithLabelValues(request.Action, label).Inc()
+
+	now := metav1.NewTime(time.Now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", request.Object.Name, now.UnixNano()),
+			Namespace: request.Object.Namespace,
+		},
+		InvolvedObject: request.Object,
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source: corev1.EventSource{
+			Component: eventSource,
+		},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	_, err := q.k8sClient.CoreV1().Events(request.Object.Namespace).Create(event)
+	if err != nil {
+		q.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cannot create event for %s '%s'", request.Object.Kind, request.Object.Name), "stack", fmt.Sprintf("%#v", err))
+	}
+}
+
+// memberHealth checks the health endpoint of the etcd member having the given
+// IP.
+func memberHealth(ip string, etcdTLS certs.TLS) (bool, error) {
+	client, err := etcdcluster.NewHTTPClient(etcdTLS)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	res, err := client.Get(fmt.Sprintf("https://%s:%d/health", ip, key.EtcdPort))
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return false, nil
+	}
+
+	var health struct {
+		Health string `json:"health"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&health)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return health.Health == "true", nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,238 @@
+package quorumguard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/certs/certstest"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_QuorumGuard_Check(t *testing.T) {
+	newDeployment := func(id string, deleted bool) runtime.Object {
+		d := &v1beta1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      key.DeploymentName(key.MasterID, id),
+				Namespace: "al9qy",
+				Labels: map[string]string{
+					"app":  key.MasterID,
+					"node": id,
+				},
+			},
+		}
+		if deleted {
+			now := apismetav1.Now()
+			d.SetDeletionTimestamp(&now)
+		}
+		return d
+	}
+
+	newEndpoints := func(ips ...string) runtime.Object {
+		var addresses []corev1.EndpointAddress
+		for _, ip := range ips {
+			addresses = append(addresses, corev1.EndpointAddress{IP: ip})
+		}
+		return &corev1.Endpoints{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      key.MasterID,
+				Namespace: "al9qy",
+			},
+			Subsets: []corev1.EndpointSubset{
+				{
+					Addresses: addresses,
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		Action          string
+		K8sObjects      []runtime.Object
+		HealthyIPs      []string
+		MasterIP        string
+		ExpectedAllowed bool
+		ExpectedEvent   string
+	}{
+		// Test 1 ensures a single master is always allowed to be disrupted.
+		{
+			Action: ActionUpdate,
+			K8sObjects: []runtime.Object{
+				newDeployment("1", false),
+			},
+			HealthyIPs:      nil,
+			MasterIP:        "10.0.0.1",
+			ExpectedAllowed: true,
+			ExpectedEvent:   reasonAllowed,
+		},
+
+		// Test 2 ensures a healthy master is allowed to be disrupted in case all
+		// other members are healthy.
+		{
+			Action: ActionUpdate,
+			K8sObjects: []runtime.Object{
+				newDeployment("1", false),
+				newDeployment("2", false),
+				newDeployment("3", false),
+				newEndpoints("10.0.0.1", "10.0.0.2", "10.0.0.3"),
+			},
+			HealthyIPs:      []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+			MasterIP:        "10.0.0.1",
+			ExpectedAllowed: true,
+			ExpectedEvent:   reasonAllowed,
+		},
+
+		// Test 3 ensures a healthy master is not allowed to be disrupted in case
+		// the quorum would be lost.
+		{
+			Action: ActionUpdate,
+			K8sObjects: []runtime.Object{
+				newDeployment("1", false),
+				newDeployment("2", false),
+				newDeployment("3", false),
+				newEndpoints("10.0.0.1", "10.0.0.2", "10.0.0.3"),
+			},
+			HealthyIPs:      []string{"10.0.0.1", "10.0.0.2"},
+			MasterIP:        "10.0.0.1",
+			ExpectedAllowed: false,
+			ExpectedEvent:   reasonDenied,
+		},
+
+		// Test 4 ensures an unhealthy master is allowed to be disrupted as long as
+		// the remaining members keep the quorum.
+		{
+			Action: ActionUpdate,
+			K8sObjects: []runtime.Object{
+				newDeployment("1", false),
+				newDeployment("2", false),
+				newDeployment("3", false),
+				newEndpoints("10.0.0.1", "10.0.0.2", "10.0.0.3"),
+			},
+			HealthyIPs:      []string{"10.0.0.1", "10.0.0.2"},
+			MasterIP:        "10.0.0.3",
+			ExpectedAllowed: true,
+			ExpectedEvent:   reasonAllowed,
+		},
+
+		// Test 5 ensures masters are not allowed to be disrupted in case the
+		// members cannot be reached.
+		{
+			Action: ActionUpdate,
+			K8sObjects: []runtime.Object{
+				newDeployment("1", false),
+				newDeployment("2", false),
+				newDeployment("3", false),
+			},
+			HealthyIPs:      nil,
+			MasterIP:        "10.0.0.1",
+			ExpectedAllowed: false,
+			ExpectedEvent:   reasonDenied,
+		},
+
+		// Test 6 ensures masters of guest clusters being deleted are always
+		// allowed to be disrupted.
+		{
+			Action: ActionUpdate,
+			K8sObjects: []runtime.Object{
+				newDeployment("1", true),
+				newDeployment("2", true),
+				newDeployment("3", true),
+			},
+			HealthyIPs:      nil,
+			MasterIP:        "10.0.0.1",
+			ExpectedAllowed: true,
+			ExpectedEvent:   reasonAllowed,
+		},
+
+		// Test 7 ensures a healthy master is allowed to be removed from an etcd
+		// cluster of two members, because the quorum shrinks with the cluster.
+		{
+			Action: ActionRemove,
+			K8sObjects: []runtime.Object{
+				newDeployment("1", false),
+				newDeployment("2", false),
+				newEndpoints("10.0.0.1", "10.0.0.2"),
+			},
+			HealthyIPs:      []string{"10.0.0.1", "10.0.0.2"},
+			MasterIP:        "10.0.0.2",
+			ExpectedAllowed: true,
+			ExpectedEvent:   reasonAllowed,
+		},
+
+		// Test 8 ensures a healthy master is not allowed to be updated in an etcd
+		// cluster of two members.
+		{
+			Action: ActionUpdate,
+			K8sObjects: []runtime.Object{
+				newDeployment("1", false),
+				newDeployment("2", false),
+				newEndpoints("10.0.0.1", "10.0.0.2"),
+			},
+			HealthyIPs:      []string{"10.0.0.1", "10.0.0.2"},
+			MasterIP:        "10.0.0.2",
+			ExpectedAllowed: false,
+			ExpectedEvent:   reasonDenied,
+		},
+	}
+
+	for i, tc := range testCases {
+		k8sClient := fake.NewSimpleClientset(tc.K8sObjects...)
+
+		var quorumGuard *QuorumGuard
+		{
+			c := Config{
+				CertsSearcher: certstest.NewSearcher(),
+				K8sClient:     k8sClient,
+				Logger:        microloggertest.New(),
+			}
+
+			var err error
+			quorumGuard, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+
+			healthyIPs := tc.HealthyIPs
+			quorumGuard.memberHealth = func(ip string, tls certs.TLS) (bool, error) {
+				return containsString(healthyIPs, ip), nil
+			}
+		}
+
+		request := Request{
+			Action:    tc.Action,
+			ClusterID: "al9qy",
+			MasterIP:  tc.MasterIP,
+			Object: corev1.ObjectReference{
+				Kind:      "Deployment",
+				Name:      "master-1",
+				Namespace: "al9qy",
+			},
+		}
+
+		decision, err := quorumGuard.Check(context.TODO(), request)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+		if decision.Allowed != tc.ExpectedAllowed {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedAllowed, decision.Allowed)
+		}
+
+		events, err := k8sClient.CoreV1().Events("al9qy").List(apismetav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+		if len(events.Items) != 1 {
+			t.Fatalf("case %d expected %#v got %#v", i+1, 1, len(events.Items))
+		}
+		if events.Items[0].Reason != tc.ExpectedEvent {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedEvent, events.Items[0].Reason)
+		}
+	}
+}
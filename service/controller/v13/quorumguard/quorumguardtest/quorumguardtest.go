@@ -0,0 +1,28 @@
+package quorumguardtest
+
+import (
+	"context"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard"
+)
+
+// QuorumGuard is a quorumguard.Interface implementation recording the
+// requests it was asked to check and deciding as configured, for use in tests.
+type QuorumGuard struct {
+	Denied   bool
+	Requests []quorumguard.Request
+}
+
+func New() *QuorumGuard {
+	return &QuorumGuard{}
+}
+
+func (q *QuorumGuard) Check(ctx context.Context, request quorumguard.Request) (quorumguard.Decision, error) {
+	q.Requests = append(q.Requests, request)
+
+	d := quorumguard.Decision{
+		Allowed: !q.Denied,
+	}
+
+	return d, nil
+}
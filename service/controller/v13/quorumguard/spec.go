@@ -0,0 +1,55 @@
+package quorumguard
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ActionDrain is the action of draining the guest cluster node of a master
+	// pod.
+	ActionDrain = "drain"
+	// ActionRemove is the action of removing a master from the etcd cluster
+	// when scaling down masters.
+	ActionRemove = "remove"
+	// ActionUpdate is the action of updating a master deployment.
+	ActionUpdate = "update"
+)
+
+const (
+	DecisionAllowed = "allowed"
+	DecisionDenied  = "denied"
+)
+
+// Interface describes how disruptions of guest cluster masters are guarded, so
+// the etcd cluster formed by the masters never loses its quorum.
+type Interface interface {
+	// Check decides whether the master described by the given request may be
+	// disrupted. The decision is logged, emitted as event on the object of the
+	// request and counted in the etcd quorum guard metric.
+	Check(ctx context.Context, request Request) (Decision, error)
+}
+
+// Request describes the disruption of a single guest cluster master.
+type Request struct {
+	// Action is the disruption being guarded, either ActionDrain or
+	// ActionUpdate.
+	Action string
+	// ClusterID is the ID of the guest cluster the master belongs to.
+	ClusterID string
+	// MasterIP is the IP of the master being disrupted. In case it is empty the
+	// master is assumed to be a healthy etcd member.
+	MasterIP string
+	// Object references the object being disrupted, which is where the
+	// decision is emitted as event.
+	Object corev1.ObjectReference
+}
+
+// Decision is the result of a quorum guard check.
+type Decision struct {
+	Allowed bool
+	Healthy int
+	Members int
+	Reason  string
+}
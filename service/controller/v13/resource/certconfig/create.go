@@ -0,0 +1,99 @@
+package certconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange interface{}) error {
+	certConfigsToCreate, err := toCertConfigs(createChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Create the cert configs in the Kubernetes API.
+	if len(certConfigsToCreate) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the cert configs in the Kubernetes API")
+
+		for _, certConfig := range certConfigsToCreate {
+			_, err := r.g8sClient.CoreV1alpha1().CertConfigs(certConfig.Namespace).Create(certConfig)
+			if apierrors.IsAlreadyExists(err) {
+				err = r.adoptCertConfig(ctx, certConfig)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "created the cert configs in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the cert configs do not need to be created in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) newCreateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentCertConfigs, err := toCertConfigs(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredCertConfigs, err := toCertConfigs(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which cert configs have to be created")
+
+	var certConfigsToCreate []*v1alpha1.CertConfig
+
+	for _, desiredCertConfig := range desiredCertConfigs {
+		if !containsCertConfig(currentCertConfigs, desiredCertConfig) {
+			certConfigsToCreate = append(certConfigsToCreate, desiredCertConfig)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d cert configs that have to be created", len(certConfigsToCreate)))
+
+	return certConfigsToCreate, nil
+}
+
+// adoptCertConfig takes over the existing cert config having the name of the
+// given one, e.g. one created for the guest cluster before cert configs were
+// provisioned by this operator. It is not found as current state, because it
+// lacks key.LabelCertConfig, so it is updated to the given cert config.
+func (r *Resource) adoptCertConfig(ctx context.Context, certConfig *v1alpha1.CertConfig) error {
+	current, err := r.g8sClient.CoreV1alpha1().CertConfigs(certConfig.Namespace).Get(certConfig.Name, apismetav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if current.GetLabels()[key.LabelCertConfig] == "true" {
+		// The cert config got created concurrently and is reconciled with the
+		// next update.
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("adopting existing cert config '%s/%s'", certConfig.Namespace, certConfig.Name))
+
+	adopted := certConfig.DeepCopy()
+	adopted.ResourceVersion = current.ResourceVersion
+
+	_, err = r.g8sClient.CoreV1alpha1().CertConfigs(certConfig.Namespace).Update(adopted)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("adopted existing cert config '%s/%s'", certConfig.Namespace, certConfig.Name))
+
+	return nil
+}
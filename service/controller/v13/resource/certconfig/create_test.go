@@ -0,0 +1,113 @@
+package certconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/core/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
+	"github.com/giantswarm/micrologger/microloggertest"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_Resource_CertConfig_ApplyCreateChange(t *testing.T) {
+	newCertConfig := func(labels map[string]string, ttl string) *v1alpha1.CertConfig {
+		return &v1alpha1.CertConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      "al9qy-api",
+				Namespace: "default",
+				Labels:    labels,
+			},
+			Spec: v1alpha1.CertConfigSpec{
+				Cert: v1alpha1.CertConfigSpecCert{
+					ClusterComponent: "api",
+					ClusterID:        "al9qy",
+					TTL:              ttl,
+				},
+			},
+		}
+	}
+
+	labels := map[string]string{
+		key.LabelCertConfig:   "true",
+		labelClusterComponent: "api",
+		labelClusterID:        "al9qy",
+	}
+
+	testCases := []struct {
+		CertConfigs        []runtime.Object
+		ExpectedCertConfig *v1alpha1.CertConfig
+	}{
+		// Test 1 ensures cert configs are created.
+		{
+			CertConfigs:        nil,
+			ExpectedCertConfig: newCertConfig(labels, "4320h"),
+		},
+
+		// Test 2 ensures existing cert configs lacking the label of this
+		// operator are adopted.
+		{
+			CertConfigs: []runtime.Object{
+				newCertConfig(map[string]string{labelClusterID: "al9qy"}, "720h"),
+			},
+			ExpectedCertConfig: newCertConfig(labels, "4320h"),
+		},
+
+		// Test 3 ensures cert configs already having the label of this operator
+		// are left to the update.
+		{
+			CertConfigs: []runtime.Object{
+				newCertConfig(labels, "720h"),
+			},
+			ExpectedCertConfig: newCertConfig(labels, "720h"),
+		},
+	}
+
+	for i, tc := range testCases {
+		g8sClient := g8sfake.NewSimpleClientset(tc.CertConfigs...)
+
+		var newResource *Resource
+		{
+			c := Config{
+				ClusterStatus: clusterstatustest.New(),
+				G8sClient:     g8sClient,
+				K8sClient:     fake.NewSimpleClientset(),
+				Logger:        microloggertest.New(),
+
+				TTL:                  "4320h",
+				VersionBundleVersion: "0.1.0",
+			}
+
+			var err error
+			newResource, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		createChange := []*v1alpha1.CertConfig{
+			newCertConfig(labels, "4320h"),
+		}
+		err := newResource.ApplyCreateChange(context.Background(), nil, createChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		certConfig, err := g8sClient.CoreV1alpha1().CertConfigs("default").Get("al9qy-api", apismetav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+		if !reflect.DeepEqual(certConfig.Labels, tc.ExpectedCertConfig.Labels) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedCertConfig.Labels, certConfig.Labels)
+		}
+		if !reflect.DeepEqual(certConfig.Spec, tc.ExpectedCertConfig.Spec) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedCertConfig.Spec, certConfig.Spec)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package certconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller/context/resourcecanceledcontext"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if key.IsDeleted(customObject) != r.cleanup {
+		if r.cleanup {
+			r.logger.LogCtx(ctx, "level", "debug", "message", "cert configs are only deleted by the cleanup resource")
+		} else {
+			r.logger.LogCtx(ctx, "level", "debug", "message", "redirecting responsibility of deletion of cert configs to the cleanup resource")
+		}
+		resourcecanceledcontext.SetCanceled(ctx)
+		r.logger.LogCtx(ctx, "level", "debug", "message", "canceling resource for custom object")
+
+		return nil, nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "looking for a list of cert configs in the Kubernetes API")
+
+	var currentCertConfigs []*v1alpha1.CertConfig
+	{
+		o := apismetav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s,%s=%s", key.LabelCertConfig, "true", labelClusterID, key.ClusterID(customObject)),
+		}
+		list, err := r.g8sClient.CoreV1alpha1().CertConfigs(certs.SecretNamespace).List(o)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, item := range list.Items {
+			c := item
+			currentCertConfigs = append(currentCertConfigs, &c)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found a list of %d cert configs in the Kubernetes API", len(currentCertConfigs)))
+
+	return currentCertConfigs, nil
+}
@@ -0,0 +1,101 @@
+package certconfig
+
+import (
+	"context"
+	"testing"
+
+	providerv1alpha1 "github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/operatorkit/controller/context/resourcecanceledcontext"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+)
+
+func Test_Resource_CertConfig_GetCurrentState_Cleanup(t *testing.T) {
+	now := apismetav1.Now()
+
+	testCases := []struct {
+		Cleanup          bool
+		Deleted          bool
+		ExpectedCanceled bool
+	}{
+		// Test 1 ensures the resource reconciles guest clusters not being
+		// deleted.
+		{
+			Cleanup:          false,
+			Deleted:          false,
+			ExpectedCanceled: false,
+		},
+
+		// Test 2 ensures the resource leaves the deletion of cert configs to the
+		// cleanup resource.
+		{
+			Cleanup:          false,
+			Deleted:          true,
+			ExpectedCanceled: true,
+		},
+
+		// Test 3 ensures the cleanup resource ignores guest clusters not being
+		// deleted.
+		{
+			Cleanup:          true,
+			Deleted:          false,
+			ExpectedCanceled: true,
+		},
+
+		// Test 4 ensures the cleanup resource deletes the cert configs of deleted
+		// guest clusters.
+		{
+			Cleanup:          true,
+			Deleted:          true,
+			ExpectedCanceled: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		var newResource *Resource
+		{
+			c := Config{
+				ClusterStatus: clusterstatustest.New(),
+				G8sClient:     g8sfake.NewSimpleClientset(),
+				K8sClient:     fake.NewSimpleClientset(),
+				Logger:        microloggertest.New(),
+
+				Cleanup:              tc.Cleanup,
+				TTL:                  "4320h",
+				VersionBundleVersion: "0.1.0",
+			}
+
+			var err error
+			newResource, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		customObject := &providerv1alpha1.KVMConfig{
+			Spec: providerv1alpha1.KVMConfigSpec{
+				Cluster: providerv1alpha1.Cluster{
+					ID: "al9qy",
+				},
+			},
+		}
+		if tc.Deleted {
+			customObject.DeletionTimestamp = &now
+		}
+
+		ctx := resourcecanceledcontext.NewContext(context.Background(), make(chan struct{}))
+
+		_, err := newResource.GetCurrentState(ctx, customObject)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		if resourcecanceledcontext.IsCanceled(ctx) != tc.ExpectedCanceled {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedCanceled, resourcecanceledcontext.IsCanceled(ctx))
+		}
+	}
+}
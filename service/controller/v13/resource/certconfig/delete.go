@@ -0,0 +1,102 @@
+package certconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteChange interface{}) error {
+	certConfigsToDelete, err := toCertConfigs(deleteChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(certConfigsToDelete) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleting the cert configs in the Kubernetes API")
+
+		// Delete the cert configs in the Kubernetes API. They do not live in the
+		// cluster namespace, so they are not deleted with it.
+		for _, certConfig := range certConfigsToDelete {
+			err := r.g8sClient.CoreV1alpha1().CertConfigs(certConfig.Namespace).Delete(certConfig.Name, &apismetav1.DeleteOptions{})
+			if apierrors.IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleted the cert configs in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the cert configs do not need to be deleted from the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) NewDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	delete, err := r.newDeleteChangeForDeletePatch(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetDeleteChange(delete)
+
+	return patch, nil
+}
+
+func (r *Resource) newDeleteChangeForDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentCertConfigs, err := toCertConfigs(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredCertConfigs, err := toCertConfigs(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which cert configs have to be deleted")
+
+	var certConfigsToDelete []*v1alpha1.CertConfig
+
+	for _, currentCertConfig := range currentCertConfigs {
+		if containsCertConfig(desiredCertConfigs, currentCertConfig) {
+			certConfigsToDelete = append(certConfigsToDelete, currentCertConfig)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d cert configs that have to be deleted", len(certConfigsToDelete)))
+
+	return certConfigsToDelete, nil
+}
+
+func (r *Resource) newDeleteChangeForUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentCertConfigs, err := toCertConfigs(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredCertConfigs, err := toCertConfigs(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which cert configs have to be deleted")
+
+	var certConfigsToDelete []*v1alpha1.CertConfig
+
+	for _, currentCertConfig := range currentCertConfigs {
+		if !containsCertConfig(desiredCertConfigs, currentCertConfig) {
+			certConfigsToDelete = append(certConfigsToDelete, currentCertConfig)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d cert configs that have to be deleted", len(certConfigsToDelete)))
+
+	return certConfigsToDelete, nil
+}
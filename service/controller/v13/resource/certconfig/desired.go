@@ -0,0 +1,144 @@
+package certconfig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/core/v1alpha1"
+	providerv1alpha1 "github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/microerror"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// labelClusterComponent and labelClusterID are the labels cert-operator
+	// puts on the secrets holding the issued certificates. They are put on the
+	// cert configs as well.
+	labelClusterComponent = "clusterComponent"
+	labelClusterID        = "clusterID"
+)
+
+// clusterCerts are the certificates cert-operator has to issue, so the
+// cloud-configs of guest cluster nodes can be rendered.
+var clusterCerts = []certs.Cert{
+	certs.APICert,
+	certs.CalicoCert,
+	certs.EtcdCert,
+	certs.ServiceAccountCert,
+	certs.WorkerCert,
+}
+
+// kubernetesAltNames are the names the API server is reachable with from
+// within the guest cluster.
+var kubernetesAltNames = []string{
+	"kubernetes",
+	"kubernetes.default",
+	"kubernetes.default.svc",
+	"kubernetes.default.svc.cluster.local",
+}
+
+func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "computing the new cert configs")
+
+	certConfigs := r.newCertConfigs(customObject)
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("computed the %d new cert configs", len(certConfigs)))
+
+	return certConfigs, nil
+}
+
+func (r *Resource) newCertConfigs(customObject providerv1alpha1.KVMConfig) []*v1alpha1.CertConfig {
+	cluster := customObject.Spec.Cluster
+
+	var apiIPSANs []string
+	if cluster.Kubernetes.API.IP != nil {
+		apiIPSANs = append(apiIPSANs, cluster.Kubernetes.API.IP.String())
+	}
+
+	specs := map[certs.Cert]v1alpha1.CertConfigSpecCert{
+		certs.APICert: {
+			AllowBareDomains: true,
+			AltNames:         append(append([]string{}, kubernetesAltNames...), splitAltNames(cluster.Kubernetes.API.AltNames)...),
+			CommonName:       cluster.Kubernetes.API.Domain,
+			IPSANs:           apiIPSANs,
+		},
+		certs.CalicoCert: {
+			CommonName: cluster.Calico.Domain,
+		},
+		certs.EtcdCert: {
+			AltNames:   splitAltNames(cluster.Etcd.AltNames),
+			CommonName: cluster.Etcd.Domain,
+			IPSANs:     []string{"127.0.0.1"},
+		},
+		certs.ServiceAccountCert: {
+			CommonName: fmt.Sprintf("service-account.%s", baseDomain(cluster.Kubernetes.API.Domain)),
+		},
+		certs.WorkerCert: {
+			AllowBareDomains: true,
+			AltNames:         append(append([]string{}, kubernetesAltNames...), splitAltNames(cluster.Kubernetes.Kubelet.AltNames)...),
+			CommonName:       cluster.Kubernetes.Kubelet.Domain,
+		},
+	}
+
+	var certConfigs []*v1alpha1.CertConfig
+	for _, c := range clusterCerts {
+		spec := specs[c]
+		spec.ClusterComponent = string(c)
+		spec.ClusterID = key.ClusterID(customObject)
+		spec.TTL = r.ttl
+
+		certConfig := &v1alpha1.CertConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s", key.ClusterID(customObject), c),
+				Namespace: certs.SecretNamespace,
+				Labels: map[string]string{
+					key.LabelCertConfig:   "true",
+					labelClusterComponent: string(c),
+					labelClusterID:        key.ClusterID(customObject),
+				},
+			},
+			Spec: v1alpha1.CertConfigSpec{
+				Cert: spec,
+				VersionBundle: v1alpha1.CertConfigSpecVersionBundle{
+					Version: r.versionBundleVersion,
+				},
+			},
+		}
+
+		certConfigs = append(certConfigs, certConfig)
+	}
+
+	return certConfigs
+}
+
+// baseDomain returns the given domain without its first label, e.g.
+// "al9qy.k8s.gigantic.io" for "api.al9qy.k8s.gigantic.io".
+func baseDomain(domain string) string {
+	i := strings.Index(domain, ".")
+	if i < 0 {
+		return domain
+	}
+
+	return domain[i+1:]
+}
+
+func splitAltNames(altNames string) []string {
+	var split []string
+	for _, n := range strings.Split(altNames, ",") {
+		n = strings.TrimSpace(n)
+		if n != "" {
+			split = append(split, n)
+		}
+	}
+
+	return split
+}
@@ -0,0 +1,163 @@
+package certconfig
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/core/v1alpha1"
+	providerv1alpha1 "github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+)
+
+func Test_Resource_CertConfig_GetDesiredState(t *testing.T) {
+	customObject := &providerv1alpha1.KVMConfig{
+		Spec: providerv1alpha1.KVMConfigSpec{
+			Cluster: providerv1alpha1.Cluster{
+				ID: "al9qy",
+				Calico: providerv1alpha1.ClusterCalico{
+					Domain: "calico.al9qy.k8s.gigantic.io",
+				},
+				Etcd: providerv1alpha1.ClusterEtcd{
+					Domain: "etcd.al9qy.k8s.gigantic.io",
+				},
+				Kubernetes: providerv1alpha1.ClusterKubernetes{
+					API: providerv1alpha1.ClusterKubernetesAPI{
+						AltNames: "api.customer.com",
+						Domain:   "api.al9qy.k8s.gigantic.io",
+						IP:       net.ParseIP("172.31.0.1"),
+					},
+					Kubelet: providerv1alpha1.ClusterKubernetesKubelet{
+						Domain: "worker.al9qy.k8s.gigantic.io",
+					},
+				},
+			},
+		},
+	}
+
+	var newResource *Resource
+	{
+		c := Config{
+			ClusterStatus: clusterstatustest.New(),
+			G8sClient:     g8sfake.NewSimpleClientset(),
+			K8sClient:     fake.NewSimpleClientset(),
+			Logger:        microloggertest.New(),
+
+			TTL:                  "4320h",
+			VersionBundleVersion: "0.1.0",
+		}
+
+		var err error
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+	}
+
+	result, err := newResource.GetDesiredState(context.TODO(), customObject)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	certConfigs := result.([]*v1alpha1.CertConfig)
+
+	testCases := []struct {
+		ExpectedName string
+		ExpectedCert v1alpha1.CertConfigSpecCert
+	}{
+		// Test 1 ensures the API certificate is issued for the API domain, its
+		// alt names and the API service IP.
+		{
+			ExpectedName: "al9qy-api",
+			ExpectedCert: v1alpha1.CertConfigSpecCert{
+				AllowBareDomains: true,
+				AltNames: []string{
+					"kubernetes",
+					"kubernetes.default",
+					"kubernetes.default.svc",
+					"kubernetes.default.svc.cluster.local",
+					"api.customer.com",
+				},
+				ClusterComponent: "api",
+				ClusterID:        "al9qy",
+				CommonName:       "api.al9qy.k8s.gigantic.io",
+				IPSANs:           []string{"172.31.0.1"},
+				TTL:              "4320h",
+			},
+		},
+		// Test 2 ensures the Calico certificate is issued for the Calico domain.
+		{
+			ExpectedName: "al9qy-calico",
+			ExpectedCert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: "calico",
+				ClusterID:        "al9qy",
+				CommonName:       "calico.al9qy.k8s.gigantic.io",
+				TTL:              "4320h",
+			},
+		},
+		// Test 3 ensures the etcd certificate is valid for local clients.
+		{
+			ExpectedName: "al9qy-etcd",
+			ExpectedCert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: "etcd",
+				ClusterID:        "al9qy",
+				CommonName:       "etcd.al9qy.k8s.gigantic.io",
+				IPSANs:           []string{"127.0.0.1"},
+				TTL:              "4320h",
+			},
+		},
+		// Test 4 ensures the service account certificate is issued for the base
+		// domain of the guest cluster.
+		{
+			ExpectedName: "al9qy-service-account",
+			ExpectedCert: v1alpha1.CertConfigSpecCert{
+				ClusterComponent: "service-account",
+				ClusterID:        "al9qy",
+				CommonName:       "service-account.al9qy.k8s.gigantic.io",
+				TTL:              "4320h",
+			},
+		},
+		// Test 5 ensures the worker certificate is issued for the kubelet domain.
+		{
+			ExpectedName: "al9qy-worker",
+			ExpectedCert: v1alpha1.CertConfigSpecCert{
+				AllowBareDomains: true,
+				AltNames: []string{
+					"kubernetes",
+					"kubernetes.default",
+					"kubernetes.default.svc",
+					"kubernetes.default.svc.cluster.local",
+				},
+				ClusterComponent: "worker",
+				ClusterID:        "al9qy",
+				CommonName:       "worker.al9qy.k8s.gigantic.io",
+				TTL:              "4320h",
+			},
+		},
+	}
+
+	if len(certConfigs) != len(testCases) {
+		t.Fatalf("expected %d cert configs got %d", len(testCases), len(certConfigs))
+	}
+
+	for i, tc := range testCases {
+		c := certConfigs[i]
+
+		if c.Name != tc.ExpectedName {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedName, c.Name)
+		}
+		if c.Namespace != "default" {
+			t.Fatalf("case %d expected %#v got %#v", i+1, "default", c.Namespace)
+		}
+		if !reflect.DeepEqual(c.Spec.Cert, tc.ExpectedCert) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedCert, c.Spec.Cert)
+		}
+		if c.Spec.VersionBundle.Version != "0.1.0" {
+			t.Fatalf("case %d expected %#v got %#v", i+1, "0.1.0", c.Spec.VersionBundle.Version)
+		}
+	}
+}
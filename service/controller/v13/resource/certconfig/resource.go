@@ -0,0 +1,201 @@
+package certconfig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/core/v1alpha1"
+	providerv1alpha1 "github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/apiextensions/pkg/clientset/versioned"
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/operatorkit/controller/context/reconciliationcanceledcontext"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+)
+
+const (
+	// Name is the identifier of the resource.
+	Name = "certconfigv13"
+	// CleanupName is the identifier of the resource in case it is configured
+	// using Config.Cleanup.
+	CleanupName = "certconfigcleanupv13"
+)
+
+// Config represents the configuration used to create a new cert config
+// resource.
+type Config struct {
+	ClusterStatus clusterstatus.Interface
+	G8sClient     versioned.Interface
+	K8sClient     kubernetes.Interface
+	Logger        micrologger.Logger
+
+	// Cleanup makes the resource only delete the cert configs of deleted guest
+	// clusters, while it otherwise only creates and updates them. This way the
+	// cert configs are created at the beginning of the resource set and are
+	// deleted at its end, after the resources still needing the certificates.
+	Cleanup bool
+	// TTL is the time to live of the certificates issued for guest clusters.
+	TTL string
+	// VersionBundleVersion is the version bundle version of the cert-operator
+	// issuing the certificates of guest clusters.
+	VersionBundleVersion string
+}
+
+// Resource implements the cert config resource. It creates the CertConfig
+// custom objects cert-operator issues the certificates of guest clusters for.
+// Until all certificates are issued, the reconciliation of the guest cluster
+// is canceled and its status reports that it is waiting for certificates.
+type Resource struct {
+	clusterStatus clusterstatus.Interface
+	g8sClient     versioned.Interface
+	k8sClient     kubernetes.Interface
+	logger        micrologger.Logger
+
+	cleanup              bool
+	ttl                  string
+	versionBundleVersion string
+}
+
+// New creates a new configured cert config resource.
+func New(config Config) (*Resource, error) {
+	if config.ClusterStatus == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ClusterStatus must not be empty", config)
+	}
+	if config.G8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.G8sClient must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.TTL == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TTL must not be empty", config)
+	}
+	if config.VersionBundleVersion == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VersionBundleVersion must not be empty", config)
+	}
+
+	r := &Resource{
+		clusterStatus: config.ClusterStatus,
+		g8sClient:     config.G8sClient,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+
+		cleanup:              config.Cleanup,
+		ttl:                  config.TTL,
+		versionBundleVersion: config.VersionBundleVersion,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	if r.cleanup {
+		return CleanupName
+	}
+
+	return Name
+}
+
+// ensureCertsPresent checks whether cert-operator issued all certificates of
+// the given guest cluster. As long as certificates are missing, the status of
+// the guest cluster reports it and the reconciliation is canceled, so the
+// following resources do not fail searching for certificates.
+func (r *Resource) ensureCertsPresent(ctx context.Context, customObject providerv1alpha1.KVMConfig) error {
+	clusterID := customObject.Spec.Cluster.ID
+
+	var missing []string
+	{
+		o := apismetav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", labelClusterID, clusterID),
+		}
+		list, err := r.k8sClient.CoreV1().Secrets(certs.SecretNamespace).List(o)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, c := range clusterCerts {
+			var found bool
+			for _, s := range list.Items {
+				if s.GetLabels()[labelClusterComponent] == string(c) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				missing = append(missing, string(c))
+			}
+		}
+	}
+
+	condition := clusterstatus.Condition{
+		Type: clusterstatus.ConditionCertificatesPresent,
+	}
+
+	if len(missing) == 0 {
+		condition.Status = clusterstatus.ConditionStatusTrue
+		condition.Reason = "CertificatesIssued"
+		condition.Message = "all certificates of the guest cluster are issued"
+	} else {
+		condition.Status = clusterstatus.ConditionStatusFalse
+		condition.Reason = "WaitingForCertificates"
+		condition.Message = fmt.Sprintf("waiting for certificates %v to be issued", missing)
+	}
+
+	err := r.clusterStatus.SetCondition(ctx, customObject, condition)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cannot set status condition '%s'", condition.Type), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	if len(missing) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", condition.Message)
+		reconciliationcanceledcontext.SetCanceled(ctx)
+		r.logger.LogCtx(ctx, "level", "debug", "message", "canceling reconciliation for custom object")
+	}
+
+	return nil
+}
+
+func containsCertConfig(list []*v1alpha1.CertConfig, item *v1alpha1.CertConfig) bool {
+	_, err := getCertConfigByName(list, item.Name)
+	if err != nil {
+		return false
+	}
+
+	return true
+}
+
+func getCertConfigByName(list []*v1alpha1.CertConfig, name string) (*v1alpha1.CertConfig, error) {
+	for _, l := range list {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+
+	return nil, microerror.Mask(notFoundError)
+}
+
+func isCertConfigModified(a, b *v1alpha1.CertConfig) bool {
+	return !reflect.DeepEqual(a.Spec, b.Spec)
+}
+
+func toCertConfigs(v interface{}) ([]*v1alpha1.CertConfig, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	certConfigs, ok := v.([]*v1alpha1.CertConfig)
+	if !ok {
+		return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", []*v1alpha1.CertConfig{}, v)
+	}
+
+	return certConfigs, nil
+}
@@ -0,0 +1,107 @@
+package certconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	certConfigsToUpdate, err := toCertConfigs(updateChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(certConfigsToUpdate) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the cert configs in the Kubernetes API")
+
+		// Update the cert configs in the Kubernetes API.
+		for _, certConfig := range certConfigsToUpdate {
+			_, err := r.g8sClient.CoreV1alpha1().CertConfigs(certConfig.Namespace).Update(certConfig)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the cert configs in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the cert configs do not need to be updated in the Kubernetes API")
+	}
+
+	// Updates are applied last, so the cert configs are ensured at this point
+	// and the certificates issued for them can be checked.
+	err = r.ensureCertsPresent(ctx, customObject)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	create, err := r.newCreateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	delete, err := r.newDeleteChangeForUpdatePatch(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	update, err := r.newUpdateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetCreateChange(create)
+	patch.SetDeleteChange(delete)
+	patch.SetUpdateChange(update)
+
+	return patch, nil
+}
+
+func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentCertConfigs, err := toCertConfigs(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredCertConfigs, err := toCertConfigs(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var certConfigsToUpdate []*v1alpha1.CertConfig
+	{
+		r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which cert configs have to be updated")
+
+		for _, currentCertConfig := range currentCertConfigs {
+			desiredCertConfig, err := getCertConfigByName(desiredCertConfigs, currentCertConfig.Name)
+			if IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			if isCertConfigModified(desiredCertConfig, currentCertConfig) {
+				// Custom objects can only be updated using their current resource
+				// version.
+				c := desiredCertConfig.DeepCopy()
+				c.ResourceVersion = currentCertConfig.ResourceVersion
+				certConfigsToUpdate = append(certConfigsToUpdate, c)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d cert configs that have to be updated", len(certConfigsToUpdate)))
+	}
+
+	return certConfigsToUpdate, nil
+}
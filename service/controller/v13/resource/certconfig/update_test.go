@@ -0,0 +1,134 @@
+package certconfig
+
+import (
+	"context"
+	"testing"
+
+	providerv1alpha1 "github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/operatorkit/controller/context/reconciliationcanceledcontext"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+)
+
+func Test_Resource_CertConfig_ApplyUpdateChange(t *testing.T) {
+	customObject := &providerv1alpha1.KVMConfig{
+		Spec: providerv1alpha1.KVMConfigSpec{
+			Cluster: providerv1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	newSecret := func(clusterID, component string) *apiv1.Secret {
+		return &apiv1.Secret{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      clusterID + "-" + component,
+				Namespace: "default",
+				Labels: map[string]string{
+					"clusterComponent": component,
+					"clusterID":        clusterID,
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		Secrets          []runtime.Object
+		ExpectedStatus   string
+		ExpectedReason   string
+		ExpectedCanceled bool
+	}{
+		// Test 1 ensures the reconciliation is canceled while no certificate is
+		// issued.
+		{
+			Secrets:          nil,
+			ExpectedStatus:   clusterstatus.ConditionStatusFalse,
+			ExpectedReason:   "WaitingForCertificates",
+			ExpectedCanceled: true,
+		},
+
+		// Test 2 ensures the reconciliation is canceled while certificates are
+		// missing and certificates of other clusters are ignored.
+		{
+			Secrets: []runtime.Object{
+				newSecret("al9qy", "api"),
+				newSecret("al9qy", "calico"),
+				newSecret("al9qy", "etcd"),
+				newSecret("al9qy", "service-account"),
+				newSecret("5xchu", "worker"),
+			},
+			ExpectedStatus:   clusterstatus.ConditionStatusFalse,
+			ExpectedReason:   "WaitingForCertificates",
+			ExpectedCanceled: true,
+		},
+
+		// Test 3 ensures the reconciliation goes on once all certificates are
+		// issued.
+		{
+			Secrets: []runtime.Object{
+				newSecret("al9qy", "api"),
+				newSecret("al9qy", "calico"),
+				newSecret("al9qy", "etcd"),
+				newSecret("al9qy", "service-account"),
+				newSecret("al9qy", "worker"),
+			},
+			ExpectedStatus:   clusterstatus.ConditionStatusTrue,
+			ExpectedReason:   "CertificatesIssued",
+			ExpectedCanceled: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		clusterStatus := clusterstatustest.New()
+
+		var newResource *Resource
+		{
+			c := Config{
+				ClusterStatus: clusterStatus,
+				G8sClient:     g8sfake.NewSimpleClientset(),
+				K8sClient:     fake.NewSimpleClientset(tc.Secrets...),
+				Logger:        microloggertest.New(),
+
+				TTL:                  "4320h",
+				VersionBundleVersion: "0.1.0",
+			}
+
+			var err error
+			newResource, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		ctx := reconciliationcanceledcontext.NewContext(context.Background(), make(chan struct{}))
+
+		err := newResource.ApplyUpdateChange(ctx, customObject, nil)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		if len(clusterStatus.Conditions) != 1 {
+			t.Fatalf("case %d expected %#v got %#v", i+1, 1, len(clusterStatus.Conditions))
+		}
+		condition := clusterStatus.Conditions[0]
+		if condition.Type != clusterstatus.ConditionCertificatesPresent {
+			t.Fatalf("case %d expected %#v got %#v", i+1, clusterstatus.ConditionCertificatesPresent, condition.Type)
+		}
+		if condition.Status != tc.ExpectedStatus {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedStatus, condition.Status)
+		}
+		if condition.Reason != tc.ExpectedReason {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedReason, condition.Reason)
+		}
+		if reconciliationcanceledcontext.IsCanceled(ctx) != tc.ExpectedCanceled {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedCanceled, reconciliationcanceledcontext.IsCanceled(ctx))
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cloudconfigsecret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller/context/resourcecanceledcontext"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if key.IsDeleted(customObject) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "redirecting responsibility of deletion of cloud-config secrets to namespace termination")
+		resourcecanceledcontext.SetCanceled(ctx)
+		r.logger.LogCtx(ctx, "level", "debug", "message", "canceling resource for custom object")
+
+		return nil, nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "looking for a list of cloud-config secrets in the Kubernetes API")
+
+	var currentSecrets []*apiv1.Secret
+	{
+		o := apismetav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", key.LabelCloudConfig, "true"),
+		}
+		secretList, err := r.k8sClient.CoreV1().Secrets(key.ClusterNamespace(customObject)).List(o)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, item := range secretList.Items {
+			s := item
+			currentSecrets = append(currentSecrets, &s)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found a list of %d cloud-config secrets in the Kubernetes API", len(currentSecrets)))
+
+	return currentSecrets, nil
+}
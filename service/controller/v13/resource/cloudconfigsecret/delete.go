@@ -0,0 +1,148 @@
+package cloudconfigsecret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteChange interface{}) error {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	secretsToDelete, err := toSecrets(deleteChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(secretsToDelete) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleting the cloud-config secrets in the Kubernetes API")
+
+		// Delete the cloud-config secrets in the Kubernetes API.
+		namespace := key.ClusterNamespace(customObject)
+		for _, secret := range secretsToDelete {
+			err := r.k8sClient.CoreV1().Secrets(namespace).Delete(secret.Name, &apismetav1.DeleteOptions{})
+			if apierrors.IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleted the cloud-config secrets in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the cloud-config secrets do not need to be deleted from the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) NewDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	delete, err := r.newDeleteChangeForDeletePatch(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetDeleteChange(delete)
+
+	return patch, nil
+}
+
+func (r *Resource) newDeleteChangeForDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentSecrets, err := toSecrets(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredSecrets, err := toSecrets(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which cloud-config secrets have to be deleted")
+
+	var secretsToDelete []*apiv1.Secret
+
+	for _, currentSecret := range currentSecrets {
+		if containsSecret(desiredSecrets, currentSecret) {
+			secretsToDelete = append(secretsToDelete, currentSecret)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d cloud-config secrets that have to be deleted", len(secretsToDelete)))
+
+	return secretsToDelete, nil
+}
+
+// newDeleteChangeForUpdatePatch returns the current cloud-config secrets not
+// being desired anymore. Secrets still mounted by a deployment are kept, e.g.
+// the ones of workers being drained before their deployments are deleted on
+// scale down. They are deleted once their deployments are gone.
+func (r *Resource) newDeleteChangeForUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	currentSecrets, err := toSecrets(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredSecrets, err := toSecrets(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which cloud-config secrets have to be deleted")
+
+	mounted, err := r.mountedSecrets(customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var secretsToDelete []*apiv1.Secret
+
+	for _, currentSecret := range currentSecrets {
+		if containsSecret(desiredSecrets, currentSecret) {
+			continue
+		}
+		if mounted[currentSecret.Name] {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("keeping cloud-config secret '%s' mounted by a deployment", currentSecret.Name))
+			continue
+		}
+
+		secretsToDelete = append(secretsToDelete, currentSecret)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d cloud-config secrets that have to be deleted", len(secretsToDelete)))
+
+	return secretsToDelete, nil
+}
+
+// mountedSecrets returns the names of the secrets mounted by the deployments of
+// the guest cluster.
+func (r *Resource) mountedSecrets(customObject v1alpha1.KVMConfig) (map[string]bool, error) {
+	deploymentList, err := r.k8sClient.Extensions().Deployments(key.ClusterNamespace(customObject)).List(apismetav1.ListOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	mounted := map[string]bool{}
+	for _, d := range deploymentList.Items {
+		for _, v := range d.Spec.Template.Spec.Volumes {
+			if v.Secret != nil {
+				mounted[v.Secret.SecretName] = true
+			}
+		}
+	}
+
+	return mounted, nil
+}
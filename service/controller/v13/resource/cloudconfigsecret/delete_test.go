@@ -0,0 +1,138 @@
+package cloudconfigsecret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/certs/certstest"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/randomkeys/randomkeystest"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/cloudconfig/cloudconfigtest"
+)
+
+func Test_Resource_CloudConfigSecret_newDeleteChangeForUpdatePatch(t *testing.T) {
+	obj := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	newDeployment := func(name, secret string) *v1beta1.Deployment {
+		return &v1beta1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      name,
+				Namespace: "al9qy",
+			},
+			Spec: v1beta1.DeploymentSpec{
+				Template: apiv1.PodTemplateSpec{
+					Spec: apiv1.PodSpec{
+						Volumes: []apiv1.Volume{
+							{
+								Name: "cloud-config",
+								VolumeSource: apiv1.VolumeSource{
+									Secret: &apiv1.SecretVolumeSource{
+										SecretName: secret,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		CurrentState        []*apiv1.Secret
+		DesiredState        []*apiv1.Secret
+		Deployments         []*v1beta1.Deployment
+		ExpectedSecretNames []string
+	}{
+		// Test 0 ensures secrets not being desired anymore are deleted.
+		{
+			CurrentState: []*apiv1.Secret{
+				testNewSecret("worker-5xchu", "foo"),
+				testNewSecret("worker-p7jqk", "foo"),
+			},
+			DesiredState: []*apiv1.Secret{
+				testNewSecret("worker-5xchu", "foo"),
+			},
+			Deployments: []*v1beta1.Deployment{
+				newDeployment("worker-5xchu", "worker-5xchu"),
+			},
+			ExpectedSecretNames: []string{
+				"worker-p7jqk",
+			},
+		},
+
+		// Test 1 ensures secrets not being desired anymore are kept as long as a
+		// deployment mounts them, e.g. while the worker is drained on scale down.
+		{
+			CurrentState: []*apiv1.Secret{
+				testNewSecret("worker-5xchu", "foo"),
+				testNewSecret("worker-p7jqk", "foo"),
+			},
+			DesiredState: []*apiv1.Secret{
+				testNewSecret("worker-5xchu", "foo"),
+			},
+			Deployments: []*v1beta1.Deployment{
+				newDeployment("worker-5xchu", "worker-5xchu"),
+				newDeployment("worker-p7jqk", "worker-p7jqk"),
+			},
+			ExpectedSecretNames: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		fakeK8sClient := fake.NewSimpleClientset()
+		for _, d := range tc.Deployments {
+			_, err := fakeK8sClient.Extensions().Deployments(d.Namespace).Create(d)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		var err error
+		var newResource *Resource
+		{
+			c := Config{
+				CertSearcher: certstest.NewSearcher(),
+				CloudConfig:  cloudconfigtest.New(),
+				K8sClient:    fakeK8sClient,
+				KeyWatcher:   randomkeystest.NewSearcher(),
+				Logger:       microloggertest.New(),
+			}
+			newResource, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		result, err := newResource.newDeleteChangeForUpdatePatch(context.TODO(), obj, tc.CurrentState, tc.DesiredState)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		secrets, err := toSecrets(result)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		var names []string
+		for _, s := range secrets {
+			names = append(names, s.Name)
+		}
+		if !reflect.DeepEqual(names, tc.ExpectedSecretNames) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedSecretNames, names)
+		}
+	}
+}
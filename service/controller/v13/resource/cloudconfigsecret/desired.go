@@ -0,0 +1,123 @@
+package cloudconfigsecret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/workerscontext"
+)
+
+func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "computing the new cloud-config secrets")
+
+	workers, ok := workerscontext.FromContext(ctx)
+	if !ok {
+		workers, err = key.WorkerNodes(customObject)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	secrets, err := r.newSecrets(customObject, workers)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("computed the %d new cloud-config secrets", len(secrets)))
+
+	return secrets, nil
+}
+
+func (r *Resource) newSecrets(customObject v1alpha1.KVMConfig, workers []key.WorkerNode) ([]*apiv1.Secret, error) {
+	var secrets []*apiv1.Secret
+
+	certs, err := r.certSearcher.SearchCluster(key.ClusterID(customObject))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	keys, err := r.keyWatcher.SearchCluster(key.ClusterID(customObject))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	encryptionKeys, err := r.cloudConfig.EncryptionKeys(key.ClusterID(customObject), keys)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, node := range customObject.Spec.Cluster.Masters {
+		template, err := r.cloudConfig.NewMasterTemplate(customObject, certs, node, encryptionKeys)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		secret, err := r.newSecret(customObject, template, node, key.MasterID)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		secret.Annotations[key.AnnotationCertsHash] = key.MasterCertsHash(certs)
+		secret.Annotations[key.AnnotationEncryptionKeysHash] = key.EncryptionKeysHash(encryptionKeys)
+
+		secrets = append(secrets, secret)
+	}
+
+	for _, worker := range workers {
+		template, err := r.cloudConfig.NewWorkerTemplate(customObject, certs, worker)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		secret, err := r.newSecret(customObject, template, worker.Node, key.WorkerID)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		secret.Annotations[key.AnnotationCertsHash] = key.WorkerCertsHash(certs)
+
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
+// newSecret creates a new Kubernetes secret holding the given cloud-config of
+// the given node. prefix can be either "master" or "worker" and is used to
+// prefix the secret name.
+func (r *Resource) newSecret(customObject v1alpha1.KVMConfig, template string, node v1alpha1.ClusterNode, prefix string) (*apiv1.Secret, error) {
+	name := key.CloudConfigSecretName(customObject, node, prefix)
+
+	if len(template) > key.CloudConfigMaxSize {
+		return nil, microerror.Maskf(userDataTooLargeError, "user data of secret '%s' has %d bytes, exceeding the maximum of %d bytes", name, len(template), key.CloudConfigMaxSize)
+	}
+
+	secret := &apiv1.Secret{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				key.AnnotationCloudConfigEncoding: r.cloudConfig.Encoding(),
+			},
+			Labels: map[string]string{
+				"cluster":            key.ClusterID(customObject),
+				"customer":           key.ClusterCustomer(customObject),
+				key.LabelCloudConfig: "true",
+			},
+		},
+		Type: apiv1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			key.CloudConfigUserDataKey: []byte(template),
+		},
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,110 @@
+package cloudconfigsecret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/certs/certstest"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/randomkeys/randomkeystest"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/cloudconfig/cloudconfigtest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_Resource_CloudConfigSecret_GetDesiredState(t *testing.T) {
+	testCases := []struct {
+		Obj           interface{}
+		ExpectedNames []string
+	}{
+		// Test 1 ensures there is one secret for each master and worker.
+		{
+			Obj: &v1alpha1.KVMConfig{
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+						Masters: []v1alpha1.ClusterNode{
+							{ID: "m1"},
+						},
+						Workers: []v1alpha1.ClusterNode{
+							{ID: "w1"},
+							{ID: "w2"},
+						},
+					},
+				},
+			},
+			ExpectedNames: []string{
+				"master-al9qy-m1",
+				"worker-al9qy-w1",
+				"worker-al9qy-w2",
+			},
+		},
+		// Test 2 ensures there is one secret for each of multiple masters.
+		{
+			Obj: &v1alpha1.KVMConfig{
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+						Masters: []v1alpha1.ClusterNode{
+							{ID: "m1"},
+							{ID: "m2"},
+							{ID: "m3"},
+						},
+					},
+				},
+			},
+			ExpectedNames: []string{
+				"master-al9qy-m1",
+				"master-al9qy-m2",
+				"master-al9qy-m3",
+			},
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		c := Config{
+			CertSearcher: certstest.NewSearcher(),
+			CloudConfig:  cloudconfigtest.New(),
+			K8sClient:    fake.NewSimpleClientset(),
+			KeyWatcher:   randomkeystest.NewSearcher(),
+			Logger:       microloggertest.New(),
+		}
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	for i, tc := range testCases {
+		result, err := newResource.GetDesiredState(context.TODO(), tc.Obj)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		secrets, ok := result.([]*apiv1.Secret)
+		if !ok {
+			t.Fatalf("case %d expected %T got %T", i+1, []*apiv1.Secret{}, result)
+		}
+
+		if len(secrets) != len(tc.ExpectedNames) {
+			t.Fatalf("case %d expected %d secrets got %d", i+1, len(tc.ExpectedNames), len(secrets))
+		}
+
+		for j, s := range secrets {
+			if s.Name != tc.ExpectedNames[j] {
+				t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedNames[j], s.Name)
+			}
+			if s.Labels[key.LabelCloudConfig] != "true" {
+				t.Fatalf("case %d expected secret %#v to be labeled", i+1, s.Name)
+			}
+			if len(s.Data[key.CloudConfigUserDataKey]) == 0 {
+				t.Fatalf("case %d expected secret %#v to have user data", i+1, s.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package cloudconfigsecret
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = microerror.New("invalid config")
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var notFoundError = microerror.New("not found")
+
+// IsNotFound asserts notFoundError.
+func IsNotFound(err error) bool {
+	return microerror.Cause(err) == notFoundError
+}
+
+var wrongTypeError = microerror.New("wrong type")
+
+// IsWrongTypeError asserts wrongTypeError.
+func IsWrongTypeError(err error) bool {
+	return microerror.Cause(err) == wrongTypeError
+}
+
+var userDataTooLargeError = microerror.New("user data too large")
+
+// IsUserDataTooLarge asserts userDataTooLargeError.
+func IsUserDataTooLarge(err error) bool {
+	return microerror.Cause(err) == userDataTooLargeError
+}
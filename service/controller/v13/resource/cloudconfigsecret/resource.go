@@ -0,0 +1,117 @@
+package cloudconfigsecret
+
+import (
+	"bytes"
+
+	"github.com/giantswarm/certs"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/randomkeys"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/cloudconfig"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// Name is the identifier of the resource.
+	Name = "cloudconfigsecretv13"
+)
+
+// Config represents the configuration used to create a new cloud-config
+// secret resource.
+type Config struct {
+	CertSearcher certs.Interface
+	CloudConfig  *cloudconfig.CloudConfig
+	K8sClient    kubernetes.Interface
+	KeyWatcher   randomkeys.Interface
+	Logger       micrologger.Logger
+}
+
+// Resource implements the cloud-config secret resource. It stores the
+// cloud-configs of guest cluster nodes in secrets, since they contain the
+// private keys of the guest cluster.
+type Resource struct {
+	certSearcher certs.Interface
+	cloudConfig  *cloudconfig.CloudConfig
+	k8sClient    kubernetes.Interface
+	keyWatcher   randomkeys.Interface
+	logger       micrologger.Logger
+}
+
+// New creates a new configured cloud-config secret resource.
+func New(config Config) (*Resource, error) {
+	if config.CertSearcher == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CertSearcher must not be empty", config)
+	}
+	if config.CloudConfig == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CloudConfig must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.KeyWatcher == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.KeyWatcher must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	r := &Resource{
+		certSearcher: config.CertSearcher,
+		cloudConfig:  config.CloudConfig,
+		k8sClient:    config.K8sClient,
+		keyWatcher:   config.KeyWatcher,
+		logger:       config.Logger,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
+
+func containsSecret(list []*apiv1.Secret, item *apiv1.Secret) bool {
+	_, err := getSecretByName(list, item.Name)
+	if err != nil {
+		return false
+	}
+
+	return true
+}
+
+func getSecretByName(list []*apiv1.Secret, name string) (*apiv1.Secret, error) {
+	for _, l := range list {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+
+	return nil, microerror.Mask(notFoundError)
+}
+
+func isSecretModified(a, b *apiv1.Secret) bool {
+	if a.GetAnnotations()[key.AnnotationCertsHash] != b.GetAnnotations()[key.AnnotationCertsHash] {
+		return true
+	}
+	if a.GetAnnotations()[key.AnnotationEncryptionKeysHash] != b.GetAnnotations()[key.AnnotationEncryptionKeysHash] {
+		return true
+	}
+
+	return !bytes.Equal(a.Data[key.CloudConfigUserDataKey], b.Data[key.CloudConfigUserDataKey])
+}
+
+func toSecrets(v interface{}) ([]*apiv1.Secret, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	secrets, ok := v.([]*apiv1.Secret)
+	if !ok {
+		return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", []*apiv1.Secret{}, v)
+	}
+
+	return secrets, nil
+}
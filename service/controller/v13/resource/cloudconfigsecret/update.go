@@ -1,4 +1,4 @@
-package clusterrolebinding
+package cloudconfigsecret
 
 import (
 	"context"
@@ -6,29 +6,36 @@ import (
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/operatorkit/controller"
-	apiv1 "k8s.io/api/rbac/v1beta1"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
 func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
-	clusterRoleBindingsToUpdate, err := toClusterRoleBindings(updateChange)
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	secretsToUpdate, err := toSecrets(updateChange)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	if len(clusterRoleBindingsToUpdate) != 0 {
-		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the cluster role bindings in the Kubernetes API")
+	if len(secretsToUpdate) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the cloud-config secrets in the Kubernetes API")
 
-		// Create the cluster role bindings in the Kubernetes API.
-		for _, clusterRoleBinding := range clusterRoleBindingsToUpdate {
-			_, err := r.k8sClient.RbacV1beta1().ClusterRoleBindings().Update(clusterRoleBinding)
+		// Update the cloud-config secrets in the Kubernetes API.
+		namespace := key.ClusterNamespace(customObject)
+		for _, secret := range secretsToUpdate {
+			_, err := r.k8sClient.CoreV1().Secrets(namespace).Update(secret)
 			if err != nil {
 				return microerror.Mask(err)
 			}
 		}
 
-		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the cluster role bindings in the Kubernetes API")
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the cloud-config secrets in the Kubernetes API")
 	} else {
-		r.logger.LogCtx(ctx, "level", "debug", "message", "the cluster role bindings do not need to be updated in the Kubernetes API")
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the cloud-config secrets do not need to be updated in the Kubernetes API")
 	}
 
 	return nil
@@ -39,6 +46,10 @@ func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desire
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
+	delete, err := r.newDeleteChangeForUpdatePatch(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 	update, err := r.newUpdateChange(ctx, obj, currentState, desiredState)
 	if err != nil {
 		return nil, microerror.Mask(err)
@@ -46,40 +57,41 @@ func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desire
 
 	patch := controller.NewPatch()
 	patch.SetCreateChange(create)
+	patch.SetDeleteChange(delete)
 	patch.SetUpdateChange(update)
 
 	return patch, nil
 }
 
 func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
-	currentClusterRoleBindings, err := toClusterRoleBindings(currentState)
+	currentSecrets, err := toSecrets(currentState)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
-	desiredClusterRoleBindings, err := toClusterRoleBindings(desiredState)
+	desiredSecrets, err := toSecrets(desiredState)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
-	var clusterRoleBindingsToUpdate []*apiv1.ClusterRoleBinding
+	var secretsToUpdate []*apiv1.Secret
 	{
-		r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which cluster role bindings have to be updated")
+		r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which cloud-config secrets have to be updated")
 
-		for _, clusterRoleBinding := range currentClusterRoleBindings {
-			desiredClusterRoleBinding, err := getClusterRoleBindingByName(desiredClusterRoleBindings, clusterRoleBinding.Name)
+		for _, currentSecret := range currentSecrets {
+			desiredSecret, err := getSecretByName(desiredSecrets, currentSecret.Name)
 			if IsNotFound(err) {
 				continue
 			} else if err != nil {
 				return nil, microerror.Mask(err)
 			}
 
-			if isClusterRoleBindingModified(desiredClusterRoleBinding, clusterRoleBinding) {
-				clusterRoleBindingsToUpdate = append(clusterRoleBindingsToUpdate, desiredClusterRoleBinding)
+			if isSecretModified(desiredSecret, currentSecret) {
+				secretsToUpdate = append(secretsToUpdate, desiredSecret)
 			}
 		}
 
-		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d cluster role bindings that have to be updated", len(clusterRoleBindingsToUpdate)))
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d cloud-config secrets that have to be updated", len(secretsToUpdate)))
 	}
 
-	return clusterRoleBindingsToUpdate, nil
+	return secretsToUpdate, nil
 }
@@ -0,0 +1,118 @@
+package cloudconfigsecret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/certs/certstest"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/randomkeys/randomkeystest"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/cloudconfig/cloudconfigtest"
+)
+
+func Test_Resource_CloudConfigSecret_newUpdateChange(t *testing.T) {
+	testCases := []struct {
+		CurrentState            interface{}
+		DesiredState            interface{}
+		ExpectedSecretsToUpdate []*apiv1.Secret
+	}{
+		// Test 1 ensures empty current and desired state result in an empty
+		// update state.
+		{
+			CurrentState:            []*apiv1.Secret{},
+			DesiredState:            []*apiv1.Secret{},
+			ExpectedSecretsToUpdate: nil,
+		},
+		// Test 2 ensures equal secrets are not updated.
+		{
+			CurrentState: []*apiv1.Secret{
+				testNewSecret("secret-1", "foo"),
+			},
+			DesiredState: []*apiv1.Secret{
+				testNewSecret("secret-1", "foo"),
+			},
+			ExpectedSecretsToUpdate: nil,
+		},
+		// Test 3 ensures secrets having modified user data are updated.
+		{
+			CurrentState: []*apiv1.Secret{
+				testNewSecret("secret-1", "foo"),
+				testNewSecret("secret-2", "foo"),
+			},
+			DesiredState: []*apiv1.Secret{
+				testNewSecret("secret-1", "foo"),
+				testNewSecret("secret-2", "bar"),
+			},
+			ExpectedSecretsToUpdate: []*apiv1.Secret{
+				testNewSecret("secret-2", "bar"),
+			},
+		},
+		// Test 4 ensures secrets not being desired are not updated.
+		{
+			CurrentState: []*apiv1.Secret{
+				testNewSecret("secret-1", "foo"),
+			},
+			DesiredState: []*apiv1.Secret{
+				testNewSecret("secret-2", "bar"),
+			},
+			ExpectedSecretsToUpdate: nil,
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		c := Config{
+			CertSearcher: certstest.NewSearcher(),
+			CloudConfig:  cloudconfigtest.New(),
+			K8sClient:    fake.NewSimpleClientset(),
+			KeyWatcher:   randomkeystest.NewSearcher(),
+			Logger:       microloggertest.New(),
+		}
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	obj := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		result, err := newResource.newUpdateChange(context.TODO(), obj, tc.CurrentState, tc.DesiredState)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		secrets, ok := result.([]*apiv1.Secret)
+		if !ok {
+			t.Fatalf("case %d expected %T got %T", i+1, []*apiv1.Secret{}, result)
+		}
+
+		if !reflect.DeepEqual(secrets, tc.ExpectedSecretsToUpdate) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedSecretsToUpdate, secrets)
+		}
+	}
+}
+
+func testNewSecret(name, userData string) *apiv1.Secret {
+	return &apiv1.Secret{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name: name,
+		},
+		Data: map[string][]byte{
+			"user_data": []byte(userData),
+		},
+	}
+}
@@ -4,58 +4,46 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/microerror"
-	apiv1 "k8s.io/api/rbac/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
-func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange interface{}) error {
-	clusterRoleBindingsToCreate, err := toClusterRoleBindings(createChange)
+// EnsureCreated deletes the cluster role bindings of the given guest cluster
+// in case they still exist.
+func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	customObject, err := key.ToCustomObject(obj)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	// Create the cluster role bindings in the Kubernetes API.
-	if len(clusterRoleBindingsToCreate) != 0 {
-		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the cluster role bindings in the Kubernetes API")
-
-		for _, clusterRoleBinding := range clusterRoleBindingsToCreate {
-			_, err := r.k8sClient.RbacV1beta1().ClusterRoleBindings().Create(clusterRoleBinding)
-			if apierrors.IsAlreadyExists(err) {
-			} else if err != nil {
-				return microerror.Mask(err)
-			}
-		}
-
-		r.logger.LogCtx(ctx, "level", "debug", "message", "created the cluster role bindings in the Kubernetes API")
-	} else {
-		r.logger.LogCtx(ctx, "level", "debug", "message", "the cluster role bindings do not need to be created in the Kubernetes API")
+	err = r.deleteClusterRoleBindings(ctx, customObject)
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
 	return nil
 }
 
-func (r *Resource) newCreateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
-	currentClusterRoleBindings, err := toClusterRoleBindings(currentState)
-	if err != nil {
-		return nil, microerror.Mask(err)
+func (r *Resource) deleteClusterRoleBindings(ctx context.Context, customObject v1alpha1.KVMConfig) error {
+	names := []string{
+		key.ClusterRoleBindingName(customObject),
+		key.ClusterRoleBindingPSPName(customObject),
 	}
-	desiredClusterRoleBindings, err := toClusterRoleBindings(desiredState)
-	if err != nil {
-		return nil, microerror.Mask(err)
-	}
-
-	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which cluster role bindings have to be created")
-
-	var clusterRoleBindingsToCreate []*apiv1.ClusterRoleBinding
 
-	for _, desiredClusterRoleBinding := range desiredClusterRoleBindings {
-		if !containsClusterRoleBinding(currentClusterRoleBindings, desiredClusterRoleBinding) {
-			clusterRoleBindingsToCreate = append(clusterRoleBindingsToCreate, desiredClusterRoleBinding)
+	for _, name := range names {
+		err := r.k8sClient.RbacV1beta1().ClusterRoleBindings().Delete(name, &apismetav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
 		}
-	}
 
-	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d cluster role bindings that have to be created", len(clusterRoleBindingsToCreate)))
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted cluster role binding '%s' in the Kubernetes API", name))
+	}
 
-	return clusterRoleBindingsToCreate, nil
+	return nil
 }
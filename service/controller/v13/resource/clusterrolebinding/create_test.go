@@ -8,237 +8,102 @@ import (
 	"github.com/giantswarm/micrologger/microloggertest"
 	apiv1 "k8s.io/api/rbac/v1beta1"
 	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
-func Test_Resource_ClusterRoleBinding_newCreateChange(t *testing.T) {
+func Test_Resource_ClusterRoleBinding_EnsureCreated(t *testing.T) {
 	testCases := []struct {
-		Obj                              interface{}
-		CurrentState                     interface{}
-		DesiredState                     interface{}
-		ExpectedClusterRoleBindingsNames []string
+		Objects       []runtime.Object
+		ExpectedNames []string
 	}{
-		// Test 1, in case current state and desired state are empty the create
-		// state should be empty.
+		// Test 0 ensures nothing fails in case the guest cluster has been
+		// migrated already.
 		{
-			Obj: &v1alpha1.KVMConfig{
-				Spec: v1alpha1.KVMConfigSpec{
-					Cluster: v1alpha1.Cluster{
-						ID: "al9qy",
-					},
-				},
-			},
-			CurrentState:                     []*apiv1.ClusterRoleBinding{},
-			DesiredState:                     []*apiv1.ClusterRoleBinding{},
-			ExpectedClusterRoleBindingsNames: []string{},
+			Objects:       nil,
+			ExpectedNames: nil,
 		},
-
-		// Test 2, in case current state equals desired state the create state
-		// should be empty.
+		// Test 1 ensures the cluster role bindings of the guest cluster are
+		// deleted while the ones of other guest clusters are kept.
 		{
-			Obj: &v1alpha1.KVMConfig{
-				Spec: v1alpha1.KVMConfigSpec{
-					Cluster: v1alpha1.Cluster{
-						ID: "al9qy",
-					},
-				},
-			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
-					},
-				},
-			},
-			DesiredState: []*apiv1.ClusterRoleBinding{
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
-					},
-				},
+			Objects: []runtime.Object{
+				newClusterRoleBinding("al9qy"),
+				newClusterRoleBinding("al9qy-psp"),
+				newClusterRoleBinding("kvm-operator"),
+				newClusterRoleBinding("p1l6x"),
+				newClusterRoleBinding("p1l6x-psp"),
+			},
+			ExpectedNames: []string{
+				"kvm-operator",
+				"p1l6x",
+				"p1l6x-psp",
 			},
-			ExpectedClusterRoleBindingsNames: []string{},
 		},
-
-		// Test 3, in case current state misses one item of desired state the create
-		// state should contain the missing item of the desired state.
+		// Test 2 ensures the cluster role binding left over is deleted in case
+		// only one of them still exists.
 		{
-			Obj: &v1alpha1.KVMConfig{
-				Spec: v1alpha1.KVMConfigSpec{
-					Cluster: v1alpha1.Cluster{
-						ID: "al9qy",
-					},
-				},
-			},
-			CurrentState: []*apiv1.ClusterRoleBinding{},
-			DesiredState: []*apiv1.ClusterRoleBinding{
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
-					},
-				},
-			},
-			ExpectedClusterRoleBindingsNames: []string{
-				"cluster-role-binding-1",
+			Objects: []runtime.Object{
+				newClusterRoleBinding("al9qy-psp"),
 			},
+			ExpectedNames: nil,
 		},
+	}
 
-		// Test 4, in case current state misses items of desired state the create
-		// state should contain the missing items of the desired state.
-		{
-			Obj: &v1alpha1.KVMConfig{
-				Spec: v1alpha1.KVMConfigSpec{
-					Cluster: v1alpha1.Cluster{
-						ID: "al9qy",
-					},
-				},
-			},
-			CurrentState: []*apiv1.ClusterRoleBinding{},
-			DesiredState: []*apiv1.ClusterRoleBinding{
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
-					},
-				},
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
-					},
-				},
-			},
-			ExpectedClusterRoleBindingsNames: []string{
-				"cluster-role-binding-1",
-				"cluster-role-binding-2",
-			},
-		},
+	for i, tc := range testCases {
+		k8sClient := fake.NewSimpleClientset(tc.Objects...)
 
-		// Test 5, in case current state contains one item not being in desired
-		// state the create state should not contain the missing item of the desired
-		// state.
+		var newResource *Resource
 		{
-			Obj: &v1alpha1.KVMConfig{
-				Spec: v1alpha1.KVMConfigSpec{
-					Cluster: v1alpha1.Cluster{
-						ID: "al9qy",
-					},
-				},
-			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
-					},
-				},
-			},
-			DesiredState:                     []*apiv1.ClusterRoleBinding{},
-			ExpectedClusterRoleBindingsNames: []string{},
-		},
+			c := Config{
+				K8sClient: k8sClient,
+				Logger:    microloggertest.New(),
+			}
 
-		// Test 6, in case current state contains items not being in desired state
-		// the create state should not contain the missing items of the desired
-		// state.
-		{
-			Obj: &v1alpha1.KVMConfig{
-				Spec: v1alpha1.KVMConfigSpec{
-					Cluster: v1alpha1.Cluster{
-						ID: "al9qy",
-					},
-				},
-			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
-					},
-				},
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
-					},
-				},
-			},
-			DesiredState:                     []*apiv1.ClusterRoleBinding{},
-			ExpectedClusterRoleBindingsNames: []string{},
-		},
+			var err error
+			newResource, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
 
-		// Test 7, in case current state contains some items of desired state the
-		// create state should contain the items being in desired state which are
-		// not in create state.
-		{
-			Obj: &v1alpha1.KVMConfig{
-				Spec: v1alpha1.KVMConfigSpec{
-					Cluster: v1alpha1.Cluster{
-						ID: "al9qy",
-					},
-				},
-			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
-					},
-				},
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
-					},
-				},
-			},
-			DesiredState: []*apiv1.ClusterRoleBinding{
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
-					},
-				},
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
-					},
-				},
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-3",
-					},
-				},
-				{
-					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-4",
-					},
+		customObject := &v1alpha1.KVMConfig{
+			Spec: v1alpha1.KVMConfigSpec{
+				Cluster: v1alpha1.Cluster{
+					ID: "al9qy",
 				},
 			},
-			ExpectedClusterRoleBindingsNames: []string{
-				"cluster-role-binding-3",
-				"cluster-role-binding-4",
-			},
-		},
-	}
-
-	var err error
-	var newResource *Resource
-	{
-		resourceConfig := Config{
-			K8sClient: fake.NewSimpleClientset(),
-			Logger:    microloggertest.New(),
 		}
-		newResource, err = New(resourceConfig)
+
+		err := newResource.EnsureCreated(context.TODO(), customObject)
 		if err != nil {
-			t.Fatal("expected", nil, "got", err)
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
 		}
-	}
 
-	for i, tc := range testCases {
-		result, err := newResource.newCreateChange(context.TODO(), tc.Obj, tc.CurrentState, tc.DesiredState)
+		list, err := k8sClient.RbacV1beta1().ClusterRoleBindings().List(apismetav1.ListOptions{})
 		if err != nil {
-			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
 		}
 
-		clusterRoleBindings, ok := result.([]*apiv1.ClusterRoleBinding)
-		if !ok {
-			t.Fatalf("case %d expected %T got %T", i+1, []*apiv1.ClusterRoleBinding{}, result)
+		var names []string
+		for _, b := range list.Items {
+			names = append(names, b.Name)
 		}
 
-		if len(clusterRoleBindings) != len(tc.ExpectedClusterRoleBindingsNames) {
-			t.Fatalf("case %d expected %d cluster role bindings got %d", i+1, len(tc.ExpectedClusterRoleBindingsNames), len(clusterRoleBindings))
+		if len(names) != len(tc.ExpectedNames) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedNames, names)
 		}
+		for j := range names {
+			if names[j] != tc.ExpectedNames[j] {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedNames, names)
+			}
+		}
+	}
+}
+
+func newClusterRoleBinding(name string) *apiv1.ClusterRoleBinding {
+	return &apiv1.ClusterRoleBinding{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name: name,
+		},
 	}
 }
@@ -1,11 +1,8 @@
 package clusterrolebinding
 
 import (
-	"reflect"
-
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
-	apiv1 "k8s.io/api/rbac/v1beta1"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -14,19 +11,25 @@ const (
 	Name = "clusterrolebindingv13"
 )
 
-// Config represents the configuration used to create a new config map resource.
+// Config represents the configuration used to create a new cluster role
+// binding resource.
 type Config struct {
 	K8sClient kubernetes.Interface
 	Logger    micrologger.Logger
 }
 
-// Resource implements the config map resource.
+// Resource deletes the cluster role bindings guest cluster service accounts
+// used to be bound to the kvm-operator and kvm-operator-psp cluster roles
+// with. They granted access to the namespaces of all guest clusters. Service
+// accounts are bound in the namespace of their guest cluster by the role and
+// rolebinding resources instead, which have to run before this resource, so
+// VM pods do not lose their permissions while guest clusters are migrated.
 type Resource struct {
 	k8sClient kubernetes.Interface
 	logger    micrologger.Logger
 }
 
-// New creates a new configured config map resource.
+// New creates a new configured cluster role binding resource.
 func New(config Config) (*Resource, error) {
 	if config.K8sClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
@@ -46,41 +49,3 @@ func New(config Config) (*Resource, error) {
 func (r *Resource) Name() string {
 	return Name
 }
-
-func containsClusterRoleBinding(list []*apiv1.ClusterRoleBinding, item *apiv1.ClusterRoleBinding) bool {
-	_, err := getClusterRoleBindingByName(list, item.Name)
-	if IsNotFound(err) {
-		return false
-	} else if err != nil {
-		return false
-	}
-
-	return true
-}
-
-func getClusterRoleBindingByName(list []*apiv1.ClusterRoleBinding, name string) (*apiv1.ClusterRoleBinding, error) {
-	for _, l := range list {
-		if l.Name == name {
-			return l, nil
-		}
-	}
-
-	return nil, microerror.Maskf(notFoundError, "cluster role binding '%s' not found", name)
-}
-
-func isClusterRoleBindingModified(a, b *apiv1.ClusterRoleBinding) bool {
-	return !reflect.DeepEqual(a.Subjects, b.Subjects) || !reflect.DeepEqual(a.RoleRef, b.RoleRef)
-}
-
-func toClusterRoleBindings(v interface{}) ([]*apiv1.ClusterRoleBinding, error) {
-	if v == nil {
-		return nil, nil
-	}
-
-	clusterRoleBindings, ok := v.([]*apiv1.ClusterRoleBinding)
-	if !ok {
-		return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", []*apiv1.ClusterRoleBinding{}, v)
-	}
-
-	return clusterRoleBindings, nil
-}
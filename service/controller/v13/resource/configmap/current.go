@@ -19,6 +19,9 @@ func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interf
 	}
 
 	if key.IsDeleted(customObject) {
+		// The guest cluster is deleted, so are its certificates.
+		deleteCertsExpiryMetric(customObject)
+
 		r.logger.LogCtx(ctx, "level", "debug", "message", "redirecting responsibility of deletion of config maps to namespace termination")
 		resourcecanceledcontext.SetCanceled(ctx)
 		r.logger.LogCtx(ctx, "level", "debug", "message", "canceling resource for custom object")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/operatorkit/controller"
 	apiv1 "k8s.io/api/core/v1"
@@ -82,7 +83,17 @@ func (r *Resource) newDeleteChangeForDeletePatch(ctx context.Context, obj, curre
 	return configMapsToDelete, nil
 }
 
+// newDeleteChangeForUpdatePatch returns the current config maps not being
+// desired anymore. Config maps still mounted by a deployment are kept, e.g.
+// the ones of workers being drained before their deployments are deleted on
+// scale down. They are deleted once their deployments are gone. Config maps
+// referenced for extra assets are never deleted, in case the custom object
+// lives in the cluster namespace.
 func (r *Resource) newDeleteChangeForUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 	currentConfigMaps, err := toConfigMaps(currentState)
 	if err != nil {
 		return nil, microerror.Mask(err)
@@ -94,15 +105,52 @@ func (r *Resource) newDeleteChangeForUpdatePatch(ctx context.Context, obj, curre
 
 	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which config maps have to be deleted")
 
+	mounted, err := r.mountedConfigMaps(customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	referenced, err := referencedConfigMaps(customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
 	var configMapsToDelete []*apiv1.ConfigMap
 
 	for _, currentConfigMap := range currentConfigMaps {
-		if !containsConfigMap(desiredConfigMaps, currentConfigMap) {
-			configMapsToDelete = append(configMapsToDelete, currentConfigMap)
+		if containsConfigMap(desiredConfigMaps, currentConfigMap) {
+			continue
 		}
+		if mounted[currentConfigMap.Name] {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("keeping config map '%s' mounted by a deployment", currentConfigMap.Name))
+			continue
+		}
+		if referenced[currentConfigMap.Name] {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("keeping config map '%s' referenced for extra assets", currentConfigMap.Name))
+			continue
+		}
+
+		configMapsToDelete = append(configMapsToDelete, currentConfigMap)
 	}
 
 	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d config maps that have to be deleted", len(configMapsToDelete)))
 
 	return configMapsToDelete, nil
 }
+
+// referencedConfigMaps returns the names of the config maps in the cluster
+// namespace the custom object references for extra assets.
+func referencedConfigMaps(customObject v1alpha1.KVMConfig) (map[string]bool, error) {
+	refs, err := key.ExtraAssetsRefs(customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	referenced := map[string]bool{}
+	for _, r := range refs {
+		if r.Namespace == key.ClusterNamespace(customObject) {
+			referenced[r.ConfigMap] = true
+		}
+	}
+
+	return referenced, nil
+}
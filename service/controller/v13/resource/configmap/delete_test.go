@@ -2,6 +2,7 @@ package configmap
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
@@ -9,10 +10,12 @@ import (
 	"github.com/giantswarm/micrologger/microloggertest"
 	"github.com/giantswarm/randomkeys/randomkeystest"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
 	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v13/cloudconfig/cloudconfigtest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
 func Test_Resource_CloudConfig_newDeleteChange(t *testing.T) {
@@ -294,3 +297,164 @@ func Test_Resource_CloudConfig_newDeleteChange(t *testing.T) {
 		}
 	}
 }
+
+func Test_Resource_CloudConfig_newDeleteChangeForUpdatePatch(t *testing.T) {
+	newCustomObject := func(extraAssets string) *v1alpha1.KVMConfig {
+		return &v1alpha1.KVMConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Namespace: "al9qy",
+				Annotations: map[string]string{
+					key.AnnotationExtraAssets: extraAssets,
+				},
+			},
+			Spec: v1alpha1.KVMConfigSpec{
+				Cluster: v1alpha1.Cluster{
+					ID: "al9qy",
+				},
+			},
+		}
+	}
+
+	newConfigMap := func(name string) *apiv1.ConfigMap {
+		return &apiv1.ConfigMap{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: name,
+			},
+		}
+	}
+
+	newDeployment := func(name, configMap string) *v1beta1.Deployment {
+		return &v1beta1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      name,
+				Namespace: "al9qy",
+			},
+			Spec: v1beta1.DeploymentSpec{
+				Template: apiv1.PodTemplateSpec{
+					Spec: apiv1.PodSpec{
+						Volumes: []apiv1.Volume{
+							{
+								Name: "cloud-config",
+								VolumeSource: apiv1.VolumeSource{
+									ConfigMap: &apiv1.ConfigMapVolumeSource{
+										LocalObjectReference: apiv1.LocalObjectReference{
+											Name: configMap,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		CustomObject           *v1alpha1.KVMConfig
+		CurrentState           []*apiv1.ConfigMap
+		DesiredState           []*apiv1.ConfigMap
+		Deployments            []*v1beta1.Deployment
+		ExpectedConfigMapNames []string
+	}{
+		// Test 0 ensures config maps not being desired anymore are deleted.
+		{
+			CustomObject: newCustomObject(""),
+			CurrentState: []*apiv1.ConfigMap{
+				newConfigMap("worker-5xchu"),
+				newConfigMap("worker-p7jqk"),
+			},
+			DesiredState: []*apiv1.ConfigMap{
+				newConfigMap("worker-5xchu"),
+			},
+			Deployments: []*v1beta1.Deployment{
+				newDeployment("worker-5xchu", "worker-5xchu"),
+			},
+			ExpectedConfigMapNames: []string{
+				"worker-p7jqk",
+			},
+		},
+
+		// Test 1 ensures config maps not being desired anymore are kept as long
+		// as a deployment mounts them, e.g. while the worker is drained on scale
+		// down.
+		{
+			CustomObject: newCustomObject(""),
+			CurrentState: []*apiv1.ConfigMap{
+				newConfigMap("worker-5xchu"),
+				newConfigMap("worker-p7jqk"),
+			},
+			DesiredState: []*apiv1.ConfigMap{
+				newConfigMap("worker-5xchu"),
+			},
+			Deployments: []*v1beta1.Deployment{
+				newDeployment("worker-5xchu", "worker-5xchu"),
+				newDeployment("worker-p7jqk", "worker-p7jqk"),
+			},
+			ExpectedConfigMapNames: nil,
+		},
+
+		// Test 2 ensures config maps referenced for extra assets are kept in case
+		// the custom object lives in the cluster namespace.
+		{
+			CustomObject: newCustomObject(`[{"configMap": "extra"}]`),
+			CurrentState: []*apiv1.ConfigMap{
+				newConfigMap("extra"),
+				newConfigMap("worker-5xchu"),
+				newConfigMap("worker-p7jqk"),
+			},
+			DesiredState: []*apiv1.ConfigMap{
+				newConfigMap("worker-5xchu"),
+			},
+			Deployments: []*v1beta1.Deployment{
+				newDeployment("worker-5xchu", "worker-5xchu"),
+			},
+			ExpectedConfigMapNames: []string{
+				"worker-p7jqk",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		fakeK8sClient := fake.NewSimpleClientset()
+		for _, d := range tc.Deployments {
+			_, err := fakeK8sClient.Extensions().Deployments(d.Namespace).Create(d)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		var err error
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.CertSearcher = certstest.NewSearcher()
+			resourceConfig.CloudConfig = cloudconfigtest.New()
+			resourceConfig.K8sClient = fakeK8sClient
+			resourceConfig.KeyWatcher = randomkeystest.NewSearcher()
+			resourceConfig.Logger = microloggertest.New()
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		result, err := newResource.newDeleteChangeForUpdatePatch(context.TODO(), tc.CustomObject, tc.CurrentState, tc.DesiredState)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		configMaps, err := toConfigMaps(result)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		var names []string
+		for _, c := range configMaps {
+			names = append(names, c.Name)
+		}
+		if !reflect.DeepEqual(names, tc.ExpectedConfigMapNames) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedConfigMapNames, names)
+		}
+	}
+}
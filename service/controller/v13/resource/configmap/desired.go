@@ -10,6 +10,7 @@ import (
 	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/workerscontext"
 )
 
 func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interface{}, error) {
@@ -20,17 +21,32 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 
 	r.logger.LogCtx(ctx, "level", "debug", "message", "computing the new config maps")
 
-	configMaps, err := r.newConfigMaps(customObject)
+	workers, ok := workerscontext.FromContext(ctx)
+	if !ok {
+		workers, err = key.WorkerNodes(customObject)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	configMaps, err := r.newConfigMaps(ctx, customObject, workers)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
+	if r.cloudConfigSecret {
+		configMaps, err = r.filterMountedConfigMaps(customObject, configMaps)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("computed the %d new config maps", len(configMaps)))
 
 	return configMaps, nil
 }
 
-func (r *Resource) newConfigMaps(customObject v1alpha1.KVMConfig) ([]*apiv1.ConfigMap, error) {
+func (r *Resource) newConfigMaps(ctx context.Context, customObject v1alpha1.KVMConfig, workers []key.WorkerNode) ([]*apiv1.ConfigMap, error) {
 	var configMaps []*apiv1.ConfigMap
 
 	certs, err := r.certSearcher.SearchCluster(key.ClusterID(customObject))
@@ -38,13 +54,20 @@ func (r *Resource) newConfigMaps(customObject v1alpha1.KVMConfig) ([]*apiv1.Conf
 		return nil, microerror.Mask(err)
 	}
 
+	r.updateCertsExpiryMetric(ctx, customObject, certs)
+
 	keys, err := r.keyWatcher.SearchCluster(key.ClusterID(customObject))
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
+	encryptionKeys, err := r.cloudConfig.EncryptionKeys(key.ClusterID(customObject), keys)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
 	for _, node := range customObject.Spec.Cluster.Masters {
-		template, err := r.cloudConfig.NewMasterTemplate(customObject, certs, node, keys)
+		template, err := r.cloudConfig.NewMasterTemplate(customObject, certs, node, encryptionKeys)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
@@ -53,20 +76,23 @@ func (r *Resource) newConfigMaps(customObject v1alpha1.KVMConfig) ([]*apiv1.Conf
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
+		configMap.Annotations[key.AnnotationCertsHash] = key.MasterCertsHash(certs)
+		configMap.Annotations[key.AnnotationEncryptionKeysHash] = key.EncryptionKeysHash(encryptionKeys)
 
 		configMaps = append(configMaps, configMap)
 	}
 
-	for _, node := range customObject.Spec.Cluster.Workers {
-		template, err := r.cloudConfig.NewWorkerTemplate(customObject, certs, node)
+	for _, worker := range workers {
+		template, err := r.cloudConfig.NewWorkerTemplate(customObject, certs, worker)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 
-		configMap, err := r.newConfigMap(customObject, template, node, key.WorkerID)
+		configMap, err := r.newConfigMap(customObject, template, worker.Node, key.WorkerID)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
+		configMap.Annotations[key.AnnotationCertsHash] = key.WorkerCertsHash(certs)
 
 		configMaps = append(configMaps, configMap)
 	}
@@ -80,11 +106,20 @@ func (r *Resource) newConfigMaps(customObject v1alpha1.KVMConfig) ([]*apiv1.Conf
 // variables. prefix can be either "master" or "worker" and is used to prefix
 // the configmap name.
 func (r *Resource) newConfigMap(customObject v1alpha1.KVMConfig, template string, node v1alpha1.ClusterNode, prefix string) (*apiv1.ConfigMap, error) {
+	name := key.ConfigMapName(customObject, node, prefix)
+
+	if len(template) > key.CloudConfigMaxSize {
+		return nil, microerror.Maskf(userDataTooLargeError, "user data of config map '%s' has %d bytes, exceeding the maximum of %d bytes", name, len(template), key.CloudConfigMaxSize)
+	}
+
 	var newConfigMap *apiv1.ConfigMap
 	{
 		newConfigMap = &apiv1.ConfigMap{
 			ObjectMeta: apismetav1.ObjectMeta{
-				Name: key.ConfigMapName(customObject, node, prefix),
+				Name: name,
+				Annotations: map[string]string{
+					key.AnnotationCloudConfigEncoding: r.cloudConfig.Encoding(),
+				},
 				Labels: map[string]string{
 					"cluster":  key.ClusterID(customObject),
 					"customer": key.ClusterCustomer(customObject),
@@ -98,3 +133,46 @@ func (r *Resource) newConfigMap(customObject v1alpha1.KVMConfig, template string
 
 	return newConfigMap, nil
 }
+
+// filterMountedConfigMaps returns the given config maps still being mounted by
+// the deployments of the guest cluster. Deployments created before
+// cloud-configs were stored in secrets keep mounting config maps until they
+// are updated anyway. This way existing guest cluster nodes are migrated
+// without being rebooted only for switching to secrets. Config maps not being
+// mounted anymore are deleted, since they expose the private keys of the guest
+// cluster.
+func (r *Resource) filterMountedConfigMaps(customObject v1alpha1.KVMConfig, configMaps []*apiv1.ConfigMap) ([]*apiv1.ConfigMap, error) {
+	mounted, err := r.mountedConfigMaps(customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var filtered []*apiv1.ConfigMap
+	for _, c := range configMaps {
+		if mounted[c.Name] {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return filtered, nil
+}
+
+// mountedConfigMaps returns the names of the config maps mounted by the
+// deployments of the guest cluster.
+func (r *Resource) mountedConfigMaps(customObject v1alpha1.KVMConfig) (map[string]bool, error) {
+	deploymentList, err := r.k8sClient.Extensions().Deployments(key.ClusterNamespace(customObject)).List(apismetav1.ListOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	mounted := map[string]bool{}
+	for _, d := range deploymentList.Items {
+		for _, v := range d.Spec.Template.Spec.Volumes {
+			if v.ConfigMap != nil {
+				mounted[v.ConfigMap.Name] = true
+			}
+		}
+	}
+
+	return mounted, nil
+}
@@ -2,6 +2,7 @@ package configmap
 
 import (
 	"context"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -10,9 +11,12 @@ import (
 	"github.com/giantswarm/micrologger/microloggertest"
 	"github.com/giantswarm/randomkeys/randomkeystest"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v13/cloudconfig/cloudconfigtest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
 func Test_Resource_CloudConfig_GetDesiredState(t *testing.T) {
@@ -120,6 +124,124 @@ func Test_Resource_CloudConfig_GetDesiredState(t *testing.T) {
 	}
 }
 
+func Test_Resource_CloudConfig_GetDesiredState_CloudConfigSecret(t *testing.T) {
+	obj := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Masters: []v1alpha1.ClusterNode{
+					{ID: "m1"},
+				},
+				Workers: []v1alpha1.ClusterNode{
+					{ID: "w1"},
+					{ID: "w2"},
+				},
+			},
+		},
+	}
+
+	newDeployment := func(name string, volumeSource apiv1.VolumeSource) *v1beta1.Deployment {
+		return &v1beta1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      name,
+				Namespace: "al9qy",
+			},
+			Spec: v1beta1.DeploymentSpec{
+				Template: apiv1.PodTemplateSpec{
+					Spec: apiv1.PodSpec{
+						Volumes: []apiv1.Volume{
+							{
+								Name:         "cloud-config",
+								VolumeSource: volumeSource,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		Deployments   []*v1beta1.Deployment
+		ExpectedNames []string
+	}{
+		// Test 1 ensures no config maps are desired for new guest clusters.
+		{
+			Deployments:   nil,
+			ExpectedNames: nil,
+		},
+		// Test 2 ensures config maps are only desired for deployments still
+		// mounting them.
+		{
+			Deployments: []*v1beta1.Deployment{
+				newDeployment("master-m1", apiv1.VolumeSource{
+					ConfigMap: &apiv1.ConfigMapVolumeSource{
+						LocalObjectReference: apiv1.LocalObjectReference{
+							Name: "master-al9qy-m1",
+						},
+					},
+				}),
+				newDeployment("worker-w1", apiv1.VolumeSource{
+					Secret: &apiv1.SecretVolumeSource{
+						SecretName: "worker-al9qy-w1",
+					},
+				}),
+				newDeployment("worker-w2", apiv1.VolumeSource{
+					ConfigMap: &apiv1.ConfigMapVolumeSource{
+						LocalObjectReference: apiv1.LocalObjectReference{
+							Name: "worker-al9qy-w2",
+						},
+					},
+				}),
+			},
+			ExpectedNames: []string{
+				"master-al9qy-m1",
+				"worker-al9qy-w2",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		k8sClient := fake.NewSimpleClientset()
+		for _, d := range tc.Deployments {
+			_, err := k8sClient.Extensions().Deployments(d.Namespace).Create(d)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		var err error
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.CertSearcher = certstest.NewSearcher()
+			resourceConfig.CloudConfig = cloudconfigtest.New()
+			resourceConfig.CloudConfigSecret = true
+			resourceConfig.K8sClient = k8sClient
+			resourceConfig.KeyWatcher = randomkeystest.NewSearcher()
+			resourceConfig.Logger = microloggertest.New()
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatal("expected", nil, "got", err)
+			}
+		}
+
+		result, err := newResource.GetDesiredState(context.TODO(), obj)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		var names []string
+		for _, c := range result.([]*apiv1.ConfigMap) {
+			names = append(names, c.Name)
+		}
+
+		if !reflect.DeepEqual(names, tc.ExpectedNames) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedNames, names)
+		}
+	}
+}
+
 func testGetMasterCount(configMaps []*apiv1.ConfigMap) int {
 	var count int
 
@@ -143,3 +265,62 @@ func testGetWorkerCount(configMaps []*apiv1.ConfigMap) int {
 
 	return count
 }
+
+func Test_Resource_CloudConfig_newConfigMap_UserDataSize(t *testing.T) {
+	testCases := []struct {
+		Size                 int
+		ExpectedErrorHandler func(error) bool
+	}{
+		// Test 1 ensures small user data is accepted.
+		{
+			Size:                 1024,
+			ExpectedErrorHandler: nil,
+		},
+		// Test 2 ensures user data of the maximum size is accepted.
+		{
+			Size:                 key.CloudConfigMaxSize,
+			ExpectedErrorHandler: nil,
+		},
+		// Test 3 ensures user data exceeding the maximum size is rejected.
+		{
+			Size:                 key.CloudConfigMaxSize + 1,
+			ExpectedErrorHandler: IsUserDataTooLarge,
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		resourceConfig := DefaultConfig()
+		resourceConfig.CertSearcher = certstest.NewSearcher()
+		resourceConfig.CloudConfig = cloudconfigtest.New()
+		resourceConfig.K8sClient = fake.NewSimpleClientset()
+		resourceConfig.KeyWatcher = randomkeystest.NewSearcher()
+		resourceConfig.Logger = microloggertest.New()
+		newResource, err = New(resourceConfig)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		configMap, err := newResource.newConfigMap(customObject, strings.Repeat("a", tc.Size), v1alpha1.ClusterNode{ID: "w1"}, key.WorkerID)
+		if err != nil && tc.ExpectedErrorHandler == nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+		if tc.ExpectedErrorHandler != nil && !tc.ExpectedErrorHandler(err) {
+			t.Fatalf("case %d expected error got %#v", i+1, err)
+		}
+		if tc.ExpectedErrorHandler == nil && configMap.Annotations[key.AnnotationCloudConfigEncoding] != key.CloudConfigEncodingBase64 {
+			t.Fatalf("case %d expected encoding %#v got %#v", i+1, key.CloudConfigEncodingBase64, configMap.Annotations[key.AnnotationCloudConfigEncoding])
+		}
+	}
+}
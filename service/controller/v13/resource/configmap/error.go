@@ -22,3 +22,10 @@ var wrongTypeError = microerror.New("wrong type")
 func IsWrongTypeError(err error) bool {
 	return microerror.Cause(err) == wrongTypeError
 }
+
+var userDataTooLargeError = microerror.New("user data too large")
+
+// IsUserDataTooLarge asserts userDataTooLargeError.
+func IsUserDataTooLarge(err error) bool {
+	return microerror.Cause(err) == userDataTooLargeError
+}
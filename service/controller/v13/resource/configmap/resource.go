@@ -1,8 +1,11 @@
 package configmap
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/certs"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
@@ -11,10 +14,12 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v13/cloudconfig"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/metric"
 )
 
 const (
-	KeyUserData = "user_data"
+	KeyUserData = key.CloudConfigUserDataKey
 	// Name is the identifier of the resource.
 	Name = "configmapv13"
 )
@@ -27,6 +32,13 @@ type Config struct {
 	K8sClient    kubernetes.Interface
 	KeyWatcher   randomkeys.Interface
 	Logger       micrologger.Logger
+
+	// Settings.
+
+	// CloudConfigSecret indicates that cloud-configs are stored in secrets. In
+	// this case config maps are only kept for deployments still mounting them,
+	// until these are updated to mount the secrets.
+	CloudConfigSecret bool
 }
 
 // DefaultConfig provides a default configuration to create a new config map
@@ -39,6 +51,9 @@ func DefaultConfig() Config {
 		K8sClient:    nil,
 		KeyWatcher:   nil,
 		Logger:       nil,
+
+		// Settings.
+		CloudConfigSecret: false,
 	}
 }
 
@@ -50,6 +65,9 @@ type Resource struct {
 	k8sClient    kubernetes.Interface
 	keyWatcher   randomkeys.Interface
 	logger       micrologger.Logger
+
+	// Settings.
+	cloudConfigSecret bool
 }
 
 // New creates a new configured config map resource.
@@ -78,6 +96,9 @@ func New(config Config) (*Resource, error) {
 		k8sClient:    config.K8sClient,
 		keyWatcher:   config.KeyWatcher,
 		logger:       config.Logger,
+
+		// Settings.
+		cloudConfigSecret: config.CloudConfigSecret,
 	}
 
 	return newService, nil
@@ -107,9 +128,37 @@ func getConfigMapByName(list []*apiv1.ConfigMap, name string) (*apiv1.ConfigMap,
 }
 
 func isConfigMapModified(a, b *apiv1.ConfigMap) bool {
+	if a.GetAnnotations()[key.AnnotationCertsHash] != b.GetAnnotations()[key.AnnotationCertsHash] {
+		return true
+	}
+	if a.GetAnnotations()[key.AnnotationEncryptionKeysHash] != b.GetAnnotations()[key.AnnotationEncryptionKeysHash] {
+		return true
+	}
+
 	return !reflect.DeepEqual(a.Data, b.Data)
 }
 
+// updateCertsExpiryMetric exports the expiry of the certificates of the given
+// guest cluster. Certificates which cannot be parsed are only logged, since
+// they do not prevent cloud-configs from being rendered.
+func (r *Resource) updateCertsExpiryMetric(ctx context.Context, customObject v1alpha1.KVMConfig, cluster certs.Cluster) {
+	expiry, err := key.CertsExpiry(cluster)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "cannot export certificate expiry", "stack", fmt.Sprintf("%#v", err))
+		return
+	}
+
+	for c, t := range expiry {
+		metric.CertsExpiryGauge.WithLabelValues(key.ClusterID(customObject), c).Set(float64(t.Unix()))
+	}
+}
+
+func deleteCertsExpiryMetric(customObject v1alpha1.KVMConfig) {
+	for _, c := range key.CertsComponents {
+		metric.CertsExpiryGauge.DeleteLabelValues(key.ClusterID(customObject), c)
+	}
+}
+
 func toConfigMaps(v interface{}) ([]*apiv1.ConfigMap, error) {
 	if v == nil {
 		return nil, nil
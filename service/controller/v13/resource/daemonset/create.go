@@ -0,0 +1,60 @@
+package daemonset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange interface{}) error {
+	daemonSetsToCreate, err := toDaemonSets(createChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(daemonSetsToCreate) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the image pre-pull daemon sets in the Kubernetes API")
+
+		for _, d := range daemonSetsToCreate {
+			_, err := r.k8sClient.Extensions().DaemonSets(r.namespace).Create(d)
+			if apierrors.IsAlreadyExists(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "created the image pre-pull daemon sets in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the image pre-pull daemon sets do not need to be created in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) newCreateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentDaemonSets, err := toDaemonSets(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredDaemonSets, err := toDaemonSets(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which image pre-pull daemon sets have to be created")
+
+	var daemonSetsToCreate []*v1beta1.DaemonSet
+	for _, d := range desiredDaemonSets {
+		if !containsDaemonSet(currentDaemonSets, d) {
+			daemonSetsToCreate = append(daemonSetsToCreate, d)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d image pre-pull daemon sets that have to be created", len(daemonSetsToCreate)))
+
+	return daemonSetsToCreate, nil
+}
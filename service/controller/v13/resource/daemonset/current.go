@@ -0,0 +1,101 @@
+package daemonset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"k8s.io/api/extensions/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "looking for a list of image pre-pull daemon sets in the Kubernetes API")
+
+	var currentDaemonSets []*v1beta1.DaemonSet
+	{
+		o := apismetav1.ListOptions{
+			LabelSelector: fmt.Sprintf("app=%s,%s=%s", key.ImagePrePullID, key.LabelVersionBundle, key.VersionBundleVersion(customObject)),
+		}
+		list, err := r.k8sClient.Extensions().DaemonSets(r.namespace).List(o)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, item := range list.Items {
+			d := item
+			currentDaemonSets = append(currentDaemonSets, &d)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found a list of %d image pre-pull daemon sets in the Kubernetes API", len(currentDaemonSets)))
+
+	if !key.IsDeleted(customObject) {
+		err = r.updateImageCondition(ctx, customObject, currentDaemonSets)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return currentDaemonSets, nil
+}
+
+// updateImageCondition reports whether the Container Linux image requested by
+// the guest cluster is present on all hosts. The pre-pull containers only
+// become ready once the image got downloaded and verified, so the image is
+// considered present as soon as all scheduled pods of the daemon set of the
+// requested version are ready. Failures to report the status are only logged,
+// because they must not block the reconciliation of the guest cluster.
+func (r *Resource) updateImageCondition(ctx context.Context, customObject v1alpha1.KVMConfig, daemonSets []*v1beta1.DaemonSet) error {
+	version, err := key.CoreosVersion(customObject)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	condition := clusterstatus.Condition{
+		Type: clusterstatus.ConditionCoreosImagePresent,
+	}
+
+	daemonSet, err := getDaemonSetByName(daemonSets, key.ImagePrePullName(key.VersionBundleVersion(customObject), version))
+	if IsNotFound(err) {
+		condition.Status = clusterstatus.ConditionStatusFalse
+		condition.Reason = "PrePullPending"
+		condition.Message = fmt.Sprintf("pre-pull of Container Linux image '%s' has not been started yet", version)
+	} else if err != nil {
+		return microerror.Mask(err)
+	} else {
+		desired := daemonSet.Status.DesiredNumberScheduled
+		ready := daemonSet.Status.NumberReady
+		upToDate := daemonSet.Status.ObservedGeneration >= daemonSet.GetGeneration() && daemonSet.Status.UpdatedNumberScheduled == desired
+
+		if desired > 0 && ready == desired && upToDate {
+			condition.Status = clusterstatus.ConditionStatusTrue
+			condition.Reason = "ImagePresent"
+			condition.Message = fmt.Sprintf("Container Linux image '%s' is present on %d hosts", version, ready)
+		} else {
+			condition.Status = clusterstatus.ConditionStatusFalse
+			condition.Reason = "PrePullInProgress"
+			condition.Message = fmt.Sprintf("Container Linux image '%s' is present on %d of %d hosts", version, ready, desired)
+		}
+	}
+
+	if condition.Status == clusterstatus.ConditionStatusFalse {
+		r.logger.LogCtx(ctx, "level", "warning", "message", condition.Message)
+	}
+
+	err = r.clusterStatus.SetCondition(ctx, customObject, condition)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cannot set status condition '%s'", condition.Type), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	return nil
+}
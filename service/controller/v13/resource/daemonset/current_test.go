@@ -0,0 +1,153 @@
+package daemonset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	extensionsv1 "k8s.io/api/extensions/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterlister/clusterlistertest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_Resource_DaemonSet_GetCurrentState(t *testing.T) {
+	customObject := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+			VersionBundle: v1alpha1.KVMConfigSpecVersionBundle{
+				Version: "1.0.0",
+			},
+		},
+	}
+
+	newDaemonSet := func(versionBundleVersion, coreosVersion string, ready int32) *extensionsv1.DaemonSet {
+		return &extensionsv1.DaemonSet{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      key.ImagePrePullName(versionBundleVersion, coreosVersion),
+				Namespace: "giantswarm",
+				Labels: map[string]string{
+					"app":                  key.ImagePrePullID,
+					key.LabelCoreosVersion: coreosVersion,
+					key.LabelVersionBundle: versionBundleVersion,
+				},
+				Annotations: map[string]string{
+					key.AnnotationCoreosVersion: coreosVersion,
+				},
+			},
+			Status: extensionsv1.DaemonSetStatus{
+				DesiredNumberScheduled: 3,
+				NumberReady:            ready,
+				UpdatedNumberScheduled: 3,
+			},
+		}
+	}
+
+	testCases := []struct {
+		DaemonSets              []*extensionsv1.DaemonSet
+		ExpectedDaemonSets      int
+		ExpectedConditionStatus string
+	}{
+		// Test 0 ensures the image is reported missing in case there is no
+		// pre-pull daemon set yet.
+		{
+			DaemonSets:              nil,
+			ExpectedDaemonSets:      0,
+			ExpectedConditionStatus: clusterstatus.ConditionStatusFalse,
+		},
+
+		// Test 1 ensures the image is reported missing in case not all pre-pull
+		// pods are ready.
+		{
+			DaemonSets: []*extensionsv1.DaemonSet{
+				newDaemonSet("1.0.0", key.DefaultCoreosVersion, 2),
+			},
+			ExpectedDaemonSets:      1,
+			ExpectedConditionStatus: clusterstatus.ConditionStatusFalse,
+		},
+
+		// Test 2 ensures the image is reported missing in case only the pre-pull
+		// daemon sets of other versions and version bundles are ready.
+		{
+			DaemonSets: []*extensionsv1.DaemonSet{
+				newDaemonSet("1.0.0", "1632.3.0", 3),
+				newDaemonSet("2.0.0", key.DefaultCoreosVersion, 3),
+			},
+			ExpectedDaemonSets:      1,
+			ExpectedConditionStatus: clusterstatus.ConditionStatusFalse,
+		},
+
+		// Test 3 ensures the image is reported present in case all pre-pull pods
+		// are ready.
+		{
+			DaemonSets: []*extensionsv1.DaemonSet{
+				newDaemonSet("1.0.0", "1632.3.0", 3),
+				newDaemonSet("1.0.0", key.DefaultCoreosVersion, 3),
+			},
+			ExpectedDaemonSets:      2,
+			ExpectedConditionStatus: clusterstatus.ConditionStatusTrue,
+		},
+	}
+
+	for i, tc := range testCases {
+		fakeK8sClient := fake.NewSimpleClientset()
+		for _, d := range tc.DaemonSets {
+			_, err := fakeK8sClient.Extensions().DaemonSets(d.Namespace).Create(d)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		clusterStatus := clusterstatustest.New()
+
+		var err error
+		var newResource *Resource
+		{
+			c := Config{
+				ClusterLister: clusterlistertest.New(),
+				ClusterStatus: clusterStatus,
+				K8sClient:     fakeK8sClient,
+				Logger:        microloggertest.New(),
+
+				BaseURL:        "https://stable.release.core-os.net/amd64-usr",
+				Namespace:      "giantswarm",
+				ServiceAccount: "kvm-operator-image-prepull",
+			}
+
+			newResource, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		result, err := newResource.GetCurrentState(context.TODO(), customObject)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		daemonSets, err := toDaemonSets(result)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if len(daemonSets) != tc.ExpectedDaemonSets {
+			t.Fatalf("case %d expected %d daemon sets got %d", i, tc.ExpectedDaemonSets, len(daemonSets))
+		}
+
+		if len(clusterStatus.Conditions) != 1 {
+			t.Fatalf("case %d expected %d conditions got %d", i, 1, len(clusterStatus.Conditions))
+		}
+		if clusterStatus.Conditions[0].Type != clusterstatus.ConditionCoreosImagePresent {
+			t.Fatalf("case %d expected %#v got %#v", i, clusterstatus.ConditionCoreosImagePresent, clusterStatus.Conditions[0].Type)
+		}
+		if clusterStatus.Conditions[0].Status != tc.ExpectedConditionStatus {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedConditionStatus, clusterStatus.Conditions[0].Status)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package daemonset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+	"k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteChange interface{}) error {
+	daemonSetsToDelete, err := toDaemonSets(deleteChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(daemonSetsToDelete) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleting the image pre-pull daemon sets in the Kubernetes API")
+
+		for _, d := range daemonSetsToDelete {
+			err := r.k8sClient.Extensions().DaemonSets(r.namespace).Delete(d.GetName(), newDeleteOptions())
+			if apierrors.IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleted the image pre-pull daemon sets in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the image pre-pull daemon sets do not need to be deleted from the Kubernetes API")
+	}
+
+	return nil
+}
+
+// NewDeletePatch deletes the image pre-pull daemon sets no other guest cluster
+// of the version bundle needs, since the daemon sets are shared by all guest
+// clusters booting the same Container Linux version.
+func (r *Resource) NewDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	delete, err := r.newDeleteChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetDeleteChange(delete)
+
+	return patch, nil
+}
+
+// newDeleteChange returns the image pre-pull daemon sets of Container Linux
+// versions no guest cluster of the version bundle boots anymore.
+func (r *Resource) newDeleteChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentDaemonSets, err := toDaemonSets(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredDaemonSets, err := toDaemonSets(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which image pre-pull daemon sets have to be deleted")
+
+	var daemonSetsToDelete []*v1beta1.DaemonSet
+	for _, d := range currentDaemonSets {
+		if !containsDaemonSet(desiredDaemonSets, d) {
+			daemonSetsToDelete = append(daemonSetsToDelete, d)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d image pre-pull daemon sets that have to be deleted", len(daemonSetsToDelete)))
+
+	return daemonSetsToDelete, nil
+}
+
+// newDeleteOptions makes the garbage collector delete the pods of a daemon set
+// along with it.
+func newDeleteOptions() *apismetav1.DeleteOptions {
+	propagation := apismetav1.DeletePropagationBackground
+
+	return &apismetav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	}
+}
@@ -0,0 +1,87 @@
+package daemonset
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "computing the new image pre-pull daemon sets")
+
+	versions, err := r.coreosVersions(ctx, customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var daemonSets []*v1beta1.DaemonSet
+	for _, v := range versions {
+		d := newImagePrePullDaemonSet(key.VersionBundleVersion(customObject), v, r.baseURL, r.registryMirror, r.serviceAccount)
+		daemonSets = append(daemonSets, d)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("computed the %d new image pre-pull daemon sets", len(daemonSets)))
+
+	return daemonSets, nil
+}
+
+// coreosVersions returns the Container Linux versions the guest clusters of
+// the version bundle of the given guest cluster boot. The given guest cluster
+// is taken into account as given, unless it is being deleted. Guest clusters
+// being deleted do not need their image anymore. Other guest clusters
+// requesting an invalid version are ignored, since they cannot boot anyway.
+func (r *Resource) coreosVersions(ctx context.Context, customObject v1alpha1.KVMConfig) ([]string, error) {
+	seen := map[string]bool{}
+
+	if !key.IsDeleted(customObject) {
+		v, err := key.CoreosVersion(customObject)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		seen[v] = true
+	}
+
+	clusters, err := r.clusterLister.List(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, c := range clusters {
+		if key.ClusterID(c.CustomObject) == key.ClusterID(customObject) || key.IsDeleted(c.CustomObject) {
+			continue
+		}
+		if key.VersionBundleVersion(c.CustomObject) != key.VersionBundleVersion(customObject) {
+			continue
+		}
+
+		v, err := key.CoreosVersion(c.CustomObject)
+		if key.IsInvalidAnnotation(err) {
+			r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cannot find out the Container Linux version of guest cluster '%s'", key.ClusterID(c.CustomObject)), "stack", fmt.Sprintf("%#v", err))
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		seen[v] = true
+	}
+
+	var versions []string
+	for v := range seen {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	return versions, nil
+}
@@ -0,0 +1,174 @@
+package daemonset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterlister"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterlister/clusterlistertest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_Resource_DaemonSet_GetDesiredState(t *testing.T) {
+	newCustomObject := func(id, versionBundleVersion, coreosVersion string, deleted bool) v1alpha1.KVMConfig {
+		customObject := v1alpha1.KVMConfig{
+			Spec: v1alpha1.KVMConfigSpec{
+				Cluster: v1alpha1.Cluster{
+					ID: id,
+				},
+				VersionBundle: v1alpha1.KVMConfigSpecVersionBundle{
+					Version: versionBundleVersion,
+				},
+			},
+		}
+		if coreosVersion != "" {
+			customObject.SetAnnotations(map[string]string{
+				key.AnnotationCoreosVersion: coreosVersion,
+			})
+		}
+		if deleted {
+			now := apismetav1.Now()
+			customObject.SetDeletionTimestamp(&now)
+		}
+
+		return customObject
+	}
+
+	testCases := []struct {
+		Obj                    v1alpha1.KVMConfig
+		Clusters               []clusterlister.Cluster
+		ExpectedCoreosVersions []string
+		ErrorMatcher           func(error) bool
+	}{
+		// Test 0 ensures the default Container Linux version is pre-pulled in
+		// case the custom object does not request a specific one.
+		{
+			Obj:                    newCustomObject("al9qy", "1.0.0", "", false),
+			ExpectedCoreosVersions: []string{key.DefaultCoreosVersion},
+			ErrorMatcher:           nil,
+		},
+
+		// Test 1 ensures the Container Linux version requested by the custom
+		// object is pre-pulled.
+		{
+			Obj:                    newCustomObject("al9qy", "1.0.0", "1745.7.0", false),
+			ExpectedCoreosVersions: []string{"1745.7.0"},
+			ErrorMatcher:           nil,
+		},
+
+		// Test 2 ensures an invalid Container Linux version is rejected.
+		{
+			Obj:          newCustomObject("al9qy", "1.0.0", "1745.7.0; rm -rf /", false),
+			ErrorMatcher: key.IsInvalidAnnotation,
+		},
+
+		// Test 3 ensures the Container Linux versions of other guest clusters of
+		// the version bundle are pre-pulled once each, while the ones of guest
+		// clusters of other version bundles, guest clusters being deleted and
+		// guest clusters requesting invalid versions are not.
+		{
+			Obj: newCustomObject("al9qy", "1.0.0", "1745.7.0", false),
+			Clusters: []clusterlister.Cluster{
+				{CustomObject: newCustomObject("al9qy", "1.0.0", "1632.3.0", false)},
+				{CustomObject: newCustomObject("b2k4x", "1.0.0", "1745.7.0", false)},
+				{CustomObject: newCustomObject("c5m8z", "1.0.0", "", false)},
+				{CustomObject: newCustomObject("d7n1w", "2.0.0", "1800.5.0", false)},
+				{CustomObject: newCustomObject("e3p6v", "1.0.0", "1800.5.0", true)},
+				{CustomObject: newCustomObject("f9r2u", "1.0.0", "1745.7.0; rm -rf /", false)},
+			},
+			ExpectedCoreosVersions: []string{key.DefaultCoreosVersion, "1745.7.0"},
+			ErrorMatcher:           nil,
+		},
+
+		// Test 4 ensures the Container Linux version of a guest cluster being
+		// deleted is not pre-pulled anymore, unless other guest clusters of the
+		// version bundle boot it.
+		{
+			Obj: newCustomObject("al9qy", "1.0.0", "1745.7.0", true),
+			Clusters: []clusterlister.Cluster{
+				{CustomObject: newCustomObject("b2k4x", "1.0.0", "1632.3.0", false)},
+			},
+			ExpectedCoreosVersions: []string{"1632.3.0"},
+			ErrorMatcher:           nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		var err error
+		var newResource *Resource
+		{
+			c := Config{
+				ClusterLister: clusterlistertest.New(tc.Clusters...),
+				ClusterStatus: clusterstatustest.New(),
+				K8sClient:     fake.NewSimpleClientset(),
+				Logger:        microloggertest.New(),
+
+				BaseURL:        "http://images.example.com/coreos/",
+				Namespace:      "giantswarm",
+				ServiceAccount: "kvm-operator-image-prepull",
+			}
+
+			newResource, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		result, err := newResource.GetDesiredState(context.TODO(), &tc.Obj)
+		if err != nil {
+			if tc.ErrorMatcher == nil || !tc.ErrorMatcher(err) {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+			continue
+		}
+		if tc.ErrorMatcher != nil {
+			t.Fatalf("case %d expected error got %#v", i, nil)
+		}
+
+		daemonSets, err := toDaemonSets(result)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		var coreosVersions []string
+		for _, d := range daemonSets {
+			coreosVersion := d.GetAnnotations()[key.AnnotationCoreosVersion]
+			coreosVersions = append(coreosVersions, coreosVersion)
+
+			if d.GetName() != key.ImagePrePullName("1.0.0", coreosVersion) {
+				t.Fatalf("case %d expected %#v got %#v", i, key.ImagePrePullName("1.0.0", coreosVersion), d.GetName())
+			}
+			if d.Spec.Template.Spec.ServiceAccountName != "kvm-operator-image-prepull" {
+				t.Fatalf("case %d expected %#v got %#v", i, "kvm-operator-image-prepull", d.Spec.Template.Spec.ServiceAccountName)
+			}
+
+			env := map[string]string{}
+			for _, e := range d.Spec.Template.Spec.Containers[0].Env {
+				env[e.Name] = e.Value
+			}
+			if env["COREOS_VERSION"] != coreosVersion {
+				t.Fatalf("case %d expected env COREOS_VERSION=%s", i, coreosVersion)
+			}
+			if env["COREOS_BASE_URL"] != "http://images.example.com/coreos" {
+				t.Fatalf("case %d expected env COREOS_BASE_URL=%s", i, "http://images.example.com/coreos")
+			}
+
+			terms := d.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+			if terms[0].MatchExpressions[0].Key != "role" || terms[0].MatchExpressions[0].Operator != apiv1.NodeSelectorOpIn {
+				t.Fatalf("case %d expected daemon set to select hosts by role", i)
+			}
+		}
+
+		if !reflect.DeepEqual(coreosVersions, tc.ExpectedCoreosVersions) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedCoreosVersions, coreosVersions)
+		}
+	}
+}
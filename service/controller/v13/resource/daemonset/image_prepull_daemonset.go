@@ -0,0 +1,182 @@
+package daemonset
+
+import (
+	"fmt"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	extensionsv1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// imageDir is the path the host's image directory is mounted to. This is
+	// the same path the k8s-kvm container expects the images to be at.
+	imageDir = "/usr/code/images"
+	// imagePrePullScript downloads the Container Linux PXE kernel and initramfs
+	// into the version specific image directory on the host, unless they are
+	// already present there. Each file is verified against the SHA512 digest
+	// published together with it before being moved into place. Downloads go
+	// to temporary files first, so concurrent pre-pulls of other version
+	// bundles on the same host never see partially written images.
+	imagePrePullScript = `set -e
+dir=${IMAGE_DIR}/${COREOS_VERSION}
+mkdir -p ${dir}
+for f in coreos_production_pxe.vmlinuz coreos_production_pxe_image.cpio.gz; do
+  if [ -f ${dir}/${f} ]; then
+    continue
+  fi
+  tmp=$(mktemp -p ${dir} .${f}.XXXXXX)
+  trap "rm -f ${tmp} ${tmp}.DIGESTS" EXIT
+  wget -q -O ${tmp} ${COREOS_BASE_URL}/${COREOS_VERSION}/${f}
+  wget -q -O ${tmp}.DIGESTS ${COREOS_BASE_URL}/${COREOS_VERSION}/${f}.DIGESTS
+  sum=$(sha512sum ${tmp} | cut -d ' ' -f 1)
+  if ! grep -q "^${sum}  ${f}$" ${tmp}.DIGESTS; then
+    echo "checksum verification of ${f} failed"
+    exit 1
+  fi
+  mv ${tmp} ${dir}/${f}
+  rm -f ${tmp}.DIGESTS
+  trap - EXIT
+done
+echo "Container Linux image ${COREOS_VERSION} is present"
+while true; do sleep 3600; done
+`
+)
+
+// newImagePrePullDaemonSet returns the daemon set pre-pulling the given
+// Container Linux version from the given base URL on all hosts for the guest
+// clusters of the given version bundle. The image of the pre-pull container
+// only provides the tools to download and verify the image, which is why it is
+// not overridden per guest cluster.
+func newImagePrePullDaemonSet(versionBundleVersion, coreosVersion, baseURL, registryMirror, serviceAccount string) *extensionsv1.DaemonSet {
+	labels := map[string]string{
+		"app":                  key.ImagePrePullID,
+		key.LabelCoreosVersion: coreosVersion,
+		key.LabelVersionBundle: versionBundleVersion,
+	}
+
+	daemonSet := &extensionsv1.DaemonSet{
+		TypeMeta: apismetav1.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: "extensions/v1beta1",
+		},
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name: key.ImagePrePullName(versionBundleVersion, coreosVersion),
+			Annotations: map[string]string{
+				key.AnnotationCoreosVersion:        coreosVersion,
+				key.VersionBundleVersionAnnotation: versionBundleVersion,
+			},
+			Labels: labels,
+		},
+		Spec: extensionsv1.DaemonSetSpec{
+			Selector: &apismetav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			UpdateStrategy: extensionsv1.DaemonSetUpdateStrategy{
+				Type: extensionsv1.RollingUpdateDaemonSetStrategyType,
+			},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: apiv1.PodSpec{
+					// The pre-pull pods have to run on every host guest cluster nodes
+					// may be scheduled to. These are the hosts matching the node
+					// selectors of the master and worker deployments.
+					Affinity: &apiv1.Affinity{
+						NodeAffinity: &apiv1.NodeAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: &apiv1.NodeSelector{
+								NodeSelectorTerms: []apiv1.NodeSelectorTerm{
+									{
+										MatchExpressions: []apiv1.NodeSelectorRequirement{
+											{
+												Key:      "role",
+												Operator: apiv1.NodeSelectorOpIn,
+												Values: []string{
+													key.MasterID,
+													key.WorkerID,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					ServiceAccountName: serviceAccount,
+					Volumes: []apiv1.Volume{
+						{
+							Name: "images",
+							VolumeSource: apiv1.VolumeSource{
+								HostPath: &apiv1.HostPathVolumeSource{
+									Path: key.CoreosImageDir,
+								},
+							},
+						},
+					},
+					Containers: []apiv1.Container{
+						{
+							Name:            key.ImagePrePullID,
+							Image:           key.ImageWithRegistryMirror(key.ImagePrePullDockerImage, registryMirror),
+							ImagePullPolicy: apiv1.PullIfNotPresent,
+							Command: []string{
+								"/bin/sh",
+								"-c",
+								imagePrePullScript,
+							},
+							Env: []apiv1.EnvVar{
+								{
+									Name:  "COREOS_BASE_URL",
+									Value: strings.TrimSuffix(baseURL, "/"),
+								},
+								{
+									Name:  "COREOS_VERSION",
+									Value: coreosVersion,
+								},
+								{
+									Name:  "IMAGE_DIR",
+									Value: imageDir,
+								},
+							},
+							ReadinessProbe: &apiv1.Probe{
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+								Handler: apiv1.Handler{
+									Exec: &apiv1.ExecAction{
+										Command: []string{
+											"/bin/sh",
+											"-c",
+											fmt.Sprintf("test -f %[1]s/%[2]s/coreos_production_pxe.vmlinuz -a -f %[1]s/%[2]s/coreos_production_pxe_image.cpio.gz", imageDir, coreosVersion),
+										},
+									},
+								},
+							},
+							Resources: apiv1.ResourceRequirements{
+								Requests: apiv1.ResourceList{
+									apiv1.ResourceCPU:    resource.MustParse("50m"),
+									apiv1.ResourceMemory: resource.MustParse("50Mi"),
+								},
+								Limits: apiv1.ResourceList{
+									apiv1.ResourceCPU:    resource.MustParse("250m"),
+									apiv1.ResourceMemory: resource.MustParse("100Mi"),
+								},
+							},
+							VolumeMounts: []apiv1.VolumeMount{
+								{
+									Name:      "images",
+									MountPath: imageDir,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return daemonSet
+}
@@ -0,0 +1,156 @@
+package daemonset
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterlister"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// Name is the identifier of the resource.
+	Name = "daemonsetv13"
+)
+
+// Config represents the configuration used to create a new daemon set
+// resource.
+type Config struct {
+	ClusterLister clusterlister.Interface
+	ClusterStatus clusterstatus.Interface
+	K8sClient     kubernetes.Interface
+	Logger        micrologger.Logger
+
+	// BaseURL is the location Container Linux PXE images and their digests are
+	// downloaded from.
+	BaseURL string
+	// Namespace is the namespace of the image pre-pull daemon sets. There is
+	// one daemon set per Container Linux version guest clusters of the
+	// version bundle boot, so every host runs a single pre-pull pod per
+	// version, no matter how many guest clusters boot it.
+	Namespace      string
+	RegistryMirror string
+	// ServiceAccount is the service account of the image pre-pull pods. It has
+	// to be allowed to use host path volumes.
+	ServiceAccount string
+}
+
+// Resource implements the daemon set resource.
+type Resource struct {
+	clusterLister clusterlister.Interface
+	clusterStatus clusterstatus.Interface
+	k8sClient     kubernetes.Interface
+	logger        micrologger.Logger
+
+	baseURL        string
+	namespace      string
+	registryMirror string
+	serviceAccount string
+}
+
+// New creates a new configured daemon set resource.
+func New(config Config) (*Resource, error) {
+	if config.ClusterLister == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ClusterLister must not be empty", config)
+	}
+	if config.ClusterStatus == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ClusterStatus must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.BaseURL == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.BaseURL must not be empty", config)
+	}
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+	if config.ServiceAccount == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ServiceAccount must not be empty", config)
+	}
+
+	r := &Resource{
+		clusterLister: config.ClusterLister,
+		clusterStatus: config.ClusterStatus,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+
+		baseURL:        config.BaseURL,
+		namespace:      config.Namespace,
+		registryMirror: config.RegistryMirror,
+		serviceAccount: config.ServiceAccount,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
+
+func containsDaemonSet(list []*v1beta1.DaemonSet, item *v1beta1.DaemonSet) bool {
+	_, err := getDaemonSetByName(list, item.GetName())
+	if err != nil {
+		return false
+	}
+
+	return true
+}
+
+func getDaemonSetByName(list []*v1beta1.DaemonSet, name string) (*v1beta1.DaemonSet, error) {
+	for _, d := range list {
+		if d.GetName() == name {
+			return d, nil
+		}
+	}
+
+	return nil, microerror.Mask(notFoundError)
+}
+
+func isDaemonSetModified(a, b *v1beta1.DaemonSet) bool {
+	annotations := []string{
+		key.AnnotationCoreosVersion,
+		key.VersionBundleVersionAnnotation,
+	}
+
+	for _, k := range annotations {
+		if a.GetAnnotations()[k] != b.GetAnnotations()[k] {
+			return true
+		}
+	}
+
+	if a.Spec.Template.Spec.ServiceAccountName != b.Spec.Template.Spec.ServiceAccountName {
+		return true
+	}
+
+	if len(a.Spec.Template.Spec.Containers) != len(b.Spec.Template.Spec.Containers) {
+		return true
+	}
+	for i := range a.Spec.Template.Spec.Containers {
+		if a.Spec.Template.Spec.Containers[i].Image != b.Spec.Template.Spec.Containers[i].Image {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toDaemonSets(v interface{}) ([]*v1beta1.DaemonSet, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	daemonSets, ok := v.([]*v1beta1.DaemonSet)
+	if !ok {
+		return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", []*v1beta1.DaemonSet{}, v)
+	}
+
+	return daemonSets, nil
+}
@@ -0,0 +1,89 @@
+package daemonset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+	"k8s.io/api/extensions/v1beta1"
+)
+
+func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
+	daemonSetsToUpdate, err := toDaemonSets(updateChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(daemonSetsToUpdate) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the image pre-pull daemon sets in the Kubernetes API")
+
+		for _, d := range daemonSetsToUpdate {
+			_, err := r.k8sClient.Extensions().DaemonSets(r.namespace).Update(d)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the image pre-pull daemon sets in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the image pre-pull daemon sets do not need to be updated in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	create, err := r.newCreateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	delete, err := r.newDeleteChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	update, err := r.newUpdateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetCreateChange(create)
+	patch.SetDeleteChange(delete)
+	patch.SetUpdateChange(update)
+
+	return patch, nil
+}
+
+func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentDaemonSets, err := toDaemonSets(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredDaemonSets, err := toDaemonSets(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which image pre-pull daemon sets have to be updated")
+
+	var daemonSetsToUpdate []*v1beta1.DaemonSet
+	for _, c := range currentDaemonSets {
+		d, err := getDaemonSetByName(desiredDaemonSets, c.GetName())
+		if IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if isDaemonSetModified(d, c) {
+			d = d.DeepCopy()
+			d.SetResourceVersion(c.GetResourceVersion())
+			daemonSetsToUpdate = append(daemonSetsToUpdate, d)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d image pre-pull daemon sets that have to be updated", len(daemonSetsToUpdate)))
+
+	return daemonSetsToUpdate, nil
+}
@@ -55,11 +55,28 @@ func (r *Resource) newCreateChange(ctx context.Context, obj, currentState, desir
 	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which deployments have to be created")
 
 	var deploymentsToCreate []*v1beta1.Deployment
+	var mastersToCreate int
+
+	multiMaster := countMasterDeployments(desiredDeployments) > 1
+	mastersJoined := allMastersJoined(currentDeployments)
 
 	for _, desiredDeployment := range desiredDeployments {
-		if !containsDeployment(currentDeployments, desiredDeployment) {
-			deploymentsToCreate = append(deploymentsToCreate, desiredDeployment)
+		if containsDeployment(currentDeployments, desiredDeployment) {
+			continue
 		}
+
+		// Masters of guest clusters running multiple masters join the etcd cluster
+		// one at a time. A new master is only created once all existing masters
+		// joined the etcd cluster. See the etcdmember resource.
+		if isMasterDeployment(desiredDeployment) && multiMaster {
+			if mastersToCreate > 0 || !mastersJoined {
+				r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not creating deployment '%s': waiting for masters to join the etcd cluster", desiredDeployment.GetName()))
+				continue
+			}
+			mastersToCreate++
+		}
+
+		deploymentsToCreate = append(deploymentsToCreate, desiredDeployment)
 	}
 
 	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d deployments that have to be created", len(deploymentsToCreate)))
@@ -2,13 +2,21 @@ package deployment
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
 	"github.com/giantswarm/micrologger/microloggertest"
 	"k8s.io/api/extensions/v1beta1"
 	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota/customerquotatest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard/quorumguardtest"
 )
 
 func Test_Resource_Deployment_newCreateChange(t *testing.T) {
@@ -217,8 +225,13 @@ func Test_Resource_Deployment_newCreateChange(t *testing.T) {
 	var newResource *Resource
 	{
 		resourceConfig := DefaultConfig()
+		resourceConfig.ClusterStatus = clusterstatustest.New()
+		resourceConfig.CustomerQuota = customerquotatest.New()
+		resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+		resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
 		resourceConfig.K8sClient = fake.NewSimpleClientset()
 		resourceConfig.Logger = microloggertest.New()
+		resourceConfig.QuorumGuard = quorumguardtest.New()
 		newResource, err = New(resourceConfig)
 		if err != nil {
 			t.Fatal("expected", nil, "got", err)
@@ -241,3 +254,100 @@ func Test_Resource_Deployment_newCreateChange(t *testing.T) {
 		}
 	}
 }
+
+func Test_Resource_Deployment_newCreateChange_Masters(t *testing.T) {
+	testCases := []struct {
+		CurrentState            []*v1beta1.Deployment
+		DesiredState            []*v1beta1.Deployment
+		ExpectedDeploymentNames []string
+	}{
+		// Test 1 ensures a single master is created right away.
+		{
+			CurrentState: nil,
+			DesiredState: []*v1beta1.Deployment{
+				newTestDeployment("master-1", key.MasterID, ""),
+				newTestDeployment("worker-1", key.WorkerID, ""),
+			},
+			ExpectedDeploymentNames: []string{"master-1", "worker-1"},
+		},
+
+		// Test 2 ensures only the first master of a new guest cluster running
+		// multiple masters is created.
+		{
+			CurrentState: nil,
+			DesiredState: []*v1beta1.Deployment{
+				newTestDeployment("master-1", key.MasterID, ""),
+				newTestDeployment("master-2", key.MasterID, ""),
+				newTestDeployment("master-3", key.MasterID, ""),
+				newTestDeployment("worker-1", key.WorkerID, ""),
+			},
+			ExpectedDeploymentNames: []string{"master-1", "worker-1"},
+		},
+
+		// Test 3 ensures no master is created as long as existing masters did not
+		// join the etcd cluster.
+		{
+			CurrentState: []*v1beta1.Deployment{
+				newTestDeployment("master-1", key.MasterID, ""),
+			},
+			DesiredState: []*v1beta1.Deployment{
+				newTestDeployment("master-1", key.MasterID, ""),
+				newTestDeployment("master-2", key.MasterID, ""),
+				newTestDeployment("master-3", key.MasterID, ""),
+			},
+			ExpectedDeploymentNames: nil,
+		},
+
+		// Test 4 ensures the next master is created once existing masters joined
+		// the etcd cluster.
+		{
+			CurrentState: []*v1beta1.Deployment{
+				withAnnotation(newTestDeployment("master-1", key.MasterID, ""), key.AnnotationEtcdMember, "True"),
+			},
+			DesiredState: []*v1beta1.Deployment{
+				newTestDeployment("master-1", key.MasterID, ""),
+				newTestDeployment("master-2", key.MasterID, ""),
+				newTestDeployment("master-3", key.MasterID, ""),
+			},
+			ExpectedDeploymentNames: []string{"master-2"},
+		},
+	}
+
+	for i, tc := range testCases {
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.ClusterStatus = clusterstatustest.New()
+			resourceConfig.CustomerQuota = customerquotatest.New()
+			resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+			resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
+			resourceConfig.K8sClient = fake.NewSimpleClientset()
+			resourceConfig.Logger = microloggertest.New()
+			resourceConfig.QuorumGuard = quorumguardtest.New()
+
+			var err error
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		result, err := newResource.newCreateChange(context.TODO(), &v1alpha1.KVMConfig{}, tc.CurrentState, tc.DesiredState)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		deployments, ok := result.([]*v1beta1.Deployment)
+		if !ok {
+			t.Fatalf("case %d expected %T got %T", i+1, []*v1beta1.Deployment{}, result)
+		}
+
+		var names []string
+		for _, d := range deployments {
+			names = append(names, d.GetName())
+		}
+		if !reflect.DeepEqual(names, tc.ExpectedDeploymentNames) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedDeploymentNames, names)
+		}
+	}
+}
@@ -38,6 +38,7 @@ func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interf
 			}
 
 			r.updateVersionBundleVersionGauge(ctx, customObject, metric.VersionBundleVersionGauge, currentDeployments)
+			r.updateStatusReplicas(ctx, customObject, currentDeployments)
 		}
 	}
 
@@ -46,6 +47,29 @@ func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interf
 	return currentDeployments, nil
 }
 
+// updateStatusReplicas reports the number of available workers in the status
+// of the custom object, which is exposed through the scale subresource of the
+// KVMConfig CRD. Only the workers scaled using the scale subresource are
+// counted, so the status replicas match the spec replicas once all of them
+// are available. Failures are only logged, because they must not block the
+// reconciliation of the guest cluster.
+func (r *Resource) updateStatusReplicas(ctx context.Context, customObject v1alpha1.KVMConfig, deployments []*v1beta1.Deployment) {
+	var replicas int
+	for _, d := range deployments {
+		if !isWorkerDeployment(d) || !key.IsScaledWorkerNode(d.GetLabels()[key.NodePoolLabel]) {
+			continue
+		}
+		if d.Status.AvailableReplicas > 0 {
+			replicas++
+		}
+	}
+
+	err := r.clusterStatus.SetReplicas(ctx, customObject, replicas)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "cannot set status replicas", "stack", fmt.Sprintf("%#v", err))
+	}
+}
+
 func (r *Resource) updateVersionBundleVersionGauge(ctx context.Context, customObject v1alpha1.KVMConfig, gauge *prometheus.GaugeVec, deployments []*v1beta1.Deployment) {
 	versionCounts := map[string]float64{}
 
@@ -0,0 +1,113 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"k8s.io/api/extensions/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota/customerquotatest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard/quorumguardtest"
+)
+
+func Test_Resource_Deployment_updateStatusReplicas(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	deployments := []*v1beta1.Deployment{
+		{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: "master-5xchu",
+				Labels: map[string]string{
+					"app": "master",
+				},
+			},
+			Status: v1beta1.DeploymentStatus{
+				AvailableReplicas: 1,
+			},
+		},
+		{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: "worker-al9qy",
+				Labels: map[string]string{
+					"app": "worker",
+				},
+			},
+			Status: v1beta1.DeploymentStatus{
+				AvailableReplicas: 1,
+			},
+		},
+		{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: "worker-p7jqk",
+				Labels: map[string]string{
+					"app": "worker",
+				},
+			},
+			Status: v1beta1.DeploymentStatus{
+				AvailableReplicas: 0,
+			},
+		},
+		{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: "worker-default-0",
+				Labels: map[string]string{
+					"app":             "worker",
+					key.NodePoolLabel: "default",
+				},
+			},
+			Status: v1beta1.DeploymentStatus{
+				AvailableReplicas: 1,
+			},
+		},
+		{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: "worker-scaled-0",
+				Labels: map[string]string{
+					"app":             "worker",
+					key.NodePoolLabel: key.ScaledNodePoolName,
+				},
+			},
+			Status: v1beta1.DeploymentStatus{
+				AvailableReplicas: 1,
+			},
+		},
+	}
+
+	clusterStatus := clusterstatustest.New()
+
+	resourceConfig := DefaultConfig()
+	resourceConfig.ClusterStatus = clusterStatus
+	resourceConfig.CustomerQuota = customerquotatest.New()
+	resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+	resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
+	resourceConfig.K8sClient = fake.NewSimpleClientset()
+	resourceConfig.Logger = microloggertest.New()
+	resourceConfig.QuorumGuard = quorumguardtest.New()
+	newResource, err := New(resourceConfig)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	newResource.updateStatusReplicas(context.TODO(), customObject, deployments)
+
+	if len(clusterStatus.Replicas) != 1 {
+		t.Fatalf("expected %d replicas updates got %d", 1, len(clusterStatus.Replicas))
+	}
+	if clusterStatus.Replicas[0] != 2 {
+		t.Fatalf("expected %d replicas got %d", 2, clusterStatus.Replicas[0])
+	}
+}
@@ -0,0 +1,137 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+// enforceCustomerQuota drops the given deployments to be created and the
+// deployments to be updated with more resources for their VMs in case the
+// customer of the guest cluster is over its quota, so the guest cluster
+// neither grows nor gets resized. Other updates like certificate rolls and
+// upgrades still go through. Deployments are still deleted, which is how
+// customers get back within their quota. The customer quota is checked on
+// every reconciliation, so its metrics and the status of the guest cluster
+// stay up to date.
+func (r *Resource) enforceCustomerQuota(ctx context.Context, obj, currentState, createChange, updateChange interface{}) (interface{}, interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+
+	decision, err := r.customerQuota.Check(ctx, customObject)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+
+	if decision.Enforced {
+		condition := clusterstatus.Condition{
+			Type:    clusterstatus.ConditionCustomerWithinQuota,
+			Message: decision.Reason,
+		}
+		if decision.Allowed {
+			condition.Status = clusterstatus.ConditionStatusTrue
+			condition.Reason = "WithinQuota"
+		} else {
+			condition.Status = clusterstatus.ConditionStatusFalse
+			condition.Reason = "QuotaExceeded"
+		}
+
+		err := r.clusterStatus.SetCondition(ctx, customObject, condition)
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cannot set status condition '%s'", condition.Type), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if decision.Allowed {
+		return createChange, updateChange, nil
+	}
+
+	currentDeployments, err := toDeployments(currentState)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+	deploymentsToCreate, err := toDeployments(createChange)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+	deploymentsToUpdate, err := toDeployments(updateChange)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+
+	for _, d := range deploymentsToCreate {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not creating deployment '%s': %s", d.GetName(), decision.Reason))
+	}
+
+	var allowedUpdates []*v1beta1.Deployment
+	for _, d := range deploymentsToUpdate {
+		current, err := getDeploymentByName(currentDeployments, d.GetName())
+		if IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return nil, nil, microerror.Mask(err)
+		}
+
+		if isVMResourceIncreased(current, d) {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not updating deployment '%s' with more resources: %s", d.GetName(), decision.Reason))
+			continue
+		}
+
+		allowedUpdates = append(allowedUpdates, d)
+	}
+
+	return nil, allowedUpdates, nil
+}
+
+// isVMResourceIncreased checks whether the desired deployment requests more
+// CPUs, memory or disk for its VM than the current deployment. Deployments not
+// running a VM never increase the resources of the guest cluster.
+func isVMResourceIncreased(current, desired *v1beta1.Deployment) bool {
+	desiredCPU, desiredMemory, desiredDisk, ok := vmResources(desired)
+	if !ok {
+		return false
+	}
+	if current == nil {
+		return true
+	}
+	currentCPU, currentMemory, currentDisk, ok := vmResources(current)
+	if !ok {
+		return true
+	}
+
+	return desiredCPU.Cmp(currentCPU) > 0 || desiredMemory.Cmp(currentMemory) > 0 || desiredDisk.Cmp(currentDisk) > 0
+}
+
+// vmResources returns the CPUs, memory and disk the k8s-kvm container of the
+// given deployment requests for its VM. The returned bool is false in case
+// the deployment does not run a VM.
+func vmResources(deployment *v1beta1.Deployment) (resource.Quantity, resource.Quantity, resource.Quantity, bool) {
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		if c.Name != key.K8SKVMContainerName {
+			continue
+		}
+
+		var disk resource.Quantity
+		for _, e := range c.Env {
+			if e.Name != "DISK" {
+				continue
+			}
+			q, err := resource.ParseQuantity(e.Value)
+			if err == nil {
+				disk = q
+			}
+		}
+
+		return *c.Resources.Requests.Cpu(), *c.Resources.Requests.Memory(), disk, true
+	}
+
+	return resource.Quantity{}, resource.Quantity{}, resource.Quantity{}, false
+}
@@ -0,0 +1,179 @@
+package deployment
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota/customerquotatest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard/quorumguardtest"
+)
+
+func Test_Resource_Deployment_enforceCustomerQuota(t *testing.T) {
+	customObject := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	current := []*v1beta1.Deployment{
+		withVMResources(newTestDeployment("worker-w1", key.WorkerID, ""), "2", "2Gi", "20G"),
+		newTestDeployment(key.NodeControllerID, key.NodeControllerID, ""),
+	}
+
+	testCases := []struct {
+		Exceeded           bool
+		CreateChange       []*v1beta1.Deployment
+		UpdateChange       []*v1beta1.Deployment
+		ExpectedCreated    []string
+		ExpectedUpdated    []string
+		ExpectedConditions []string
+	}{
+		// Test 0 ensures deployments are created and updated as computed in case
+		// the customer is within its quota.
+		{
+			Exceeded:           false,
+			CreateChange:       []*v1beta1.Deployment{withVMResources(newTestDeployment("worker-w2", key.WorkerID, ""), "2", "2Gi", "20G")},
+			UpdateChange:       []*v1beta1.Deployment{withVMResources(newTestDeployment("worker-w1", key.WorkerID, ""), "4", "4Gi", "40G")},
+			ExpectedCreated:    []string{"worker-w2"},
+			ExpectedUpdated:    []string{"worker-w1"},
+			ExpectedConditions: nil,
+		},
+
+		// Test 1 ensures no deployments are created in case the customer is over
+		// its quota, which is reported in the status. Updates not increasing the
+		// resources of VMs, like certificate rolls, still go through.
+		{
+			Exceeded:           true,
+			CreateChange:       []*v1beta1.Deployment{withVMResources(newTestDeployment("worker-w2", key.WorkerID, ""), "2", "2Gi", "20G")},
+			UpdateChange:       []*v1beta1.Deployment{withVMResources(newTestDeployment("worker-w1", key.WorkerID, ""), "2", "2Gi", "20G")},
+			ExpectedCreated:    nil,
+			ExpectedUpdated:    []string{"worker-w1"},
+			ExpectedConditions: []string{clusterstatus.ConditionStatusFalse},
+		},
+
+		// Test 2 ensures the status is reported in case the customer is over its
+		// quota even when there are no deployments to be created or updated.
+		{
+			Exceeded:           true,
+			CreateChange:       nil,
+			UpdateChange:       nil,
+			ExpectedCreated:    nil,
+			ExpectedUpdated:    nil,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusFalse},
+		},
+
+		// Test 3 ensures deployments are not updated with more CPUs, memory or
+		// disk in case the customer is over its quota.
+		{
+			Exceeded:     true,
+			CreateChange: nil,
+			UpdateChange: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, ""), "4", "2Gi", "20G"),
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, ""), "2", "4Gi", "20G"),
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, ""), "2", "2Gi", "40G"),
+			},
+			ExpectedCreated:    nil,
+			ExpectedUpdated:    nil,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusFalse},
+		},
+
+		// Test 4 ensures deployments shrinking their VMs and deployments not
+		// running VMs are updated in case the customer is over its quota.
+		{
+			Exceeded:     true,
+			CreateChange: nil,
+			UpdateChange: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, ""), "1", "1Gi", "20G"),
+				newTestDeployment(key.NodeControllerID, key.NodeControllerID, ""),
+			},
+			ExpectedCreated:    nil,
+			ExpectedUpdated:    []string{"worker-w1", key.NodeControllerID},
+			ExpectedConditions: []string{clusterstatus.ConditionStatusFalse},
+		},
+	}
+
+	for i, tc := range testCases {
+		clusterStatus := clusterstatustest.New()
+		customerQuota := customerquotatest.New()
+		customerQuota.Exceeded = tc.Exceeded
+
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.ClusterStatus = clusterStatus
+			resourceConfig.CustomerQuota = customerQuota
+			resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+			resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
+			resourceConfig.K8sClient = fake.NewSimpleClientset()
+			resourceConfig.Logger = microloggertest.New()
+			resourceConfig.QuorumGuard = quorumguardtest.New()
+
+			var err error
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		createChange, updateChange, err := newResource.enforceCustomerQuota(context.TODO(), customObject, current, tc.CreateChange, tc.UpdateChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		if len(customerQuota.Checked) != 1 || customerQuota.Checked[0] != "al9qy" {
+			t.Fatalf("case %d expected %#v got %#v", i, []string{"al9qy"}, customerQuota.Checked)
+		}
+
+		created, err := toDeployments(createChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		var createdNames []string
+		for _, d := range created {
+			createdNames = append(createdNames, d.GetName())
+		}
+		if !reflect.DeepEqual(createdNames, tc.ExpectedCreated) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedCreated, createdNames)
+		}
+		updated, err := toDeployments(updateChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		var updatedNames []string
+		for _, d := range updated {
+			updatedNames = append(updatedNames, d.GetName())
+		}
+		if !reflect.DeepEqual(updatedNames, tc.ExpectedUpdated) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedUpdated, updatedNames)
+		}
+
+		var conditions []string
+		for _, c := range clusterStatus.Conditions {
+			if c.Type != clusterstatus.ConditionCustomerWithinQuota {
+				t.Fatalf("case %d expected %#v got %#v", i, clusterstatus.ConditionCustomerWithinQuota, c.Type)
+			}
+			conditions = append(conditions, c.Status)
+		}
+		if len(conditions) != len(tc.ExpectedConditions) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedConditions, conditions)
+		}
+		for j := range conditions {
+			if conditions[j] != tc.ExpectedConditions[j] {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedConditions, conditions)
+			}
+		}
+	}
+}
@@ -27,6 +27,21 @@ func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteChange inte
 		r.logger.LogCtx(ctx, "level", "debug", "message", "deleting the deployments in the Kubernetes API")
 
 		for _, deployment := range deploymentsToDelete {
+			// Workers removed while the guest cluster keeps running are drained
+			// first, so their workloads are moved gracefully. The deployment is only
+			// deleted once the guest cluster node is drained. Until then it is kept
+			// and checked again on the next reconciliation.
+			if isWorkerDeployment(deployment) && !key.IsDeleted(customObject) {
+				drained, err := r.drainWorker(ctx, customObject, deployment)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+				if !drained {
+					r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not deleting deployment '%s': guest cluster node is not drained yet", deployment.GetName()))
+					continue
+				}
+			}
+
 			n := key.ClusterNamespace(customObject)
 			err := r.k8sClient.Extensions().Deployments(n).Delete(deployment.Name, newDeleteOptions())
 			if apierrors.IsNotFound(err) {
@@ -84,7 +99,10 @@ func (r *Resource) newDeleteChangeForDeletePatch(ctx context.Context, obj, curre
 }
 
 // newDeleteChangeForUpdatePatch is used on update events to scale down
-// deployments.
+// deployments. At most ScaleDownMaxWorkers worker deployments are removed per
+// reconciliation. Which node pool workers are removed is decided when
+// computing the desired workers. See the scaledown package. Masters are only
+// removed once they left the etcd cluster. See the etcdmember resource.
 func (r *Resource) newDeleteChangeForUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
 	currentDeployments, err := toDeployments(currentState)
 	if err != nil {
@@ -98,11 +116,31 @@ func (r *Resource) newDeleteChangeForUpdatePatch(ctx context.Context, obj, curre
 	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which deployments have to be deleted")
 
 	var deploymentsToDelete []*v1beta1.Deployment
+	var workersToDelete int
 
 	for _, currentDeployment := range currentDeployments {
-		if !containsDeployment(desiredDeployments, currentDeployment) {
-			deploymentsToDelete = append(deploymentsToDelete, currentDeployment)
+		if containsDeployment(desiredDeployments, currentDeployment) {
+			continue
+		}
+		if isReplacing(currentDeployments, currentDeployment) {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not deleting deployment '%s': replacement is in progress", currentDeployment.GetName()))
+			continue
 		}
+
+		if isMasterDeployment(currentDeployment) && currentDeployment.GetAnnotations()[key.AnnotationEtcdMember] != "False" {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not deleting deployment '%s': master is not removed from the etcd cluster yet", currentDeployment.GetName()))
+			continue
+		}
+
+		if isWorkerDeployment(currentDeployment) {
+			if workersToDelete >= r.scaleDownMaxWorkers {
+				r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not deleting deployment '%s': reached the maximum of %d workers removed per reconciliation", currentDeployment.GetName(), r.scaleDownMaxWorkers))
+				continue
+			}
+			workersToDelete++
+		}
+
+		deploymentsToDelete = append(deploymentsToDelete, currentDeployment)
 	}
 
 	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d deployments that have to be deleted", len(deploymentsToDelete)))
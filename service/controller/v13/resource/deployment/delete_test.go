@@ -2,13 +2,21 @@ package deployment
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
 	"github.com/giantswarm/micrologger/microloggertest"
 	"k8s.io/api/extensions/v1beta1"
 	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota/customerquotatest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard/quorumguardtest"
 )
 
 func Test_Resource_Deployment_newDeleteChange(t *testing.T) {
@@ -263,8 +271,13 @@ func Test_Resource_Deployment_newDeleteChange(t *testing.T) {
 	var newResource *Resource
 	{
 		resourceConfig := DefaultConfig()
+		resourceConfig.ClusterStatus = clusterstatustest.New()
+		resourceConfig.CustomerQuota = customerquotatest.New()
+		resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+		resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
 		resourceConfig.K8sClient = fake.NewSimpleClientset()
 		resourceConfig.Logger = microloggertest.New()
+		resourceConfig.QuorumGuard = quorumguardtest.New()
 		newResource, err = New(resourceConfig)
 		if err != nil {
 			t.Fatal("expected", nil, "got", err)
@@ -287,3 +300,119 @@ func Test_Resource_Deployment_newDeleteChange(t *testing.T) {
 		}
 	}
 }
+
+func Test_Resource_Deployment_newDeleteChangeForUpdatePatch(t *testing.T) {
+	testCases := []struct {
+		ScaleDownMaxWorkers     int
+		CurrentState            interface{}
+		DesiredState            interface{}
+		ExpectedDeploymentNames []string
+	}{
+		// Test 1 ensures deployments being desired are not deleted.
+		{
+			ScaleDownMaxWorkers: 1,
+			CurrentState: []*v1beta1.Deployment{
+				newTestDeployment("master-1", key.MasterID, ""),
+				newTestDeployment("worker-1", key.WorkerID, ""),
+			},
+			DesiredState: []*v1beta1.Deployment{
+				newTestDeployment("master-1", key.MasterID, ""),
+				newTestDeployment("worker-1", key.WorkerID, ""),
+			},
+			ExpectedDeploymentNames: nil,
+		},
+
+		// Test 2 ensures at most one worker deployment is deleted per
+		// reconciliation while other deployments are not limited.
+		{
+			ScaleDownMaxWorkers: 1,
+			CurrentState: []*v1beta1.Deployment{
+				withAnnotation(newTestDeployment("master-1", key.MasterID, ""), key.AnnotationEtcdMember, "False"),
+				withAnnotation(newTestDeployment("master-2", key.MasterID, ""), key.AnnotationEtcdMember, "False"),
+				newTestDeployment("worker-1", key.WorkerID, ""),
+				newTestDeployment("worker-2", key.WorkerID, ""),
+				newTestDeployment("worker-3", key.WorkerID, ""),
+			},
+			DesiredState: []*v1beta1.Deployment{
+				newTestDeployment("worker-1", key.WorkerID, ""),
+			},
+			ExpectedDeploymentNames: []string{
+				"master-1",
+				"master-2",
+				"worker-2",
+			},
+		},
+
+		// Test 3 ensures the number of worker deployments deleted per
+		// reconciliation is configurable.
+		{
+			ScaleDownMaxWorkers: 2,
+			CurrentState: []*v1beta1.Deployment{
+				newTestDeployment("worker-1", key.WorkerID, ""),
+				newTestDeployment("worker-2", key.WorkerID, ""),
+				newTestDeployment("worker-3", key.WorkerID, ""),
+			},
+			DesiredState: []*v1beta1.Deployment{},
+			ExpectedDeploymentNames: []string{
+				"worker-1",
+				"worker-2",
+			},
+		},
+
+		// Test 4 ensures master deployments are only deleted once their masters
+		// were removed from the etcd cluster.
+		{
+			ScaleDownMaxWorkers: 1,
+			CurrentState: []*v1beta1.Deployment{
+				withAnnotation(newTestDeployment("master-1", key.MasterID, ""), key.AnnotationEtcdMember, "True"),
+				withAnnotation(newTestDeployment("master-2", key.MasterID, ""), key.AnnotationEtcdMember, "True"),
+				withAnnotation(newTestDeployment("master-3", key.MasterID, ""), key.AnnotationEtcdMember, "False"),
+			},
+			DesiredState: []*v1beta1.Deployment{
+				withAnnotation(newTestDeployment("master-1", key.MasterID, ""), key.AnnotationEtcdMember, "True"),
+			},
+			ExpectedDeploymentNames: []string{
+				"master-3",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.ClusterStatus = clusterstatustest.New()
+			resourceConfig.CustomerQuota = customerquotatest.New()
+			resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+			resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
+			resourceConfig.K8sClient = fake.NewSimpleClientset()
+			resourceConfig.Logger = microloggertest.New()
+			resourceConfig.QuorumGuard = quorumguardtest.New()
+			resourceConfig.ScaleDownMaxWorkers = tc.ScaleDownMaxWorkers
+
+			var err error
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		result, err := newResource.newDeleteChangeForUpdatePatch(context.TODO(), &v1alpha1.KVMConfig{}, tc.CurrentState, tc.DesiredState)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		deployments, ok := result.([]*v1beta1.Deployment)
+		if !ok {
+			t.Fatalf("case %d expected %T got %T", i+1, []*v1beta1.Deployment{}, result)
+		}
+
+		var names []string
+		for _, d := range deployments {
+			names = append(names, d.GetName())
+		}
+		if !reflect.DeepEqual(names, tc.ExpectedDeploymentNames) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedDeploymentNames, names)
+		}
+	}
+}
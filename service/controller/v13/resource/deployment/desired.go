@@ -8,6 +8,7 @@ import (
 	"k8s.io/api/extensions/v1beta1"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/workerscontext"
 )
 
 func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interface{}, error) {
@@ -27,7 +28,15 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 		}
 		deployments = append(deployments, masterDeployments...)
 
-		workerDeployments, err := newWorkerDeployments(customObject)
+		workers, ok := workerscontext.FromContext(ctx)
+		if !ok {
+			workers, err = key.WorkerNodes(customObject)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		workerDeployments, err := newWorkerDeployments(customObject, workers)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
@@ -40,6 +49,34 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 		deployments = append(deployments, nodeControllerDeployment)
 	}
 
+	setRegistryMirror(deployments, r.registryMirror)
+	setSecurityProfiles(deployments, r.appArmorProfile)
+
+	if r.cloudConfigCompress {
+		setCloudConfigEncoding(deployments, key.CloudConfigEncoding(r.cloudConfigCompress))
+	}
+	if r.cloudConfigSecret {
+		setCloudConfigSecret(deployments)
+	}
+
+	auditHostVolume, err := key.AuditHostVolume(customObject, r.auditHostVolume)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if auditHostVolume {
+		setAuditLogVolume(deployments, key.ClusterID(customObject))
+	}
+
+	err = setVMSpecHash(deployments)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = r.setCloudConfigHash(key.ClusterNamespace(customObject), deployments)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
 	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("computed the %d new deployments", len(deployments)))
 
 	return deployments, nil
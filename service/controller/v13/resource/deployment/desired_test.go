@@ -6,11 +6,19 @@ import (
 	"testing"
 
 	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
 	"github.com/giantswarm/micrologger/microloggertest"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota/customerquotatest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard/quorumguardtest"
 )
 
 func Test_Resource_Deployment_GetDesiredState(t *testing.T) {
@@ -261,14 +269,100 @@ func Test_Resource_Deployment_GetDesiredState(t *testing.T) {
 				},
 			},
 		},
+
+		// Test 3 ensures node pools are expanded into one deployment per worker
+		// in addition to the workers defined in the spec.
+		{
+			Obj: &v1alpha1.KVMConfig{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Annotations: map[string]string{
+						key.AnnotationNodePools: `[{"name": "default", "replicas": 2, "size": {"cpus": 2, "disk": 20, "memory": "4G"}}]`,
+					},
+				},
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+						Masters: []v1alpha1.ClusterNode{
+							{},
+						},
+						Workers: []v1alpha1.ClusterNode{
+							{},
+						},
+					},
+					KVM: v1alpha1.KVMConfigSpecKVM{
+						K8sKVM: v1alpha1.KVMConfigSpecKVMK8sKVM{
+							StorageType: "hostPath",
+						},
+						Masters: []v1alpha1.KVMConfigSpecKVMNode{
+							{CPUs: 1, Memory: "1G"},
+						},
+						Workers: []v1alpha1.KVMConfigSpecKVMNode{
+							{CPUs: 4, Memory: "8G"},
+						},
+					},
+				},
+			},
+			ExpectedMasterCount:   1,
+			ExpectedNodeCtrlCount: 1,
+			ExpectedWorkerCount:   3,
+			ExpectedMastersResources: []apiv1.ResourceRequirements{
+				{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("1"),
+						apiv1.ResourceMemory: resource.MustParse("2G"),
+					},
+					Limits: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("1"),
+						apiv1.ResourceMemory: resource.MustParse("2G"),
+					},
+				},
+			},
+			ExpectedWorkersResources: []apiv1.ResourceRequirements{
+				{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("4"),
+						apiv1.ResourceMemory: resource.MustParse("9728M"),
+					},
+					Limits: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("4"),
+						apiv1.ResourceMemory: resource.MustParse("9728M"),
+					},
+				},
+				{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("2"),
+						apiv1.ResourceMemory: resource.MustParse("5632M"),
+					},
+					Limits: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("2"),
+						apiv1.ResourceMemory: resource.MustParse("5632M"),
+					},
+				},
+				{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("2"),
+						apiv1.ResourceMemory: resource.MustParse("5632M"),
+					},
+					Limits: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("2"),
+						apiv1.ResourceMemory: resource.MustParse("5632M"),
+					},
+				},
+			},
+		},
 	}
 
 	var err error
 	var newResource *Resource
 	{
 		resourceConfig := DefaultConfig()
+		resourceConfig.ClusterStatus = clusterstatustest.New()
+		resourceConfig.CustomerQuota = customerquotatest.New()
+		resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+		resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
 		resourceConfig.K8sClient = fake.NewSimpleClientset()
 		resourceConfig.Logger = microloggertest.New()
+		resourceConfig.QuorumGuard = quorumguardtest.New()
 		newResource, err = New(resourceConfig)
 		if err != nil {
 			t.Fatal("expected", nil, "got", err)
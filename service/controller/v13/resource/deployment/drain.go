@@ -0,0 +1,147 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	corev1alpha1 "github.com/giantswarm/apiextensions/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+// drainWorker ensures the guest cluster nodes of the pods of the given worker
+// deployment are drained using the node configs processed by the
+// node-operator. It returns true as soon as all pods are drained and the
+// deployment can be deleted. Drained pods are annotated accordingly, so the
+// drainer controller does not drain them again once they get deleted.
+func (r *Resource) drainWorker(ctx context.Context, customObject v1alpha1.KVMConfig, deployment *v1beta1.Deployment) (bool, error) {
+	pods, err := r.workerPods(customObject, deployment)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	drained := true
+	for _, p := range pods {
+		pod := p
+
+		ok, err := r.drainPod(ctx, &pod)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+		if !ok {
+			drained = false
+		}
+	}
+
+	return drained, nil
+}
+
+func (r *Resource) drainPod(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	isDrained, err := key.IsPodDraind(pod)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	if isDrained {
+		return true, nil
+	}
+
+	n := pod.GetNamespace()
+	p := pod.GetName()
+
+	{
+		nodeConfig, err := r.g8sClient.CoreV1alpha1().NodeConfigs(n).Get(p, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("creating node config for guest cluster node '%s'", p))
+
+			nodeConfig, err := newNodeConfig(pod)
+			if err != nil {
+				return false, microerror.Mask(err)
+			}
+
+			_, err = r.g8sClient.CoreV1alpha1().NodeConfigs(n).Create(nodeConfig)
+			if err != nil {
+				return false, microerror.Mask(err)
+			}
+
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("created node config for guest cluster node '%s'", p))
+
+			return false, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		if !nodeConfig.Status.HasFinalCondition() {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("node config of guest cluster node '%s' has no final state", p))
+
+			return false, nil
+		}
+	}
+
+	{
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleting node config for guest cluster node '%s'", p))
+
+		err := r.g8sClient.CoreV1alpha1().NodeConfigs(n).Delete(p, &metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted node config for guest cluster node '%s'", p))
+	}
+
+	{
+		a := pod.GetAnnotations()
+		a[key.AnnotationPodDrained] = "True"
+		pod.SetAnnotations(a)
+
+		_, err := r.k8sClient.CoreV1().Pods(n).Update(pod)
+		if apierrors.IsConflict(err) {
+			// The pod may be updated by other processes meanwhile. We try again on
+			// the next reconciliation. The guest cluster node is drained already
+			// then and a new node config finishes right away.
+			return false, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+	}
+
+	return true, nil
+}
+
+func newNodeConfig(pod *corev1.Pod) (*corev1alpha1.NodeConfig, error) {
+	apiEndpoint, err := key.ClusterAPIEndpointFromPod(pod)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	nodeConfig := &corev1alpha1.NodeConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: pod.GetName(),
+		},
+		Spec: corev1alpha1.NodeConfigSpec{
+			Guest: corev1alpha1.NodeConfigSpecGuest{
+				Cluster: corev1alpha1.NodeConfigSpecGuestCluster{
+					API: corev1alpha1.NodeConfigSpecGuestClusterAPI{
+						Endpoint: apiEndpoint,
+					},
+					ID: pod.GetNamespace(),
+				},
+				Node: corev1alpha1.NodeConfigSpecGuestNode{
+					Name: pod.GetName(),
+				},
+			},
+			VersionBundle: corev1alpha1.NodeConfigSpecVersionBundle{
+				Version: "0.1.0",
+			},
+		},
+	}
+
+	return nodeConfig, nil
+}
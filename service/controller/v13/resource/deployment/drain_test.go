@@ -0,0 +1,188 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	corev1alpha1 "github.com/giantswarm/apiextensions/pkg/apis/core/v1alpha1"
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota/customerquotatest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard/quorumguardtest"
+)
+
+func Test_Resource_Deployment_drainWorker(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	deployment := &v1beta1.Deployment{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name: "worker-gpu-1",
+			Labels: map[string]string{
+				"app":  key.WorkerID,
+				"node": "gpu-1",
+			},
+		},
+	}
+
+	newPod := func(drained string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      "worker-gpu-1-6d4b7c9f8-5f8c9",
+				Namespace: "al9qy",
+				Annotations: map[string]string{
+					key.AnnotationAPIEndpoint: "api.al9qy.example.com",
+					key.AnnotationPodDrained:  drained,
+				},
+				Labels: map[string]string{
+					"app":  key.WorkerID,
+					"node": "gpu-1",
+				},
+			},
+		}
+	}
+
+	newNodeConfig := func(conditions ...corev1alpha1.NodeConfigStatusCondition) *corev1alpha1.NodeConfig {
+		return &corev1alpha1.NodeConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      "worker-gpu-1-6d4b7c9f8-5f8c9",
+				Namespace: "al9qy",
+			},
+			Status: corev1alpha1.NodeConfigStatus{
+				Conditions: conditions,
+			},
+		}
+	}
+
+	testCases := []struct {
+		K8sObjects         []runtime.Object
+		G8sObjects         []runtime.Object
+		ExpectedDrained    bool
+		ExpectedNodeConfig bool
+		ExpectedPodDrained string
+	}{
+		// Test 1 ensures a worker without pods can be deleted right away.
+		{
+			K8sObjects:         nil,
+			G8sObjects:         nil,
+			ExpectedDrained:    true,
+			ExpectedNodeConfig: false,
+		},
+
+		// Test 2 ensures a node config is created for a pod not being drained yet
+		// and the worker is not deleted yet.
+		{
+			K8sObjects: []runtime.Object{
+				newPod("False"),
+			},
+			G8sObjects:         nil,
+			ExpectedDrained:    false,
+			ExpectedNodeConfig: true,
+			ExpectedPodDrained: "False",
+		},
+
+		// Test 3 ensures the worker is not deleted as long as the node config has
+		// no final state.
+		{
+			K8sObjects: []runtime.Object{
+				newPod("False"),
+			},
+			G8sObjects: []runtime.Object{
+				newNodeConfig(),
+			},
+			ExpectedDrained:    false,
+			ExpectedNodeConfig: true,
+			ExpectedPodDrained: "False",
+		},
+
+		// Test 4 ensures the node config is deleted and the pod is marked drained
+		// once the node config has a final state.
+		{
+			K8sObjects: []runtime.Object{
+				newPod("False"),
+			},
+			G8sObjects: []runtime.Object{
+				newNodeConfig(corev1alpha1.NodeConfigStatus{}.NewFinalCondition()),
+			},
+			ExpectedDrained:    true,
+			ExpectedNodeConfig: false,
+			ExpectedPodDrained: "True",
+		},
+
+		// Test 5 ensures a pod already drained is not drained again.
+		{
+			K8sObjects: []runtime.Object{
+				newPod("True"),
+			},
+			G8sObjects:         nil,
+			ExpectedDrained:    true,
+			ExpectedNodeConfig: false,
+			ExpectedPodDrained: "True",
+		},
+	}
+
+	for i, tc := range testCases {
+		k8sClient := fake.NewSimpleClientset(tc.K8sObjects...)
+		g8sClient := g8sfake.NewSimpleClientset(tc.G8sObjects...)
+
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.ClusterStatus = clusterstatustest.New()
+			resourceConfig.CustomerQuota = customerquotatest.New()
+			resourceConfig.G8sClient = g8sClient
+			resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
+			resourceConfig.K8sClient = k8sClient
+			resourceConfig.Logger = microloggertest.New()
+			resourceConfig.QuorumGuard = quorumguardtest.New()
+
+			var err error
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		drained, err := newResource.drainWorker(context.TODO(), customObject, deployment)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+		if drained != tc.ExpectedDrained {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedDrained, drained)
+		}
+
+		_, err = g8sClient.CoreV1alpha1().NodeConfigs("al9qy").Get("worker-gpu-1-6d4b7c9f8-5f8c9", apismetav1.GetOptions{})
+		if tc.ExpectedNodeConfig && err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+		if !tc.ExpectedNodeConfig && !apierrors.IsNotFound(err) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, "not found error", err)
+		}
+
+		if tc.ExpectedPodDrained != "" {
+			pod, err := k8sClient.CoreV1().Pods("al9qy").Get("worker-gpu-1-6d4b7c9f8-5f8c9", apismetav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+			if pod.GetAnnotations()[key.AnnotationPodDrained] != tc.ExpectedPodDrained {
+				t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedPodDrained, pod.GetAnnotations()[key.AnnotationPodDrained])
+			}
+		}
+	}
+}
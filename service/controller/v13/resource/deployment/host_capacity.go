@@ -0,0 +1,370 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/metric"
+)
+
+// host is a host cluster node eligible for VM pods.
+type host struct {
+	Role        string
+	Allocatable resources
+	Requested   resources
+	// Nodes are the resources requested by the VM pods of the guest cluster
+	// being reconciled on the host, by the IDs of their guest cluster nodes.
+	// No further VM pods of the guest cluster fit on a host running one of
+	// them due to the pod anti-affinity of VM pods.
+	Nodes map[string]resources
+}
+
+func (h *host) free() resources {
+	cpu := h.Allocatable.CPU.DeepCopy()
+	cpu.Sub(h.Requested.CPU)
+	memory := h.Allocatable.Memory.DeepCopy()
+	memory.Sub(h.Requested.Memory)
+
+	return resources{CPU: cpu, Memory: memory}
+}
+
+func (h *host) fits(r resources) bool {
+	free := h.free()
+	return free.CPU.Cmp(r.CPU) >= 0 && free.Memory.Cmp(r.Memory) >= 0
+}
+
+type resources struct {
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+func (r *resources) add(o resources) {
+	r.CPU.Add(o.CPU)
+	r.Memory.Add(o.Memory)
+}
+
+func (r *resources) sub(o resources) {
+	r.CPU.Sub(o.CPU)
+	r.Memory.Sub(o.Memory)
+}
+
+// vmPod is a VM pod which has to be scheduled for a deployment being created
+// or updated.
+type vmPod struct {
+	Deployment string
+	Node       string
+	Role       string
+	Requests   resources
+}
+
+// checkHostCapacity drops the given VM deployments to be created and updated
+// in case their VM pods do not fit on the hosts, so they do not stay pending
+// while the guest cluster is reconciled as if nothing was wrong. Deployments
+// not running VMs, like the one of the node controller, are still created and
+// updated. Deployments are still deleted, since that only frees capacity. The
+// capacity of all hosts is exported as metric on every reconciliation.
+//
+// VM pods fit in case each of them can be placed on a host of its role having
+// enough CPU and memory allocatable which is not requested by other pods,
+// while no two VM pods of the guest cluster are placed on the same host, as
+// the pod anti-affinity of VM pods demands. Deployments being updated in place
+// free the host their current VM pod runs on, since they are recreated. Workers
+// being replaced in surge mode need an additional host until their
+// replacement is created.
+func (r *Resource) checkHostCapacity(ctx context.Context, obj, currentState, createChange, updateChange interface{}) (interface{}, interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+	currentDeployments, err := toDeployments(currentState)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+	deploymentsToCreate, err := toDeployments(createChange)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+	deploymentsToUpdate, err := toDeployments(updateChange)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+
+	hosts, err := r.findHosts(ctx, customObject)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+
+	reportHostCapacity(hosts)
+
+	var pods []vmPod
+	for _, d := range deploymentsToCreate {
+		if !isMasterDeployment(d) && !isWorkerDeployment(d) {
+			continue
+		}
+
+		pods = append(pods, newVMPod(d))
+	}
+	for _, d := range deploymentsToUpdate {
+		if !isMasterDeployment(d) && !isWorkerDeployment(d) {
+			continue
+		}
+
+		if r.updateSurge && isWorkerDeployment(d) {
+			// The replacement of the worker does not need a host in case it got
+			// created already.
+			_, err := getDeploymentByName(currentDeployments, replacementName(d.GetName()))
+			if err == nil {
+				continue
+			} else if !IsNotFound(err) {
+				return nil, nil, microerror.Mask(err)
+			}
+		} else {
+			freeHost(hosts, d.GetLabels()["node"])
+		}
+
+		pods = append(pods, newVMPod(d))
+	}
+
+	var message string
+	if len(pods) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "finding out if the VM pods fit on the hosts")
+
+		message = placeVMPods(hosts, pods)
+	}
+
+	condition := clusterstatus.Condition{
+		Type: clusterstatus.ConditionHostCapacitySufficient,
+	}
+	if message == "" {
+		condition.Status = clusterstatus.ConditionStatusTrue
+		condition.Reason = "CapacitySufficient"
+		condition.Message = "the VM pods of the guest cluster fit on the hosts"
+	} else {
+		condition.Status = clusterstatus.ConditionStatusFalse
+		condition.Reason = "CapacityInsufficient"
+		condition.Message = message
+	}
+
+	err = r.clusterStatus.SetCondition(ctx, customObject, condition)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cannot set status condition '%s'", condition.Type), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	if message != "" {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not creating or updating VM deployments: %s", message))
+		return withoutVMDeployments(deploymentsToCreate), withoutVMDeployments(deploymentsToUpdate), nil
+	}
+
+	if len(pods) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "found out that the VM pods fit on the hosts")
+	}
+
+	return createChange, updateChange, nil
+}
+
+// findHosts returns the ready and schedulable hosts of all roles along with
+// the resources requested by the pods running on them.
+func (r *Resource) findHosts(ctx context.Context, customObject v1alpha1.KVMConfig) (map[string]*host, error) {
+	hosts := map[string]*host{}
+	{
+		o := metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("role in (%s,%s)", key.MasterID, key.WorkerID),
+		}
+		list, err := r.k8sClient.CoreV1().Nodes().List(o)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, n := range list.Items {
+			if n.Spec.Unschedulable || !isNodeReady(&n) {
+				continue
+			}
+
+			hosts[n.GetName()] = &host{
+				Role: n.GetLabels()["role"],
+				Allocatable: resources{
+					CPU:    n.Status.Allocatable[corev1.ResourceCPU],
+					Memory: n.Status.Allocatable[corev1.ResourceMemory],
+				},
+				Nodes: map[string]resources{},
+			}
+		}
+	}
+
+	{
+		// Only scheduled pods which did not finish request resources of hosts,
+		// so all others are filtered by the Kubernetes API already.
+		o := metav1.ListOptions{
+			FieldSelector: fmt.Sprintf("spec.nodeName!=,status.phase!=%s,status.phase!=%s", corev1.PodSucceeded, corev1.PodFailed),
+		}
+		list, err := r.k8sClient.CoreV1().Pods(metav1.NamespaceAll).List(o)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, p := range list.Items {
+			h, ok := hosts[p.Spec.NodeName]
+			if !ok {
+				continue
+			}
+
+			requests := podRequests(p.Spec)
+			h.Requested.add(requests)
+
+			if p.GetNamespace() == key.ClusterNamespace(customObject) && isVMPod(p.GetLabels()) {
+				n := h.Nodes[p.GetLabels()["node"]]
+				n.add(requests)
+				h.Nodes[p.GetLabels()["node"]] = n
+			}
+		}
+	}
+
+	return hosts, nil
+}
+
+// freeHost frees the host running the VM pod of the given guest cluster node,
+// which is recreated when its deployment is updated.
+func freeHost(hosts map[string]*host, node string) {
+	for _, h := range hosts {
+		requests, ok := h.Nodes[node]
+		if !ok {
+			continue
+		}
+
+		h.Requested.sub(requests)
+		delete(h.Nodes, node)
+	}
+}
+
+// placeVMPods places the given VM pods on the given hosts, largest VM pods
+// first, each on the host of its role having the least memory left which fits
+// it. It returns a message describing the first VM pod not fitting, or an
+// empty string in case all of them fit.
+func placeVMPods(hosts map[string]*host, pods []vmPod) string {
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[i].Requests.Memory.Cmp(pods[j].Requests.Memory) > 0
+	})
+
+	var names []string
+	for n := range hosts {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, p := range pods {
+		var best *host
+		for _, n := range names {
+			h := hosts[n]
+			if h.Role != p.Role || len(h.Nodes) != 0 || !h.fits(p.Requests) {
+				continue
+			}
+
+			if best == nil {
+				best = h
+				continue
+			}
+			free := h.free()
+			bestFree := best.free()
+			if free.Memory.Cmp(bestFree.Memory) < 0 {
+				best = h
+			}
+		}
+
+		if best == nil {
+			return fmt.Sprintf("no host with role '%s' not running a VM of the guest cluster has %s CPUs and %s memory available for deployment '%s'", p.Role, p.Requests.CPU.String(), p.Requests.Memory.String(), p.Deployment)
+		}
+
+		best.Requested.add(p.Requests)
+		best.Nodes[p.Node] = p.Requests
+	}
+
+	return ""
+}
+
+// reportHostCapacity exports the allocatable and free resources of the given
+// hosts per role as metric.
+func reportHostCapacity(hosts map[string]*host) {
+	for _, role := range []string{key.MasterID, key.WorkerID} {
+		var allocatable resources
+		var free resources
+		for _, h := range hosts {
+			if h.Role != role {
+				continue
+			}
+
+			allocatable.add(h.Allocatable)
+
+			f := h.free()
+			if f.CPU.Sign() > 0 {
+				free.CPU.Add(f.CPU)
+			}
+			if f.Memory.Sign() > 0 {
+				free.Memory.Add(f.Memory)
+			}
+		}
+
+		metric.HostCapacityGauge.WithLabelValues(role, "cpu", "allocatable").Set(float64(allocatable.CPU.MilliValue()) / 1000)
+		metric.HostCapacityGauge.WithLabelValues(role, "cpu", "free").Set(float64(free.CPU.MilliValue()) / 1000)
+		metric.HostCapacityGauge.WithLabelValues(role, "memory", "allocatable").Set(float64(allocatable.Memory.Value()))
+		metric.HostCapacityGauge.WithLabelValues(role, "memory", "free").Set(float64(free.Memory.Value()))
+	}
+}
+
+// withoutVMDeployments returns the given deployments except the ones running
+// VMs.
+func withoutVMDeployments(deployments []*v1beta1.Deployment) []*v1beta1.Deployment {
+	var filtered []*v1beta1.Deployment
+	for _, d := range deployments {
+		if isMasterDeployment(d) || isWorkerDeployment(d) {
+			continue
+		}
+
+		filtered = append(filtered, d)
+	}
+
+	return filtered
+}
+
+func isVMPod(labels map[string]string) bool {
+	return labels["app"] == key.MasterID || labels["app"] == key.WorkerID
+}
+
+func newVMPod(deployment *v1beta1.Deployment) vmPod {
+	return vmPod{
+		Deployment: deployment.GetName(),
+		Node:       deployment.GetLabels()["node"],
+		Role:       deployment.Spec.Template.Spec.NodeSelector["role"],
+		Requests:   podRequests(deployment.Spec.Template.Spec),
+	}
+}
+
+// podRequests returns the resources requested by the containers of the given
+// pod. Containers not defining requests request their limits, as Kubernetes
+// defaults them.
+func podRequests(spec corev1.PodSpec) resources {
+	var r resources
+
+	for _, c := range spec.Containers {
+		for name, q := range map[corev1.ResourceName]*resource.Quantity{corev1.ResourceCPU: &r.CPU, corev1.ResourceMemory: &r.Memory} {
+			v, ok := c.Resources.Requests[name]
+			if !ok {
+				v, ok = c.Resources.Limits[name]
+			}
+			if ok {
+				q.Add(v)
+			}
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,323 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota/customerquotatest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard/quorumguardtest"
+)
+
+func Test_Resource_Deployment_checkHostCapacity(t *testing.T) {
+	customObject := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	requests := func(cpu, memory string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		}
+	}
+
+	newHost := func(name, role, cpu, memory string, ready bool) *corev1.Node {
+		status := corev1.ConditionTrue
+		if !ready {
+			status = corev1.ConditionFalse
+		}
+		return &corev1.Node{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					"role": role,
+				},
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+				Conditions: []corev1.NodeCondition{
+					{
+						Type:   corev1.NodeReady,
+						Status: status,
+					},
+				},
+			},
+		}
+	}
+
+	newPod := func(namespace, name, host, role, node, cpu, memory string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels: map[string]string{
+					"app":  role,
+					"node": node,
+				},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: host,
+				Containers: []corev1.Container{
+					{
+						Name:      key.K8SKVMContainerName,
+						Resources: requests(cpu, memory),
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+			},
+		}
+	}
+
+	testCases := []struct {
+		UpdateSurge        bool
+		K8sObjects         []runtime.Object
+		CurrentState       []*v1beta1.Deployment
+		CreateChange       []*v1beta1.Deployment
+		UpdateChange       []*v1beta1.Deployment
+		ExpectedCreated    int
+		ExpectedUpdated    int
+		ExpectedConditions []string
+	}{
+		// Test 0 ensures deployments are created in case their VM pods fit on
+		// the hosts of their role.
+		{
+			UpdateSurge: false,
+			K8sObjects: []runtime.Object{
+				newHost("host1", key.MasterID, "4", "8Gi", true),
+				newHost("host2", key.WorkerID, "4", "8Gi", true),
+			},
+			CurrentState: nil,
+			CreateChange: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("master-m1", key.MasterID, "m1"), "2", "4Gi", "20G"),
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "2", "4Gi", "20G"),
+			},
+			UpdateChange:       nil,
+			ExpectedCreated:    2,
+			ExpectedUpdated:    0,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusTrue},
+		},
+
+		// Test 1 ensures no deployments are created in case the only host having
+		// enough resources runs a VM of the guest cluster already, which the pod
+		// anti-affinity of VM pods forbids.
+		{
+			UpdateSurge: false,
+			K8sObjects: []runtime.Object{
+				newHost("host1", key.WorkerID, "8", "16Gi", true),
+				newPod("al9qy", "worker-w1-1234-5678", "host1", key.WorkerID, "w1", "2", "4Gi"),
+			},
+			CurrentState: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "2", "4Gi", "20G"),
+			},
+			CreateChange: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w2", key.WorkerID, "w2"), "2", "4Gi", "20G"),
+			},
+			UpdateChange:       nil,
+			ExpectedCreated:    0,
+			ExpectedUpdated:    0,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusFalse},
+		},
+
+		// Test 2 ensures no deployments are created in case the resources of the
+		// hosts are requested by pods of other guest clusters. Hosts which are
+		// not ready are not eligible.
+		{
+			UpdateSurge: false,
+			K8sObjects: []runtime.Object{
+				newHost("host1", key.WorkerID, "8", "16Gi", true),
+				newHost("host2", key.WorkerID, "8", "16Gi", false),
+				newPod("b3kz1", "worker-w1-1234-5678", "host1", key.WorkerID, "w1", "4", "14Gi"),
+			},
+			CurrentState: nil,
+			CreateChange: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "2", "4Gi", "20G"),
+			},
+			UpdateChange:       nil,
+			ExpectedCreated:    0,
+			ExpectedUpdated:    0,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusFalse},
+		},
+
+		// Test 3 ensures larger VM pods are placed first, so VM pods fitting on
+		// the hosts only in a certain arrangement are found to fit.
+		{
+			UpdateSurge: false,
+			K8sObjects: []runtime.Object{
+				newHost("host1", key.WorkerID, "8", "4Gi", true),
+				newHost("host2", key.WorkerID, "8", "8Gi", true),
+			},
+			CurrentState: nil,
+			CreateChange: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "2", "3Gi", "20G"),
+				withVMResources(newTestDeployment("worker-w2", key.WorkerID, "w2"), "2", "7Gi", "20G"),
+			},
+			UpdateChange:       nil,
+			ExpectedCreated:    2,
+			ExpectedUpdated:    0,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusTrue},
+		},
+
+		// Test 4 ensures deployments updated in place free the host of their
+		// current VM pod.
+		{
+			UpdateSurge: false,
+			K8sObjects: []runtime.Object{
+				newHost("host1", key.WorkerID, "4", "8Gi", true),
+				newPod("al9qy", "worker-w1-1234-5678", "host1", key.WorkerID, "w1", "2", "4Gi"),
+			},
+			CurrentState: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "2", "4Gi", "20G"),
+			},
+			CreateChange: nil,
+			UpdateChange: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "4", "6Gi", "20G"),
+			},
+			ExpectedCreated:    0,
+			ExpectedUpdated:    1,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusTrue},
+		},
+
+		// Test 5 ensures workers replaced in surge mode need an additional host.
+		{
+			UpdateSurge: true,
+			K8sObjects: []runtime.Object{
+				newHost("host1", key.WorkerID, "4", "8Gi", true),
+				newPod("al9qy", "worker-w1-1234-5678", "host1", key.WorkerID, "w1", "2", "4Gi"),
+			},
+			CurrentState: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "2", "4Gi", "20G"),
+			},
+			CreateChange: nil,
+			UpdateChange: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "2", "4Gi", "20G"),
+			},
+			ExpectedCreated:    0,
+			ExpectedUpdated:    0,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusFalse},
+		},
+
+		// Test 6 ensures workers replaced in surge mode are not checked once
+		// their replacement got created. Deployments not running VMs are not
+		// checked either. The capacity is reported to be sufficient anyway.
+		{
+			UpdateSurge: true,
+			K8sObjects:  nil,
+			CurrentState: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "2", "4Gi", "20G"),
+				withVMResources(newTestDeployment(replacementName("worker-w1"), key.WorkerID, "w1"), "2", "4Gi", "20G"),
+			},
+			CreateChange: []*v1beta1.Deployment{
+				newTestDeployment(key.NodeControllerID, key.NodeControllerID, ""),
+			},
+			UpdateChange: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "2", "4Gi", "20G"),
+			},
+			ExpectedCreated:    1,
+			ExpectedUpdated:    1,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusTrue},
+		},
+
+		// Test 7 ensures deployments not running VMs are still created and
+		// updated in case the VM pods do not fit on the hosts.
+		{
+			UpdateSurge: false,
+			K8sObjects: []runtime.Object{
+				newHost("host1", key.WorkerID, "2", "2Gi", true),
+			},
+			CurrentState: nil,
+			CreateChange: []*v1beta1.Deployment{
+				withVMResources(newTestDeployment("worker-w1", key.WorkerID, "w1"), "2", "4Gi", "20G"),
+				newTestDeployment(key.NodeControllerID, key.NodeControllerID, ""),
+			},
+			UpdateChange: []*v1beta1.Deployment{
+				newTestDeployment(key.NodeControllerID, key.NodeControllerID, ""),
+			},
+			ExpectedCreated:    1,
+			ExpectedUpdated:    1,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusFalse},
+		},
+	}
+
+	for i, tc := range testCases {
+		clusterStatus := clusterstatustest.New()
+
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.ClusterStatus = clusterStatus
+			resourceConfig.CustomerQuota = customerquotatest.New()
+			resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+			resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
+			resourceConfig.K8sClient = fake.NewSimpleClientset(tc.K8sObjects...)
+			resourceConfig.Logger = microloggertest.New()
+			resourceConfig.QuorumGuard = quorumguardtest.New()
+			resourceConfig.UpdateSurge = tc.UpdateSurge
+
+			var err error
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		createChange, updateChange, err := newResource.checkHostCapacity(context.TODO(), customObject, tc.CurrentState, tc.CreateChange, tc.UpdateChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		created, err := toDeployments(createChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if len(created) != tc.ExpectedCreated {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedCreated, len(created))
+		}
+		updated, err := toDeployments(updateChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if len(updated) != tc.ExpectedUpdated {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedUpdated, len(updated))
+		}
+
+		var conditions []string
+		for _, c := range clusterStatus.Conditions {
+			if c.Type != clusterstatus.ConditionHostCapacitySufficient {
+				t.Fatalf("case %d expected %#v got %#v", i, clusterstatus.ConditionHostCapacitySufficient, c.Type)
+			}
+			conditions = append(conditions, c.Status)
+		}
+		if len(conditions) != len(tc.ExpectedConditions) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedConditions, conditions)
+		}
+		for j := range conditions {
+			if conditions[j] != tc.ExpectedConditions[j] {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedConditions, conditions)
+			}
+		}
+	}
+}
@@ -17,10 +17,14 @@ import (
 func newMasterDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Deployment, error) {
 	var deployments []*extensionsv1.Deployment
 
-	privileged := true
 	replicas := int32(1)
 	podDeletionGracePeriod := int64(key.PodDeletionGracePeriod.Seconds())
 
+	coreosVersion, err := key.CoreosVersion(customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
 	for i, masterNode := range customObject.Spec.Cluster.Masters {
 		capabilities := customObject.Spec.KVM.Masters[i]
 
@@ -151,7 +155,7 @@ func newMasterDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 						Containers: []apiv1.Container{
 							{
 								Name:            "k8s-endpoint-updater",
-								Image:           key.K8SEndpointUpdaterDocker,
+								Image:           key.EndpointUpdaterImage(customObject),
 								ImagePullPolicy: apiv1.PullIfNotPresent,
 								Command: []string{
 									"/bin/sh",
@@ -162,9 +166,7 @@ func newMasterDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 										" --service.kubernetes.inCluster=true" +
 										" --service.kubernetes.pod.name=${POD_NAME}",
 								},
-								SecurityContext: &apiv1.SecurityContext{
-									Privileged: &privileged,
-								},
+								SecurityContext: newUnprivilegedSecurityContext(),
 								Env: []apiv1.EnvVar{
 									{
 										Name: "POD_NAME",
@@ -178,12 +180,10 @@ func newMasterDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 								},
 							},
 							{
-								Name:            "k8s-kvm",
-								Image:           key.K8SKVMDockerImage,
+								Name:            key.K8SKVMContainerName,
+								Image:           key.K8SKVMImage(customObject),
 								ImagePullPolicy: apiv1.PullIfNotPresent,
-								SecurityContext: &apiv1.SecurityContext{
-									Privileged: &privileged,
-								},
+								SecurityContext: newKVMSecurityContext(),
 								Args: []string{
 									key.MasterID,
 								},
@@ -194,7 +194,7 @@ func newMasterDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 									},
 									{
 										Name:  "COREOS_VERSION",
-										Value: key.CoreosVersion,
+										Value: coreosVersion,
 									},
 									{
 										Name:  "DISK",
@@ -252,6 +252,21 @@ func newMasterDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 										},
 									},
 								},
+								// The readiness probe makes sure the endpoints of the
+								// master service only route traffic to healthy masters.
+								ReadinessProbe: &apiv1.Probe{
+									TimeoutSeconds:   key.TimeoutSeconds,
+									PeriodSeconds:    key.PeriodSeconds,
+									FailureThreshold: key.FailureThreshold,
+									SuccessThreshold: key.SuccessThreshold,
+									Handler: apiv1.Handler{
+										HTTPGet: &apiv1.HTTPGetAction{
+											Path: key.HealthEndpoint,
+											Port: intstr.IntOrString{IntVal: key.LivenessPort(customObject)},
+											Host: key.ProbeHost,
+										},
+									},
+								},
 								Resources: apiv1.ResourceRequirements{
 									Requests: apiv1.ResourceList{
 										apiv1.ResourceCPU:    cpuQuantity,
@@ -266,6 +281,7 @@ func newMasterDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 									{
 										Name:      "cloud-config",
 										MountPath: "/cloudconfig/",
+										ReadOnly:  true,
 									},
 									{
 										Name:      "etcd-data",
@@ -295,13 +311,12 @@ func newMasterDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 										Value: key.NetworkEnvFilePath(customObject),
 									},
 								},
-								SecurityContext: &apiv1.SecurityContext{
-									Privileged: &privileged,
-								},
+								SecurityContext: newUnprivilegedSecurityContext(),
 								VolumeMounts: []apiv1.VolumeMount{
 									{
 										Name:      "flannel",
 										MountPath: key.FlannelEnvPathPrefix,
+										ReadOnly:  true,
 									},
 								},
 							},
@@ -1,12 +1,24 @@
 package deployment
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/clientset/versioned"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard"
 )
 
 const (
@@ -14,11 +26,45 @@ const (
 	Name = "deploymentv13"
 )
 
+const (
+	auditLogVolumeName    = "audit-log"
+	cloudConfigVolumeName = "cloud-config"
+)
+
 // Config represents the configuration used to create a new deployment resource.
 type Config struct {
 	// Dependencies.
-	K8sClient kubernetes.Interface
-	Logger    micrologger.Logger
+	ClusterStatus clusterstatus.Interface
+	CustomerQuota customerquota.Interface
+	G8sClient     versioned.Interface
+	GuestClient   guestclient.Interface
+	K8sClient     kubernetes.Interface
+	Logger        micrologger.Logger
+	QuorumGuard   quorumguard.Interface
+
+	// Settings.
+
+	// AppArmorProfile is the AppArmor profile of the unprivileged containers of
+	// VM pods. No AppArmor profiles are set when empty.
+	AppArmorProfile string
+	// AuditHostVolume makes master deployments mount a dedicated host volume
+	// the API server audit logs are stored on, unless the custom object
+	// defines otherwise.
+	AuditHostVolume bool
+	// CloudConfigCompress tells the k8s-kvm containers of VM pods that the
+	// cloud-configs of their VMs are gzip compressed.
+	CloudConfigCompress bool
+	// CloudConfigSecret makes deployments mount the cloud-configs of their VMs
+	// from secrets instead of config maps.
+	CloudConfigSecret bool
+	RegistryMirror    string
+	// ScaleDownMaxWorkers is the maximum number of worker deployments removed
+	// per reconciliation.
+	ScaleDownMaxWorkers int
+	// UpdateSurge enables replacing workers on updates. A replacement worker
+	// is created and has to become ready before the updated worker is drained
+	// and deleted. Masters are always updated in place.
+	UpdateSurge bool
 }
 
 // DefaultConfig provides a default configuration to create a new deployment
@@ -26,32 +72,94 @@ type Config struct {
 func DefaultConfig() Config {
 	return Config{
 		// Dependencies.
-		K8sClient: nil,
-		Logger:    nil,
+		ClusterStatus: nil,
+		CustomerQuota: nil,
+		G8sClient:     nil,
+		GuestClient:   nil,
+		K8sClient:     nil,
+		Logger:        nil,
+		QuorumGuard:   nil,
+
+		// Settings.
+		AppArmorProfile:     "",
+		AuditHostVolume:     false,
+		CloudConfigCompress: false,
+		CloudConfigSecret:   false,
+		RegistryMirror:      "",
+		ScaleDownMaxWorkers: 1,
+		UpdateSurge:         false,
 	}
 }
 
 // Resource implements the deployment resource.
 type Resource struct {
 	// Dependencies.
-	k8sClient kubernetes.Interface
-	logger    micrologger.Logger
+	clusterStatus clusterstatus.Interface
+	customerQuota customerquota.Interface
+	g8sClient     versioned.Interface
+	guestClient   guestclient.Interface
+	k8sClient     kubernetes.Interface
+	logger        micrologger.Logger
+	quorumGuard   quorumguard.Interface
+
+	// Settings.
+	appArmorProfile     string
+	auditHostVolume     bool
+	cloudConfigCompress bool
+	cloudConfigSecret   bool
+	registryMirror      string
+	scaleDownMaxWorkers int
+	updateSurge         bool
 }
 
 // New creates a new configured deployment resource.
 func New(config Config) (*Resource, error) {
 	// Dependencies.
+	if config.ClusterStatus == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.ClusterStatus must not be empty")
+	}
+	if config.CustomerQuota == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.CustomerQuota must not be empty")
+	}
+	if config.G8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.G8sClient must not be empty")
+	}
+	if config.GuestClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.GuestClient must not be empty")
+	}
 	if config.K8sClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
 	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
 	}
+	if config.QuorumGuard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.QuorumGuard must not be empty")
+	}
+
+	// Settings.
+	if config.ScaleDownMaxWorkers <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "config.ScaleDownMaxWorkers must be greater than zero")
+	}
 
 	newResource := &Resource{
 		// Dependencies.
-		k8sClient: config.K8sClient,
-		logger:    config.Logger,
+		clusterStatus: config.ClusterStatus,
+		customerQuota: config.CustomerQuota,
+		g8sClient:     config.G8sClient,
+		guestClient:   config.GuestClient,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+		quorumGuard:   config.QuorumGuard,
+
+		// Settings.
+		appArmorProfile:     config.AppArmorProfile,
+		auditHostVolume:     config.AuditHostVolume,
+		cloudConfigCompress: config.CloudConfigCompress,
+		cloudConfigSecret:   config.CloudConfigSecret,
+		registryMirror:      config.RegistryMirror,
+		scaleDownMaxWorkers: config.ScaleDownMaxWorkers,
+		updateSurge:         config.UpdateSurge,
 	}
 
 	return newResource, nil
@@ -97,6 +205,37 @@ func getDeploymentByName(list []*v1beta1.Deployment, name string) (*v1beta1.Depl
 	return nil, microerror.Mask(notFoundError)
 }
 
+// allMastersJoined returns true in case all given master deployments are
+// marked as members of the etcd cluster.
+func allMastersJoined(deployments []*v1beta1.Deployment) bool {
+	for _, d := range deployments {
+		if isMasterDeployment(d) && d.GetAnnotations()[key.AnnotationEtcdMember] != "True" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func countMasterDeployments(deployments []*v1beta1.Deployment) int {
+	var n int
+	for _, d := range deployments {
+		if isMasterDeployment(d) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func isMasterDeployment(deployment *v1beta1.Deployment) bool {
+	return deployment.GetLabels()["app"] == key.MasterID
+}
+
+func isWorkerDeployment(deployment *v1beta1.Deployment) bool {
+	return deployment.GetLabels()["app"] == key.WorkerID
+}
+
 func isDeploymentModified(a, b *v1beta1.Deployment) bool {
 	aVersion, ok := a.GetAnnotations()[key.VersionBundleVersionAnnotation]
 	if !ok {
@@ -118,9 +257,286 @@ func isDeploymentModified(a, b *v1beta1.Deployment) bool {
 		return true
 	}
 
+	// Deployments created before the hashes got introduced do not have them.
+	// They are not considered to be modified until they get updated anyway.
+	if isHashModified(a.GetAnnotations()[key.AnnotationVMSpecHash], b.GetAnnotations()[key.AnnotationVMSpecHash]) {
+		return true
+	}
+	if isHashModified(a.Spec.Template.GetAnnotations()[key.AnnotationCloudConfigHash], b.Spec.Template.GetAnnotations()[key.AnnotationCloudConfigHash]) {
+		return true
+	}
+	if isImageModified(a, b) {
+		return true
+	}
+
 	return false
 }
 
+// isImageModified returns true in case any container of the given deployments
+// runs a different image in one than in the other, e.g. because the registry
+// mirror got configured. Containers only one of the deployments defines are
+// not compared.
+func isImageModified(a, b *v1beta1.Deployment) bool {
+	images := map[string]string{}
+	for _, c := range b.Spec.Template.Spec.InitContainers {
+		images["init/"+c.Name] = c.Image
+	}
+	for _, c := range b.Spec.Template.Spec.Containers {
+		images[c.Name] = c.Image
+	}
+
+	for _, c := range a.Spec.Template.Spec.InitContainers {
+		i, ok := images["init/"+c.Name]
+		if ok && i != c.Image {
+			return true
+		}
+	}
+	for _, c := range a.Spec.Template.Spec.Containers {
+		i, ok := images[c.Name]
+		if ok && i != c.Image {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isCertsHashModified returns true in case the certificates rendered into the
+// cloud-config of the given desired deployment differ from the ones the
+// current deployment was rendered with.
+func isCertsHashModified(desired, current *v1beta1.Deployment) bool {
+	return isHashModified(desired.Spec.Template.GetAnnotations()[key.AnnotationCertsHash], current.Spec.Template.GetAnnotations()[key.AnnotationCertsHash])
+}
+
+func isHashModified(a, b string) bool {
+	return a != "" && b != "" && a != b
+}
+
+// setCloudConfigHash annotates the pod templates of the given deployments with
+// the hash of the cloud-config their VM boots with. The cloud-config is read
+// from the config map or secret mounted into the k8s-kvm container. Changing
+// the cloud-config, e.g. due to rotated certificates or new SSH keys, changes
+// the hash and causes the deployment to be updated. Config maps and secrets
+// not existing yet are ignored, since they are created before the
+// deployments. The hash of the certificates rendered into the cloud-config is
+// put on the pod templates as well, so rotated certificates can be rolled out
+// to masters before workers. So is the hash of the encryption keys of masters,
+// which tells the encryption key rotation when all masters were rolled.
+func (r *Resource) setCloudConfigHash(namespace string, deployments []*v1beta1.Deployment) error {
+	for _, d := range deployments {
+		userData, annotations, err := r.findCloudConfig(namespace, d)
+		if IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if d.Spec.Template.Annotations == nil {
+			d.Spec.Template.Annotations = map[string]string{}
+		}
+		d.Spec.Template.Annotations[key.AnnotationCloudConfigHash] = fmt.Sprintf("%x", sha256.Sum256(userData))
+		for _, a := range []string{key.AnnotationCertsHash, key.AnnotationEncryptionKeysHash} {
+			if annotations[a] != "" {
+				d.Spec.Template.Annotations[a] = annotations[a]
+			}
+		}
+	}
+
+	return nil
+}
+
+// findCloudConfig returns the user data and the annotations of the
+// cloud-config mounted by the given deployment.
+func (r *Resource) findCloudConfig(namespace string, deployment *v1beta1.Deployment) ([]byte, map[string]string, error) {
+	for _, v := range deployment.Spec.Template.Spec.Volumes {
+		if v.Name != cloudConfigVolumeName {
+			continue
+		}
+
+		if v.ConfigMap != nil {
+			m, err := r.k8sClient.CoreV1().ConfigMaps(namespace).Get(v.ConfigMap.Name, metav1.GetOptions{})
+			if errors.IsNotFound(err) {
+				return nil, nil, microerror.Mask(notFoundError)
+			} else if err != nil {
+				return nil, nil, microerror.Mask(err)
+			}
+
+			return []byte(m.Data[key.CloudConfigUserDataKey]), m.GetAnnotations(), nil
+		}
+
+		if v.Secret != nil {
+			s, err := r.k8sClient.CoreV1().Secrets(namespace).Get(v.Secret.SecretName, metav1.GetOptions{})
+			if errors.IsNotFound(err) {
+				return nil, nil, microerror.Mask(notFoundError)
+			} else if err != nil {
+				return nil, nil, microerror.Mask(err)
+			}
+
+			return s.Data[key.CloudConfigUserDataKey], s.GetAnnotations(), nil
+		}
+	}
+
+	return nil, nil, microerror.Mask(notFoundError)
+}
+
+// setAuditLogVolume makes the given master deployments mount a dedicated host
+// volume into their k8s-kvm container, which shares it with the VM as
+// auditlogshare. The API server of the VM stores its audit logs there.
+func setAuditLogVolume(deployments []*v1beta1.Deployment, clusterID string) {
+	for _, d := range deployments {
+		if d.GetLabels()["app"] != key.MasterID {
+			continue
+		}
+
+		v := corev1.Volume{
+			Name: auditLogVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: key.MasterAuditLogHostPathDir(clusterID, d.GetLabels()["node"]),
+				},
+			},
+		}
+		d.Spec.Template.Spec.Volumes = append(d.Spec.Template.Spec.Volumes, v)
+
+		for i, c := range d.Spec.Template.Spec.Containers {
+			if c.Name != key.K8SKVMContainerName {
+				continue
+			}
+
+			m := corev1.VolumeMount{
+				Name:      auditLogVolumeName,
+				MountPath: "/etc/kubernetes/data/audit-log/",
+			}
+			d.Spec.Template.Spec.Containers[i].VolumeMounts = append(c.VolumeMounts, m)
+		}
+	}
+}
+
+// setCloudConfigEncoding annotates the pod templates of the given deployments
+// running a VM with the given cloud-config encoding and passes it to their
+// k8s-kvm container, which decodes the user data accordingly. The VM spec hash
+// is affected, so deployments are updated when the encoding changes.
+func setCloudConfigEncoding(deployments []*v1beta1.Deployment, encoding string) {
+	for _, d := range deployments {
+		for i, c := range d.Spec.Template.Spec.Containers {
+			if c.Name != key.K8SKVMContainerName {
+				continue
+			}
+
+			if d.Spec.Template.Annotations == nil {
+				d.Spec.Template.Annotations = map[string]string{}
+			}
+			d.Spec.Template.Annotations[key.AnnotationCloudConfigEncoding] = encoding
+
+			e := corev1.EnvVar{
+				Name:  "CLOUD_CONFIG_ENCODING",
+				Value: encoding,
+			}
+			d.Spec.Template.Spec.Containers[i].Env = append(c.Env, e)
+		}
+	}
+}
+
+// setCloudConfigSecret makes the given deployments mount the cloud-configs of
+// their VMs from secrets instead of config maps. The secrets are named like
+// the config maps. The cloud-config hash is not affected, but the VM spec hash
+// is, so deployments still mounting config maps are updated explicitly to
+// mount the secrets.
+func setCloudConfigSecret(deployments []*v1beta1.Deployment) {
+	for _, d := range deployments {
+		for i, v := range d.Spec.Template.Spec.Volumes {
+			if v.Name != cloudConfigVolumeName || v.ConfigMap == nil {
+				continue
+			}
+
+			d.Spec.Template.Spec.Volumes[i].VolumeSource = corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: v.ConfigMap.Name,
+				},
+			}
+		}
+	}
+}
+
+// setVMSpecHash annotates the given deployments with the hash of the VM spec
+// of their k8s-kvm container. Changing the CPUs, memory or disk of a guest
+// cluster node changes the hash and causes the deployment to be updated. So
+// does mounting the cloud-config from a secret instead of a config map.
+// Deployments not running a VM are not annotated.
+func setVMSpecHash(deployments []*v1beta1.Deployment) error {
+	for _, d := range deployments {
+		h, err := vmSpecHash(d)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if h == "" {
+			continue
+		}
+
+		if d.Annotations == nil {
+			d.Annotations = map[string]string{}
+		}
+		d.Annotations[key.AnnotationVMSpecHash] = h
+	}
+
+	return nil
+}
+
+func vmSpecHash(deployment *v1beta1.Deployment) (string, error) {
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		if c.Name != key.K8SKVMContainerName {
+			continue
+		}
+
+		// The cloud-config secret is omitted in case the cloud-config is mounted
+		// from a config map, so the hash of these deployments stays the same as
+		// before secrets were supported.
+		spec := struct {
+			CloudConfigSecret *corev1.SecretVolumeSource  `json:"cloudConfigSecret,omitempty"`
+			Env               []corev1.EnvVar             `json:"env"`
+			Resources         corev1.ResourceRequirements `json:"resources"`
+		}{
+			CloudConfigSecret: cloudConfigSecret(deployment),
+			Env:               c.Env,
+			Resources:         c.Resources,
+		}
+
+		b, err := json.Marshal(spec)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+	}
+
+	return "", nil
+}
+
+// cloudConfigSecret returns the secret the given deployment mounts its
+// cloud-config from, if any.
+func cloudConfigSecret(deployment *v1beta1.Deployment) *corev1.SecretVolumeSource {
+	for _, v := range deployment.Spec.Template.Spec.Volumes {
+		if v.Name == cloudConfigVolumeName && v.Secret != nil {
+			return v.Secret
+		}
+	}
+
+	return nil
+}
+
+// setRegistryMirror rewrites the images of all containers of the given
+// deployments to be pulled from the given registry mirror.
+func setRegistryMirror(deployments []*v1beta1.Deployment, mirror string) {
+	for _, d := range deployments {
+		for i, c := range d.Spec.Template.Spec.InitContainers {
+			d.Spec.Template.Spec.InitContainers[i].Image = key.ImageWithRegistryMirror(c.Image, mirror)
+		}
+		for i, c := range d.Spec.Template.Spec.Containers {
+			d.Spec.Template.Spec.Containers[i].Image = key.ImageWithRegistryMirror(c.Image, mirror)
+		}
+	}
+}
+
 func toDeployments(v interface{}) ([]*v1beta1.Deployment, error) {
 	if v == nil {
 		return nil, nil
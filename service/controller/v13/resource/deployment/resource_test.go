@@ -0,0 +1,628 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_Resource_Deployment_isDeploymentModified(t *testing.T) {
+	testCases := []struct {
+		A                *v1beta1.Deployment
+		B                *v1beta1.Deployment
+		ExpectedModified bool
+	}{
+		// Test 1 ensures deployments having the same version bundle version and VM
+		// spec hash are not modified.
+		{
+			A:                newHashedDeployment("1.0.0", "a"),
+			B:                newHashedDeployment("1.0.0", "a"),
+			ExpectedModified: false,
+		},
+		// Test 2 ensures deployments having different version bundle versions are
+		// modified.
+		{
+			A:                newHashedDeployment("1.1.0", "a"),
+			B:                newHashedDeployment("1.0.0", "a"),
+			ExpectedModified: true,
+		},
+		// Test 3 ensures deployments having different VM spec hashes are modified.
+		{
+			A:                newHashedDeployment("1.0.0", "b"),
+			B:                newHashedDeployment("1.0.0", "a"),
+			ExpectedModified: true,
+		},
+		// Test 4 ensures deployments created before the VM spec hash got
+		// introduced are not modified.
+		{
+			A:                newHashedDeployment("1.0.0", "b"),
+			B:                newHashedDeployment("1.0.0", ""),
+			ExpectedModified: false,
+		},
+		// Test 5 ensures deployments having different cloud-config hashes are
+		// modified.
+		{
+			A:                withCloudConfigHash(newHashedDeployment("1.0.0", "a"), "b"),
+			B:                withCloudConfigHash(newHashedDeployment("1.0.0", "a"), "a"),
+			ExpectedModified: true,
+		},
+		// Test 6 ensures deployments created before the cloud-config hash got
+		// introduced are not modified.
+		{
+			A:                withCloudConfigHash(newHashedDeployment("1.0.0", "a"), "b"),
+			B:                newHashedDeployment("1.0.0", "a"),
+			ExpectedModified: false,
+		},
+		// Test 7 ensures deployments running different images are modified.
+		{
+			A:                withImage(newHashedDeployment("1.0.0", "a"), "registry.example.com/giantswarm/k8s-kvm:1"),
+			B:                withImage(newHashedDeployment("1.0.0", "a"), "quay.io/giantswarm/k8s-kvm:1"),
+			ExpectedModified: true,
+		},
+		// Test 8 ensures deployments running the same images are not modified.
+		{
+			A:                withImage(newHashedDeployment("1.0.0", "a"), "quay.io/giantswarm/k8s-kvm:1"),
+			B:                withImage(newHashedDeployment("1.0.0", "a"), "quay.io/giantswarm/k8s-kvm:1"),
+			ExpectedModified: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		modified := isDeploymentModified(tc.A, tc.B)
+		if modified != tc.ExpectedModified {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedModified, modified)
+		}
+	}
+}
+
+func Test_Resource_Deployment_setVMSpecHash(t *testing.T) {
+	newCustomObject := func(cpus int, memory string, disk float64) v1alpha1.KVMConfig {
+		return v1alpha1.KVMConfig{
+			Spec: v1alpha1.KVMConfigSpec{
+				Cluster: v1alpha1.Cluster{
+					ID: "al9qy",
+					Workers: []v1alpha1.ClusterNode{
+						{ID: "worker-1"},
+					},
+				},
+				KVM: v1alpha1.KVMConfigSpecKVM{
+					Workers: []v1alpha1.KVMConfigSpecKVMNode{
+						{CPUs: cpus, Disk: disk, Memory: memory},
+					},
+				},
+			},
+		}
+	}
+
+	newHash := func(customObject v1alpha1.KVMConfig) string {
+		workers, err := key.WorkerNodes(customObject)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		deployments, err := newWorkerDeployments(customObject, workers)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		err = setVMSpecHash(deployments)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+
+		h := deployments[0].GetAnnotations()[key.AnnotationVMSpecHash]
+		if h == "" {
+			t.Fatalf("expected VM spec hash to be set")
+		}
+
+		return h
+	}
+
+	testCases := []struct {
+		A             v1alpha1.KVMConfig
+		B             v1alpha1.KVMConfig
+		ExpectedEqual bool
+	}{
+		// Test 1 ensures the hash is stable for the same VM spec.
+		{
+			A:             newCustomObject(2, "4G", 20),
+			B:             newCustomObject(2, "4G", 20),
+			ExpectedEqual: true,
+		},
+		// Test 2 ensures changing the CPUs changes the hash.
+		{
+			A:             newCustomObject(2, "4G", 20),
+			B:             newCustomObject(4, "4G", 20),
+			ExpectedEqual: false,
+		},
+		// Test 3 ensures changing the memory changes the hash.
+		{
+			A:             newCustomObject(2, "4G", 20),
+			B:             newCustomObject(2, "8G", 20),
+			ExpectedEqual: false,
+		},
+		// Test 4 ensures changing the disk changes the hash.
+		{
+			A:             newCustomObject(2, "4G", 20),
+			B:             newCustomObject(2, "4G", 40),
+			ExpectedEqual: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		equal := newHash(tc.A) == newHash(tc.B)
+		if equal != tc.ExpectedEqual {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedEqual, equal)
+		}
+	}
+}
+
+func Test_Resource_Deployment_setCloudConfigHash(t *testing.T) {
+	newConfigMap := func(userData string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      "worker-al9qy-worker-1",
+				Namespace: "al9qy",
+			},
+			Data: map[string]string{
+				key.CloudConfigUserDataKey: userData,
+			},
+		}
+	}
+
+	newHash := func(configMap *corev1.ConfigMap) string {
+		k8sClient := fake.NewSimpleClientset()
+		if configMap != nil {
+			_, err := k8sClient.CoreV1().ConfigMaps(configMap.Namespace).Create(configMap)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+		}
+
+		r := &Resource{
+			k8sClient: k8sClient,
+		}
+
+		d := &v1beta1.Deployment{
+			Spec: v1beta1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Volumes: []corev1.Volume{
+							{
+								Name: "cloud-config",
+								VolumeSource: corev1.VolumeSource{
+									ConfigMap: &corev1.ConfigMapVolumeSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: "worker-al9qy-worker-1",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		err := r.setCloudConfigHash("al9qy", []*v1beta1.Deployment{d})
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+
+		return d.Spec.Template.GetAnnotations()[key.AnnotationCloudConfigHash]
+	}
+
+	testCases := []struct {
+		A             *corev1.ConfigMap
+		B             *corev1.ConfigMap
+		ExpectedEqual bool
+	}{
+		// Test 1 ensures the hash is stable for the same cloud-config.
+		{
+			A:             newConfigMap("foo"),
+			B:             newConfigMap("foo"),
+			ExpectedEqual: true,
+		},
+		// Test 2 ensures changing the cloud-config changes the hash.
+		{
+			A:             newConfigMap("foo"),
+			B:             newConfigMap("bar"),
+			ExpectedEqual: false,
+		},
+		// Test 3 ensures no hash is set in case the config map does not exist
+		// yet.
+		{
+			A:             nil,
+			B:             nil,
+			ExpectedEqual: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		a := newHash(tc.A)
+		b := newHash(tc.B)
+		if (tc.A == nil) != (a == "") {
+			t.Fatalf("case %d expected hash to be set only for existing config maps got %#v", i+1, a)
+		}
+
+		equal := a == b
+		if equal != tc.ExpectedEqual {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedEqual, equal)
+		}
+	}
+}
+
+func Test_Resource_Deployment_setCloudConfigSecret(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Workers: []v1alpha1.ClusterNode{
+					{ID: "worker-1"},
+				},
+			},
+			KVM: v1alpha1.KVMConfigSpecKVM{
+				Workers: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 2, Memory: "4G"},
+				},
+			},
+		},
+	}
+
+	workers, err := key.WorkerNodes(customObject)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	deployments, err := newWorkerDeployments(customObject, workers)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	k8sClient := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: apismetav1.ObjectMeta{Name: "worker-al9qy-worker-1", Namespace: "al9qy"},
+			Data:       map[string]string{key.CloudConfigUserDataKey: "foo"},
+		},
+		&corev1.Secret{
+			ObjectMeta: apismetav1.ObjectMeta{Name: "worker-al9qy-worker-1", Namespace: "al9qy"},
+			Data:       map[string][]byte{key.CloudConfigUserDataKey: []byte("foo")},
+		},
+	)
+
+	r := &Resource{
+		k8sClient: k8sClient,
+	}
+
+	err = r.setCloudConfigHash("al9qy", deployments)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	configMapHash := deployments[0].Spec.Template.Annotations[key.AnnotationCloudConfigHash]
+
+	setCloudConfigSecret(deployments)
+
+	var found bool
+	for _, v := range deployments[0].Spec.Template.Spec.Volumes {
+		if v.Name != "cloud-config" {
+			continue
+		}
+		if v.ConfigMap != nil || v.Secret == nil || v.Secret.SecretName != "worker-al9qy-worker-1" {
+			t.Fatalf("expected cloud-config volume to be mounted from secret got %#v", v.VolumeSource)
+		}
+		found = true
+	}
+	if !found {
+		t.Fatalf("expected cloud-config volume to exist")
+	}
+
+	err = r.setCloudConfigHash("al9qy", deployments)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	secretHash := deployments[0].Spec.Template.Annotations[key.AnnotationCloudConfigHash]
+
+	if configMapHash == "" || configMapHash != secretHash {
+		t.Fatalf("expected switching to secrets to keep cloud-config hash %#v got %#v", configMapHash, secretHash)
+	}
+}
+
+func Test_Resource_Deployment_setVMSpecHash_CloudConfigSecret(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Workers: []v1alpha1.ClusterNode{
+					{ID: "worker-1"},
+				},
+			},
+			KVM: v1alpha1.KVMConfigSpecKVM{
+				Workers: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 2, Memory: "4G"},
+				},
+			},
+		},
+	}
+
+	workers, err := key.WorkerNodes(customObject)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	deployments, err := newWorkerDeployments(customObject, workers)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	err = setVMSpecHash(deployments)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	configMapHash := deployments[0].GetAnnotations()[key.AnnotationVMSpecHash]
+
+	setCloudConfigSecret(deployments)
+
+	err = setVMSpecHash(deployments)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	secretHash := deployments[0].GetAnnotations()[key.AnnotationVMSpecHash]
+
+	if configMapHash == "" || configMapHash == secretHash {
+		t.Fatalf("expected switching to secrets to change VM spec hash %#v got %#v", configMapHash, secretHash)
+	}
+}
+
+// newTestDeployment returns a deployment of the given app, labelled with the
+// given guest cluster node in case it is not empty.
+func newTestDeployment(name, app, node string) *v1beta1.Deployment {
+	d := &v1beta1.Deployment{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{},
+			Labels: map[string]string{
+				"app": app,
+			},
+		},
+	}
+
+	if node != "" {
+		d.Labels["node"] = node
+	}
+
+	return d
+}
+
+func newHashedDeployment(version, hash string) *v1beta1.Deployment {
+	d := &v1beta1.Deployment{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Annotations: map[string]string{
+				key.VersionBundleVersionAnnotation: version,
+			},
+		},
+	}
+
+	if hash != "" {
+		d.Annotations[key.AnnotationVMSpecHash] = hash
+	}
+
+	return d
+}
+
+func withCloudConfigHash(d *v1beta1.Deployment, hash string) *v1beta1.Deployment {
+	d.Spec.Template.Annotations = map[string]string{
+		key.AnnotationCloudConfigHash: hash,
+	}
+
+	return d
+}
+
+func withAnnotation(d *v1beta1.Deployment, name, value string) *v1beta1.Deployment {
+	d.Annotations[name] = value
+
+	return d
+}
+
+// withCertsHash annotates the pods of the given deployment with the given
+// certificates hash. Rotated certificates change the cloud-config as well, so
+// the cloud-config hash is set to the same value.
+func withCertsHash(d *v1beta1.Deployment, hash string) *v1beta1.Deployment {
+	d.Spec.Template.Annotations = map[string]string{
+		key.AnnotationCertsHash:       hash,
+		key.AnnotationCloudConfigHash: hash,
+	}
+
+	return d
+}
+
+func withNamespace(d *v1beta1.Deployment, namespace string) *v1beta1.Deployment {
+	d.SetNamespace(namespace)
+
+	return d
+}
+
+func withReplacementLabel(d *v1beta1.Deployment, replacement string) *v1beta1.Deployment {
+	setReplacementLabel(d, replacement)
+
+	return d
+}
+
+// withVMResources schedules the given deployment on hosts of the role of its
+// app, with a k8s-kvm container requesting the given resources for its VM.
+func withVMResources(d *v1beta1.Deployment, cpu, memory, disk string) *v1beta1.Deployment {
+	d.Spec.Template.Spec.NodeSelector = map[string]string{
+		"role": d.Labels["app"],
+	}
+	d.Spec.Template.Spec.Containers = []corev1.Container{
+		{
+			Name: key.K8SKVMContainerName,
+			Env: []corev1.EnvVar{
+				{
+					Name:  "DISK",
+					Value: disk,
+				},
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		},
+		{
+			Name: "k8s-kvm-health",
+		},
+	}
+
+	return d
+}
+
+func withImage(d *v1beta1.Deployment, image string) *v1beta1.Deployment {
+	d.Spec.Template.Spec.Containers = []corev1.Container{
+		{
+			Name:  key.K8SKVMContainerName,
+			Image: image,
+		},
+	}
+
+	return d
+}
+
+func Test_Resource_Deployment_setAuditLogVolume(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Masters: []v1alpha1.ClusterNode{
+					{ID: "m1"},
+				},
+				Workers: []v1alpha1.ClusterNode{
+					{ID: "w1"},
+				},
+			},
+			KVM: v1alpha1.KVMConfigSpecKVM{
+				K8sKVM: v1alpha1.KVMConfigSpecKVMK8sKVM{
+					StorageType: "hostPath",
+				},
+				Masters: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 1, Memory: "1G"},
+				},
+				Workers: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 1, Memory: "1G"},
+				},
+			},
+		},
+	}
+
+	var deployments []*v1beta1.Deployment
+	{
+		masters, err := newMasterDeployments(customObject)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		deployments = append(deployments, masters...)
+
+		workers, err := key.WorkerNodes(customObject)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		workerDeployments, err := newWorkerDeployments(customObject, workers)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		deployments = append(deployments, workerDeployments...)
+	}
+
+	setAuditLogVolume(deployments, key.ClusterID(customObject))
+
+	for _, d := range deployments {
+		var path string
+		for _, v := range d.Spec.Template.Spec.Volumes {
+			if v.Name == auditLogVolumeName {
+				path = v.HostPath.Path
+			}
+		}
+		var mounted bool
+		for _, c := range d.Spec.Template.Spec.Containers {
+			for _, m := range c.VolumeMounts {
+				if m.Name == auditLogVolumeName {
+					mounted = c.Name == key.K8SKVMContainerName
+				}
+			}
+		}
+
+		if d.GetLabels()["app"] == key.MasterID {
+			expected := "/home/core/volumes/al9qy/k8s-master-m1-audit-log"
+			if path != expected {
+				t.Fatalf("expected %#v got %#v", expected, path)
+			}
+			if !mounted {
+				t.Fatalf("expected audit log volume to be mounted into %#v", key.K8SKVMContainerName)
+			}
+		} else if path != "" || mounted {
+			t.Fatalf("expected deployment %#v not to have an audit log volume", d.Name)
+		}
+	}
+}
+
+func Test_Resource_Deployment_setCloudConfigEncoding(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Workers: []v1alpha1.ClusterNode{
+					{ID: "worker-1"},
+				},
+			},
+			KVM: v1alpha1.KVMConfigSpecKVM{
+				Workers: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 2, Memory: "4G"},
+				},
+			},
+		},
+	}
+
+	workers, err := key.WorkerNodes(customObject)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	deployments, err := newWorkerDeployments(customObject, workers)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	plainHash, err := vmSpecHash(deployments[0])
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	setCloudConfigEncoding(deployments, key.CloudConfigEncodingGzip)
+
+	encoding := deployments[0].Spec.Template.Annotations[key.AnnotationCloudConfigEncoding]
+	if encoding != key.CloudConfigEncodingGzip {
+		t.Fatalf("expected %#v got %#v", key.CloudConfigEncodingGzip, encoding)
+	}
+
+	var found bool
+	for _, c := range deployments[0].Spec.Template.Spec.Containers {
+		for _, e := range c.Env {
+			if e.Name == "CLOUD_CONFIG_ENCODING" {
+				found = c.Name == key.K8SKVMContainerName && e.Value == key.CloudConfigEncodingGzip
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected %#v to get the cloud-config encoding", key.K8SKVMContainerName)
+	}
+
+	gzipHash, err := vmSpecHash(deployments[0])
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	if plainHash == gzipHash {
+		t.Fatalf("expected the VM spec hash to change with the cloud-config encoding")
+	}
+}
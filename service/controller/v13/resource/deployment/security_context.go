@@ -0,0 +1,93 @@
+package deployment
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+)
+
+const (
+	appArmorContainerAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+	seccompContainerAnnotationPrefix  = "container.seccomp.security.alpha.kubernetes.io/"
+
+	// seccompProfileDefault is the seccomp profile of the container runtime,
+	// which blocks syscalls containers do not need.
+	seccompProfileDefault = "docker/default"
+	// profileUnconfined is used for privileged containers. The container
+	// runtime does not confine them anyway, so the annotations say so
+	// explicitly.
+	profileUnconfined = "unconfined"
+
+	// nobodyUser is the user unprivileged containers of VM pods run as.
+	nobodyUser = int64(65534)
+)
+
+// newKVMSecurityContext returns the security context of the k8s-kvm
+// container. QEMU needs access to /dev/kvm and creates the tap device of the
+// VM on the host network. Device access cannot be granted to containers
+// selectively, so the container has to be privileged.
+func newKVMSecurityContext() *apiv1.SecurityContext {
+	privileged := true
+
+	return &apiv1.SecurityContext{
+		Privileged: &privileged,
+	}
+}
+
+// newUnprivilegedSecurityContext returns the security context of the
+// containers of VM pods not running the VM. The k8s-endpoint-updater
+// container only reads the address of the bridge of the host network and
+// talks to the Kubernetes API. The k8s-kvm-health container only reads the
+// flannel env file and serves the health endpoint on an unprivileged port.
+// Neither needs any capability, nor to write to its root filesystem.
+func newUnprivilegedSecurityContext() *apiv1.SecurityContext {
+	allowPrivilegeEscalation := false
+	privileged := false
+	readOnlyRootFilesystem := true
+	runAsNonRoot := true
+	runAsUser := nobodyUser
+
+	return &apiv1.SecurityContext{
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		Capabilities: &apiv1.Capabilities{
+			Drop: []apiv1.Capability{
+				"ALL",
+			},
+		},
+		Privileged:             &privileged,
+		ReadOnlyRootFilesystem: &readOnlyRootFilesystem,
+		RunAsNonRoot:           &runAsNonRoot,
+		RunAsUser:              &runAsUser,
+	}
+}
+
+// setSecurityProfiles annotates the pod templates of the given deployments
+// with the seccomp profile of each container. Unprivileged containers use
+// the default seccomp profile of the container runtime. In case an AppArmor
+// profile is given, the containers are annotated with it as well. AppArmor
+// is opt-in, since the kubelet refuses to run pods with AppArmor profiles on
+// hosts not supporting AppArmor.
+func setSecurityProfiles(deployments []*v1beta1.Deployment, appArmorProfile string) {
+	for _, d := range deployments {
+		if d.Spec.Template.Annotations == nil {
+			d.Spec.Template.Annotations = map[string]string{}
+		}
+
+		for _, c := range d.Spec.Template.Spec.Containers {
+			seccompProfile := seccompProfileDefault
+			containerAppArmorProfile := appArmorProfile
+			if isPrivileged(c) {
+				seccompProfile = profileUnconfined
+				containerAppArmorProfile = profileUnconfined
+			}
+
+			d.Spec.Template.Annotations[seccompContainerAnnotationPrefix+c.Name] = seccompProfile
+			if appArmorProfile != "" {
+				d.Spec.Template.Annotations[appArmorContainerAnnotationPrefix+c.Name] = containerAppArmorProfile
+			}
+		}
+	}
+}
+
+func isPrivileged(c apiv1.Container) bool {
+	return c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged
+}
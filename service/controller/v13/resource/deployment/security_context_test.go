@@ -0,0 +1,132 @@
+package deployment
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_Resource_Deployment_SecurityContexts(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Masters: []v1alpha1.ClusterNode{
+					{ID: "m1"},
+				},
+				Workers: []v1alpha1.ClusterNode{
+					{ID: "w1"},
+				},
+			},
+			KVM: v1alpha1.KVMConfigSpecKVM{
+				K8sKVM: v1alpha1.KVMConfigSpecKVMK8sKVM{
+					StorageType: "hostPath",
+				},
+				Masters: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 1, Memory: "1G"},
+				},
+				Workers: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 1, Memory: "1G"},
+				},
+			},
+		},
+	}
+
+	var deployments []*v1beta1.Deployment
+	{
+		masters, err := newMasterDeployments(customObject)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		deployments = append(deployments, masters...)
+
+		workerNodes, err := key.WorkerNodes(customObject)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		workers, err := newWorkerDeployments(customObject, workerNodes)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		deployments = append(deployments, workers...)
+	}
+
+	for _, d := range deployments {
+		for _, c := range d.Spec.Template.Spec.Containers {
+			// Only the container running the VM is privileged.
+			if isPrivileged(c) != (c.Name == key.K8SKVMContainerName) {
+				t.Fatalf("deployment %s container %s expected privileged %#v got %#v", d.Name, c.Name, c.Name == key.K8SKVMContainerName, isPrivileged(c))
+			}
+			if c.Name == key.K8SKVMContainerName {
+				continue
+			}
+
+			s := c.SecurityContext
+			if s.AllowPrivilegeEscalation == nil || *s.AllowPrivilegeEscalation {
+				t.Fatalf("deployment %s container %s expected privilege escalation to be disallowed", d.Name, c.Name)
+			}
+			if s.Capabilities == nil || !reflect.DeepEqual(s.Capabilities.Drop, newUnprivilegedSecurityContext().Capabilities.Drop) {
+				t.Fatalf("deployment %s container %s expected all capabilities to be dropped", d.Name, c.Name)
+			}
+			if s.ReadOnlyRootFilesystem == nil || !*s.ReadOnlyRootFilesystem {
+				t.Fatalf("deployment %s container %s expected read-only root filesystem", d.Name, c.Name)
+			}
+			for _, m := range c.VolumeMounts {
+				if !m.ReadOnly {
+					t.Fatalf("deployment %s container %s expected volume mount %s to be read-only", d.Name, c.Name, m.Name)
+				}
+			}
+		}
+	}
+}
+
+func Test_Resource_Deployment_setSecurityProfiles(t *testing.T) {
+	testCases := []struct {
+		AppArmorProfile     string
+		ExpectedAnnotations map[string]string
+	}{
+		// Test 0 ensures only seccomp profiles are set in case no AppArmor profile
+		// is given.
+		{
+			AppArmorProfile: "",
+			ExpectedAnnotations: map[string]string{
+				"container.seccomp.security.alpha.kubernetes.io/k8s-endpoint-updater": "docker/default",
+				"container.seccomp.security.alpha.kubernetes.io/k8s-kvm":              "unconfined",
+				"container.seccomp.security.alpha.kubernetes.io/k8s-kvm-health":       "docker/default",
+			},
+		},
+		// Test 1 ensures the given AppArmor profile is set for unprivileged
+		// containers.
+		{
+			AppArmorProfile: "runtime/default",
+			ExpectedAnnotations: map[string]string{
+				"container.apparmor.security.beta.kubernetes.io/k8s-endpoint-updater": "runtime/default",
+				"container.apparmor.security.beta.kubernetes.io/k8s-kvm":              "unconfined",
+				"container.apparmor.security.beta.kubernetes.io/k8s-kvm-health":       "runtime/default",
+				"container.seccomp.security.alpha.kubernetes.io/k8s-endpoint-updater": "docker/default",
+				"container.seccomp.security.alpha.kubernetes.io/k8s-kvm":              "unconfined",
+				"container.seccomp.security.alpha.kubernetes.io/k8s-kvm-health":       "docker/default",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		d := &v1beta1.Deployment{}
+		d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers,
+			apiv1.Container{Name: "k8s-endpoint-updater", SecurityContext: newUnprivilegedSecurityContext()},
+			apiv1.Container{Name: key.K8SKVMContainerName, SecurityContext: newKVMSecurityContext()},
+			apiv1.Container{Name: "k8s-kvm-health", SecurityContext: newUnprivilegedSecurityContext()},
+		)
+
+		setSecurityProfiles([]*v1beta1.Deployment{d}, tc.AppArmorProfile)
+
+		if !reflect.DeepEqual(d.Spec.Template.Annotations, tc.ExpectedAnnotations) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedAnnotations, d.Spec.Template.Annotations)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// replacementSuffix is toggled on the name of a worker deployment to get the
+	// name of the deployment replacing it in surge mode. Replacing a replacement
+	// again results in the original name.
+	replacementSuffix = "-r"
+)
+
+// replaceWorker updates the given worker deployment in surge mode. Instead of
+// recreating the guest cluster node in place, a replacement deployment is
+// created first. Once the guest cluster node of the replacement is ready, the
+// node of the replaced deployment is drained and the replaced deployment is
+// deleted. Every call moves the replacement one step forward, so it is
+// finished within a couple of reconciliations.
+func (r *Resource) replaceWorker(ctx context.Context, customObject v1alpha1.KVMConfig, deployment *v1beta1.Deployment) error {
+	n := key.ClusterNamespace(customObject)
+
+	var replacement *v1beta1.Deployment
+	{
+		name := replacementName(deployment.GetName())
+
+		manifest, err := r.k8sClient.Extensions().Deployments(n).Get(name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("creating deployment '%s' replacing deployment '%s'", name, deployment.GetName()))
+
+			replacement = deployment.DeepCopy()
+			replacement.SetName(name)
+			replacement.SetResourceVersion("")
+			replacement.Annotations[key.AnnotationReplaces] = deployment.GetName()
+			setReplacementLabel(replacement, name)
+
+			_, err := r.k8sClient.Extensions().Deployments(n).Create(replacement)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("created deployment '%s' replacing deployment '%s'", name, deployment.GetName()))
+
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		replacement = manifest
+	}
+
+	{
+		ready, err := r.isWorkerReady(ctx, customObject, replacement)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if !ready {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not deleting deployment '%s': guest cluster node of replacement '%s' is not ready yet", deployment.GetName(), replacement.GetName()))
+			return nil
+		}
+	}
+
+	{
+		drained, err := r.drainWorker(ctx, customObject, deployment)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if !drained {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not deleting deployment '%s': guest cluster node is not drained yet", deployment.GetName()))
+			return nil
+		}
+	}
+
+	{
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleting deployment '%s' replaced by deployment '%s'", deployment.GetName(), replacement.GetName()))
+
+		err := r.k8sClient.Extensions().Deployments(n).Delete(deployment.GetName(), newDeleteOptions())
+		if apierrors.IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted deployment '%s' replaced by deployment '%s'", deployment.GetName(), replacement.GetName()))
+	}
+
+	return nil
+}
+
+// isWorkerReady checks whether the guest cluster nodes of all running pods of
+// the given worker deployment joined the guest cluster and are ready. The node
+// name of a worker is the name of its pod.
+func (r *Resource) isWorkerReady(ctx context.Context, customObject v1alpha1.KVMConfig, deployment *v1beta1.Deployment) (bool, error) {
+	pods, err := r.workerPods(customObject, deployment)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	var running []corev1.Pod
+	for _, p := range pods {
+		if p.Status.Phase == corev1.PodRunning && !key.IsPodDeleted(&p) {
+			running = append(running, p)
+		}
+	}
+	if len(running) == 0 {
+		return false, nil
+	}
+
+	guestK8sClient, err := r.guestClient.NewK8sClient(customObject)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	for _, p := range running {
+		node, err := guestK8sClient.CoreV1().Nodes().Get(p.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		if !isNodeReady(node) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// workerPods returns the pods managed by the given worker deployment.
+func (r *Resource) workerPods(customObject v1alpha1.KVMConfig, deployment *v1beta1.Deployment) ([]corev1.Pod, error) {
+	n := key.ClusterNamespace(customObject)
+	o := metav1.ListOptions{
+		LabelSelector: workerPodSelector(deployment),
+	}
+
+	list, err := r.k8sClient.CoreV1().Pods(n).List(o)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return list.Items, nil
+}
+
+// adoptReplacements renames desired worker deployments to the name of the
+// current deployment of the same worker, in case the deployment carrying the
+// desired name does not exist anymore. This happens once a replacement
+// created in surge mode took over the worker.
+func adoptReplacements(currentDeployments, desiredDeployments []*v1beta1.Deployment) []*v1beta1.Deployment {
+	var deployments []*v1beta1.Deployment
+
+	for _, d := range desiredDeployments {
+		if !isWorkerDeployment(d) || containsDeployment(currentDeployments, d) {
+			deployments = append(deployments, d)
+			continue
+		}
+
+		var adopted *v1beta1.Deployment
+		for _, c := range currentDeployments {
+			if isWorkerDeployment(c) && c.GetLabels()["node"] == d.GetLabels()["node"] {
+				adopted = d.DeepCopy()
+				adopted.SetName(c.GetName())
+				if v, ok := c.Spec.Template.GetLabels()[key.LabelReplacement]; ok {
+					setReplacementLabel(adopted, v)
+				}
+				break
+			}
+		}
+
+		if adopted != nil {
+			deployments = append(deployments, adopted)
+		} else {
+			deployments = append(deployments, d)
+		}
+	}
+
+	return deployments
+}
+
+// isReplacing returns true in case the given deployment was created in surge
+// mode to replace one of the given deployments.
+func isReplacing(deployments []*v1beta1.Deployment, deployment *v1beta1.Deployment) bool {
+	replaced, ok := deployment.GetAnnotations()[key.AnnotationReplaces]
+	if !ok {
+		return false
+	}
+
+	_, err := getDeploymentByName(deployments, replaced)
+	if err != nil {
+		return false
+	}
+
+	return true
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setReplacementLabel puts key.LabelReplacement with the given value on the
+// pods of the given deployment and into its selector. The selector is set
+// explicitly, so it does not match the pods of the replaced deployment, which
+// do not carry the label.
+func setReplacementLabel(deployment *v1beta1.Deployment, value string) {
+	labels := map[string]string{}
+	for k, v := range deployment.Spec.Template.GetLabels() {
+		labels[k] = v
+	}
+	labels[key.LabelReplacement] = value
+
+	deployment.Spec.Template.SetLabels(labels)
+	deployment.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: labels,
+	}
+}
+
+// workerPodSelector returns the label selector matching the pods of the given
+// worker deployment. Pods of a worker and its replacement share all labels but
+// key.LabelReplacement, which only the pods of replacements carry.
+func workerPodSelector(deployment *v1beta1.Deployment) string {
+	selector := fmt.Sprintf("app=%s,node=%s", key.WorkerID, deployment.GetLabels()["node"])
+
+	v, ok := deployment.Spec.Template.GetLabels()[key.LabelReplacement]
+	if ok {
+		return fmt.Sprintf("%s,%s=%s", selector, key.LabelReplacement, v)
+	}
+
+	return fmt.Sprintf("%s,!%s", selector, key.LabelReplacement)
+}
+
+func replacementName(name string) string {
+	if strings.HasSuffix(name, replacementSuffix) {
+		return strings.TrimSuffix(name, replacementSuffix)
+	}
+
+	return name + replacementSuffix
+}
@@ -0,0 +1,331 @@
+package deployment
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota/customerquotatest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard/quorumguardtest"
+)
+
+func Test_Resource_Deployment_replaceWorker(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	newPod := func(name, replacement, drained string) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      name,
+				Namespace: "al9qy",
+				Annotations: map[string]string{
+					key.AnnotationAPIEndpoint: "api.al9qy.example.com",
+					key.AnnotationPodDrained:  drained,
+				},
+				Labels: map[string]string{
+					"app":  key.WorkerID,
+					"node": "1",
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+			},
+		}
+		if replacement != "" {
+			p.Labels[key.LabelReplacement] = replacement
+		}
+
+		return p
+	}
+
+	newNode := func(name string, ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: name,
+			},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{
+						Type:   corev1.NodeReady,
+						Status: ready,
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		K8sObjects          []runtime.Object
+		GuestK8sObjects     []runtime.Object
+		ExpectedReplacement bool
+		ExpectedReplaces    string
+		ExpectedReplaced    bool
+		ExpectedNodeConfig  bool
+	}{
+		// Test 1 ensures the replacement deployment is created first and the
+		// replaced deployment is kept.
+		{
+			K8sObjects: []runtime.Object{
+				withNamespace(newTestDeployment("worker-1", key.WorkerID, "1"), "al9qy"),
+				newPod("worker-1-6d4b7c9f8-5f8c9", "", "False"),
+			},
+			GuestK8sObjects:     nil,
+			ExpectedReplacement: true,
+			ExpectedReplaces:    "worker-1",
+			ExpectedReplaced:    true,
+			ExpectedNodeConfig:  false,
+		},
+
+		// Test 2 ensures the replaced deployment is kept as long as the guest
+		// cluster node of the replacement did not join the guest cluster.
+		{
+			K8sObjects: []runtime.Object{
+				withNamespace(newTestDeployment("worker-1", key.WorkerID, "1"), "al9qy"),
+				withReplacementLabel(withNamespace(newTestDeployment("worker-1-r", key.WorkerID, "1"), "al9qy"), "worker-1-r"),
+				newPod("worker-1-6d4b7c9f8-5f8c9", "", "False"),
+				newPod("worker-1-r-7b8d5c6f4-x2k9w", "worker-1-r", "False"),
+			},
+			GuestK8sObjects:     nil,
+			ExpectedReplacement: true,
+			ExpectedReplaced:    true,
+			ExpectedNodeConfig:  false,
+		},
+
+		// Test 3 ensures the replaced deployment is kept as long as the guest
+		// cluster node of the replacement is not ready.
+		{
+			K8sObjects: []runtime.Object{
+				withNamespace(newTestDeployment("worker-1", key.WorkerID, "1"), "al9qy"),
+				withReplacementLabel(withNamespace(newTestDeployment("worker-1-r", key.WorkerID, "1"), "al9qy"), "worker-1-r"),
+				newPod("worker-1-6d4b7c9f8-5f8c9", "", "False"),
+				newPod("worker-1-r-7b8d5c6f4-x2k9w", "worker-1-r", "False"),
+			},
+			GuestK8sObjects: []runtime.Object{
+				newNode("worker-1-r-7b8d5c6f4-x2k9w", corev1.ConditionFalse),
+			},
+			ExpectedReplacement: true,
+			ExpectedReplaced:    true,
+			ExpectedNodeConfig:  false,
+		},
+
+		// Test 4 ensures the guest cluster node of the replaced deployment is
+		// drained once the replacement is ready.
+		{
+			K8sObjects: []runtime.Object{
+				withNamespace(newTestDeployment("worker-1", key.WorkerID, "1"), "al9qy"),
+				withReplacementLabel(withNamespace(newTestDeployment("worker-1-r", key.WorkerID, "1"), "al9qy"), "worker-1-r"),
+				newPod("worker-1-6d4b7c9f8-5f8c9", "", "False"),
+				newPod("worker-1-r-7b8d5c6f4-x2k9w", "worker-1-r", "False"),
+			},
+			GuestK8sObjects: []runtime.Object{
+				newNode("worker-1-r-7b8d5c6f4-x2k9w", corev1.ConditionTrue),
+			},
+			ExpectedReplacement: true,
+			ExpectedReplaced:    true,
+			ExpectedNodeConfig:  true,
+		},
+
+		// Test 5 ensures the replaced deployment is deleted once its guest
+		// cluster node is drained.
+		{
+			K8sObjects: []runtime.Object{
+				withNamespace(newTestDeployment("worker-1", key.WorkerID, "1"), "al9qy"),
+				withReplacementLabel(withNamespace(newTestDeployment("worker-1-r", key.WorkerID, "1"), "al9qy"), "worker-1-r"),
+				newPod("worker-1-6d4b7c9f8-5f8c9", "", "True"),
+				newPod("worker-1-r-7b8d5c6f4-x2k9w", "worker-1-r", "False"),
+			},
+			GuestK8sObjects: []runtime.Object{
+				newNode("worker-1-r-7b8d5c6f4-x2k9w", corev1.ConditionTrue),
+			},
+			ExpectedReplacement: true,
+			ExpectedReplaced:    false,
+			ExpectedNodeConfig:  false,
+		},
+	}
+
+	for i, tc := range testCases {
+		k8sClient := fake.NewSimpleClientset(tc.K8sObjects...)
+		g8sClient := g8sfake.NewSimpleClientset()
+
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.ClusterStatus = clusterstatustest.New()
+			resourceConfig.CustomerQuota = customerquotatest.New()
+			resourceConfig.G8sClient = g8sClient
+			resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset(tc.GuestK8sObjects...))
+			resourceConfig.K8sClient = k8sClient
+			resourceConfig.Logger = microloggertest.New()
+			resourceConfig.QuorumGuard = quorumguardtest.New()
+			resourceConfig.UpdateSurge = true
+
+			var err error
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		err := newResource.replaceWorker(context.TODO(), customObject, withNamespace(newTestDeployment("worker-1", key.WorkerID, "1"), "al9qy"))
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		replacement, err := k8sClient.Extensions().Deployments("al9qy").Get("worker-1-r", apismetav1.GetOptions{})
+		if tc.ExpectedReplacement && err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+		if tc.ExpectedReplaces != "" && replacement.GetAnnotations()[key.AnnotationReplaces] != tc.ExpectedReplaces {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedReplaces, replacement.GetAnnotations()[key.AnnotationReplaces])
+		}
+		if tc.ExpectedReplacement && replacement.Spec.Selector.MatchLabels[key.LabelReplacement] != "worker-1-r" {
+			t.Fatalf("case %d expected %#v got %#v", i+1, "worker-1-r", replacement.Spec.Selector.MatchLabels[key.LabelReplacement])
+		}
+
+		_, err = k8sClient.Extensions().Deployments("al9qy").Get("worker-1", apismetav1.GetOptions{})
+		if tc.ExpectedReplaced && err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+		if !tc.ExpectedReplaced && !apierrors.IsNotFound(err) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, "not found error", err)
+		}
+
+		_, err = g8sClient.CoreV1alpha1().NodeConfigs("al9qy").Get("worker-1-6d4b7c9f8-5f8c9", apismetav1.GetOptions{})
+		if tc.ExpectedNodeConfig && err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+		if !tc.ExpectedNodeConfig && !apierrors.IsNotFound(err) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, "not found error", err)
+		}
+	}
+}
+
+func Test_Resource_Deployment_adoptReplacements(t *testing.T) {
+	testCases := []struct {
+		CurrentDeployments   []*v1beta1.Deployment
+		DesiredDeployments   []*v1beta1.Deployment
+		ExpectedNames        []string
+		ExpectedReplacements []string
+	}{
+		// Test 1 ensures desired deployments are kept when they exist.
+		{
+			CurrentDeployments: []*v1beta1.Deployment{
+				newTestDeployment("master-1", key.MasterID, "1"),
+				newTestDeployment("worker-2", key.WorkerID, "2"),
+			},
+			DesiredDeployments: []*v1beta1.Deployment{
+				newTestDeployment("master-1", key.MasterID, "1"),
+				newTestDeployment("worker-2", key.WorkerID, "2"),
+				newTestDeployment("worker-3", key.WorkerID, "3"),
+			},
+			ExpectedNames: []string{"master-1", "worker-2", "worker-3"},
+		},
+
+		// Test 2 ensures desired deployments are kept while a replacement is in
+		// progress.
+		{
+			CurrentDeployments: []*v1beta1.Deployment{
+				newTestDeployment("worker-2", key.WorkerID, "2"),
+				newTestDeployment("worker-2-r", key.WorkerID, "2"),
+			},
+			DesiredDeployments: []*v1beta1.Deployment{
+				newTestDeployment("worker-2", key.WorkerID, "2"),
+			},
+			ExpectedNames: []string{"worker-2"},
+		},
+
+		// Test 3 ensures desired deployments are renamed once the replacement
+		// took over the worker.
+		{
+			CurrentDeployments: []*v1beta1.Deployment{
+				newTestDeployment("worker-2-r", key.WorkerID, "2"),
+			},
+			DesiredDeployments: []*v1beta1.Deployment{
+				newTestDeployment("worker-2", key.WorkerID, "2"),
+			},
+			ExpectedNames: []string{"worker-2-r"},
+		},
+
+		// Test 4 ensures renamed desired deployments keep selecting the pods of
+		// the replacement.
+		{
+			CurrentDeployments: []*v1beta1.Deployment{
+				withReplacementLabel(newTestDeployment("worker-2-r", key.WorkerID, "2"), "worker-2-r"),
+			},
+			DesiredDeployments: []*v1beta1.Deployment{
+				newTestDeployment("worker-2", key.WorkerID, "2"),
+			},
+			ExpectedNames:        []string{"worker-2-r"},
+			ExpectedReplacements: []string{"worker-2-r"},
+		},
+	}
+
+	for i, tc := range testCases {
+		deployments := adoptReplacements(tc.CurrentDeployments, tc.DesiredDeployments)
+
+		var names []string
+		var replacements []string
+		for _, d := range deployments {
+			names = append(names, d.GetName())
+			if d.Spec.Selector != nil {
+				replacements = append(replacements, d.Spec.Selector.MatchLabels[key.LabelReplacement])
+			}
+		}
+		if !reflect.DeepEqual(names, tc.ExpectedNames) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedNames, names)
+		}
+		if !reflect.DeepEqual(replacements, tc.ExpectedReplacements) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedReplacements, replacements)
+		}
+	}
+}
+
+func Test_Resource_Deployment_workerPodSelector(t *testing.T) {
+	testCases := []struct {
+		Deployment       *v1beta1.Deployment
+		ExpectedSelector string
+	}{
+		// Test 1 ensures the pods of replacements are not selected for workers
+		// which are not replacements.
+		{
+			Deployment:       newTestDeployment("worker-1", key.WorkerID, "1"),
+			ExpectedSelector: "app=worker,node=1,!kvm-operator.giantswarm.io/replacement",
+		},
+		// Test 2 ensures only the pods of the replacement are selected for
+		// replacements.
+		{
+			Deployment:       withReplacementLabel(newTestDeployment("worker-1-r", key.WorkerID, "1"), "worker-1-r"),
+			ExpectedSelector: "app=worker,node=1,kvm-operator.giantswarm.io/replacement=worker-1-r",
+		},
+		// Test 3 ensures workers replacing replacements keep their own selector.
+		{
+			Deployment:       withReplacementLabel(newTestDeployment("worker-1", key.WorkerID, "1"), "worker-1"),
+			ExpectedSelector: "app=worker,node=1,kvm-operator.giantswarm.io/replacement=worker-1",
+		},
+	}
+
+	for i, tc := range testCases {
+		selector := workerPodSelector(tc.Deployment)
+		if selector != tc.ExpectedSelector {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedSelector, selector)
+		}
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/operatorkit/controller"
 	"github.com/giantswarm/operatorkit/controller/context/updateallowedcontext"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard"
 )
 
 func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
@@ -27,6 +30,15 @@ func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange inte
 
 		namespace := key.ClusterNamespace(customObject)
 		for _, deployment := range deploymentsToUpdate {
+			if r.updateSurge && isWorkerDeployment(deployment) {
+				err := r.replaceWorker(ctx, customObject, deployment)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				continue
+			}
+
 			_, err := r.k8sClient.Extensions().Deployments(namespace).Update(deployment)
 			if err != nil {
 				return microerror.Mask(err)
@@ -42,6 +54,19 @@ func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange inte
 }
 
 func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	{
+		currentDeployments, err := toDeployments(currentState)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		desiredDeployments, err := toDeployments(desiredState)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		desiredState = adoptReplacements(currentDeployments, desiredDeployments)
+	}
+
 	create, err := r.newCreateChange(ctx, obj, currentState, desiredState)
 	if err != nil {
 		return nil, microerror.Mask(err)
@@ -55,6 +80,15 @@ func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desire
 		return nil, microerror.Mask(err)
 	}
 
+	create, update, err = r.enforceCustomerQuota(ctx, obj, currentState, create, update)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	create, update, err = r.checkHostCapacity(ctx, obj, currentState, create, update)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
 	patch := controller.NewPatch()
 	patch.SetCreateChange(create)
 	patch.SetDeleteChange(delete)
@@ -88,6 +122,24 @@ func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desir
 			}
 		}
 
+		// Rotated certificates are rolled out to masters first. Workers rendered
+		// with rotated certificates might not be able to talk to masters still
+		// running with the previous ones, e.g. in case the CA got rotated.
+		var mastersRotatingCerts bool
+		for _, currentDeployment := range currentDeployments {
+			desiredDeployment, err := getDeploymentByName(desiredDeployments, currentDeployment.Name)
+			if IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			if isMasterDeployment(desiredDeployment) && isCertsHashModified(desiredDeployment, currentDeployment) {
+				mastersRotatingCerts = true
+				break
+			}
+		}
+
 		// We select one deployment to be updated per reconciliation loop. Therefore
 		// we have to check its state on the version bundle level to see if a
 		// deployment is already up to date. We also check if there are any other
@@ -110,8 +162,31 @@ func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desir
 				continue
 			}
 
+			if isWorkerDeployment(desiredDeployment) && isCertsHashModified(desiredDeployment, currentDeployment) && mastersRotatingCerts {
+				r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not updating deployment '%s': masters have to be updated with rotated certificates first", currentDeployment.GetName()))
+				continue
+			}
+
+			if isMasterDeployment(desiredDeployment) {
+				allowed, err := r.isMasterUpdateAllowed(ctx, obj, currentDeployment)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+				if !allowed {
+					r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("cannot update any deployment: updating deployment '%s' would break the etcd quorum", currentDeployment.GetName()))
+					return nil, nil
+				}
+			}
+
 			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found deployment '%s' that has to be updated", desiredDeployment.GetName()))
 
+			// The etcd membership of masters is tracked on the current deployment
+			// and must survive the update.
+			if v, ok := currentDeployment.GetAnnotations()[key.AnnotationEtcdMember]; ok {
+				desiredDeployment = desiredDeployment.DeepCopy()
+				desiredDeployment.Annotations[key.AnnotationEtcdMember] = v
+			}
+
 			return []*v1beta1.Deployment{desiredDeployment}, nil
 		}
 	} else {
@@ -120,3 +195,57 @@ func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desir
 
 	return nil, nil
 }
+
+// isMasterUpdateAllowed asks the quorum guard whether the given master
+// deployment can be updated without the etcd cluster of the guest cluster
+// losing its quorum. Updating a master recreates its VM, which is why the IP
+// of its current pod identifies the etcd member going away.
+func (r *Resource) isMasterUpdateAllowed(ctx context.Context, obj interface{}, deployment *v1beta1.Deployment) (bool, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	n := key.ClusterNamespace(customObject)
+
+	var masterIP string
+	{
+		o := metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("app=%s,node=%s", key.MasterID, deployment.GetLabels()["node"]),
+		}
+		list, err := r.k8sClient.CoreV1().Pods(n).List(o)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		for _, p := range list.Items {
+			if key.IsPodDeleted(&p) {
+				continue
+			}
+			masterIP = p.GetAnnotations()[key.AnnotationIp]
+			if masterIP != "" {
+				break
+			}
+		}
+	}
+
+	request := quorumguard.Request{
+		Action:    quorumguard.ActionUpdate,
+		ClusterID: key.ClusterID(customObject),
+		MasterIP:  masterIP,
+		Object: corev1.ObjectReference{
+			APIVersion: "extensions/v1beta1",
+			Kind:       "Deployment",
+			Name:       deployment.GetName(),
+			Namespace:  n,
+			UID:        deployment.GetUID(),
+		},
+	}
+
+	decision, err := r.quorumGuard.Check(ctx, request)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return decision.Allowed, nil
+}
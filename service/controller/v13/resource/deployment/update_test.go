@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	g8sfake "github.com/giantswarm/apiextensions/pkg/clientset/versioned/fake"
 	"github.com/giantswarm/micrologger/microloggertest"
 	"github.com/giantswarm/operatorkit/controller/context/updateallowedcontext"
 	apiv1 "k8s.io/api/core/v1"
@@ -14,7 +15,11 @@ import (
 	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/customerquota/customerquotatest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
 	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard/quorumguardtest"
 )
 
 func Test_Resource_Deployment_newUpdateChange(t *testing.T) {
@@ -1209,8 +1214,13 @@ func Test_Resource_Deployment_newUpdateChange(t *testing.T) {
 	var newResource *Resource
 	{
 		resourceConfig := DefaultConfig()
+		resourceConfig.ClusterStatus = clusterstatustest.New()
+		resourceConfig.CustomerQuota = customerquotatest.New()
+		resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+		resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
 		resourceConfig.K8sClient = fake.NewSimpleClientset()
 		resourceConfig.Logger = microloggertest.New()
+		resourceConfig.QuorumGuard = quorumguardtest.New()
 		newResource, err = New(resourceConfig)
 		if err != nil {
 			t.Fatal("expected", nil, "got", err)
@@ -1240,3 +1250,193 @@ func Test_Resource_Deployment_newUpdateChange(t *testing.T) {
 		})
 	}
 }
+
+func Test_Resource_Deployment_newUpdateChange_QuorumGuard(t *testing.T) {
+	customObject := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	pod := &apiv1.Pod{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "master-1-6d4b7c9f8-5f8c9",
+			Namespace: "al9qy",
+			Annotations: map[string]string{
+				key.AnnotationIp: "10.0.0.1",
+			},
+			Labels: map[string]string{
+				"app":  key.MasterID,
+				"node": "1",
+			},
+		},
+	}
+
+	testCases := []struct {
+		Denied          bool
+		ExpectedUpdated bool
+	}{
+		// Test 1 ensures a master deployment is updated in case the quorum guard
+		// allows it.
+		{
+			Denied:          false,
+			ExpectedUpdated: true,
+		},
+
+		// Test 2 ensures a master deployment is not updated in case the quorum
+		// guard denies it.
+		{
+			Denied:          true,
+			ExpectedUpdated: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		quorumGuard := quorumguardtest.New()
+		quorumGuard.Denied = tc.Denied
+
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.ClusterStatus = clusterstatustest.New()
+			resourceConfig.CustomerQuota = customerquotatest.New()
+			resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+			resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
+			resourceConfig.K8sClient = fake.NewSimpleClientset(pod)
+			resourceConfig.Logger = microloggertest.New()
+			resourceConfig.QuorumGuard = quorumGuard
+
+			var err error
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		ctx := updateallowedcontext.NewContext(context.Background(), make(chan struct{}))
+		updateallowedcontext.SetUpdateAllowed(ctx)
+
+		currentState := []*v1beta1.Deployment{withAnnotation(newTestDeployment("master-1", key.MasterID, "1"), key.VersionBundleVersionAnnotation, "1.0.0")}
+		desiredState := []*v1beta1.Deployment{withAnnotation(newTestDeployment("master-1", key.MasterID, "1"), key.VersionBundleVersionAnnotation, "1.1.0")}
+
+		updateState, err := newResource.newUpdateChange(ctx, customObject, currentState, desiredState)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+		if (updateState != nil) != tc.ExpectedUpdated {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedUpdated, updateState != nil)
+		}
+
+		if len(quorumGuard.Requests) != 1 {
+			t.Fatalf("case %d expected %#v got %#v", i+1, 1, len(quorumGuard.Requests))
+		}
+		if quorumGuard.Requests[0].MasterIP != "10.0.0.1" {
+			t.Fatalf("case %d expected %#v got %#v", i+1, "10.0.0.1", quorumGuard.Requests[0].MasterIP)
+		}
+	}
+}
+
+func Test_Resource_Deployment_newUpdateChange_CertsRotation(t *testing.T) {
+	customObject := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	testCases := []struct {
+		CurrentState    []*v1beta1.Deployment
+		DesiredState    []*v1beta1.Deployment
+		Denied          bool
+		ExpectedUpdated []string
+	}{
+		// Test 1 ensures the master is updated first in case workers are listed
+		// before masters.
+		{
+			CurrentState: []*v1beta1.Deployment{
+				withCertsHash(withAnnotation(newTestDeployment("worker-1", key.WorkerID, "worker-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "old-worker"),
+				withCertsHash(withAnnotation(newTestDeployment("master-1", key.MasterID, "master-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "old-master"),
+			},
+			DesiredState: []*v1beta1.Deployment{
+				withCertsHash(withAnnotation(newTestDeployment("worker-1", key.WorkerID, "worker-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "new-worker"),
+				withCertsHash(withAnnotation(newTestDeployment("master-1", key.MasterID, "master-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "new-master"),
+			},
+			Denied:          false,
+			ExpectedUpdated: []string{"master-1"},
+		},
+
+		// Test 2 ensures workers are updated once the masters got the rotated
+		// certificates.
+		{
+			CurrentState: []*v1beta1.Deployment{
+				withCertsHash(withAnnotation(newTestDeployment("worker-1", key.WorkerID, "worker-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "old-worker"),
+				withCertsHash(withAnnotation(newTestDeployment("master-1", key.MasterID, "master-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "new-master"),
+			},
+			DesiredState: []*v1beta1.Deployment{
+				withCertsHash(withAnnotation(newTestDeployment("worker-1", key.WorkerID, "worker-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "new-worker"),
+				withCertsHash(withAnnotation(newTestDeployment("master-1", key.MasterID, "master-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "new-master"),
+			},
+			Denied:          false,
+			ExpectedUpdated: []string{"worker-1"},
+		},
+
+		// Test 3 ensures workers are not updated in case the update of the master
+		// is denied.
+		{
+			CurrentState: []*v1beta1.Deployment{
+				withCertsHash(withAnnotation(newTestDeployment("worker-1", key.WorkerID, "worker-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "old-worker"),
+				withCertsHash(withAnnotation(newTestDeployment("master-1", key.MasterID, "master-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "old-master"),
+			},
+			DesiredState: []*v1beta1.Deployment{
+				withCertsHash(withAnnotation(newTestDeployment("worker-1", key.WorkerID, "worker-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "new-worker"),
+				withCertsHash(withAnnotation(newTestDeployment("master-1", key.MasterID, "master-1"), key.VersionBundleVersionAnnotation, "1.0.0"), "new-master"),
+			},
+			Denied:          true,
+			ExpectedUpdated: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		quorumGuard := quorumguardtest.New()
+		quorumGuard.Denied = tc.Denied
+
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.ClusterStatus = clusterstatustest.New()
+			resourceConfig.CustomerQuota = customerquotatest.New()
+			resourceConfig.G8sClient = g8sfake.NewSimpleClientset()
+			resourceConfig.GuestClient = guestclienttest.New(fake.NewSimpleClientset())
+			resourceConfig.K8sClient = fake.NewSimpleClientset()
+			resourceConfig.Logger = microloggertest.New()
+			resourceConfig.QuorumGuard = quorumGuard
+
+			var err error
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		ctx := updateallowedcontext.NewContext(context.Background(), make(chan struct{}))
+		updateallowedcontext.SetUpdateAllowed(ctx)
+
+		updateState, err := newResource.newUpdateChange(ctx, customObject, tc.CurrentState, tc.DesiredState)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		var updated []string
+		if updateState != nil {
+			for _, d := range updateState.([]*v1beta1.Deployment) {
+				updated = append(updated, d.GetName())
+			}
+		}
+		if !reflect.DeepEqual(updated, tc.ExpectedUpdated) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedUpdated, updated)
+		}
+	}
+}
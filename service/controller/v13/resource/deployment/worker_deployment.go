@@ -14,15 +14,30 @@ import (
 	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
-func newWorkerDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Deployment, error) {
+func newWorkerDeployments(customObject v1alpha1.KVMConfig, workers []key.WorkerNode) ([]*extensionsv1.Deployment, error) {
 	var deployments []*extensionsv1.Deployment
 
-	privileged := true
 	replicas := int32(1)
 	podDeletionGracePeriod := int64(key.PodDeletionGracePeriod.Seconds())
 
-	for i, workerNode := range customObject.Spec.Cluster.Workers {
-		capabilities := customObject.Spec.KVM.Workers[i]
+	coreosVersion, err := key.CoreosVersion(customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, worker := range workers {
+		workerNode := worker.Node
+		capabilities := worker.Size
+
+		labels := map[string]string{
+			"app":      key.WorkerID,
+			"cluster":  key.ClusterID(customObject),
+			"customer": key.ClusterCustomer(customObject),
+			"node":     workerNode.ID,
+		}
+		if worker.Pool != "" {
+			labels[key.NodePoolLabel] = worker.Pool
+		}
 
 		cpuQuantity, err := key.CPUQuantity(capabilities)
 		if err != nil {
@@ -44,12 +59,7 @@ func newWorkerDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 				Annotations: map[string]string{
 					key.VersionBundleVersionAnnotation: key.VersionBundleVersion(customObject),
 				},
-				Labels: map[string]string{
-					"app":      key.WorkerID,
-					"cluster":  key.ClusterID(customObject),
-					"customer": key.ClusterCustomer(customObject),
-					"node":     workerNode.ID,
-				},
+				Labels: labels,
 			},
 			Spec: extensionsv1.DeploymentSpec{
 				Strategy: extensionsv1.DeploymentStrategy{
@@ -119,7 +129,7 @@ func newWorkerDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 						Containers: []apiv1.Container{
 							{
 								Name:            "k8s-endpoint-updater",
-								Image:           key.K8SEndpointUpdaterDocker,
+								Image:           key.EndpointUpdaterImage(customObject),
 								ImagePullPolicy: apiv1.PullIfNotPresent,
 								Command: []string{
 									"/bin/sh",
@@ -130,9 +140,7 @@ func newWorkerDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 										" --service.kubernetes.inCluster=true" +
 										" --service.kubernetes.pod.name=${POD_NAME}",
 								},
-								SecurityContext: &apiv1.SecurityContext{
-									Privileged: &privileged,
-								},
+								SecurityContext: newUnprivilegedSecurityContext(),
 								Env: []apiv1.EnvVar{
 									{
 										Name: "POD_NAME",
@@ -146,12 +154,10 @@ func newWorkerDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 								},
 							},
 							{
-								Name:            "k8s-kvm",
-								Image:           key.K8SKVMDockerImage,
+								Name:            key.K8SKVMContainerName,
+								Image:           key.K8SKVMImage(customObject),
 								ImagePullPolicy: apiv1.PullIfNotPresent,
-								SecurityContext: &apiv1.SecurityContext{
-									Privileged: &privileged,
-								},
+								SecurityContext: newKVMSecurityContext(),
 								Args: []string{
 									key.WorkerID,
 								},
@@ -162,7 +168,7 @@ func newWorkerDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 									},
 									{
 										Name:  "COREOS_VERSION",
-										Value: key.CoreosVersion,
+										Value: coreosVersion,
 									},
 									{
 										Name:  "DISK",
@@ -234,6 +240,7 @@ func newWorkerDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 									{
 										Name:      "cloud-config",
 										MountPath: "/cloudconfig/",
+										ReadOnly:  true,
 									},
 									{
 										Name:      "images",
@@ -259,13 +266,12 @@ func newWorkerDeployments(customObject v1alpha1.KVMConfig) ([]*extensionsv1.Depl
 										Value: key.NetworkEnvFilePath(customObject),
 									},
 								},
-								SecurityContext: &apiv1.SecurityContext{
-									Privileged: &privileged,
-								},
+								SecurityContext: newUnprivilegedSecurityContext(),
 								VolumeMounts: []apiv1.VolumeMount{
 									{
 										Name:      "flannel",
 										MountPath: key.FlannelEnvPathPrefix,
+										ReadOnly:  true,
 									},
 								},
 							},
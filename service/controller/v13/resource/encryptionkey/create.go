@@ -0,0 +1,316 @@
+package encryptionkey
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/randomkeys"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// encryptionKeySize is the size in bytes of the AES-CBC keys the API server
+	// encrypts secrets with.
+	encryptionKeySize = 32
+)
+
+// EnsureCreated moves the requested rotation of the encryption key one phase
+// forward. The new key is first added to the masters as secondary key in case
+// the guest cluster runs multiple masters. The new key is then promoted to be
+// the primary key, all secrets of the guest cluster are rewritten to be
+// encrypted with it and the old key is removed. Masters are rolled by the
+// deployment resource, since their cloud-configs change with the keys of each
+// phase.
+func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	secret, err := r.findSecret(customObject)
+	if IsNotFound(err) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "not rotating encryption key: encryption key secret not found")
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	keys, err := key.EncryptionKeysFromSecret(secret)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	requested := key.EncryptionKeyRotation(customObject)
+	id := secret.GetAnnotations()[key.AnnotationEncryptionKeyRotationID]
+	phase := secret.GetAnnotations()[key.AnnotationEncryptionKeyRotationPhase]
+
+	if phase == "" || phase == key.EncryptionKeyRotationPhaseCompleted {
+		if requested == "" || requested == id {
+			r.logger.LogCtx(ctx, "level", "debug", "message", "not rotating encryption key: no rotation requested")
+			return nil
+		}
+
+		newKey, err := newEncryptionKey(keys)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// With multiple masters, the masters not rolled yet would not be able to
+		// decrypt secrets encrypted with the new key. So the new key is added to
+		// all masters before any of them encrypts with it.
+		if len(customObject.Spec.Cluster.Masters) > 1 {
+			setEncryptionKeys(secret, keys[0], &newKey)
+			phase = key.EncryptionKeyRotationPhaseAddingKey
+		} else {
+			setEncryptionKeys(secret, newKey, &keys[0])
+			phase = key.EncryptionKeyRotationPhasePromotingKey
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("starting encryption key rotation '%s'", requested))
+
+		err = r.updateSecret(ctx, customObject, secret, requested, phase)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("started encryption key rotation '%s'", requested))
+
+		return nil
+	}
+
+	if requested != id {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("encryption key rotation '%s' requested, waiting for rotation '%s' to complete first", requested, id))
+	}
+
+	switch phase {
+	case key.EncryptionKeyRotationPhaseAddingKey, key.EncryptionKeyRotationPhasePromotingKey, key.EncryptionKeyRotationPhaseRemovingKey:
+		rolled, err := r.mastersRolled(customObject, key.EncryptionKeysHash(keys))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if !rolled {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("encryption key rotation '%s' in phase '%s' waiting for masters to be rolled", id, phase))
+
+			err = r.setCondition(ctx, customObject, id, phase)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+
+		switch phase {
+		case key.EncryptionKeyRotationPhaseAddingKey:
+			if len(keys) != 2 {
+				return microerror.Maskf(invalidSecretError, "encryption key rotation '%s' in phase '%s' requires a secondary key", id, phase)
+			}
+			setEncryptionKeys(secret, keys[1], &keys[0])
+			phase = key.EncryptionKeyRotationPhasePromotingKey
+		case key.EncryptionKeyRotationPhasePromotingKey:
+			phase = key.EncryptionKeyRotationPhaseRewritingSecrets
+		case key.EncryptionKeyRotationPhaseRemovingKey:
+			phase = key.EncryptionKeyRotationPhaseCompleted
+		}
+	case key.EncryptionKeyRotationPhaseRewritingSecrets:
+		err := r.rewriteSecrets(ctx, customObject)
+		if err != nil {
+			// The guest cluster API may not be reachable while masters are
+			// restarted. Failing here would block the resources following this
+			// one, so we just try again on the next reconciliation.
+			r.logger.LogCtx(ctx, "level", "warning", "message", "cannot rewrite secrets of guest cluster", "stack", fmt.Sprintf("%#v", err))
+
+			err = r.setCondition(ctx, customObject, id, phase)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+
+		setEncryptionKeys(secret, keys[0], nil)
+		phase = key.EncryptionKeyRotationPhaseRemovingKey
+	default:
+		return microerror.Maskf(unknownPhaseError, "encryption key rotation '%s' is in unknown phase '%s'", id, phase)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("moving encryption key rotation '%s' to phase '%s'", id, phase))
+
+	err = r.updateSecret(ctx, customObject, secret, id, phase)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("moved encryption key rotation '%s' to phase '%s'", id, phase))
+
+	return nil
+}
+
+func (r *Resource) findSecret(customObject v1alpha1.KVMConfig) (*corev1.Secret, error) {
+	o := metav1.ListOptions{
+		LabelSelector: key.EncryptionKeySecretSelector(key.ClusterID(customObject)),
+	}
+	list, err := r.k8sClient.CoreV1().Secrets(randomkeys.SecretNamespace).List(o)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if len(list.Items) == 0 {
+		return nil, microerror.Mask(notFoundError)
+	}
+
+	return &list.Items[0], nil
+}
+
+// mastersRolled returns true in case all master deployments of the guest
+// cluster run pods booted with the encryption keys of the given hash.
+func (r *Resource) mastersRolled(customObject v1alpha1.KVMConfig, keysHash string) (bool, error) {
+	o := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", key.MasterID),
+	}
+	list, err := r.k8sClient.Extensions().Deployments(key.ClusterNamespace(customObject)).List(o)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if len(list.Items) == 0 {
+		return false, nil
+	}
+
+	for _, d := range list.Items {
+		if d.Spec.Template.GetAnnotations()[key.AnnotationEncryptionKeysHash] != keysHash {
+			return false, nil
+		}
+		if d.Status.ObservedGeneration < d.Generation {
+			return false, nil
+		}
+
+		s := d.Status
+		if s.Replicas == 0 || s.AvailableReplicas != s.Replicas || s.ReadyReplicas != s.Replicas || s.UpdatedReplicas != s.Replicas {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// rewriteSecrets updates all secrets of the guest cluster without modifying
+// them, which makes the API server encrypt them using the primary key.
+func (r *Resource) rewriteSecrets(ctx context.Context, customObject v1alpha1.KVMConfig) error {
+	k8sClient, err := r.guestClient.NewK8sClient(customObject)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	list, err := k8sClient.CoreV1().Secrets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("rewriting %d secrets of guest cluster", len(list.Items)))
+
+	for i := range list.Items {
+		s := &list.Items[i]
+
+		_, err := k8sClient.CoreV1().Secrets(s.Namespace).Update(s)
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			// Secrets changed or deleted in the meantime have been written with
+			// the primary key already.
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("rewrote %d secrets of guest cluster", len(list.Items)))
+
+	return nil
+}
+
+// updateSecret writes the state of the rotation to the given secret and
+// reports the phase in the status of the custom object.
+func (r *Resource) updateSecret(ctx context.Context, customObject v1alpha1.KVMConfig, secret *corev1.Secret, id, phase string) error {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[key.AnnotationEncryptionKeyRotationID] = id
+	secret.Annotations[key.AnnotationEncryptionKeyRotationPhase] = phase
+
+	_, err := r.k8sClient.CoreV1().Secrets(secret.Namespace).Update(secret)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.setCondition(ctx, customObject, id, phase)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *Resource) setCondition(ctx context.Context, customObject v1alpha1.KVMConfig, id, phase string) error {
+	c := clusterstatus.Condition{
+		Message: fmt.Sprintf("encryption key rotation '%s' is in phase '%s'", id, phase),
+		Reason:  phase,
+		Status:  clusterstatus.ConditionStatusFalse,
+		Type:    clusterstatus.ConditionEncryptionKeyRotated,
+	}
+	if phase == key.EncryptionKeyRotationPhaseCompleted {
+		c.Message = fmt.Sprintf("encryption key rotation '%s' is completed", id)
+		c.Status = clusterstatus.ConditionStatusTrue
+	}
+
+	err := r.clusterStatus.SetCondition(ctx, customObject, c)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// newEncryptionKey generates a new random key named to follow the given keys.
+func newEncryptionKey(keys []key.EncryptionKey) (key.EncryptionKey, error) {
+	b := make([]byte, encryptionKeySize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return key.EncryptionKey{}, microerror.Mask(err)
+	}
+
+	k := key.EncryptionKey{
+		Name:   key.NextEncryptionKeyName(keys),
+		Secret: base64.StdEncoding.EncodeToString(b),
+	}
+
+	return k, nil
+}
+
+// setEncryptionKeys stores the given keys in the given secret. The secondary
+// key is removed in case it is nil.
+func setEncryptionKeys(secret *corev1.Secret, primary key.EncryptionKey, secondary *key.EncryptionKey) {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	secret.Annotations[key.AnnotationEncryptionKeyName] = primary.Name
+	secret.Data[string(randomkeys.EncryptionKey)] = []byte(primary.Secret)
+
+	if secondary != nil {
+		secret.Annotations[key.AnnotationEncryptionKeySecondaryName] = secondary.Name
+		secret.Data[key.EncryptionKeySecondaryDataKey] = []byte(secondary.Secret)
+	} else {
+		delete(secret.Annotations, key.AnnotationEncryptionKeySecondaryName)
+		delete(secret.Data, key.EncryptionKeySecondaryDataKey)
+	}
+}
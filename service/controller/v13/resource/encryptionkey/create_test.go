@@ -0,0 +1,321 @@
+package encryptionkey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_Resource_EncryptionKey_EnsureCreated(t *testing.T) {
+	newCustomObject := func(rotation string, masters int) v1alpha1.KVMConfig {
+		customObject := v1alpha1.KVMConfig{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Annotations: map[string]string{},
+			},
+			Spec: v1alpha1.KVMConfigSpec{
+				Cluster: v1alpha1.Cluster{
+					ID:      "al9qy",
+					Masters: make([]v1alpha1.ClusterNode, masters),
+				},
+			},
+		}
+		if rotation != "" {
+			customObject.Annotations[key.AnnotationEncryptionKeyRotation] = rotation
+		}
+
+		return customObject
+	}
+
+	newSecret := func(id, phase string, keys ...key.EncryptionKey) *corev1.Secret {
+		s := &corev1.Secret{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:        "al9qy-encryption",
+				Namespace:   "default",
+				Annotations: map[string]string{},
+				Labels: map[string]string{
+					"clusterID":  "al9qy",
+					"clusterKey": "encryption",
+				},
+			},
+			Data: map[string][]byte{},
+		}
+		if id != "" {
+			s.Annotations[key.AnnotationEncryptionKeyRotationID] = id
+			s.Annotations[key.AnnotationEncryptionKeyRotationPhase] = phase
+		}
+		setEncryptionKeys(s, keys[0], nil)
+		if len(keys) > 1 {
+			setEncryptionKeys(s, keys[0], &keys[1])
+		}
+
+		return s
+	}
+
+	newDeployment := func(id string, keys ...key.EncryptionKey) runtime.Object {
+		return &v1beta1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      key.DeploymentName(key.MasterID, id),
+				Namespace: "al9qy",
+				Labels: map[string]string{
+					"app": key.MasterID,
+				},
+			},
+			Spec: v1beta1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Annotations: map[string]string{
+							key.AnnotationEncryptionKeysHash: key.EncryptionKeysHash(keys),
+						},
+					},
+				},
+			},
+			Status: v1beta1.DeploymentStatus{
+				AvailableReplicas: 1,
+				ReadyReplicas:     1,
+				Replicas:          1,
+				UpdatedReplicas:   1,
+			},
+		}
+	}
+
+	oldKey := key.EncryptionKey{Name: "key1", Secret: "b2xk"}
+	newKey := key.EncryptionKey{Name: "key2", Secret: "bmV3"}
+
+	testCases := []struct {
+		CustomObject            v1alpha1.KVMConfig
+		Secret                  *corev1.Secret
+		Deployments             []runtime.Object
+		ExpectedPhase           string
+		ExpectedKeyNames        []string
+		ExpectedConditionStatus string
+		ExpectedRewrites        int
+		ExpectedErrorFn         func(error) bool
+	}{
+		// Test 0 ensures nothing happens in case no rotation is requested.
+		{
+			CustomObject:     newCustomObject("", 1),
+			Secret:           newSecret("", "", oldKey),
+			ExpectedPhase:    "",
+			ExpectedKeyNames: []string{"key1"},
+		},
+
+		// Test 1 ensures the new key is promoted right away for guest clusters
+		// running a single master.
+		{
+			CustomObject:            newCustomObject("r1", 1),
+			Secret:                  newSecret("", "", oldKey),
+			ExpectedPhase:           key.EncryptionKeyRotationPhasePromotingKey,
+			ExpectedKeyNames:        []string{"key2", "key1"},
+			ExpectedConditionStatus: clusterstatus.ConditionStatusFalse,
+		},
+
+		// Test 2 ensures the new key is added as secondary key first for guest
+		// clusters running multiple masters.
+		{
+			CustomObject:            newCustomObject("r1", 3),
+			Secret:                  newSecret("", "", oldKey),
+			ExpectedPhase:           key.EncryptionKeyRotationPhaseAddingKey,
+			ExpectedKeyNames:        []string{"key1", "key2"},
+			ExpectedConditionStatus: clusterstatus.ConditionStatusFalse,
+		},
+
+		// Test 3 ensures the rotation waits for masters not rolled yet.
+		{
+			CustomObject: newCustomObject("r1", 3),
+			Secret:       newSecret("r1", key.EncryptionKeyRotationPhaseAddingKey, oldKey, newKey),
+			Deployments: []runtime.Object{
+				newDeployment("m1", oldKey, newKey),
+				newDeployment("m2", oldKey),
+			},
+			ExpectedPhase:           key.EncryptionKeyRotationPhaseAddingKey,
+			ExpectedKeyNames:        []string{"key1", "key2"},
+			ExpectedConditionStatus: clusterstatus.ConditionStatusFalse,
+		},
+
+		// Test 4 ensures the new key is promoted once all masters were rolled
+		// with it.
+		{
+			CustomObject: newCustomObject("r1", 3),
+			Secret:       newSecret("r1", key.EncryptionKeyRotationPhaseAddingKey, oldKey, newKey),
+			Deployments: []runtime.Object{
+				newDeployment("m1", oldKey, newKey),
+				newDeployment("m2", oldKey, newKey),
+			},
+			ExpectedPhase:           key.EncryptionKeyRotationPhasePromotingKey,
+			ExpectedKeyNames:        []string{"key2", "key1"},
+			ExpectedConditionStatus: clusterstatus.ConditionStatusFalse,
+		},
+
+		// Test 5 ensures secrets are rewritten once all masters encrypt with the
+		// new key.
+		{
+			CustomObject: newCustomObject("r1", 1),
+			Secret:       newSecret("r1", key.EncryptionKeyRotationPhasePromotingKey, newKey, oldKey),
+			Deployments: []runtime.Object{
+				newDeployment("m1", newKey, oldKey),
+			},
+			ExpectedPhase:           key.EncryptionKeyRotationPhaseRewritingSecrets,
+			ExpectedKeyNames:        []string{"key2", "key1"},
+			ExpectedConditionStatus: clusterstatus.ConditionStatusFalse,
+		},
+
+		// Test 6 ensures all secrets of the guest cluster are rewritten and the
+		// old key is removed afterwards.
+		{
+			CustomObject:            newCustomObject("r1", 1),
+			Secret:                  newSecret("r1", key.EncryptionKeyRotationPhaseRewritingSecrets, newKey, oldKey),
+			ExpectedPhase:           key.EncryptionKeyRotationPhaseRemovingKey,
+			ExpectedKeyNames:        []string{"key2"},
+			ExpectedConditionStatus: clusterstatus.ConditionStatusFalse,
+			ExpectedRewrites:        2,
+		},
+
+		// Test 7 ensures the rotation completes once all masters were rolled
+		// without the old key.
+		{
+			CustomObject: newCustomObject("r1", 1),
+			Secret:       newSecret("r1", key.EncryptionKeyRotationPhaseRemovingKey, newKey),
+			Deployments: []runtime.Object{
+				newDeployment("m1", newKey),
+			},
+			ExpectedPhase:           key.EncryptionKeyRotationPhaseCompleted,
+			ExpectedKeyNames:        []string{"key2"},
+			ExpectedConditionStatus: clusterstatus.ConditionStatusTrue,
+		},
+
+		// Test 8 ensures a completed rotation is not started again.
+		{
+			CustomObject:     newCustomObject("r1", 1),
+			Secret:           newSecret("r1", key.EncryptionKeyRotationPhaseCompleted, newKey),
+			ExpectedPhase:    key.EncryptionKeyRotationPhaseCompleted,
+			ExpectedKeyNames: []string{"key2"},
+		},
+
+		// Test 9 ensures a new rotation gets the next key name.
+		{
+			CustomObject:            newCustomObject("r2", 1),
+			Secret:                  newSecret("r1", key.EncryptionKeyRotationPhaseCompleted, newKey),
+			ExpectedPhase:           key.EncryptionKeyRotationPhasePromotingKey,
+			ExpectedKeyNames:        []string{"key3", "key2"},
+			ExpectedConditionStatus: clusterstatus.ConditionStatusFalse,
+		},
+
+		// Test 10 ensures an error is returned for unknown phases.
+		{
+			CustomObject:    newCustomObject("r1", 1),
+			Secret:          newSecret("r1", "Unknown", newKey),
+			ExpectedErrorFn: IsUnknownPhase,
+		},
+	}
+
+	for i, tc := range testCases {
+		objs := append([]runtime.Object{tc.Secret}, tc.Deployments...)
+		k8sClient := fake.NewSimpleClientset(objs...)
+
+		guestK8sClient := fake.NewSimpleClientset(
+			&corev1.Secret{ObjectMeta: apismetav1.ObjectMeta{Name: "s1", Namespace: "default"}},
+			&corev1.Secret{ObjectMeta: apismetav1.ObjectMeta{Name: "s2", Namespace: "kube-system"}},
+		)
+
+		clusterStatus := clusterstatustest.New()
+
+		var newResource *Resource
+		{
+			c := Config{
+				ClusterStatus: clusterStatus,
+				GuestClient:   guestclienttest.New(guestK8sClient),
+				K8sClient:     k8sClient,
+				Logger:        microloggertest.New(),
+			}
+
+			var err error
+			newResource, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		err := newResource.EnsureCreated(context.TODO(), &tc.CustomObject)
+		if tc.ExpectedErrorFn != nil {
+			if !tc.ExpectedErrorFn(err) {
+				t.Fatalf("case %d expected error got %#v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		secret, err := k8sClient.CoreV1().Secrets("default").Get("al9qy-encryption", apismetav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		phase := secret.Annotations[key.AnnotationEncryptionKeyRotationPhase]
+		if phase != tc.ExpectedPhase {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedPhase, phase)
+		}
+
+		keys, err := key.EncryptionKeysFromSecret(secret)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		var names []string
+		for _, k := range keys {
+			if k.Secret == "" {
+				t.Fatalf("case %d expected key %#v to have a secret", i, k.Name)
+			}
+			names = append(names, k.Name)
+		}
+		if len(names) != len(tc.ExpectedKeyNames) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedKeyNames, names)
+		}
+		for j := range names {
+			if names[j] != tc.ExpectedKeyNames[j] {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedKeyNames, names)
+			}
+		}
+
+		if tc.ExpectedConditionStatus == "" {
+			if len(clusterStatus.Conditions) != 0 {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, clusterStatus.Conditions)
+			}
+		} else {
+			if len(clusterStatus.Conditions) != 1 {
+				t.Fatalf("case %d expected %#v got %#v", i, 1, len(clusterStatus.Conditions))
+			}
+			c := clusterStatus.Conditions[0]
+			if c.Type != clusterstatus.ConditionEncryptionKeyRotated {
+				t.Fatalf("case %d expected %#v got %#v", i, clusterstatus.ConditionEncryptionKeyRotated, c.Type)
+			}
+			if c.Status != tc.ExpectedConditionStatus {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedConditionStatus, c.Status)
+			}
+			if c.Reason != tc.ExpectedPhase {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedPhase, c.Reason)
+			}
+		}
+
+		var rewrites int
+		for _, a := range guestK8sClient.Actions() {
+			if a.GetVerb() == "update" && a.GetResource().Resource == "secrets" {
+				rewrites++
+			}
+		}
+		if rewrites != tc.ExpectedRewrites {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedRewrites, rewrites)
+		}
+	}
+}
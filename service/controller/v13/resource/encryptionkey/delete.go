@@ -0,0 +1,11 @@
+package encryptionkey
+
+import (
+	"context"
+)
+
+// EnsureDeleted does nothing. The secret holding the encryption key is
+// managed by cert-operator and goes away together with the guest cluster.
+func (r *Resource) EnsureDeleted(ctx context.Context, obj interface{}) error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package encryptionkey
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = microerror.New("invalid config")
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var notFoundError = microerror.New("not found")
+
+// IsNotFound asserts notFoundError.
+func IsNotFound(err error) bool {
+	return microerror.Cause(err) == notFoundError
+}
+
+var unknownPhaseError = microerror.New("unknown phase")
+
+// IsUnknownPhase asserts unknownPhaseError.
+func IsUnknownPhase(err error) bool {
+	return microerror.Cause(err) == unknownPhaseError
+}
+
+var invalidSecretError = microerror.New("invalid secret")
+
+// IsInvalidSecret asserts invalidSecretError.
+func IsInvalidSecret(err error) bool {
+	return microerror.Cause(err) == invalidSecretError
+}
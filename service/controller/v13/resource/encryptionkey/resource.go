@@ -0,0 +1,61 @@
+package encryptionkey
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient"
+)
+
+const (
+	Name = "encryptionkeyv13"
+)
+
+type Config struct {
+	ClusterStatus clusterstatus.Interface
+	GuestClient   guestclient.Interface
+	K8sClient     kubernetes.Interface
+	Logger        micrologger.Logger
+}
+
+// Resource rotates the key the API server of guest clusters encrypts secrets
+// with, as requested using key.AnnotationEncryptionKeyRotation. The state of
+// the rotation is tracked on the secret holding the encryption key, which the
+// cloud-configs of masters are rendered from. The rotation moves at most one
+// phase forward per reconciliation.
+type Resource struct {
+	clusterStatus clusterstatus.Interface
+	guestClient   guestclient.Interface
+	k8sClient     kubernetes.Interface
+	logger        micrologger.Logger
+}
+
+func New(config Config) (*Resource, error) {
+	if config.ClusterStatus == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ClusterStatus must not be empty", config)
+	}
+	if config.GuestClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GuestClient must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	r := &Resource{
+		clusterStatus: config.ClusterStatus,
+		guestClient:   config.GuestClient,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
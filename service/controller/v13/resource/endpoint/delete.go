@@ -73,7 +73,7 @@ func (r *Resource) newDeleteChangeForDeletePatch(ctx context.Context, obj, curre
 	endpoint := &Endpoint{
 		ServiceName:      currentEndpoint.ServiceName,
 		ServiceNamespace: currentEndpoint.ServiceNamespace,
-		IPs:              cutIPs(currentEndpoint.IPs, desiredEndpoint.IPs),
+		IPs:              cutIPs(currentEndpoint.IPs, append(desiredEndpoint.IPs, desiredEndpoint.NotReadyIPs...)),
 	}
 	if len(endpoint.IPs) > 0 {
 		return nil, nil
@@ -107,7 +107,7 @@ func (r *Resource) newDeleteChangeForUpdatePatch(ctx context.Context, obj, curre
 	endpoint := &Endpoint{
 		ServiceName:      currentEndpoint.ServiceName,
 		ServiceNamespace: currentEndpoint.ServiceNamespace,
-		IPs:              cutIPs(currentEndpoint.IPs, desiredEndpoint.IPs),
+		IPs:              cutIPs(currentEndpoint.IPs, append(desiredEndpoint.IPs, desiredEndpoint.NotReadyIPs...)),
 	}
 
 	if len(endpoint.IPs) == 0 {
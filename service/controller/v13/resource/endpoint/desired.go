@@ -18,11 +18,18 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 	}
 
 	desiredEndpoint := &Endpoint{
-		IPs: []string{
-			endpointIP,
-		},
 		ServiceName:      serviceName,
 		ServiceNamespace: pod.GetNamespace(),
 	}
+	if isMasterPod(pod) && isPodNotReady(pod) {
+		desiredEndpoint.NotReadyIPs = []string{
+			endpointIP,
+		}
+	} else {
+		desiredEndpoint.IPs = []string{
+			endpointIP,
+		}
+	}
+
 	return desiredEndpoint, nil
 }
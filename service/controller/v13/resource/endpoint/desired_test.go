@@ -76,6 +76,37 @@ func Test_Resource_Endpoint_GetDesiredState(t *testing.T) {
 			ExpectedErrorHandler: IsMissingAnnotationError,
 			ExpectedEndpoint:     nil,
 		},
+		{
+			Obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "TestPod",
+					Namespace: "TestNamespace",
+					Annotations: map[string]string{
+						"endpoint.kvm.giantswarm.io/ip":      "1.1.1.1",
+						"endpoint.kvm.giantswarm.io/service": "TestService",
+					},
+					Labels: map[string]string{
+						"app": "master",
+					},
+				},
+				Status: corev1.PodStatus{
+					Conditions: []corev1.PodCondition{
+						{
+							Type:   corev1.PodReady,
+							Status: corev1.ConditionFalse,
+						},
+					},
+				},
+			},
+			ExpectedEndpoint: &Endpoint{
+				NotReadyIPs: []string{
+					"1.1.1.1",
+				},
+				ServiceName:      "TestService",
+				ServiceNamespace: "TestNamespace",
+			},
+			ExpectedErrorHandler: nil,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -8,6 +8,8 @@ import (
 	"github.com/giantswarm/micrologger"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
 const (
@@ -138,6 +140,22 @@ func isEmptyEndpoint(endpoint corev1.Endpoints) bool {
 	return true
 }
 
+func isMasterPod(pod *corev1.Pod) bool {
+	return pod.GetLabels()["app"] == key.MasterID
+}
+
+// isPodNotReady checks whether the pod reports to fail its readiness probe.
+// Pods not reporting their readiness yet are not considered to be not ready.
+func isPodNotReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionFalse
+		}
+	}
+
+	return false
+}
+
 func removeIP(ips []string, ip string) []string {
 	for index, foundIP := range ips {
 		if foundIP == ip {
@@ -1,7 +1,11 @@
 package endpoint
 
+// Endpoint is the state of the endpoint of a service. NotReadyIPs are the IPs
+// of master pods failing their readiness probe. They are cut from the endpoint
+// so that traffic is only routed to healthy masters.
 type Endpoint struct {
 	IPs              []string
+	NotReadyIPs      []string
 	ServiceName      string
 	ServiceNamespace string
 }
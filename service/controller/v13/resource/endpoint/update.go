@@ -79,6 +79,7 @@ func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desir
 			endpoint.IPs = append(endpoint.IPs, desiredIP)
 		}
 	}
+	endpoint.IPs = cutIPs(endpoint.IPs, desiredEndpoint.NotReadyIPs)
 
 	if len(endpoint.IPs) == 0 {
 		// Nothing to do.
@@ -0,0 +1,367 @@
+package etcdmember
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/etcdcluster"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard"
+)
+
+const (
+	// legacyMemberName is the name of the etcd member of guest clusters created
+	// with a single master.
+	legacyMemberName = "etcd0"
+	// localPeerURL is the peer URL advertised by the etcd member of guest
+	// clusters created with a single master.
+	localPeerURL = "https://127.0.0.1:2380"
+)
+
+// EnsureCreated moves the etcd membership of the masters one step towards the
+// masters defined in the custom object. Per reconciliation either a master
+// leaving the guest cluster is removed from the etcd cluster, the peer URL of
+// a single member is changed to the current IP of its master, or a new master
+// is added to the etcd cluster.
+func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	n := key.ClusterNamespace(customObject)
+
+	var deployments []v1beta1.Deployment
+	{
+		o := metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("app=%s", key.MasterID),
+		}
+		list, err := r.k8sClient.Extensions().Deployments(n).List(o)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		deployments = list.Items
+	}
+
+	if len(customObject.Spec.Cluster.Masters) <= 1 && len(deployments) <= 1 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "not managing etcd members: guest cluster runs a single master")
+		return nil
+	}
+
+	masterIPs := map[string]string{}
+	{
+		o := metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("app=%s", key.MasterID),
+		}
+		list, err := r.k8sClient.CoreV1().Pods(n).List(o)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, p := range list.Items {
+			ip := p.GetAnnotations()[key.AnnotationIp]
+			if ip == "" || key.IsPodDeleted(&p) {
+				continue
+			}
+			masterIPs[p.GetLabels()["node"]] = ip
+		}
+	}
+
+	members, err := r.etcdCluster.Members(ctx, key.ClusterID(customObject))
+	if err != nil {
+		// The etcd cluster may not be reachable yet while the guest cluster is
+		// being created. Failing here would block the resources following this
+		// one, so we just try again on the next reconciliation.
+		r.logger.LogCtx(ctx, "level", "warning", "message", "cannot look up etcd members", "stack", fmt.Sprintf("%#v", err))
+		return nil
+	}
+
+	legacy := legacyMaster(deployments)
+
+	err = r.markJoined(ctx, customObject, deployments, members, masterIPs, legacy)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	done, err := r.removeMember(ctx, customObject, deployments, members, masterIPs, legacy)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if done {
+		return nil
+	}
+
+	done, err = r.updatePeerURL(ctx, customObject, members, masterIPs, legacy)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if done {
+		return nil
+	}
+
+	err = r.addMember(ctx, customObject, members, masterIPs, legacy)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// markJoined annotates the deployments of masters being started members of
+// the etcd cluster.
+func (r *Resource) markJoined(ctx context.Context, customObject v1alpha1.KVMConfig, deployments []v1beta1.Deployment, members []etcdcluster.Member, masterIPs map[string]string, legacy string) error {
+	for _, d := range deployments {
+		node, ok := masterNode(customObject, d)
+		if !ok || d.GetAnnotations()[key.AnnotationEtcdMember] == "True" {
+			continue
+		}
+
+		m, ok := memberOf(members, node, masterIPs[node.ID], legacy)
+		if !ok || !m.IsStarted() {
+			continue
+		}
+
+		err := r.annotate(ctx, d, "True")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// removeMember removes the etcd member of one master not being defined in the
+// custom object anymore. The master deployment is annotated afterwards, so the
+// deployment resource can delete it.
+func (r *Resource) removeMember(ctx context.Context, customObject v1alpha1.KVMConfig, deployments []v1beta1.Deployment, members []etcdcluster.Member, masterIPs map[string]string, legacy string) (bool, error) {
+	for _, d := range deployments {
+		if _, ok := masterNode(customObject, d); ok {
+			continue
+		}
+		if d.GetDeletionTimestamp() != nil || d.GetAnnotations()[key.AnnotationEtcdMember] == "False" {
+			continue
+		}
+
+		node := v1alpha1.ClusterNode{ID: d.GetLabels()["node"]}
+		ip := masterIPs[node.ID]
+
+		m, ok := memberOf(members, node, ip, legacy)
+		if ok {
+			request := quorumguard.Request{
+				Action:    quorumguard.ActionRemove,
+				ClusterID: key.ClusterID(customObject),
+				MasterIP:  ip,
+				Object: corev1.ObjectReference{
+					APIVersion: "extensions/v1beta1",
+					Kind:       "Deployment",
+					Name:       d.GetName(),
+					Namespace:  d.GetNamespace(),
+					UID:        d.GetUID(),
+				},
+			}
+
+			decision, err := r.quorumGuard.Check(ctx, request)
+			if err != nil {
+				return false, microerror.Mask(err)
+			}
+			if !decision.Allowed {
+				r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not removing etcd member of deployment '%s': removing it would break the etcd quorum", d.GetName()))
+				return true, nil
+			}
+
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("removing etcd member of deployment '%s'", d.GetName()))
+
+			err = r.etcdCluster.RemoveMember(ctx, key.ClusterID(customObject), m.ID)
+			if err != nil {
+				return false, microerror.Mask(err)
+			}
+
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("removed etcd member of deployment '%s'", d.GetName()))
+		} else if ip == "" {
+			// Without knowing the IP of the master we cannot tell whether it is
+			// still a member of the etcd cluster.
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not removing etcd member of deployment '%s': master has no IP", d.GetName()))
+			return true, nil
+		}
+
+		err := r.annotate(ctx, d, "False")
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// updatePeerURL changes the peer URL of one etcd member not matching the
+// current IP of its master. This is the case for the member of guest clusters
+// created with a single master, which advertises localhost other members
+// cannot reach, as well as for members of masters whose IP changed.
+func (r *Resource) updatePeerURL(ctx context.Context, customObject v1alpha1.KVMConfig, members []etcdcluster.Member, masterIPs map[string]string, legacy string) (bool, error) {
+	for _, node := range customObject.Spec.Cluster.Masters {
+		ip := masterIPs[node.ID]
+		if ip == "" {
+			continue
+		}
+
+		m, ok := memberOf(members, node, ip, legacy)
+		if !ok || containsString(m.PeerURLs, key.EtcdPeerURL(ip)) {
+			continue
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("updating peer URL of etcd member of master '%s'", node.ID))
+
+		err := r.etcdCluster.UpdateMember(ctx, key.ClusterID(customObject), m.ID, key.EtcdPeerURL(ip))
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("updated peer URL of etcd member of master '%s'", node.ID))
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// addMember adds the first master defined in the custom object not being a
+// member of the etcd cluster yet. Masters are added one at a time. In case a
+// member was added but did not start yet, no other member is added.
+func (r *Resource) addMember(ctx context.Context, customObject v1alpha1.KVMConfig, members []etcdcluster.Member, masterIPs map[string]string, legacy string) error {
+	for _, m := range members {
+		if !m.IsStarted() {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not adding etcd members: waiting for etcd member '%s' to start", m.ID))
+			return nil
+		}
+	}
+
+	for _, node := range customObject.Spec.Cluster.Masters {
+		ip := masterIPs[node.ID]
+		if ip == "" {
+			continue
+		}
+		if _, ok := memberOf(members, node, ip, legacy); ok {
+			continue
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("adding etcd member of master '%s'", node.ID))
+
+		err := r.etcdCluster.AddMember(ctx, key.ClusterID(customObject), key.EtcdPeerURL(ip))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("added etcd member of master '%s'", node.ID))
+
+		return nil
+	}
+
+	return nil
+}
+
+func (r *Resource) annotate(ctx context.Context, deployment v1beta1.Deployment, value string) error {
+	d := deployment.DeepCopy()
+
+	a := d.GetAnnotations()
+	if a == nil {
+		a = map[string]string{}
+	}
+	a[key.AnnotationEtcdMember] = value
+	d.SetAnnotations(a)
+
+	_, err := r.k8sClient.Extensions().Deployments(d.GetNamespace()).Update(d)
+	if apierrors.IsConflict(err) {
+		// The deployment may be updated by other processes meanwhile. We try
+		// again on the next reconciliation.
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("annotated deployment '%s' with etcd member state '%s'", d.GetName(), value))
+
+	return nil
+}
+
+// masterNode returns the master node of the given deployment in case it is
+// defined in the custom object.
+func masterNode(customObject v1alpha1.KVMConfig, deployment v1beta1.Deployment) (v1alpha1.ClusterNode, bool) {
+	for _, n := range customObject.Spec.Cluster.Masters {
+		if n.ID == deployment.GetLabels()["node"] {
+			return n, true
+		}
+	}
+
+	return v1alpha1.ClusterNode{}, false
+}
+
+// legacyMaster returns the ID of the master possibly running the etcd member
+// of a guest cluster created with a single master. This is the master of the
+// oldest master deployment, since masters added later on get new deployments.
+func legacyMaster(deployments []v1beta1.Deployment) string {
+	var oldest *v1beta1.Deployment
+	for i, d := range deployments {
+		if d.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if oldest == nil || isOlder(d, *oldest) {
+			oldest = &deployments[i]
+		}
+	}
+
+	if oldest == nil {
+		return ""
+	}
+
+	return oldest.GetLabels()["node"]
+}
+
+func isOlder(a, b v1beta1.Deployment) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return a.GetLabels()["node"] < b.GetLabels()["node"]
+}
+
+// memberOf finds the etcd member of the given master node. Members are
+// identified by their name, which is only known once they started, or by
+// their peer URL, which contains the IP of the master VM. The member of guest
+// clusters created with a single master is named legacyMemberName and
+// advertises localPeerURL until its peer URL is updated. It belongs to the
+// given legacy master.
+func memberOf(members []etcdcluster.Member, node v1alpha1.ClusterNode, ip string, legacy string) (etcdcluster.Member, bool) {
+	for _, m := range members {
+		if m.Name == key.EtcdMemberName(node) {
+			return m, true
+		}
+		if node.ID == legacy && (m.Name == legacyMemberName || containsString(m.PeerURLs, localPeerURL)) {
+			return m, true
+		}
+		if ip != "" && containsString(m.PeerURLs, key.EtcdPeerURL(ip)) {
+			return m, true
+		}
+	}
+
+	return etcdcluster.Member{}, false
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
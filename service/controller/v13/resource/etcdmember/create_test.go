@@ -0,0 +1,417 @@
+package etcdmember
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/etcdcluster"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/etcdcluster/etcdclustertest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard/quorumguardtest"
+)
+
+func Test_Resource_EtcdMember_EnsureCreated(t *testing.T) {
+	newCustomObject := func(ids ...string) v1alpha1.KVMConfig {
+		var masters []v1alpha1.ClusterNode
+		for _, id := range ids {
+			masters = append(masters, v1alpha1.ClusterNode{ID: id})
+		}
+
+		return v1alpha1.KVMConfig{
+			Spec: v1alpha1.KVMConfigSpec{
+				Cluster: v1alpha1.Cluster{
+					ID:      "al9qy",
+					Masters: masters,
+				},
+			},
+		}
+	}
+
+	newDeployment := func(id string, member string) runtime.Object {
+		d := &v1beta1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:        key.DeploymentName(key.MasterID, id),
+				Namespace:   "al9qy",
+				Annotations: map[string]string{},
+				Labels: map[string]string{
+					"app":  key.MasterID,
+					"node": id,
+				},
+			},
+		}
+		if member != "" {
+			d.Annotations[key.AnnotationEtcdMember] = member
+		}
+		return d
+	}
+
+	newPod := func(id, ip string) runtime.Object {
+		return &corev1.Pod{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      key.DeploymentName(key.MasterID, id) + "-6d4b7c9f8-5f8c9",
+				Namespace: "al9qy",
+				Annotations: map[string]string{
+					key.AnnotationIp: ip,
+				},
+				Labels: map[string]string{
+					"app":  key.MasterID,
+					"node": id,
+				},
+			},
+		}
+	}
+
+	newMember := func(id, name, peerURL string) etcdcluster.Member {
+		return etcdcluster.Member{
+			ID:       id,
+			Name:     name,
+			PeerURLs: []string{peerURL},
+		}
+	}
+
+	testCases := []struct {
+		CustomObject        v1alpha1.KVMConfig
+		K8sObjects          []runtime.Object
+		Members             []etcdcluster.Member
+		Denied              bool
+		ExpectedPeerURLs    []string
+		ExpectedAnnotations map[string]string
+	}{
+		// Test 1 ensures the etcd membership of guest clusters running a single
+		// master is not managed.
+		{
+			CustomObject: newCustomObject("1"),
+			K8sObjects: []runtime.Object{
+				newDeployment("1", ""),
+				newPod("1", "10.0.0.1"),
+			},
+			Members: []etcdcluster.Member{
+				newMember("a", "etcd0", localPeerURL),
+			},
+			ExpectedPeerURLs: []string{localPeerURL},
+			ExpectedAnnotations: map[string]string{
+				"master-1": "",
+			},
+		},
+
+		// Test 2 ensures the peer URL of the single etcd member is changed to the
+		// IP of its master when scaling up masters and the master is marked as
+		// member.
+		{
+			CustomObject: newCustomObject("1", "2", "3"),
+			K8sObjects: []runtime.Object{
+				newDeployment("1", ""),
+				newPod("1", "10.0.0.1"),
+			},
+			Members: []etcdcluster.Member{
+				newMember("a", "etcd0", localPeerURL),
+			},
+			ExpectedPeerURLs: []string{"https://10.0.0.1:2380"},
+			ExpectedAnnotations: map[string]string{
+				"master-1": "True",
+			},
+		},
+
+		// Test 3 ensures masters being started members are marked and a new
+		// master is added to the etcd cluster.
+		{
+			CustomObject: newCustomObject("1", "2", "3"),
+			K8sObjects: []runtime.Object{
+				newDeployment("1", ""),
+				newDeployment("2", ""),
+				newPod("1", "10.0.0.1"),
+				newPod("2", "10.0.0.2"),
+			},
+			Members: []etcdcluster.Member{
+				newMember("a", "etcd0", "https://10.0.0.1:2380"),
+			},
+			ExpectedPeerURLs: []string{"https://10.0.0.1:2380", "https://10.0.0.2:2380"},
+			ExpectedAnnotations: map[string]string{
+				"master-1": "True",
+				"master-2": "",
+			},
+		},
+
+		// Test 4 ensures no master is added as long as an added member did not
+		// start yet.
+		{
+			CustomObject: newCustomObject("1", "2", "3"),
+			K8sObjects: []runtime.Object{
+				newDeployment("1", "True"),
+				newDeployment("2", ""),
+				newDeployment("3", ""),
+				newPod("1", "10.0.0.1"),
+				newPod("2", "10.0.0.2"),
+				newPod("3", "10.0.0.3"),
+			},
+			Members: []etcdcluster.Member{
+				newMember("a", "etcd0", "https://10.0.0.1:2380"),
+				newMember("b", "", "https://10.0.0.2:2380"),
+			},
+			ExpectedPeerURLs: []string{"https://10.0.0.1:2380", "https://10.0.0.2:2380"},
+			ExpectedAnnotations: map[string]string{
+				"master-1": "True",
+				"master-2": "",
+				"master-3": "",
+			},
+		},
+
+		// Test 5 ensures the etcd member of a master being removed from the custom
+		// object is removed and its deployment is marked.
+		{
+			CustomObject: newCustomObject("1"),
+			K8sObjects: []runtime.Object{
+				newDeployment("1", "True"),
+				newDeployment("2", "True"),
+				newPod("1", "10.0.0.1"),
+				newPod("2", "10.0.0.2"),
+			},
+			Members: []etcdcluster.Member{
+				newMember("a", "etcd0", "https://10.0.0.1:2380"),
+				newMember("b", "etcd-2", "https://10.0.0.2:2380"),
+			},
+			ExpectedPeerURLs: []string{"https://10.0.0.1:2380"},
+			ExpectedAnnotations: map[string]string{
+				"master-1": "True",
+				"master-2": "False",
+			},
+		},
+
+		// Test 6 ensures the etcd member of a master being removed from the custom
+		// object is kept in case the quorum guard denies removing it.
+		{
+			CustomObject: newCustomObject("1"),
+			K8sObjects: []runtime.Object{
+				newDeployment("1", "True"),
+				newDeployment("2", "True"),
+				newPod("1", "10.0.0.1"),
+				newPod("2", "10.0.0.2"),
+			},
+			Members: []etcdcluster.Member{
+				newMember("a", "etcd0", "https://10.0.0.1:2380"),
+				newMember("b", "etcd-2", "https://10.0.0.2:2380"),
+			},
+			Denied:           true,
+			ExpectedPeerURLs: []string{"https://10.0.0.1:2380", "https://10.0.0.2:2380"},
+			ExpectedAnnotations: map[string]string{
+				"master-1": "True",
+				"master-2": "True",
+			},
+		},
+
+		// Test 7 ensures the peer URL of the single etcd member is changed to the
+		// IP of its master when the masters added meanwhile got IPs already.
+		{
+			CustomObject: newCustomObject("1", "2", "3"),
+			K8sObjects: []runtime.Object{
+				newDeployment("1", ""),
+				newDeployment("2", ""),
+				newDeployment("3", ""),
+				newPod("1", "10.0.0.1"),
+				newPod("2", "10.0.0.2"),
+				newPod("3", "10.0.0.3"),
+			},
+			Members: []etcdcluster.Member{
+				newMember("a", "etcd0", localPeerURL),
+			},
+			ExpectedPeerURLs: []string{"https://10.0.0.1:2380"},
+			ExpectedAnnotations: map[string]string{
+				"master-1": "True",
+				"master-2": "",
+				"master-3": "",
+			},
+		},
+
+		// Test 8 ensures the peer URL of an etcd member is changed in case the IP
+		// of its master changed.
+		{
+			CustomObject: newCustomObject("1", "2"),
+			K8sObjects: []runtime.Object{
+				newDeployment("1", "True"),
+				newDeployment("2", "True"),
+				newPod("1", "10.0.0.1"),
+				newPod("2", "10.0.0.5"),
+			},
+			Members: []etcdcluster.Member{
+				newMember("a", "etcd0", "https://10.0.0.1:2380"),
+				newMember("b", "etcd-2", "https://10.0.0.2:2380"),
+			},
+			ExpectedPeerURLs: []string{"https://10.0.0.1:2380", "https://10.0.0.5:2380"},
+			ExpectedAnnotations: map[string]string{
+				"master-1": "True",
+				"master-2": "True",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		k8sClient := fake.NewSimpleClientset(tc.K8sObjects...)
+		etcdCluster := etcdclustertest.New(tc.Members...)
+
+		quorumGuard := quorumguardtest.New()
+		quorumGuard.Denied = tc.Denied
+
+		var newResource *Resource
+		{
+			c := Config{
+				EtcdCluster: etcdCluster,
+				K8sClient:   k8sClient,
+				Logger:      microloggertest.New(),
+				QuorumGuard: quorumGuard,
+			}
+
+			var err error
+			newResource, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		err := newResource.EnsureCreated(context.TODO(), &tc.CustomObject)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		var peerURLs []string
+		for _, m := range etcdCluster.List {
+			peerURLs = append(peerURLs, m.PeerURLs...)
+		}
+		if !reflect.DeepEqual(peerURLs, tc.ExpectedPeerURLs) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedPeerURLs, peerURLs)
+		}
+
+		for name, expected := range tc.ExpectedAnnotations {
+			d, err := k8sClient.Extensions().Deployments("al9qy").Get(name, apismetav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+			if d.GetAnnotations()[key.AnnotationEtcdMember] != expected {
+				t.Fatalf("case %d expected %#v got %#v", i+1, expected, d.GetAnnotations()[key.AnnotationEtcdMember])
+			}
+		}
+	}
+}
+
+// Test_Resource_EtcdMember_EnsureCreated_ScaleUp ensures a guest cluster
+// created with a single master ends up with three started etcd members when
+// scaling up to three masters, reconciling until nothing changes anymore.
+func Test_Resource_EtcdMember_EnsureCreated_ScaleUp(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Masters: []v1alpha1.ClusterNode{
+					{ID: "1"},
+					{ID: "2"},
+					{ID: "3"},
+				},
+			},
+		},
+	}
+
+	ips := map[string]string{
+		"1": "10.0.0.1",
+		"2": "10.0.0.2",
+		"3": "10.0.0.3",
+	}
+
+	var k8sObjects []runtime.Object
+	for i, id := range []string{"1", "2", "3"} {
+		k8sObjects = append(k8sObjects, &v1beta1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:              key.DeploymentName(key.MasterID, id),
+				Namespace:         "al9qy",
+				CreationTimestamp: apismetav1.NewTime(time.Unix(int64(i), 0)),
+				Labels: map[string]string{
+					"app":  key.MasterID,
+					"node": id,
+				},
+			},
+		})
+		k8sObjects = append(k8sObjects, &corev1.Pod{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:      key.DeploymentName(key.MasterID, id) + "-6d4b7c9f8-5f8c9",
+				Namespace: "al9qy",
+				Annotations: map[string]string{
+					key.AnnotationIp: ips[id],
+				},
+				Labels: map[string]string{
+					"app":  key.MasterID,
+					"node": id,
+				},
+			},
+		})
+	}
+
+	k8sClient := fake.NewSimpleClientset(k8sObjects...)
+	etcdCluster := etcdclustertest.New(etcdcluster.Member{
+		ID:       "a",
+		Name:     "etcd0",
+		PeerURLs: []string{localPeerURL},
+	})
+
+	var newResource *Resource
+	{
+		c := Config{
+			EtcdCluster: etcdCluster,
+			K8sClient:   k8sClient,
+			Logger:      microloggertest.New(),
+			QuorumGuard: quorumguardtest.New(),
+		}
+
+		var err error
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		err := newResource.EnsureCreated(context.TODO(), &customObject)
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+
+		// Members added by the resource start once their master VM picks them
+		// up, which is when they publish their name.
+		for j, m := range etcdCluster.List {
+			if m.IsStarted() {
+				continue
+			}
+			for id, ip := range ips {
+				if containsString(m.PeerURLs, key.EtcdPeerURL(ip)) {
+					etcdCluster.List[j].Name = key.EtcdMemberName(v1alpha1.ClusterNode{ID: id})
+				}
+			}
+		}
+	}
+
+	var peerURLs []string
+	for _, m := range etcdCluster.List {
+		peerURLs = append(peerURLs, m.PeerURLs...)
+	}
+	expected := []string{"https://10.0.0.1:2380", "https://10.0.0.2:2380", "https://10.0.0.3:2380"}
+	if !reflect.DeepEqual(peerURLs, expected) {
+		t.Fatalf("expected %#v got %#v", expected, peerURLs)
+	}
+
+	for id := range ips {
+		d, err := k8sClient.Extensions().Deployments("al9qy").Get(key.DeploymentName(key.MasterID, id), apismetav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		if d.GetAnnotations()[key.AnnotationEtcdMember] != "True" {
+			t.Fatalf("expected %#v got %#v", "True", d.GetAnnotations()[key.AnnotationEtcdMember])
+		}
+	}
+}
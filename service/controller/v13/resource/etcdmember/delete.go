@@ -0,0 +1,11 @@
+package etcdmember
+
+import (
+	"context"
+)
+
+// EnsureDeleted does nothing. The etcd cluster goes away together with all
+// masters of the guest cluster.
+func (r *Resource) EnsureDeleted(ctx context.Context, obj interface{}) error {
+	return nil
+}
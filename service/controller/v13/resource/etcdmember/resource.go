@@ -0,0 +1,61 @@
+package etcdmember
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/etcdcluster"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard"
+)
+
+const (
+	Name = "etcdmemberv13"
+)
+
+type Config struct {
+	EtcdCluster etcdcluster.Interface
+	K8sClient   kubernetes.Interface
+	Logger      micrologger.Logger
+	QuorumGuard quorumguard.Interface
+}
+
+// Resource manages the etcd membership of the masters of guest clusters
+// running multiple masters. Masters join and leave the etcd cluster one at a
+// time. The progress is tracked on the master deployments using
+// key.AnnotationEtcdMember, which the deployment resource relies on to create
+// and delete master deployments safely.
+type Resource struct {
+	etcdCluster etcdcluster.Interface
+	k8sClient   kubernetes.Interface
+	logger      micrologger.Logger
+	quorumGuard quorumguard.Interface
+}
+
+func New(config Config) (*Resource, error) {
+	if config.EtcdCluster == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EtcdCluster must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.QuorumGuard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.QuorumGuard must not be empty", config)
+	}
+
+	r := &Resource{
+		etcdCluster: config.EtcdCluster,
+		k8sClient:   config.K8sClient,
+		logger:      config.Logger,
+		quorumGuard: config.QuorumGuard,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
@@ -34,6 +34,16 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 		},
 	}
 
+	// The master bootstrapping the etcd cluster is decided once when the
+	// namespace is created, which is why the namespace is never updated. This
+	// way masters added or replaced later on never bootstrap a second etcd
+	// cluster.
+	if masters := customObject.Spec.Cluster.Masters; len(masters) > 0 {
+		namespace.Annotations = map[string]string{
+			key.AnnotationEtcdBootstrapMember: masters[0].ID,
+		}
+	}
+
 	r.logger.LogCtx(ctx, "level", "debug", "message", "computed the desired namespace")
 
 	return namespace, nil
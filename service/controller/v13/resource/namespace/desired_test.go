@@ -8,12 +8,16 @@ import (
 	"github.com/giantswarm/micrologger/microloggertest"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
 func Test_Resource_Namespace_GetDesiredState(t *testing.T) {
 	testCases := []struct {
-		Obj          interface{}
-		ExpectedName string
+		Obj                         interface{}
+		ExpectedName                string
+		ExpectedEtcdBootstrapMember string
 	}{
 		{
 			Obj: &v1alpha1.KVMConfig{
@@ -30,10 +34,19 @@ func Test_Resource_Namespace_GetDesiredState(t *testing.T) {
 				Spec: v1alpha1.KVMConfigSpec{
 					Cluster: v1alpha1.Cluster{
 						ID: "foobar",
+						Masters: []v1alpha1.ClusterNode{
+							{
+								ID: "5xchu",
+							},
+							{
+								ID: "p7jqk",
+							},
+						},
 					},
 				},
 			},
-			ExpectedName: "foobar",
+			ExpectedName:                "foobar",
+			ExpectedEtcdBootstrapMember: "5xchu",
 		},
 	}
 
@@ -41,6 +54,7 @@ func Test_Resource_Namespace_GetDesiredState(t *testing.T) {
 	var newResource *Resource
 	{
 		resourceConfig := DefaultConfig()
+		resourceConfig.ClusterStatus = clusterstatustest.New()
 		resourceConfig.K8sClient = fake.NewSimpleClientset()
 		resourceConfig.Logger = microloggertest.New()
 		newResource, err = New(resourceConfig)
@@ -58,5 +72,9 @@ func Test_Resource_Namespace_GetDesiredState(t *testing.T) {
 		if tc.ExpectedName != name {
 			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedName, name)
 		}
+		etcdBootstrapMember := result.(*apiv1.Namespace).GetAnnotations()[key.AnnotationEtcdBootstrapMember]
+		if tc.ExpectedEtcdBootstrapMember != etcdBootstrapMember {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedEtcdBootstrapMember, etcdBootstrapMember)
+		}
 	}
 }
@@ -0,0 +1,303 @@
+package namespace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller/context/reconciliationcanceledcontext"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// Containers not defining resources themselves get the defaults below
+	// through the limit range of the namespace. Only the containers running
+	// VMs define resources. The others are the sidecars of VM pods and the
+	// node controller, which need few resources. The image pre-pull pods do
+	// not run in the namespace of the guest cluster.
+	containerDefaultCPULimit      = "100m"
+	containerDefaultCPURequest    = "10m"
+	containerDefaultMemoryLimit   = "128Mi"
+	containerDefaultMemoryRequest = "32Mi"
+
+	// vmPodSidecars is the number of containers of VM pods not running the VM.
+	vmPodSidecars = 2
+)
+
+// quota is the update change of the namespace resource. The namespace itself
+// is never updated, but the resource quota and limit range in it are.
+type quota struct {
+	LimitRange    *corev1.LimitRange
+	ResourceQuota *corev1.ResourceQuota
+}
+
+// exceedsCeiling returns a message describing the resources exceeding the
+// configured ceiling, or an empty string in case the given nodes fit.
+func (r *Resource) exceedsCeiling(nodes []key.NodeResources) string {
+	cpu, memory := key.SumNodeResources(nodes)
+
+	var message string
+	if r.cpuCeiling != nil && cpu.Cmp(*r.cpuCeiling) > 0 {
+		message += fmt.Sprintf("requested CPUs %s exceed ceiling %s", cpu.String(), r.cpuCeiling.String())
+	}
+	if r.memoryCeiling != nil && memory.Cmp(*r.memoryCeiling) > 0 {
+		if message != "" {
+			message += ", "
+		}
+		message += fmt.Sprintf("requested memory %s exceeds ceiling %s", memory.String(), r.memoryCeiling.String())
+	}
+
+	return message
+}
+
+// checkCeiling reports whether the resources requested for the VMs of the
+// guest cluster are within the configured ceiling. In case they are not, the
+// reconciliation is canceled, so no VM pods are created or resized which
+// could not be scheduled or would take resources from other guest clusters.
+func (r *Resource) checkCeiling(ctx context.Context, customObject v1alpha1.KVMConfig, nodes []key.NodeResources) bool {
+	if r.cpuCeiling == nil && r.memoryCeiling == nil {
+		return true
+	}
+
+	message := r.exceedsCeiling(nodes)
+
+	condition := clusterstatus.Condition{
+		Type: clusterstatus.ConditionResourcesWithinCeiling,
+	}
+	if message == "" {
+		condition.Status = clusterstatus.ConditionStatusTrue
+		condition.Reason = "WithinCeiling"
+		condition.Message = "resources requested by the guest cluster are within the ceiling"
+	} else {
+		condition.Status = clusterstatus.ConditionStatusFalse
+		condition.Reason = "CeilingExceeded"
+		condition.Message = message
+	}
+
+	err := r.clusterStatus.SetCondition(ctx, customObject, condition)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cannot set status condition '%s'", condition.Type), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	if message != "" {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("holding guest cluster: %s", message))
+		reconciliationcanceledcontext.SetCanceled(ctx)
+		r.logger.LogCtx(ctx, "level", "debug", "message", "canceling reconciliation for custom object")
+
+		return false
+	}
+
+	return true
+}
+
+// newQuota computes the resource quota and limit range of the namespace of
+// the given guest cluster. The quota allows the VM pods of all nodes, one
+// additional worker while workers are replaced in surge mode and the node
+// controller.
+func (r *Resource) newQuota(customObject v1alpha1.KVMConfig, nodes []key.NodeResources) (*quota, error) {
+	vmPods := append([]key.NodeResources{}, nodes...)
+	if r.updateSurge {
+		var largest *key.NodeResources
+		for i, n := range nodes {
+			if n.Role != key.WorkerID {
+				continue
+			}
+			if largest == nil || n.Memory.Cmp(largest.Memory) > 0 {
+				largest = &nodes[i]
+			}
+		}
+		if largest != nil {
+			vmPods = append(vmPods, *largest)
+		}
+	}
+
+	cpu, memory := key.SumNodeResources(vmPods)
+	defaultedContainers := int64(len(vmPods)*vmPodSidecars + 1)
+	pods := int64(len(vmPods) + 1)
+
+	requestsCPU := cpu.DeepCopy()
+	requestsCPU.Add(multiplyQuantity(containerDefaultCPURequest, defaultedContainers))
+	requestsMemory := memory.DeepCopy()
+	requestsMemory.Add(multiplyQuantity(containerDefaultMemoryRequest, defaultedContainers))
+	limitsCPU := cpu.DeepCopy()
+	limitsCPU.Add(multiplyQuantity(containerDefaultCPULimit, defaultedContainers))
+	limitsMemory := memory.DeepCopy()
+	limitsMemory.Add(multiplyQuantity(containerDefaultMemoryLimit, defaultedContainers))
+
+	labels := map[string]string{
+		"app":                       "kvm-operator",
+		"giantswarm.io/cluster-id":  key.ClusterID(customObject),
+		"giantswarm.io/customer-id": key.ClusterCustomer(customObject),
+	}
+
+	q := &quota{
+		LimitRange: &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.LimitRangeName(customObject),
+				Namespace: key.ClusterNamespace(customObject),
+				Labels:    labels,
+			},
+			Spec: corev1.LimitRangeSpec{
+				Limits: []corev1.LimitRangeItem{
+					{
+						Type: corev1.LimitTypeContainer,
+						Default: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(containerDefaultCPULimit),
+							corev1.ResourceMemory: resource.MustParse(containerDefaultMemoryLimit),
+						},
+						DefaultRequest: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(containerDefaultCPURequest),
+							corev1.ResourceMemory: resource.MustParse(containerDefaultMemoryRequest),
+						},
+					},
+				},
+			},
+		},
+		ResourceQuota: &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.ResourceQuotaName(customObject),
+				Namespace: key.ClusterNamespace(customObject),
+				Labels:    labels,
+			},
+			Spec: corev1.ResourceQuotaSpec{
+				Hard: corev1.ResourceList{
+					corev1.ResourcePods:           *resource.NewQuantity(pods, resource.DecimalSI),
+					corev1.ResourceRequestsCPU:    requestsCPU,
+					corev1.ResourceRequestsMemory: requestsMemory,
+					corev1.ResourceLimitsCPU:      limitsCPU,
+					corev1.ResourceLimitsMemory:   limitsMemory,
+				},
+			},
+		},
+	}
+
+	return q, nil
+}
+
+func (r *Resource) ensureLimitRange(ctx context.Context, limitRange *corev1.LimitRange) error {
+	current, err := r.k8sClient.CoreV1().LimitRanges(limitRange.Namespace).Get(limitRange.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the limit range in the Kubernetes API")
+
+		_, err := r.k8sClient.CoreV1().LimitRanges(limitRange.Namespace).Create(limitRange)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "created the limit range in the Kubernetes API")
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if isLimitRangeModified(current, limitRange) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the limit range in the Kubernetes API")
+
+		current.Labels = limitRange.Labels
+		current.Spec = limitRange.Spec
+
+		_, err := r.k8sClient.CoreV1().LimitRanges(limitRange.Namespace).Update(current)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the limit range in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) ensureResourceQuota(ctx context.Context, resourceQuota *corev1.ResourceQuota) error {
+	current, err := r.k8sClient.CoreV1().ResourceQuotas(resourceQuota.Namespace).Get(resourceQuota.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the resource quota in the Kubernetes API")
+
+		_, err := r.k8sClient.CoreV1().ResourceQuotas(resourceQuota.Namespace).Create(resourceQuota)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "created the resource quota in the Kubernetes API")
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if isResourceListModified(current.Spec.Hard, resourceQuota.Spec.Hard) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the resource quota in the Kubernetes API")
+
+		current.Labels = resourceQuota.Labels
+		current.Spec = resourceQuota.Spec
+
+		_, err := r.k8sClient.CoreV1().ResourceQuotas(resourceQuota.Namespace).Update(current)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the resource quota in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func isLimitRangeModified(a, b *corev1.LimitRange) bool {
+	if len(a.Spec.Limits) != len(b.Spec.Limits) {
+		return true
+	}
+
+	for i := range a.Spec.Limits {
+		x := a.Spec.Limits[i]
+		y := b.Spec.Limits[i]
+
+		if x.Type != y.Type || isResourceListModified(x.Default, y.Default) || isResourceListModified(x.DefaultRequest, y.DefaultRequest) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isResourceListModified compares the given resource lists by the values of
+// their quantities, since quantities read from the Kubernetes API may be
+// formatted differently than the computed ones.
+func isResourceListModified(a, b corev1.ResourceList) bool {
+	if len(a) != len(b) {
+		return true
+	}
+
+	for name, x := range a {
+		y, ok := b[name]
+		if !ok || x.Cmp(y) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func multiplyQuantity(s string, n int64) resource.Quantity {
+	q := resource.MustParse(s)
+	return *resource.NewMilliQuantity(q.MilliValue()*n, q.Format)
+}
+
+func toQuota(v interface{}) (*quota, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	q, ok := v.(*quota)
+	if !ok {
+		return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", &quota{}, v)
+	}
+
+	return q, nil
+}
@@ -0,0 +1,348 @@
+package namespace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/giantswarm/operatorkit/controller/context/reconciliationcanceledcontext"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus/clusterstatustest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/workerscontext"
+)
+
+func Test_Resource_Namespace_newUpdateChange(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Customer: v1alpha1.ClusterCustomer{
+					ID: "test-customer",
+				},
+				Masters: []v1alpha1.ClusterNode{
+					{ID: "m1"},
+				},
+				Workers: []v1alpha1.ClusterNode{
+					{ID: "w1"},
+					{ID: "w2"},
+				},
+			},
+			KVM: v1alpha1.KVMConfigSpecKVM{
+				Masters: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 1, Memory: "1G"},
+				},
+				Workers: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 2, Memory: "2G"},
+					{CPUs: 4, Memory: "4G"},
+				},
+			},
+		},
+	}
+
+	masterMemory, err := key.MemoryQuantityMaster(customObject.Spec.KVM.Masters[0])
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	smallWorkerMemory, err := key.MemoryQuantityWorker(customObject.Spec.KVM.Workers[0])
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	largeWorkerMemory, err := key.MemoryQuantityWorker(customObject.Spec.KVM.Workers[1])
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	testCases := []struct {
+		CPUCeiling             string
+		MemoryCeiling          string
+		UpdateSurge            bool
+		Workers                []key.WorkerNode
+		ExpectedCanceled       bool
+		ExpectedConditions     []string
+		ExpectedPods           int64
+		ExpectedRequestsCPU    string
+		ExpectedLimitsCPU      string
+		ExpectedRequestsMemory []resource.Quantity
+	}{
+		// Test 0 ensures the quota covers the VMs of all nodes, two sidecars
+		// per VM pod and the node controller.
+		{
+			CPUCeiling:             "",
+			MemoryCeiling:          "",
+			UpdateSurge:            false,
+			Workers:                nil,
+			ExpectedCanceled:       false,
+			ExpectedConditions:     nil,
+			ExpectedPods:           4,
+			ExpectedRequestsCPU:    "7070m",
+			ExpectedLimitsCPU:      "7700m",
+			ExpectedRequestsMemory: []resource.Quantity{masterMemory, smallWorkerMemory, largeWorkerMemory, resource.MustParse("224Mi")},
+		},
+
+		// Test 1 ensures the quota covers the largest worker once more when
+		// workers are replaced in surge mode.
+		{
+			CPUCeiling:             "",
+			MemoryCeiling:          "",
+			UpdateSurge:            true,
+			Workers:                nil,
+			ExpectedCanceled:       false,
+			ExpectedConditions:     nil,
+			ExpectedPods:           5,
+			ExpectedRequestsCPU:    "11090m",
+			ExpectedLimitsCPU:      "11900m",
+			ExpectedRequestsMemory: []resource.Quantity{masterMemory, smallWorkerMemory, largeWorkerMemory, largeWorkerMemory, resource.MustParse("288Mi")},
+		},
+
+		// Test 2 ensures guest clusters within the ceiling get their quota and
+		// are reported to be within the ceiling.
+		{
+			CPUCeiling:             "7",
+			MemoryCeiling:          "64Gi",
+			UpdateSurge:            false,
+			Workers:                nil,
+			ExpectedCanceled:       false,
+			ExpectedConditions:     []string{clusterstatus.ConditionStatusTrue},
+			ExpectedPods:           4,
+			ExpectedRequestsCPU:    "7070m",
+			ExpectedLimitsCPU:      "7700m",
+			ExpectedRequestsMemory: []resource.Quantity{masterMemory, smallWorkerMemory, largeWorkerMemory, resource.MustParse("224Mi")},
+		},
+
+		// Test 3 ensures guest clusters requesting more CPUs than the ceiling
+		// are held.
+		{
+			CPUCeiling:         "6",
+			MemoryCeiling:      "",
+			UpdateSurge:        false,
+			Workers:            nil,
+			ExpectedCanceled:   true,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusFalse},
+		},
+
+		// Test 4 ensures guest clusters requesting more memory than the ceiling
+		// are held.
+		{
+			CPUCeiling:         "",
+			MemoryCeiling:      "4Gi",
+			UpdateSurge:        false,
+			Workers:            nil,
+			ExpectedCanceled:   true,
+			ExpectedConditions: []string{clusterstatus.ConditionStatusFalse},
+		},
+
+		// Test 5 ensures the quota covers the workers the deployments are
+		// reconciled with instead of the workers of the spec.
+		{
+			CPUCeiling:    "",
+			MemoryCeiling: "",
+			UpdateSurge:   false,
+			Workers: []key.WorkerNode{
+				{
+					Node: customObject.Spec.Cluster.Workers[0],
+					Size: customObject.Spec.KVM.Workers[0],
+				},
+			},
+			ExpectedCanceled:       false,
+			ExpectedConditions:     nil,
+			ExpectedPods:           3,
+			ExpectedRequestsCPU:    "3050m",
+			ExpectedLimitsCPU:      "3500m",
+			ExpectedRequestsMemory: []resource.Quantity{masterMemory, smallWorkerMemory, resource.MustParse("160Mi")},
+		},
+	}
+
+	for i, tc := range testCases {
+		clusterStatus := clusterstatustest.New()
+
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.ClusterStatus = clusterStatus
+			resourceConfig.K8sClient = fake.NewSimpleClientset()
+			resourceConfig.Logger = microloggertest.New()
+			resourceConfig.CPUCeiling = tc.CPUCeiling
+			resourceConfig.MemoryCeiling = tc.MemoryCeiling
+			resourceConfig.UpdateSurge = tc.UpdateSurge
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		ctx := reconciliationcanceledcontext.NewContext(context.Background(), make(chan struct{}))
+		if tc.Workers != nil {
+			ctx = workerscontext.NewContext(ctx, tc.Workers)
+		}
+
+		result, err := newResource.newUpdateChange(ctx, &customObject, nil, nil)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		canceled := reconciliationcanceledcontext.IsCanceled(ctx)
+		if canceled != tc.ExpectedCanceled {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedCanceled, canceled)
+		}
+
+		var conditions []string
+		for _, c := range clusterStatus.Conditions {
+			if c.Type != clusterstatus.ConditionResourcesWithinCeiling {
+				t.Fatalf("case %d expected %#v got %#v", i, clusterstatus.ConditionResourcesWithinCeiling, c.Type)
+			}
+			conditions = append(conditions, c.Status)
+		}
+		if len(conditions) != len(tc.ExpectedConditions) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedConditions, conditions)
+		}
+		for j := range conditions {
+			if conditions[j] != tc.ExpectedConditions[j] {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedConditions, conditions)
+			}
+		}
+
+		q, err := toQuota(result)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if tc.ExpectedCanceled {
+			if q != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, q)
+			}
+			continue
+		}
+
+		if q.ResourceQuota.Namespace != "al9qy" || q.LimitRange.Namespace != "al9qy" {
+			t.Fatalf("case %d expected %#v got %#v and %#v", i, "al9qy", q.ResourceQuota.Namespace, q.LimitRange.Namespace)
+		}
+
+		hard := q.ResourceQuota.Spec.Hard
+
+		pods := hard[apiv1.ResourcePods]
+		if pods.Value() != tc.ExpectedPods {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedPods, pods.Value())
+		}
+		requestsCPU := hard[apiv1.ResourceRequestsCPU]
+		if requestsCPU.Cmp(resource.MustParse(tc.ExpectedRequestsCPU)) != 0 {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedRequestsCPU, requestsCPU.String())
+		}
+		limitsCPU := hard[apiv1.ResourceLimitsCPU]
+		if limitsCPU.Cmp(resource.MustParse(tc.ExpectedLimitsCPU)) != 0 {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedLimitsCPU, limitsCPU.String())
+		}
+		expectedRequestsMemory := resource.Quantity{}
+		for _, m := range tc.ExpectedRequestsMemory {
+			expectedRequestsMemory.Add(m)
+		}
+		requestsMemory := hard[apiv1.ResourceRequestsMemory]
+		if requestsMemory.Cmp(expectedRequestsMemory) != 0 {
+			t.Fatalf("case %d expected %#v got %#v", i, expectedRequestsMemory.String(), requestsMemory.String())
+		}
+	}
+}
+
+func Test_Resource_Namespace_ApplyUpdateChange(t *testing.T) {
+	customObject := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Masters: []v1alpha1.ClusterNode{
+					{ID: "m1"},
+				},
+			},
+			KVM: v1alpha1.KVMConfigSpecKVM{
+				Masters: []v1alpha1.KVMConfigSpecKVMNode{
+					{CPUs: 1, Memory: "1G"},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		Current []runtime.Object
+	}{
+		// Test 0 ensures the limit range and the resource quota are created in
+		// case they do not exist yet.
+		{
+			Current: nil,
+		},
+
+		// Test 1 ensures outdated limit ranges and resource quotas are updated.
+		{
+			Current: []runtime.Object{
+				&apiv1.LimitRange{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name:      "al9qy",
+						Namespace: "al9qy",
+					},
+				},
+				&apiv1.ResourceQuota{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name:      "al9qy",
+						Namespace: "al9qy",
+					},
+					Spec: apiv1.ResourceQuotaSpec{
+						Hard: apiv1.ResourceList{
+							apiv1.ResourcePods: resource.MustParse("1"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		k8sClient := fake.NewSimpleClientset(tc.Current...)
+
+		var err error
+		var newResource *Resource
+		{
+			resourceConfig := DefaultConfig()
+			resourceConfig.ClusterStatus = clusterstatustest.New()
+			resourceConfig.K8sClient = k8sClient
+			resourceConfig.Logger = microloggertest.New()
+			newResource, err = New(resourceConfig)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		updateChange, err := newResource.newUpdateChange(context.TODO(), customObject, nil, nil)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		err = newResource.ApplyUpdateChange(context.TODO(), customObject, updateChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		desired, err := toQuota(updateChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		limitRange, err := k8sClient.CoreV1().LimitRanges("al9qy").Get("al9qy", apismetav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if isLimitRangeModified(limitRange, desired.LimitRange) {
+			t.Fatalf("case %d expected %#v got %#v", i, desired.LimitRange.Spec, limitRange.Spec)
+		}
+
+		resourceQuota, err := k8sClient.CoreV1().ResourceQuotas("al9qy").Get("al9qy", apismetav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if isResourceListModified(resourceQuota.Spec.Hard, desired.ResourceQuota.Spec.Hard) {
+			t.Fatalf("case %d expected %#v got %#v", i, desired.ResourceQuota.Spec.Hard, resourceQuota.Spec.Hard)
+		}
+	}
+}
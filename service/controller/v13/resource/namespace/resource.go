@@ -4,7 +4,10 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/clusterstatus"
 )
 
 const (
@@ -15,8 +18,20 @@ const (
 // Config represents the configuration used to create a new cloud config resource.
 type Config struct {
 	// Dependencies.
-	K8sClient kubernetes.Interface
-	Logger    micrologger.Logger
+	ClusterStatus clusterstatus.Interface
+	K8sClient     kubernetes.Interface
+	Logger        micrologger.Logger
+
+	// Settings.
+
+	// CPUCeiling and MemoryCeiling are the maximum CPU and memory the VMs of
+	// a single guest cluster may request in total. Guest clusters requesting
+	// more are held. Empty values disable the respective ceiling.
+	CPUCeiling    string
+	MemoryCeiling string
+	// UpdateSurge has to match the setting of the deployment resource, so the
+	// resource quota allows the additional worker created during updates.
+	UpdateSurge bool
 }
 
 // DefaultConfig provides a default configuration to create a new cloud config
@@ -24,21 +39,36 @@ type Config struct {
 func DefaultConfig() Config {
 	return Config{
 		// Dependencies.
-		K8sClient: nil,
-		Logger:    nil,
+		ClusterStatus: nil,
+		K8sClient:     nil,
+		Logger:        nil,
+
+		// Settings.
+		CPUCeiling:    "",
+		MemoryCeiling: "",
+		UpdateSurge:   false,
 	}
 }
 
 // Resource implements the cloud config resource.
 type Resource struct {
 	// Dependencies.
-	k8sClient kubernetes.Interface
-	logger    micrologger.Logger
+	clusterStatus clusterstatus.Interface
+	k8sClient     kubernetes.Interface
+	logger        micrologger.Logger
+
+	// Settings.
+	cpuCeiling    *resource.Quantity
+	memoryCeiling *resource.Quantity
+	updateSurge   bool
 }
 
 // New creates a new configured cloud config resource.
 func New(config Config) (*Resource, error) {
 	// Dependencies.
+	if config.ClusterStatus == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.ClusterStatus must not be empty")
+	}
 	if config.K8sClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
 	}
@@ -46,10 +76,26 @@ func New(config Config) (*Resource, error) {
 		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
 	}
 
+	// Settings.
+	cpuCeiling, err := parseCeiling(config.CPUCeiling)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.CPUCeiling must be a valid quantity: %s", err)
+	}
+	memoryCeiling, err := parseCeiling(config.MemoryCeiling)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.MemoryCeiling must be a valid quantity: %s", err)
+	}
+
 	newService := &Resource{
 		// Dependencies.
-		k8sClient: config.K8sClient,
-		logger:    config.Logger,
+		clusterStatus: config.ClusterStatus,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+
+		// Settings.
+		cpuCeiling:    cpuCeiling,
+		memoryCeiling: memoryCeiling,
+		updateSurge:   config.UpdateSurge,
 	}
 
 	return newService, nil
@@ -59,6 +105,19 @@ func (r *Resource) Name() string {
 	return Name
 }
 
+func parseCeiling(s string) (*resource.Quantity, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &q, nil
+}
+
 func toNamespace(v interface{}) (*apiv1.Namespace, error) {
 	if v == nil {
 		return nil, nil
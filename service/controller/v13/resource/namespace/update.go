@@ -5,9 +5,32 @@ import (
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/operatorkit/controller"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/workerscontext"
 )
 
+// ApplyUpdateChange ensures the limit range and the resource quota of the
+// namespace. The limit range comes first, so pods created once the quota is
+// in place get the default resources the quota accounts for.
 func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
+	quotaToApply, err := toQuota(updateChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if quotaToApply != nil {
+		err = r.ensureLimitRange(ctx, quotaToApply.LimitRange)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = r.ensureResourceQuota(ctx, quotaToApply.ResourceQuota)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
 	return nil
 }
 
@@ -29,6 +52,41 @@ func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desire
 	return patch, nil
 }
 
+// newUpdateChange computes the resource quota and limit range of the
+// namespace. The quota is sized for the workers the deployments are reconciled
+// with, which may differ from the spec while scaling down. Guest clusters requesting more resources than the configured
+// ceiling are held, so neither the namespace nor its quota are changed.
 func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
-	return nil, nil
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "computing the resource quota of the namespace")
+
+	workers, ok := workerscontext.FromContext(ctx)
+	if !ok {
+		workers, err = key.WorkerNodes(customObject)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	nodes, err := key.ClusterNodeResources(customObject, workers)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if !r.checkCeiling(ctx, customObject, nodes) {
+		return nil, nil
+	}
+
+	q, err := r.newQuota(customObject, nodes)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "computed the resource quota of the namespace")
+
+	return q, nil
 }
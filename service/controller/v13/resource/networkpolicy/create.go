@@ -0,0 +1,62 @@
+package networkpolicy
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange interface{}) error {
+	networkPolicyToCreate, err := toNetworkPolicy(createChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// The namespaces selected by the network policy are labeled first, so
+	// guest cluster namespaces can exchange traffic with them right away.
+	err = r.ensureNamespaceLabels(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Create the network policy in the Kubernetes API.
+	if networkPolicyToCreate != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the network policy in the Kubernetes API")
+
+		_, err := r.k8sClient.NetworkingV1().NetworkPolicies(networkPolicyToCreate.Namespace).Create(networkPolicyToCreate)
+		if apierrors.IsAlreadyExists(err) {
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "created the network policy in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the network policy does not need to be created in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) newCreateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentNetworkPolicy, err := toNetworkPolicy(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredNetworkPolicy, err := toNetworkPolicy(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out if the network policy has to be created")
+
+	var networkPolicyToCreate *networkingv1.NetworkPolicy
+	if currentNetworkPolicy == nil {
+		networkPolicyToCreate = desiredNetworkPolicy
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "found out if the network policy has to be created")
+
+	return networkPolicyToCreate, nil
+}
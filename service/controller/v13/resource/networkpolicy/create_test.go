@@ -0,0 +1,61 @@
+package networkpolicy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_Resource_NetworkPolicy_ApplyCreateChange_NamespaceLabels(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset(
+		&apiv1.Namespace{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: "kube-system",
+			},
+		},
+		&apiv1.Namespace{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: "giantswarm",
+				Labels: map[string]string{
+					"name": "giantswarm",
+				},
+			},
+		},
+	)
+
+	c := Config{
+		K8sClient: k8sClient,
+		Logger:    microloggertest.New(),
+
+		DNSNamespace:               "kube-system",
+		IngressControllerNamespace: "kube-system",
+		OperatorNamespace:          "giantswarm",
+		PodCIDR:                    "10.2.0.0/16",
+	}
+	newResource, err := New(c)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	err = newResource.ApplyCreateChange(context.TODO(), &v1alpha1.KVMConfig{}, nil)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	for _, name := range []string{"kube-system", "giantswarm"} {
+		namespace, err := k8sClient.CoreV1().Namespaces().Get(name, apismetav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected %#v got %#v", nil, err)
+		}
+		if namespace.Labels[key.LabelNamespace] != name {
+			t.Fatalf("expected namespace %#v to be labeled %#v got %#v", name, name, namespace.Labels)
+		}
+	}
+}
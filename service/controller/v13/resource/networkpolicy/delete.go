@@ -0,0 +1,65 @@
+package networkpolicy
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteChange interface{}) error {
+	networkPolicyToDelete, err := toNetworkPolicy(deleteChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Delete the network policy in the Kubernetes API.
+	if networkPolicyToDelete != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleting the network policy in the Kubernetes API")
+
+		err := r.k8sClient.NetworkingV1().NetworkPolicies(networkPolicyToDelete.Namespace).Delete(networkPolicyToDelete.Name, &apismetav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleted the network policy in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the network policy does not need to be deleted from the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) NewDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	deleteChange, err := r.newDeleteChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetDeleteChange(deleteChange)
+
+	return patch, nil
+}
+
+func (r *Resource) newDeleteChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentNetworkPolicy, err := toNetworkPolicy(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out if the network policy has to be deleted")
+
+	var networkPolicyToDelete *networkingv1.NetworkPolicy
+	if currentNetworkPolicy != nil {
+		networkPolicyToDelete = currentNetworkPolicy
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "found out if the network policy has to be deleted")
+
+	return networkPolicyToDelete, nil
+}
@@ -0,0 +1,170 @@
+package networkpolicy
+
+import (
+	"context"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	dnsPort = 53
+
+	// workerHTTPPort and workerHTTPSPort are the ports of the worker service
+	// the ingress controller of the host cluster forwards the ingress traffic
+	// of guest clusters to.
+	workerHTTPPort  = 30010
+	workerHTTPSPort = 30011
+)
+
+func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "computing the new network policy")
+
+	networkPolicy := r.newNetworkPolicy(customObject)
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "computed the new network policy")
+
+	return networkPolicy, nil
+}
+
+// newNetworkPolicy returns the network policy selecting all pods in the
+// namespace of the given guest cluster. Pods in the namespace are allowed to
+// talk to each other. Besides that, only the ingress controller is allowed to
+// reach the ports of the master and worker services, and pods are allowed to
+// resolve names and to exchange traffic with the operators of the host
+// cluster. All other traffic from and to pods of other namespaces is denied.
+func (r *Resource) newNetworkPolicy(customObject v1alpha1.KVMConfig) *networkingv1.NetworkPolicy {
+	tcp := apiv1.ProtocolTCP
+	udp := apiv1.ProtocolUDP
+
+	newPort := func(protocol *apiv1.Protocol, port int) networkingv1.NetworkPolicyPort {
+		p := intstr.FromInt(port)
+		return networkingv1.NetworkPolicyPort{
+			Protocol: protocol,
+			Port:     &p,
+		}
+	}
+
+	sameNamespace := networkingv1.NetworkPolicyPeer{
+		PodSelector: &apismetav1.LabelSelector{},
+	}
+
+	ingress := []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: []networkingv1.NetworkPolicyPeer{
+				sameNamespace,
+			},
+		},
+		{
+			From: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: newNamespaceSelector(r.ingressControllerNamespace),
+				},
+			},
+			Ports: []networkingv1.NetworkPolicyPort{
+				newPort(&tcp, key.EtcdPort),
+				newPort(&tcp, customObject.Spec.Cluster.Kubernetes.API.SecurePort),
+				newPort(&tcp, workerHTTPPort),
+				newPort(&tcp, workerHTTPSPort),
+			},
+		},
+		{
+			From: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: newNamespaceSelector(r.operatorNamespace),
+				},
+			},
+		},
+	}
+
+	egress := []networkingv1.NetworkPolicyEgressRule{
+		{
+			To: []networkingv1.NetworkPolicyPeer{
+				sameNamespace,
+			},
+		},
+		{
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: newNamespaceSelector(r.dnsNamespace),
+				},
+			},
+			Ports: []networkingv1.NetworkPolicyPort{
+				newPort(&udp, dnsPort),
+				newPort(&tcp, dnsPort),
+			},
+		},
+		// The node controller reaches the Kubernetes API of its guest cluster
+		// through the ingress controller.
+		{
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: newNamespaceSelector(r.ingressControllerNamespace),
+				},
+			},
+		},
+		{
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: newNamespaceSelector(r.operatorNamespace),
+				},
+			},
+		},
+	}
+
+	// Addresses outside of the pod network of the host cluster, like the ones
+	// of the Kubernetes API and the hosts, are allowed, in case the pod network
+	// is known.
+	if r.podCIDR != "" {
+		egress = append(egress, networkingv1.NetworkPolicyEgressRule{
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					IPBlock: &networkingv1.IPBlock{
+						CIDR: "0.0.0.0/0",
+						Except: []string{
+							r.podCIDR,
+						},
+					},
+				},
+			},
+		})
+	}
+
+	networkPolicy := &networkingv1.NetworkPolicy{
+		TypeMeta: apismetav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: "networking.k8s.io/v1",
+		},
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      key.NetworkPolicyName(customObject),
+			Namespace: key.ClusterNamespace(customObject),
+			Labels: map[string]string{
+				"app":                       "kvm-operator",
+				"giantswarm.io/cluster-id":  key.ClusterID(customObject),
+				"giantswarm.io/customer-id": key.ClusterCustomer(customObject),
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: apismetav1.LabelSelector{},
+			Ingress:     ingress,
+			Egress:      egress,
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
+				networkingv1.PolicyTypeEgress,
+			},
+		},
+	}
+
+	return networkPolicy
+}
@@ -0,0 +1,196 @@
+package networkpolicy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	networkingv1 "k8s.io/api/networking/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_Resource_NetworkPolicy_GetDesiredState(t *testing.T) {
+	customObject := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+				Kubernetes: v1alpha1.ClusterKubernetes{
+					API: v1alpha1.ClusterKubernetesAPI{
+						SecurePort: 443,
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		PodCIDR                string
+		ExpectedIngressPorts   []int
+		ExpectedIPBlockExcepts []string
+	}{
+		// Test 0 ensures the pod network of the host cluster is excluded from the
+		// addresses allowed as egress.
+		{
+			PodCIDR:                "10.2.0.0/16",
+			ExpectedIngressPorts:   []int{2379, 443, 30010, 30011},
+			ExpectedIPBlockExcepts: []string{"10.2.0.0/16"},
+		},
+		// Test 1 ensures no egress to addresses outside of the host cluster is
+		// allowed in case the pod network of the host cluster is unknown.
+		{
+			PodCIDR:                "",
+			ExpectedIngressPorts:   []int{2379, 443, 30010, 30011},
+			ExpectedIPBlockExcepts: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		var newResource *Resource
+		{
+			c := Config{
+				K8sClient: fake.NewSimpleClientset(),
+				Logger:    microloggertest.New(),
+
+				DNSNamespace:               "kube-system",
+				IngressControllerNamespace: "kube-system",
+				OperatorNamespace:          "giantswarm",
+				PodCIDR:                    tc.PodCIDR,
+			}
+
+			var err error
+			newResource, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+			}
+		}
+
+		result, err := newResource.GetDesiredState(context.TODO(), customObject)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		networkPolicy, err := toNetworkPolicy(result)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		if networkPolicy.Namespace != "al9qy" {
+			t.Fatalf("case %d expected %#v got %#v", i, "al9qy", networkPolicy.Namespace)
+		}
+		if !reflect.DeepEqual(networkPolicy.Spec.PodSelector, apismetav1.LabelSelector{}) {
+			t.Fatalf("case %d expected network policy to select all pods got %#v", i, networkPolicy.Spec.PodSelector)
+		}
+
+		var ingressPorts []int
+		for _, r := range networkPolicy.Spec.Ingress {
+			for _, f := range r.From {
+				if f.NamespaceSelector != nil && f.NamespaceSelector.MatchLabels[key.LabelNamespace] == "kube-system" {
+					for _, p := range r.Ports {
+						ingressPorts = append(ingressPorts, p.Port.IntValue())
+					}
+				}
+			}
+		}
+		if !reflect.DeepEqual(ingressPorts, tc.ExpectedIngressPorts) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedIngressPorts, ingressPorts)
+		}
+
+		var ipBlock *networkingv1.IPBlock
+		for _, r := range networkPolicy.Spec.Egress {
+			for _, to := range r.To {
+				if to.IPBlock != nil {
+					ipBlock = to.IPBlock
+				}
+			}
+		}
+		if tc.ExpectedIPBlockExcepts == nil {
+			if ipBlock != nil {
+				t.Fatalf("case %d expected %#v got %#v", i, nil, ipBlock)
+			}
+		} else {
+			if ipBlock == nil || ipBlock.CIDR != "0.0.0.0/0" {
+				t.Fatalf("case %d expected egress to %#v got %#v", i, "0.0.0.0/0", ipBlock)
+			}
+			if !reflect.DeepEqual(ipBlock.Except, tc.ExpectedIPBlockExcepts) {
+				t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedIPBlockExcepts, ipBlock.Except)
+			}
+		}
+
+		dnsPort := intstr.FromInt(53)
+		var dnsAllowed bool
+		for _, r := range networkPolicy.Spec.Egress {
+			for _, p := range r.Ports {
+				if *p.Port == dnsPort {
+					dnsAllowed = true
+				}
+			}
+		}
+		if !dnsAllowed {
+			t.Fatalf("case %d expected DNS egress to be allowed", i)
+		}
+	}
+}
+
+func Test_Resource_NetworkPolicy_New(t *testing.T) {
+	testCases := []struct {
+		DNSNamespace string
+		PodCIDR      string
+		ErrorMatcher func(err error) bool
+	}{
+		// Test 0 ensures a valid configuration is accepted.
+		{
+			DNSNamespace: "kube-system",
+			PodCIDR:      "10.2.0.0/16",
+			ErrorMatcher: nil,
+		},
+		// Test 1 ensures an empty namespace is rejected.
+		{
+			DNSNamespace: "",
+			PodCIDR:      "10.2.0.0/16",
+			ErrorMatcher: IsInvalidConfig,
+		},
+		// Test 2 ensures an empty pod network is accepted, so installations not
+		// configuring it yet keep working.
+		{
+			DNSNamespace: "kube-system",
+			PodCIDR:      "",
+			ErrorMatcher: nil,
+		},
+		// Test 3 ensures an invalid pod network is rejected.
+		{
+			DNSNamespace: "kube-system",
+			PodCIDR:      "10.2.0.0",
+			ErrorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		c := Config{
+			K8sClient: fake.NewSimpleClientset(),
+			Logger:    microloggertest.New(),
+
+			DNSNamespace:               tc.DNSNamespace,
+			IngressControllerNamespace: "kube-system",
+			OperatorNamespace:          "giantswarm",
+			PodCIDR:                    tc.PodCIDR,
+		}
+
+		_, err := New(c)
+
+		switch {
+		case err == nil && tc.ErrorMatcher == nil:
+			// correct; carry on
+		case err != nil && tc.ErrorMatcher == nil:
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		case err == nil && tc.ErrorMatcher != nil:
+			t.Fatalf("case %d expected %#v got %#v", i, "error", nil)
+		case !tc.ErrorMatcher(err):
+			t.Fatalf("case %d expected %#v got %#v", i, true, false)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package networkpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	networkingv1 "k8s.io/api/networking/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// Name is the identifier of the resource.
+	Name = "networkpolicyv13"
+)
+
+// Config represents the configuration used to create a new network policy
+// resource.
+type Config struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// DNSNamespace is the namespace running the DNS of the host cluster.
+	DNSNamespace string
+	// IngressControllerNamespace is the namespace running the ingress
+	// controller of the host cluster.
+	IngressControllerNamespace string
+	// OperatorNamespace is the namespace running the operators of the host
+	// cluster.
+	OperatorNamespace string
+	// PodCIDR is the pod network of the host cluster. Pods in guest cluster
+	// namespaces are allowed to reach addresses outside of it. They are not
+	// allowed to reach any addresses besides the host cluster namespaces above
+	// when empty.
+	PodCIDR string
+}
+
+// Resource implements the network policy resource. It isolates the namespace
+// of each guest cluster, so pods running there can neither reach nor be
+// reached by pods of other guest clusters. Note that VM pods use the host
+// network, which network policies do not apply to. The host cluster namespaces
+// guest cluster namespaces are allowed to exchange traffic with are labeled
+// using key.LabelNamespace, so the network policies can select them.
+type Resource struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+
+	dnsNamespace               string
+	ingressControllerNamespace string
+	operatorNamespace          string
+	podCIDR                    string
+}
+
+// New creates a new configured network policy resource.
+func New(config Config) (*Resource, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+
+	if config.DNSNamespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.DNSNamespace must not be empty")
+	}
+	if config.IngressControllerNamespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.IngressControllerNamespace must not be empty")
+	}
+	if config.OperatorNamespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "config.OperatorNamespace must not be empty")
+	}
+	// Without the pod network, egress to all addresses had to be allowed, which
+	// includes the pods of all other namespaces. So egress to addresses outside
+	// of the host cluster is not allowed at all in this case.
+	if config.PodCIDR == "" {
+		config.Logger.Log("level", "warning", "message", "config.PodCIDR is empty, pods in guest cluster namespaces are not allowed to reach addresses outside of the host cluster")
+	} else {
+		_, _, err := net.ParseCIDR(config.PodCIDR)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "config.PodCIDR must be a CIDR: %s", err)
+		}
+	}
+
+	newService := &Resource{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		dnsNamespace:               config.DNSNamespace,
+		ingressControllerNamespace: config.IngressControllerNamespace,
+		operatorNamespace:          config.OperatorNamespace,
+		podCIDR:                    config.PodCIDR,
+	}
+
+	return newService, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
+
+func isNetworkPolicyModified(a, b *networkingv1.NetworkPolicy) bool {
+	return !reflect.DeepEqual(a.Spec, b.Spec)
+}
+
+// ensureNamespaceLabels labels the host cluster namespaces guest cluster
+// namespaces are allowed to exchange traffic with, in case they are not yet.
+func (r *Resource) ensureNamespaceLabels(ctx context.Context) error {
+	for _, name := range []string{r.dnsNamespace, r.ingressControllerNamespace, r.operatorNamespace} {
+		namespace, err := r.k8sClient.CoreV1().Namespaces().Get(name, apismetav1.GetOptions{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if namespace.GetLabels()[key.LabelNamespace] == name {
+			continue
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("labeling namespace '%s' in the Kubernetes API", name))
+
+		if namespace.Labels == nil {
+			namespace.Labels = map[string]string{}
+		}
+		namespace.Labels[key.LabelNamespace] = name
+
+		_, err = r.k8sClient.CoreV1().Namespaces().Update(namespace)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("labeled namespace '%s' in the Kubernetes API", name))
+	}
+
+	return nil
+}
+
+// newNamespaceSelector returns the label selector of the given namespace
+// labeled by ensureNamespaceLabels.
+func newNamespaceSelector(name string) *apismetav1.LabelSelector {
+	return &apismetav1.LabelSelector{
+		MatchLabels: map[string]string{
+			key.LabelNamespace: name,
+		},
+	}
+}
+
+func toNetworkPolicy(v interface{}) (*networkingv1.NetworkPolicy, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	networkPolicy, ok := v.(*networkingv1.NetworkPolicy)
+	if !ok {
+		return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", &networkingv1.NetworkPolicy{}, v)
+	}
+
+	return networkPolicy, nil
+}
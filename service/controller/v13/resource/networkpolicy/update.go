@@ -0,0 +1,73 @@
+package networkpolicy
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
+	networkPolicyToUpdate, err := toNetworkPolicy(updateChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Update the network policy in the Kubernetes API.
+	if networkPolicyToUpdate != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the network policy in the Kubernetes API")
+
+		_, err := r.k8sClient.NetworkingV1().NetworkPolicies(networkPolicyToUpdate.Namespace).Update(networkPolicyToUpdate)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the network policy in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the network policy does not need to be updated in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	create, err := r.newCreateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	update, err := r.newUpdateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetCreateChange(create)
+	patch.SetUpdateChange(update)
+
+	return patch, nil
+}
+
+func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentNetworkPolicy, err := toNetworkPolicy(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredNetworkPolicy, err := toNetworkPolicy(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out if the network policy has to be updated")
+
+	var networkPolicyToUpdate *networkingv1.NetworkPolicy
+	if currentNetworkPolicy != nil && desiredNetworkPolicy != nil && isNetworkPolicyModified(desiredNetworkPolicy, currentNetworkPolicy) {
+		networkPolicyToUpdate = currentNetworkPolicy.DeepCopy()
+		networkPolicyToUpdate.Labels = desiredNetworkPolicy.Labels
+		networkPolicyToUpdate.Spec = desiredNetworkPolicy.Spec
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "found out if the network policy has to be updated")
+
+	return networkPolicyToUpdate, nil
+}
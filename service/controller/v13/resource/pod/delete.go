@@ -13,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard"
 )
 
 func (r *Resource) EnsureDeleted(ctx context.Context, obj interface{}) error {
@@ -66,6 +67,21 @@ func (r *Resource) EnsureDeleted(ctx context.Context, obj interface{}) error {
 		if apierrors.IsNotFound(err) {
 			r.logger.LogCtx(ctx, "level", "debug", "message", "did not find node config for guest cluster node")
 
+			if isMasterPod(currentPod) {
+				allowed, err := r.isMasterDrainAllowed(ctx, currentPod)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+				if !allowed {
+					r.logger.LogCtx(ctx, "level", "debug", "message", "not draining guest cluster node: draining it would break the etcd quorum")
+					resourcecanceledcontext.SetCanceled(ctx)
+					finalizerskeptcontext.SetKept(ctx)
+					r.logger.LogCtx(ctx, "debug", "canceling reconciliation for pod")
+
+					return nil
+				}
+			}
+
 			err := r.createNodeConfig(ctx, currentPod)
 			if err != nil {
 				return microerror.Mask(err)
@@ -205,6 +221,31 @@ func (r *Resource) createNodeConfig(ctx context.Context, pod *corev1.Pod) error
 	return nil
 }
 
+// isMasterDrainAllowed asks the quorum guard whether the guest cluster node of
+// the given master pod can be drained without the etcd cluster of the guest
+// cluster losing its quorum.
+func (r *Resource) isMasterDrainAllowed(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	request := quorumguard.Request{
+		Action:    quorumguard.ActionDrain,
+		ClusterID: pod.GetNamespace(),
+		MasterIP:  pod.GetAnnotations()[key.AnnotationIp],
+		Object: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Name:       pod.GetName(),
+			Namespace:  pod.GetNamespace(),
+			UID:        pod.GetUID(),
+		},
+	}
+
+	decision, err := r.quorumGuard.Check(ctx, request)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return decision.Allowed, nil
+}
+
 func forcePodCleanup(pod *corev1.Pod) bool {
 	if !key.IsPodDeleted(pod) {
 		return false
@@ -216,3 +257,7 @@ func forcePodCleanup(pod *corev1.Pod) bool {
 
 	return true
 }
+
+func isMasterPod(pod *corev1.Pod) bool {
+	return pod.GetLabels()["app"] == key.MasterID
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/quorumguard"
 )
 
 const (
@@ -12,15 +14,17 @@ const (
 )
 
 type Config struct {
-	G8sClient versioned.Interface
-	K8sClient kubernetes.Interface
-	Logger    micrologger.Logger
+	G8sClient   versioned.Interface
+	K8sClient   kubernetes.Interface
+	Logger      micrologger.Logger
+	QuorumGuard quorumguard.Interface
 }
 
 type Resource struct {
-	g8sClient versioned.Interface
-	k8sClient kubernetes.Interface
-	logger    micrologger.Logger
+	g8sClient   versioned.Interface
+	k8sClient   kubernetes.Interface
+	logger      micrologger.Logger
+	quorumGuard quorumguard.Interface
 }
 
 func New(config Config) (*Resource, error) {
@@ -33,11 +37,15 @@ func New(config Config) (*Resource, error) {
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.QuorumGuard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.QuorumGuard must not be empty", config)
+	}
 
 	r := &Resource{
-		g8sClient: config.G8sClient,
-		k8sClient: config.K8sClient,
-		logger:    config.Logger,
+		g8sClient:   config.G8sClient,
+		k8sClient:   config.K8sClient,
+		logger:      config.Logger,
+		quorumGuard: config.QuorumGuard,
 	}
 
 	return r, nil
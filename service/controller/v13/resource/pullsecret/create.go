@@ -0,0 +1,61 @@
+package pullsecret
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange interface{}) error {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	secretToCreate, err := toSecret(createChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if secretToCreate != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the pull secret in the Kubernetes API")
+
+		namespace := key.ClusterNamespace(customObject)
+		_, err = r.k8sClient.CoreV1().Secrets(namespace).Create(secretToCreate)
+		if apierrors.IsAlreadyExists(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "created the pull secret in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the pull secret does not need to be created in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) newCreateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentSecret, err := toSecret(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredSecret, err := toSecret(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out if the pull secret has to be created")
+
+	var secretToCreate interface{}
+	if currentSecret == nil && desiredSecret != nil {
+		secretToCreate = desiredSecret
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "found out if the pull secret has to be created")
+
+	return secretToCreate, nil
+}
@@ -0,0 +1,19 @@
+package pullsecret
+
+import (
+	"context"
+
+	"github.com/giantswarm/operatorkit/controller"
+)
+
+func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteChange interface{}) error {
+	return nil
+}
+
+// NewDeletePatch returns an empty patch because the pull secret is removed
+// together with the cluster namespace.
+func (r *Resource) NewDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	patch := controller.NewPatch()
+
+	return patch, nil
+}
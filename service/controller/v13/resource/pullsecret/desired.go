@@ -0,0 +1,51 @@
+package pullsecret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if r.pullSecretName == "" {
+		return nil, nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("looking for the pull secret '%s/%s' to propagate in the Kubernetes API", r.pullSecretNamespace, r.pullSecretName))
+
+	source, err := r.k8sClient.CoreV1().Secrets(r.pullSecretNamespace).Get(r.pullSecretName, apismetav1.GetOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found the pull secret '%s/%s' to propagate in the Kubernetes API", r.pullSecretNamespace, r.pullSecretName))
+
+	secret := &apiv1.Secret{
+		TypeMeta: apismetav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      r.pullSecretName,
+			Namespace: key.ClusterNamespace(customObject),
+			Labels: map[string]string{
+				"cluster":  key.ClusterID(customObject),
+				"customer": key.ClusterCustomer(customObject),
+			},
+		},
+		Data: source.Data,
+		Type: source.Type,
+	}
+
+	return secret, nil
+}
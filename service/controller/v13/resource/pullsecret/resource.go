@@ -0,0 +1,84 @@
+package pullsecret
+
+import (
+	"reflect"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Name is the identifier of the resource.
+	Name = "pullsecretv13"
+)
+
+// Config represents the configuration used to create a new pull secret
+// resource.
+type Config struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// PullSecretName is the name of the image pull secret propagated into the
+	// cluster namespace. Nothing is propagated when it is empty.
+	PullSecretName string
+	// PullSecretNamespace is the namespace the image pull secret is read from.
+	PullSecretNamespace string
+}
+
+// Resource implements the pull secret resource. It copies the image pull
+// secret the operator is configured with into the cluster namespace, so the
+// pods of guest clusters can pull their images from private registries.
+type Resource struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+
+	pullSecretName      string
+	pullSecretNamespace string
+}
+
+// New creates a new configured pull secret resource.
+func New(config Config) (*Resource, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.PullSecretName != "" && config.PullSecretNamespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.PullSecretNamespace must not be empty when %T.PullSecretName is set", config, config)
+	}
+
+	r := &Resource{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		pullSecretName:      config.PullSecretName,
+		pullSecretNamespace: config.PullSecretNamespace,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
+
+func isSecretModified(a, b *apiv1.Secret) bool {
+	return a.Type != b.Type || !reflect.DeepEqual(a.Data, b.Data)
+}
+
+func toSecret(v interface{}) (*apiv1.Secret, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	secret, ok := v.(*apiv1.Secret)
+	if !ok {
+		return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", &apiv1.Secret{}, v)
+	}
+
+	return secret, nil
+}
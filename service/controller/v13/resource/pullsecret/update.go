@@ -0,0 +1,78 @@
+package pullsecret
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	secretToUpdate, err := toSecret(updateChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if secretToUpdate != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the pull secret in the Kubernetes API")
+
+		namespace := key.ClusterNamespace(customObject)
+		_, err = r.k8sClient.CoreV1().Secrets(namespace).Update(secretToUpdate)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the pull secret in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the pull secret does not need to be updated in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	create, err := r.newCreateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	update, err := r.newUpdateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetCreateChange(create)
+	patch.SetUpdateChange(update)
+
+	return patch, nil
+}
+
+func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentSecret, err := toSecret(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredSecret, err := toSecret(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out if the pull secret has to be updated")
+
+	var secretToUpdate interface{}
+	if currentSecret != nil && desiredSecret != nil && isSecretModified(desiredSecret, currentSecret) {
+		s := desiredSecret.DeepCopy()
+		s.SetResourceVersion(currentSecret.GetResourceVersion())
+		secretToUpdate = s
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "found out if the pull secret has to be updated")
+
+	return secretToUpdate, nil
+}
@@ -0,0 +1,136 @@
+package pullsecret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Resource_PullSecret_newUpdateChange(t *testing.T) {
+	customObject := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	source := &apiv1.Secret{
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      "kvm-operator-pull-secret",
+			Namespace: "giantswarm",
+		},
+		Data: map[string][]byte{
+			".dockerconfigjson": []byte(`{"auths":{}}`),
+		},
+		Type: apiv1.SecretTypeDockerConfigJson,
+	}
+
+	testCases := []struct {
+		Current        *apiv1.Secret
+		ExpectedCreate bool
+		ExpectedUpdate bool
+	}{
+		// Test 0 ensures the pull secret is created in case it does not exist in
+		// the cluster namespace yet.
+		{
+			Current:        nil,
+			ExpectedCreate: true,
+			ExpectedUpdate: false,
+		},
+
+		// Test 1 ensures the pull secret is not touched in case it matches the
+		// source.
+		{
+			Current: &apiv1.Secret{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Name:      "kvm-operator-pull-secret",
+					Namespace: "al9qy",
+				},
+				Data: map[string][]byte{
+					".dockerconfigjson": []byte(`{"auths":{}}`),
+				},
+				Type: apiv1.SecretTypeDockerConfigJson,
+			},
+			ExpectedCreate: false,
+			ExpectedUpdate: false,
+		},
+
+		// Test 2 ensures the pull secret is updated in case the source changed.
+		{
+			Current: &apiv1.Secret{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Name:      "kvm-operator-pull-secret",
+					Namespace: "al9qy",
+				},
+				Data: map[string][]byte{
+					".dockerconfigjson": []byte(`{"auths":{"quay.io":{}}}`),
+				},
+				Type: apiv1.SecretTypeDockerConfigJson,
+			},
+			ExpectedCreate: false,
+			ExpectedUpdate: true,
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		c := Config{
+			K8sClient: fake.NewSimpleClientset(source),
+			Logger:    microloggertest.New(),
+
+			PullSecretName:      "kvm-operator-pull-secret",
+			PullSecretNamespace: "giantswarm",
+		}
+
+		newResource, err = New(c)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	for i, tc := range testCases {
+		desired, err := newResource.GetDesiredState(context.TODO(), customObject)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+
+		var current interface{}
+		if tc.Current != nil {
+			current = tc.Current
+		}
+
+		createChange, err := newResource.newCreateChange(context.TODO(), customObject, current, desired)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		create, err := toSecret(createChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if (create != nil) != tc.ExpectedCreate {
+			t.Fatalf("case %d expected create %t got %#v", i, tc.ExpectedCreate, create)
+		}
+		if create != nil && create.Namespace != "al9qy" {
+			t.Fatalf("case %d expected %#v got %#v", i, "al9qy", create.Namespace)
+		}
+
+		updateChange, err := newResource.newUpdateChange(context.TODO(), customObject, current, desired)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		update, err := toSecret(updateChange)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if (update != nil) != tc.ExpectedUpdate {
+			t.Fatalf("case %d expected update %t got %#v", i, tc.ExpectedUpdate, update)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package role
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange interface{}) error {
+	roleToCreate, err := toRole(createChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Create the role in the Kubernetes API.
+	if roleToCreate != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the role in the Kubernetes API")
+
+		_, err := r.k8sClient.RbacV1beta1().Roles(roleToCreate.Namespace).Create(roleToCreate)
+		if apierrors.IsAlreadyExists(err) {
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "created the role in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the role does not need to be created in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) newCreateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentRole, err := toRole(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredRole, err := toRole(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out if the role has to be created")
+
+	var roleToCreate *apiv1.Role
+	if currentRole == nil {
+		roleToCreate = desiredRole
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "found out if the role has to be created")
+
+	return roleToCreate, nil
+}
@@ -0,0 +1,65 @@
+package role
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteChange interface{}) error {
+	roleToDelete, err := toRole(deleteChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Delete the role in the Kubernetes API.
+	if roleToDelete != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleting the role in the Kubernetes API")
+
+		err := r.k8sClient.RbacV1beta1().Roles(roleToDelete.Namespace).Delete(roleToDelete.Name, &apismetav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleted the role in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the role does not need to be deleted from the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) NewDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	deleteChange, err := r.newDeleteChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetDeleteChange(deleteChange)
+
+	return patch, nil
+}
+
+func (r *Resource) newDeleteChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentRole, err := toRole(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out if the role has to be deleted")
+
+	var roleToDelete *apiv1.Role
+	if currentRole != nil {
+		roleToDelete = currentRole
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "found out if the role has to be deleted")
+
+	return roleToDelete, nil
+}
@@ -0,0 +1,62 @@
+package role
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interface{}, error) {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "computing the new role")
+
+	// The role only grants access to resources in the namespace of the guest
+	// cluster. The k8s-endpoint-updater container of VM pods looks up its own
+	// pod and writes the address of the VM to the endpoints of the master and
+	// worker services. The node controller lists and watches pods and endpoints
+	// to follow the VMs of the guest cluster.
+	role := &apiv1.Role{
+		TypeMeta: apismetav1.TypeMeta{
+			Kind:       "Role",
+			APIVersion: apiv1.GroupName,
+		},
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name:      key.RoleName(customObject),
+			Namespace: key.ClusterNamespace(customObject),
+			Labels: map[string]string{
+				"app":                       "kvm-operator",
+				"giantswarm.io/cluster-id":  key.ClusterID(customObject),
+				"giantswarm.io/customer-id": key.ClusterCustomer(customObject),
+			},
+		},
+		Rules: []apiv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"endpoints"},
+				Verbs:     []string{"get", "list", "watch", "create", "update"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"services"},
+				Verbs:     []string{"get"},
+			},
+		},
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "computed the new role")
+
+	return role, nil
+}
@@ -0,0 +1,73 @@
+package role
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+)
+
+func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
+	roleToUpdate, err := toRole(updateChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Update the role in the Kubernetes API.
+	if roleToUpdate != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the role in the Kubernetes API")
+
+		_, err := r.k8sClient.RbacV1beta1().Roles(roleToUpdate.Namespace).Update(roleToUpdate)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the role in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the role does not need to be updated in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	create, err := r.newCreateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	update, err := r.newUpdateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetCreateChange(create)
+	patch.SetUpdateChange(update)
+
+	return patch, nil
+}
+
+func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentRole, err := toRole(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredRole, err := toRole(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out if the role has to be updated")
+
+	var roleToUpdate *apiv1.Role
+	if currentRole != nil && desiredRole != nil && isRoleModified(desiredRole, currentRole) {
+		roleToUpdate = currentRole.DeepCopy()
+		roleToUpdate.Labels = desiredRole.Labels
+		roleToUpdate.Rules = desiredRole.Rules
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "found out if the role has to be updated")
+
+	return roleToUpdate, nil
+}
@@ -0,0 +1,121 @@
+package role
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Resource_Role_newUpdatePatch(t *testing.T) {
+	customObject := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		resourceConfig := Config{
+			K8sClient: fake.NewSimpleClientset(),
+			Logger:    microloggertest.New(),
+		}
+		newResource, err = New(resourceConfig)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	desiredState, err := newResource.GetDesiredState(context.TODO(), customObject)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	desiredRole := desiredState.(*apiv1.Role)
+
+	testCases := []struct {
+		CurrentState   *apiv1.Role
+		ExpectedCreate *apiv1.Role
+		ExpectedUpdate *apiv1.Role
+	}{
+		// Test 0 ensures the role is created in case it does not exist.
+		{
+			CurrentState:   nil,
+			ExpectedCreate: desiredRole,
+			ExpectedUpdate: nil,
+		},
+		// Test 1 ensures the role is neither created nor updated in case it
+		// grants the desired permissions.
+		{
+			CurrentState:   desiredRole,
+			ExpectedCreate: nil,
+			ExpectedUpdate: nil,
+		},
+		// Test 2 ensures the role is updated in case it grants other permissions
+		// than desired.
+		{
+			CurrentState: &apiv1.Role{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Name:            "al9qy",
+					Namespace:       "al9qy",
+					ResourceVersion: "42",
+				},
+				Rules: []apiv1.PolicyRule{
+					{
+						APIGroups: []string{""},
+						Resources: []string{"secrets"},
+						Verbs:     []string{"*"},
+					},
+				},
+			},
+			ExpectedCreate: nil,
+			ExpectedUpdate: &apiv1.Role{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Name:            "al9qy",
+					Namespace:       "al9qy",
+					Labels:          desiredRole.Labels,
+					ResourceVersion: "42",
+				},
+				Rules: desiredRole.Rules,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		var currentState interface{}
+		if tc.CurrentState != nil {
+			currentState = tc.CurrentState
+		}
+
+		result, err := newResource.newCreateChange(context.TODO(), customObject, currentState, desiredState)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		create, err := toRole(result)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if !reflect.DeepEqual(create, tc.ExpectedCreate) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedCreate, create)
+		}
+
+		result, err = newResource.newUpdateChange(context.TODO(), customObject, currentState, desiredState)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		update, err := toRole(result)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i, nil, err)
+		}
+		if !reflect.DeepEqual(update, tc.ExpectedUpdate) {
+			t.Fatalf("case %d expected %#v got %#v", i, tc.ExpectedUpdate, update)
+		}
+	}
+}
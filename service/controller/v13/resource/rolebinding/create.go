@@ -0,0 +1,61 @@
+package rolebinding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func (r *Resource) ApplyCreateChange(ctx context.Context, obj, createChange interface{}) error {
+	roleBindingsToCreate, err := toRoleBindings(createChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Create the role bindings in the Kubernetes API.
+	if len(roleBindingsToCreate) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "creating the role bindings in the Kubernetes API")
+
+		for _, roleBinding := range roleBindingsToCreate {
+			_, err := r.k8sClient.RbacV1beta1().RoleBindings(roleBinding.Namespace).Create(roleBinding)
+			if apierrors.IsAlreadyExists(err) {
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "created the role bindings in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the role bindings do not need to be created in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) newCreateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentRoleBindings, err := toRoleBindings(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredRoleBindings, err := toRoleBindings(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which role bindings have to be created")
+
+	var roleBindingsToCreate []*apiv1.RoleBinding
+
+	for _, desiredRoleBinding := range desiredRoleBindings {
+		if !containsRoleBinding(currentRoleBindings, desiredRoleBinding) {
+			roleBindingsToCreate = append(roleBindingsToCreate, desiredRoleBinding)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d role bindings that have to be created", len(roleBindingsToCreate)))
+
+	return roleBindingsToCreate, nil
+}
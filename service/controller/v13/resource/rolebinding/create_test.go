@@ -0,0 +1,244 @@
+package rolebinding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Resource_RoleBinding_newCreateChange(t *testing.T) {
+	testCases := []struct {
+		Obj                       interface{}
+		CurrentState              interface{}
+		DesiredState              interface{}
+		ExpectedRoleBindingsNames []string
+	}{
+		// Test 1, in case current state and desired state are empty the create
+		// state should be empty.
+		{
+			Obj: &v1alpha1.KVMConfig{
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+					},
+				},
+			},
+			CurrentState:              []*apiv1.RoleBinding{},
+			DesiredState:              []*apiv1.RoleBinding{},
+			ExpectedRoleBindingsNames: []string{},
+		},
+
+		// Test 2, in case current state equals desired state the create state
+		// should be empty.
+		{
+			Obj: &v1alpha1.KVMConfig{
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+					},
+				},
+			},
+			CurrentState: []*apiv1.RoleBinding{
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-1",
+					},
+				},
+			},
+			DesiredState: []*apiv1.RoleBinding{
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-1",
+					},
+				},
+			},
+			ExpectedRoleBindingsNames: []string{},
+		},
+
+		// Test 3, in case current state misses one item of desired state the create
+		// state should contain the missing item of the desired state.
+		{
+			Obj: &v1alpha1.KVMConfig{
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+					},
+				},
+			},
+			CurrentState: []*apiv1.RoleBinding{},
+			DesiredState: []*apiv1.RoleBinding{
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-1",
+					},
+				},
+			},
+			ExpectedRoleBindingsNames: []string{
+				"role-binding-1",
+			},
+		},
+
+		// Test 4, in case current state misses items of desired state the create
+		// state should contain the missing items of the desired state.
+		{
+			Obj: &v1alpha1.KVMConfig{
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+					},
+				},
+			},
+			CurrentState: []*apiv1.RoleBinding{},
+			DesiredState: []*apiv1.RoleBinding{
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-1",
+					},
+				},
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-2",
+					},
+				},
+			},
+			ExpectedRoleBindingsNames: []string{
+				"role-binding-1",
+				"role-binding-2",
+			},
+		},
+
+		// Test 5, in case current state contains one item not being in desired
+		// state the create state should not contain the missing item of the desired
+		// state.
+		{
+			Obj: &v1alpha1.KVMConfig{
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+					},
+				},
+			},
+			CurrentState: []*apiv1.RoleBinding{
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-1",
+					},
+				},
+			},
+			DesiredState:              []*apiv1.RoleBinding{},
+			ExpectedRoleBindingsNames: []string{},
+		},
+
+		// Test 6, in case current state contains items not being in desired state
+		// the create state should not contain the missing items of the desired
+		// state.
+		{
+			Obj: &v1alpha1.KVMConfig{
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+					},
+				},
+			},
+			CurrentState: []*apiv1.RoleBinding{
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-1",
+					},
+				},
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-2",
+					},
+				},
+			},
+			DesiredState:              []*apiv1.RoleBinding{},
+			ExpectedRoleBindingsNames: []string{},
+		},
+
+		// Test 7, in case current state contains some items of desired state the
+		// create state should contain the items being in desired state which are
+		// not in create state.
+		{
+			Obj: &v1alpha1.KVMConfig{
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+					},
+				},
+			},
+			CurrentState: []*apiv1.RoleBinding{
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-1",
+					},
+				},
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-2",
+					},
+				},
+			},
+			DesiredState: []*apiv1.RoleBinding{
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-1",
+					},
+				},
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-2",
+					},
+				},
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-3",
+					},
+				},
+				{
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name: "role-binding-4",
+					},
+				},
+			},
+			ExpectedRoleBindingsNames: []string{
+				"role-binding-3",
+				"role-binding-4",
+			},
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		resourceConfig := Config{
+			K8sClient: fake.NewSimpleClientset(),
+			Logger:    microloggertest.New(),
+		}
+		newResource, err = New(resourceConfig)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	for i, tc := range testCases {
+		result, err := newResource.newCreateChange(context.TODO(), tc.Obj, tc.CurrentState, tc.DesiredState)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		roleBindings, ok := result.([]*apiv1.RoleBinding)
+		if !ok {
+			t.Fatalf("case %d expected %T got %T", i+1, []*apiv1.RoleBinding{}, result)
+		}
+
+		if len(roleBindings) != len(tc.ExpectedRoleBindingsNames) {
+			t.Fatalf("case %d expected %d role bindings got %d", i+1, len(tc.ExpectedRoleBindingsNames), len(roleBindings))
+		}
+	}
+}
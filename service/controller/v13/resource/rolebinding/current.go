@@ -1,4 +1,4 @@
-package clusterrolebinding
+package rolebinding
 
 import (
 	"context"
@@ -17,36 +17,36 @@ func (r *Resource) GetCurrentState(ctx context.Context, obj interface{}) (interf
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
-	r.logger.LogCtx(ctx, "level", "debug", "message", "looking for a list of cluster role bindings in the Kubernetes API")
+	r.logger.LogCtx(ctx, "level", "debug", "message", "looking for a list of role bindings in the Kubernetes API")
 
-	var currentClusterRoleBinding []*apiv1.ClusterRoleBinding
+	var currentRoleBinding []*apiv1.RoleBinding
 	{
-		clusterRoleBinding, err := r.k8sClient.RbacV1beta1().ClusterRoleBindings().Get(key.ClusterRoleBindingName(customObject), apismetav1.GetOptions{})
+		roleBinding, err := r.k8sClient.RbacV1beta1().RoleBindings(key.ClusterNamespace(customObject)).Get(key.RoleBindingName(customObject), apismetav1.GetOptions{})
 		if apierrors.IsNotFound(err) {
-			r.logger.LogCtx(ctx, "level", "debug", "message", "did not find cluster role binding in the Kubernetes API")
+			r.logger.LogCtx(ctx, "level", "debug", "message", "did not find role binding in the Kubernetes API")
 			// fall through
 		} else if err != nil {
 			return nil, microerror.Mask(err)
 		} else {
-			r.logger.LogCtx(ctx, "level", "debug", "message", "found a list of cluster role binding in the Kubernetes API")
+			r.logger.LogCtx(ctx, "level", "debug", "message", "found a list of role binding in the Kubernetes API")
 
-			currentClusterRoleBinding = append(currentClusterRoleBinding, clusterRoleBinding)
+			currentRoleBinding = append(currentRoleBinding, roleBinding)
 		}
 
-		clusterRoleBindingPSP, err := r.k8sClient.RbacV1beta1().ClusterRoleBindings().Get(key.ClusterRoleBindingPSPName(customObject), apismetav1.GetOptions{})
+		roleBindingPSP, err := r.k8sClient.RbacV1beta1().RoleBindings(key.ClusterNamespace(customObject)).Get(key.RoleBindingPSPName(customObject), apismetav1.GetOptions{})
 		if apierrors.IsNotFound(err) {
-			r.logger.LogCtx(ctx, "level", "debug", "message", "did not find cluster role binding psp in the Kubernetes API")
+			r.logger.LogCtx(ctx, "level", "debug", "message", "did not find role binding psp in the Kubernetes API")
 			// fall through
 		} else if err != nil {
 			return nil, microerror.Mask(err)
 		} else {
-			r.logger.LogCtx(ctx, "level", "debug", "message", "found a list of cluster role binding psp in the Kubernetes API")
+			r.logger.LogCtx(ctx, "level", "debug", "message", "found a list of role binding psp in the Kubernetes API")
 
-			currentClusterRoleBinding = append(currentClusterRoleBinding, clusterRoleBindingPSP)
+			currentRoleBinding = append(currentRoleBinding, roleBindingPSP)
 		}
 	}
 
-	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found a list of %d cluster role bindings in the Kubernetes API", len(currentClusterRoleBinding)))
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found a list of %d role bindings in the Kubernetes API", len(currentRoleBinding)))
 
-	return currentClusterRoleBinding, nil
+	return currentRoleBinding, nil
 }
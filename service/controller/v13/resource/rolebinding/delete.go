@@ -0,0 +1,75 @@
+package rolebinding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (r *Resource) ApplyDeleteChange(ctx context.Context, obj, deleteChange interface{}) error {
+	roleBindingsToDelete, err := toRoleBindings(deleteChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(roleBindingsToDelete) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleting the role bindings in the Kubernetes API")
+
+		// Delete the role bindings in the Kubernetes API.
+		for _, roleBinding := range roleBindingsToDelete {
+			err := r.k8sClient.RbacV1beta1().RoleBindings(roleBinding.Namespace).Delete(roleBinding.Name, &apismetav1.DeleteOptions{})
+			if apierrors.IsNotFound(err) {
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "deleted the role bindings in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the role bindings do not need to be deleted from the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) NewDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	delete, err := r.newDeleteChangeForDeletePatch(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetDeleteChange(delete)
+
+	return patch, nil
+}
+
+func (r *Resource) newDeleteChangeForDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentRoleBindings, err := toRoleBindings(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredRoleBindings, err := toRoleBindings(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which role bindings have to be deleted")
+
+	var roleBindingsToDelete []*apiv1.RoleBinding
+
+	for _, currentRoleBinding := range currentRoleBindings {
+		if containsRoleBinding(desiredRoleBindings, currentRoleBinding) {
+			roleBindingsToDelete = append(roleBindingsToDelete, currentRoleBinding)
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d role bindings that have to be deleted", len(roleBindingsToDelete)))
+
+	return roleBindingsToDelete, nil
+}
@@ -1,4 +1,4 @@
-package clusterrolebinding
+package rolebinding
 
 import (
 	"context"
@@ -11,12 +11,12 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 )
 
-func Test_Resource_ClusterRoleBinding_newDeleteChange(t *testing.T) {
+func Test_Resource_RoleBinding_newDeleteChange(t *testing.T) {
 	testCases := []struct {
-		Obj                             interface{}
-		CurrentState                    interface{}
-		DesiredState                    interface{}
-		ExpectedClusterRoleBindingNames []string
+		Obj                      interface{}
+		CurrentState             interface{}
+		DesiredState             interface{}
+		ExpectedRoleBindingNames []string
 	}{
 		// Test 1, in case current state and desired state are empty the delete
 		// state should be empty.
@@ -28,9 +28,9 @@ func Test_Resource_ClusterRoleBinding_newDeleteChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState:                    []*apiv1.ClusterRoleBinding{},
-			DesiredState:                    []*apiv1.ClusterRoleBinding{},
-			ExpectedClusterRoleBindingNames: []string{},
+			CurrentState:             []*apiv1.RoleBinding{},
+			DesiredState:             []*apiv1.RoleBinding{},
+			ExpectedRoleBindingNames: []string{},
 		},
 
 		// Test 2, in case current state has one item and equals desired state the
@@ -43,22 +43,22 @@ func Test_Resource_ClusterRoleBinding_newDeleteChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
+			CurrentState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 				},
 			},
-			DesiredState: []*apiv1.ClusterRoleBinding{
+			DesiredState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 				},
 			},
-			ExpectedClusterRoleBindingNames: []string{
-				"cluster-role-binding-1",
+			ExpectedRoleBindingNames: []string{
+				"role-binding-1",
 			},
 		},
 
@@ -72,15 +72,15 @@ func Test_Resource_ClusterRoleBinding_newDeleteChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState: []*apiv1.ClusterRoleBinding{},
-			DesiredState: []*apiv1.ClusterRoleBinding{
+			CurrentState: []*apiv1.RoleBinding{},
+			DesiredState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 				},
 			},
-			ExpectedClusterRoleBindingNames: []string{},
+			ExpectedRoleBindingNames: []string{},
 		},
 
 		// Test 4, in case current state misses items of desired state the delete
@@ -93,20 +93,20 @@ func Test_Resource_ClusterRoleBinding_newDeleteChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState: []*apiv1.ClusterRoleBinding{},
-			DesiredState: []*apiv1.ClusterRoleBinding{
+			CurrentState: []*apiv1.RoleBinding{},
+			DesiredState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
+						Name: "role-binding-2",
 					},
 				},
 			},
-			ExpectedClusterRoleBindingNames: []string{},
+			ExpectedRoleBindingNames: []string{},
 		},
 
 		// Test 5, in case current state contains one item and desired state is
@@ -119,15 +119,15 @@ func Test_Resource_ClusterRoleBinding_newDeleteChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
+			CurrentState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 				},
 			},
-			DesiredState:                    []*apiv1.ClusterRoleBinding{},
-			ExpectedClusterRoleBindingNames: []string{},
+			DesiredState:             []*apiv1.RoleBinding{},
+			ExpectedRoleBindingNames: []string{},
 		},
 
 		// Test 6, in case current state contains items and desired state is empty
@@ -140,20 +140,20 @@ func Test_Resource_ClusterRoleBinding_newDeleteChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
+			CurrentState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
+						Name: "role-binding-2",
 					},
 				},
 			},
-			DesiredState:                    []*apiv1.ClusterRoleBinding{},
-			ExpectedClusterRoleBindingNames: []string{},
+			DesiredState:             []*apiv1.RoleBinding{},
+			ExpectedRoleBindingNames: []string{},
 		},
 
 		// Test 7, in case all items of current state are in desired state and
@@ -167,43 +167,43 @@ func Test_Resource_ClusterRoleBinding_newDeleteChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
+			CurrentState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
+						Name: "role-binding-2",
 					},
 				},
 			},
-			DesiredState: []*apiv1.ClusterRoleBinding{
+			DesiredState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
+						Name: "role-binding-2",
 					},
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-3",
+						Name: "role-binding-3",
 					},
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-4",
+						Name: "role-binding-4",
 					},
 				},
 			},
-			ExpectedClusterRoleBindingNames: []string{
-				"cluster-role-binding-1",
-				"cluster-role-binding-2",
+			ExpectedRoleBindingNames: []string{
+				"role-binding-1",
+				"role-binding-2",
 			},
 		},
 
@@ -218,43 +218,43 @@ func Test_Resource_ClusterRoleBinding_newDeleteChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
+			CurrentState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
+						Name: "role-binding-2",
 					},
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-3",
+						Name: "role-binding-3",
 					},
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-4",
+						Name: "role-binding-4",
 					},
 				},
 			},
-			DesiredState: []*apiv1.ClusterRoleBinding{
+			DesiredState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
+						Name: "role-binding-2",
 					},
 				},
 			},
-			ExpectedClusterRoleBindingNames: []string{
-				"cluster-role-binding-1",
-				"cluster-role-binding-2",
+			ExpectedRoleBindingNames: []string{
+				"role-binding-1",
+				"role-binding-2",
 			},
 		},
 	}
@@ -279,13 +279,13 @@ func Test_Resource_ClusterRoleBinding_newDeleteChange(t *testing.T) {
 			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
 		}
 
-		clusterRoleBindings, ok := result.([]*apiv1.ClusterRoleBinding)
+		roleBindings, ok := result.([]*apiv1.RoleBinding)
 		if !ok {
-			t.Fatalf("case %d expected %T got %T", i+1, []*apiv1.ClusterRoleBinding{}, result)
+			t.Fatalf("case %d expected %T got %T", i+1, []*apiv1.RoleBinding{}, result)
 		}
 
-		if len(clusterRoleBindings) != len(tc.ExpectedClusterRoleBindingNames) {
-			t.Fatalf("case %d expected %d cluster role bindings got %d", i+1, len(tc.ExpectedClusterRoleBindingNames), len(clusterRoleBindings))
+		if len(roleBindings) != len(tc.ExpectedRoleBindingNames) {
+			t.Fatalf("case %d expected %d role bindings got %d", i+1, len(tc.ExpectedRoleBindingNames), len(roleBindings))
 		}
 	}
 }
@@ -1,4 +1,4 @@
-package clusterrolebinding
+package rolebinding
 
 import (
 	"context"
@@ -18,30 +18,31 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 		return nil, microerror.Mask(err)
 	}
 
-	r.logger.LogCtx(ctx, "level", "debug", "message", "computing the new cluster role bindings")
+	r.logger.LogCtx(ctx, "level", "debug", "message", "computing the new role bindings")
 
-	clusterRoleBindings, err := r.newClusterRoleBindings(customObject)
+	roleBindings, err := r.newRoleBindings(customObject)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
-	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("computed the %d new cluster role bindings", len(clusterRoleBindings)))
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("computed the %d new role bindings", len(roleBindings)))
 
-	return clusterRoleBindings, nil
+	return roleBindings, nil
 }
 
-func (r *Resource) newClusterRoleBindings(customObject v1alpha1.KVMConfig) ([]*apiv1.ClusterRoleBinding, error) {
-	var clusterRoleBindings []*apiv1.ClusterRoleBinding
+func (r *Resource) newRoleBindings(customObject v1alpha1.KVMConfig) ([]*apiv1.RoleBinding, error) {
+	var roleBindings []*apiv1.RoleBinding
 
-	generalClusterRoleBinding := &apiv1.ClusterRoleBinding{
+	generalRoleBinding := &apiv1.RoleBinding{
 		TypeMeta: apismetav1.TypeMeta{
-			Kind:       "ClusterRoleBinding",
+			Kind:       "RoleBinding",
 			APIVersion: apiv1.GroupName,
 		},
 		ObjectMeta: apismetav1.ObjectMeta{
-			Name: key.ClusterRoleBindingName(customObject),
+			Name:      key.RoleBindingName(customObject),
+			Namespace: key.ClusterNamespace(customObject),
 			Labels: map[string]string{
-				"app": "kvm-operator",
+				"app":                       "kvm-operator",
 				"giantswarm.io/cluster-id":  key.ClusterID(customObject),
 				"giantswarm.io/customer-id": key.ClusterCustomer(customObject),
 			},
@@ -55,22 +56,27 @@ func (r *Resource) newClusterRoleBindings(customObject v1alpha1.KVMConfig) ([]*a
 		},
 		RoleRef: apiv1.RoleRef{
 			APIGroup: apiv1.GroupName,
-			Kind:     "ClusterRole",
-			Name:     "kvm-operator",
+			Kind:     "Role",
+			Name:     key.RoleName(customObject),
 		},
 	}
 
-	clusterRoleBindings = append(clusterRoleBindings, generalClusterRoleBinding)
+	roleBindings = append(roleBindings, generalRoleBinding)
 
-	pspClusterRoleBinding := &apiv1.ClusterRoleBinding{
+	// Pod security policies are cluster scoped, so the role allowing to use
+	// the pod security policy of VM pods is a cluster role. Binding it using a
+	// role binding only allows to use the policy for pods in the namespace of
+	// the guest cluster.
+	pspRoleBinding := &apiv1.RoleBinding{
 		TypeMeta: apismetav1.TypeMeta{
-			Kind:       "ClusterRoleBinding",
+			Kind:       "RoleBinding",
 			APIVersion: apiv1.GroupName,
 		},
 		ObjectMeta: apismetav1.ObjectMeta{
-			Name: key.ClusterRoleBindingPSPName(customObject),
+			Name:      key.RoleBindingPSPName(customObject),
+			Namespace: key.ClusterNamespace(customObject),
 			Labels: map[string]string{
-				"app": "kvm-operator",
+				"app":                       "kvm-operator",
 				"giantswarm.io/cluster-id":  key.ClusterID(customObject),
 				"giantswarm.io/customer-id": key.ClusterCustomer(customObject),
 			},
@@ -85,11 +91,11 @@ func (r *Resource) newClusterRoleBindings(customObject v1alpha1.KVMConfig) ([]*a
 		RoleRef: apiv1.RoleRef{
 			APIGroup: apiv1.GroupName,
 			Kind:     "ClusterRole",
-			Name:     "kvm-operator-psp",
+			Name:     "kvm-operator-vm-psp",
 		},
 	}
 
-	clusterRoleBindings = append(clusterRoleBindings, pspClusterRoleBinding)
+	roleBindings = append(roleBindings, pspRoleBinding)
 
-	return clusterRoleBindings, nil
+	return roleBindings, nil
 }
@@ -0,0 +1,99 @@
+package rolebinding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Resource_RoleBinding_GetDesiredState(t *testing.T) {
+	testCases := []struct {
+		Obj                  interface{}
+		ExpectedRoleBindings []*apiv1.RoleBinding
+	}{
+		// Test 1, check it returns a couple of role bindings in the namespace of
+		// the guest cluster
+		{
+			Obj: &v1alpha1.KVMConfig{
+				Spec: v1alpha1.KVMConfigSpec{
+					Cluster: v1alpha1.Cluster{
+						ID: "al9qy",
+					},
+				},
+			},
+			ExpectedRoleBindings: []*apiv1.RoleBinding{
+				{
+					TypeMeta: apismetav1.TypeMeta{
+						Kind:       "RoleBinding",
+						APIVersion: apiv1.GroupName,
+					},
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name:      "al9qy",
+						Namespace: "al9qy",
+					},
+					RoleRef: apiv1.RoleRef{
+						APIGroup: apiv1.GroupName,
+						Kind:     "Role",
+						Name:     "al9qy",
+					},
+				},
+				{
+					TypeMeta: apismetav1.TypeMeta{
+						Kind:       "RoleBinding",
+						APIVersion: apiv1.GroupName,
+					},
+					ObjectMeta: apismetav1.ObjectMeta{
+						Name:      "al9qy-psp",
+						Namespace: "al9qy",
+					},
+					RoleRef: apiv1.RoleRef{
+						APIGroup: apiv1.GroupName,
+						Kind:     "ClusterRole",
+						Name:     "kvm-operator-vm-psp",
+					},
+				},
+			},
+		},
+	}
+
+	var err error
+	var newResource *Resource
+	{
+		resourceConfig := Config{
+			K8sClient: fake.NewSimpleClientset(),
+			Logger:    microloggertest.New(),
+		}
+		newResource, err = New(resourceConfig)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	for i, tc := range testCases {
+		result, err := newResource.GetDesiredState(context.TODO(), tc.Obj)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		roleBindings, ok := result.([]*apiv1.RoleBinding)
+		if !ok {
+			t.Fatalf("case %d expected %T got %T", i+1, []*apiv1.RoleBinding{}, result)
+		}
+
+		if len(roleBindings) != len(tc.ExpectedRoleBindings) {
+			t.Fatalf("case %d expected %d role bindings got %d", i+1, len(tc.ExpectedRoleBindings), len(roleBindings))
+		}
+
+		for j, b := range roleBindings {
+			e := tc.ExpectedRoleBindings[j]
+			if b.Name != e.Name || b.Namespace != e.Namespace || b.RoleRef != e.RoleRef {
+				t.Fatalf("case %d expected %#v got %#v", i+1, e, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package rolebinding
+
+import (
+	"reflect"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Name is the identifier of the resource.
+	Name = "clusterrolebindingv13"
+)
+
+// Config represents the configuration used to create a new config map resource.
+type Config struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+}
+
+// Resource implements the config map resource.
+type Resource struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+}
+
+// New creates a new configured config map resource.
+func New(config Config) (*Resource, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "config.Logger must not be empty")
+	}
+
+	newService := &Resource{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+	}
+
+	return newService, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
+
+func containsRoleBinding(list []*apiv1.RoleBinding, item *apiv1.RoleBinding) bool {
+	_, err := getRoleBindingByName(list, item.Name)
+	if IsNotFound(err) {
+		return false
+	} else if err != nil {
+		return false
+	}
+
+	return true
+}
+
+func getRoleBindingByName(list []*apiv1.RoleBinding, name string) (*apiv1.RoleBinding, error) {
+	for _, l := range list {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+
+	return nil, microerror.Maskf(notFoundError, "role binding '%s' not found", name)
+}
+
+func isRoleBindingModified(a, b *apiv1.RoleBinding) bool {
+	return !reflect.DeepEqual(a.Subjects, b.Subjects) || !reflect.DeepEqual(a.RoleRef, b.RoleRef)
+}
+
+func toRoleBindings(v interface{}) ([]*apiv1.RoleBinding, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	roleBindings, ok := v.([]*apiv1.RoleBinding)
+	if !ok {
+		return nil, microerror.Maskf(wrongTypeError, "expected '%T', got '%T'", []*apiv1.RoleBinding{}, v)
+	}
+
+	return roleBindings, nil
+}
@@ -0,0 +1,117 @@
+package rolebinding
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/controller"
+	apiv1 "k8s.io/api/rbac/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
+	roleBindingsToUpdate, err := toRoleBindings(updateChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(roleBindingsToUpdate) != 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the role bindings in the Kubernetes API")
+
+		// Update the role bindings in the Kubernetes API.
+		for _, roleBinding := range roleBindingsToUpdate {
+			err := r.updateRoleBinding(roleBinding)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the role bindings in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the role bindings do not need to be updated in the Kubernetes API")
+	}
+
+	return nil
+}
+
+func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*controller.Patch, error) {
+	create, err := r.newCreateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	update, err := r.newUpdateChange(ctx, obj, currentState, desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	patch := controller.NewPatch()
+	patch.SetCreateChange(create)
+	patch.SetUpdateChange(update)
+
+	return patch, nil
+}
+
+func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
+	currentRoleBindings, err := toRoleBindings(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredRoleBindings, err := toRoleBindings(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var roleBindingsToUpdate []*apiv1.RoleBinding
+	{
+		r.logger.LogCtx(ctx, "level", "debug", "message", "finding out which role bindings have to be updated")
+
+		for _, roleBinding := range currentRoleBindings {
+			desiredRoleBinding, err := getRoleBindingByName(desiredRoleBindings, roleBinding.Name)
+			if IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			if isRoleBindingModified(desiredRoleBinding, roleBinding) {
+				roleBindingsToUpdate = append(roleBindingsToUpdate, desiredRoleBinding)
+			}
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d role bindings that have to be updated", len(roleBindingsToUpdate)))
+	}
+
+	return roleBindingsToUpdate, nil
+}
+
+// updateRoleBinding updates the given role binding. The role
+// reference of role bindings cannot be changed, so cluster role
+// bindings referencing another role are deleted and created again.
+func (r *Resource) updateRoleBinding(roleBinding *apiv1.RoleBinding) error {
+	current, err := r.k8sClient.RbacV1beta1().RoleBindings(roleBinding.Namespace).Get(roleBinding.Name, apismetav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if reflect.DeepEqual(current.RoleRef, roleBinding.RoleRef) {
+		_, err := r.k8sClient.RbacV1beta1().RoleBindings(roleBinding.Namespace).Update(roleBinding)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	err = r.k8sClient.RbacV1beta1().RoleBindings(roleBinding.Namespace).Delete(roleBinding.Name, &apismetav1.DeleteOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	_, err = r.k8sClient.RbacV1beta1().RoleBindings(roleBinding.Namespace).Create(roleBinding)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
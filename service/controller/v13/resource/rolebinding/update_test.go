@@ -1,4 +1,4 @@
-package clusterrolebinding
+package rolebinding
 
 import (
 	"context"
@@ -12,13 +12,13 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 )
 
-func Test_Resource_ClusterRoleBinding_newUpdateChange(t *testing.T) {
+func Test_Resource_RoleBinding_newUpdateChange(t *testing.T) {
 	testCases := []struct {
-		Ctx                                context.Context
-		Obj                                interface{}
-		CurrentState                       interface{}
-		DesiredState                       interface{}
-		ExpectedClusterRoleBindinsToUpdate []*apiv1.ClusterRoleBinding
+		Ctx                          context.Context
+		Obj                          interface{}
+		CurrentState                 interface{}
+		DesiredState                 interface{}
+		ExpectedRoleBindingsToUpdate []*apiv1.RoleBinding
 	}{
 		// Test 1, in case current state and desired state are empty the update
 		// state should be empty.
@@ -31,9 +31,9 @@ func Test_Resource_ClusterRoleBinding_newUpdateChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState:                       []*apiv1.ClusterRoleBinding{},
-			DesiredState:                       []*apiv1.ClusterRoleBinding{},
-			ExpectedClusterRoleBindinsToUpdate: nil,
+			CurrentState:                 []*apiv1.RoleBinding{},
+			DesiredState:                 []*apiv1.RoleBinding{},
+			ExpectedRoleBindingsToUpdate: nil,
 		},
 
 		// Test 2, in case current state and desired state are equal the update
@@ -47,10 +47,10 @@ func Test_Resource_ClusterRoleBinding_newUpdateChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
+			CurrentState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 					Subjects: []apiv1.Subject{
 						{
@@ -66,10 +66,10 @@ func Test_Resource_ClusterRoleBinding_newUpdateChange(t *testing.T) {
 					},
 				},
 			},
-			DesiredState: []*apiv1.ClusterRoleBinding{
+			DesiredState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 					Subjects: []apiv1.Subject{
 						{
@@ -85,7 +85,7 @@ func Test_Resource_ClusterRoleBinding_newUpdateChange(t *testing.T) {
 					},
 				},
 			},
-			ExpectedClusterRoleBindinsToUpdate: nil,
+			ExpectedRoleBindingsToUpdate: nil,
 		},
 
 		// Test 3, in case current state contains two items and desired state is
@@ -100,10 +100,10 @@ func Test_Resource_ClusterRoleBinding_newUpdateChange(t *testing.T) {
 					},
 				},
 			},
-			CurrentState: []*apiv1.ClusterRoleBinding{
+			CurrentState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 					Subjects: []apiv1.Subject{
 						{
@@ -120,7 +120,7 @@ func Test_Resource_ClusterRoleBinding_newUpdateChange(t *testing.T) {
 				},
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-2",
+						Name: "role-binding-2",
 					},
 					Subjects: []apiv1.Subject{
 						{
@@ -136,10 +136,10 @@ func Test_Resource_ClusterRoleBinding_newUpdateChange(t *testing.T) {
 					},
 				},
 			},
-			DesiredState: []*apiv1.ClusterRoleBinding{
+			DesiredState: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 					Subjects: []apiv1.Subject{
 						{
@@ -155,10 +155,10 @@ func Test_Resource_ClusterRoleBinding_newUpdateChange(t *testing.T) {
 					},
 				},
 			},
-			ExpectedClusterRoleBindinsToUpdate: []*apiv1.ClusterRoleBinding{
+			ExpectedRoleBindingsToUpdate: []*apiv1.RoleBinding{
 				{
 					ObjectMeta: apismetav1.ObjectMeta{
-						Name: "cluster-role-binding-1",
+						Name: "role-binding-1",
 					},
 					Subjects: []apiv1.Subject{
 						{
@@ -196,12 +196,12 @@ func Test_Resource_ClusterRoleBinding_newUpdateChange(t *testing.T) {
 			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
 		}
 
-		clusterRoleBindingsToUpdate, ok := updateState.([]*apiv1.ClusterRoleBinding)
+		roleBindingsToUpdate, ok := updateState.([]*apiv1.RoleBinding)
 		if !ok {
-			t.Fatalf("case %d expected %T got %T", i+1, []*apiv1.ClusterRoleBinding{}, updateState)
+			t.Fatalf("case %d expected %T got %T", i+1, []*apiv1.RoleBinding{}, updateState)
 		}
-		if !reflect.DeepEqual(clusterRoleBindingsToUpdate, tc.ExpectedClusterRoleBindinsToUpdate) {
-			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedClusterRoleBindinsToUpdate, clusterRoleBindingsToUpdate)
+		if !reflect.DeepEqual(roleBindingsToUpdate, tc.ExpectedRoleBindingsToUpdate) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedRoleBindingsToUpdate, roleBindingsToUpdate)
 		}
 	}
 }
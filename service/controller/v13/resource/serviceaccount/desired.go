@@ -33,6 +33,14 @@ func (r *Resource) GetDesiredState(ctx context.Context, obj interface{}) (interf
 		},
 	}
 
+	if r.pullSecretName != "" {
+		serviceAccount.ImagePullSecrets = []apiv1.LocalObjectReference{
+			{
+				Name: r.pullSecretName,
+			},
+		}
+	}
+
 	r.logger.LogCtx(ctx, "level", "debug", "message", "computed the new service account")
 
 	return serviceAccount, nil
@@ -17,6 +17,9 @@ type Config struct {
 	// Dependencies.
 	K8sClient kubernetes.Interface
 	Logger    micrologger.Logger
+
+	// Settings.
+	PullSecretName string
 }
 
 // DefaultConfig provides a default configuration to create a new config map
@@ -25,6 +28,9 @@ func DefaultConfig() Config {
 	return Config{
 		K8sClient: nil,
 		Logger:    nil,
+
+		// Settings.
+		PullSecretName: "",
 	}
 }
 
@@ -33,6 +39,9 @@ type Resource struct {
 	// Dependencies.
 	k8sClient kubernetes.Interface
 	logger    micrologger.Logger
+
+	// Settings.
+	pullSecretName string
 }
 
 // New creates a new configured config map resource.
@@ -47,6 +56,9 @@ func New(config Config) (*Resource, error) {
 	newService := &Resource{
 		k8sClient: config.K8sClient,
 		logger:    config.Logger,
+
+		// Settings.
+		pullSecretName: config.PullSecretName,
 	}
 
 	return newService, nil
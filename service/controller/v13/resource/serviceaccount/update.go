@@ -2,12 +2,38 @@ package serviceaccount
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/operatorkit/controller"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
 )
 
 func (r *Resource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
+	customObject, err := key.ToCustomObject(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	serviceAccountToUpdate, err := toServiceAccount(updateChange)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if serviceAccountToUpdate != nil {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updating the service account in the Kubernetes API")
+
+		namespace := key.ClusterNamespace(customObject)
+		_, err := r.k8sClient.CoreV1().ServiceAccounts(namespace).Update(serviceAccountToUpdate)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", "updated the service account in the Kubernetes API")
+	} else {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "the service account does not need to be updated in the Kubernetes API")
+	}
+
 	return nil
 }
 
@@ -29,6 +55,33 @@ func (r *Resource) NewUpdatePatch(ctx context.Context, obj, currentState, desire
 	return patch, nil
 }
 
+// newUpdateChange only reconciles the image pull secrets of the service
+// account. Other fields like the token secrets are managed by Kubernetes and
+// must be preserved.
 func (r *Resource) newUpdateChange(ctx context.Context, obj, currentState, desiredState interface{}) (interface{}, error) {
-	return nil, nil
+	currentServiceAccount, err := toServiceAccount(currentState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	desiredServiceAccount, err := toServiceAccount(desiredState)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "finding out if the service account has to be updated")
+
+	var serviceAccountToUpdate interface{}
+	if currentServiceAccount != nil && desiredServiceAccount != nil {
+		if len(currentServiceAccount.ImagePullSecrets) != 0 || len(desiredServiceAccount.ImagePullSecrets) != 0 {
+			if !reflect.DeepEqual(currentServiceAccount.ImagePullSecrets, desiredServiceAccount.ImagePullSecrets) {
+				s := currentServiceAccount.DeepCopy()
+				s.ImagePullSecrets = desiredServiceAccount.ImagePullSecrets
+				serviceAccountToUpdate = s
+			}
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "found out if the service account has to be updated")
+
+	return serviceAccountToUpdate, nil
 }
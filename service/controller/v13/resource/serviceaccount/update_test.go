@@ -0,0 +1,122 @@
+package serviceaccount
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	apiv1 "k8s.io/api/core/v1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Resource_ServiceAccount_newUpdateChange(t *testing.T) {
+	testCases := []struct {
+		PullSecretName           string
+		Cur                      *apiv1.ServiceAccount
+		ExpectedImagePullSecrets []apiv1.LocalObjectReference
+		ExpectedUpdate           bool
+	}{
+		// Test 0 ensures the service account is not updated in case no pull
+		// secret is configured.
+		{
+			PullSecretName: "",
+			Cur: &apiv1.ServiceAccount{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Name: "al9qy",
+				},
+			},
+			ExpectedUpdate: false,
+		},
+
+		// Test 1 ensures the configured pull secret is added to an existing
+		// service account while preserving its token secrets.
+		{
+			PullSecretName: "kvm-operator-pull-secret",
+			Cur: &apiv1.ServiceAccount{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Name: "al9qy",
+				},
+				Secrets: []apiv1.ObjectReference{
+					{
+						Name: "al9qy-token-x2b4m",
+					},
+				},
+			},
+			ExpectedImagePullSecrets: []apiv1.LocalObjectReference{
+				{
+					Name: "kvm-operator-pull-secret",
+				},
+			},
+			ExpectedUpdate: true,
+		},
+
+		// Test 2 ensures the service account is not updated in case it already
+		// references the configured pull secret.
+		{
+			PullSecretName: "kvm-operator-pull-secret",
+			Cur: &apiv1.ServiceAccount{
+				ObjectMeta: apismetav1.ObjectMeta{
+					Name: "al9qy",
+				},
+				ImagePullSecrets: []apiv1.LocalObjectReference{
+					{
+						Name: "kvm-operator-pull-secret",
+					},
+				},
+			},
+			ExpectedUpdate: false,
+		},
+	}
+
+	customObject := &v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		resourceConfig := DefaultConfig()
+		resourceConfig.K8sClient = fake.NewSimpleClientset()
+		resourceConfig.Logger = microloggertest.New()
+		resourceConfig.PullSecretName = tc.PullSecretName
+		newResource, err := New(resourceConfig)
+		if err != nil {
+			t.Fatal("case", i, "expected", nil, "got", err)
+		}
+
+		desired, err := newResource.GetDesiredState(context.TODO(), customObject)
+		if err != nil {
+			t.Fatal("case", i, "expected", nil, "got", err)
+		}
+
+		result, err := newResource.newUpdateChange(context.TODO(), customObject, tc.Cur, desired)
+		if err != nil {
+			t.Fatal("case", i, "expected", nil, "got", err)
+		}
+		serviceAccount, err := toServiceAccount(result)
+		if err != nil {
+			t.Fatal("case", i, "expected", nil, "got", err)
+		}
+
+		if !tc.ExpectedUpdate {
+			if serviceAccount != nil {
+				t.Fatal("case", i, "expected", nil, "got", serviceAccount)
+			}
+			continue
+		}
+
+		if serviceAccount == nil {
+			t.Fatal("case", i, "expected service account update got", nil)
+		}
+		if len(serviceAccount.ImagePullSecrets) != 1 || serviceAccount.ImagePullSecrets[0].Name != tc.ExpectedImagePullSecrets[0].Name {
+			t.Fatal("case", i, "expected", tc.ExpectedImagePullSecrets, "got", serviceAccount.ImagePullSecrets)
+		}
+		if len(serviceAccount.Secrets) != len(tc.Cur.Secrets) {
+			t.Fatal("case", i, "expected", tc.Cur.Secrets, "got", serviceAccount.Secrets)
+		}
+	}
+}
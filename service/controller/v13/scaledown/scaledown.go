@@ -0,0 +1,333 @@
+package scaledown
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+type Config struct {
+	GuestClient guestclient.Interface
+	K8sClient   kubernetes.Interface
+	Logger      micrologger.Logger
+
+	Policy string
+}
+
+// ScaleDown implements Interface. Existing workers are looked up using the
+// worker deployments of the guest cluster namespace. Their load is looked up
+// using the guest cluster API, where the node name of a worker is the name of
+// its pod.
+type ScaleDown struct {
+	guestClient guestclient.Interface
+	k8sClient   kubernetes.Interface
+	logger      micrologger.Logger
+
+	policy string
+}
+
+func New(config Config) (*ScaleDown, error) {
+	if config.GuestClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GuestClient must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	switch config.Policy {
+	case PolicyNewest, PolicyOldest, PolicyLeastLoaded:
+	default:
+		return nil, microerror.Maskf(invalidConfigError, "%T.Policy must be one of '%s', '%s' or '%s'", config, PolicyNewest, PolicyOldest, PolicyLeastLoaded)
+	}
+
+	s := &ScaleDown{
+		guestClient: config.GuestClient,
+		k8sClient:   config.K8sClient,
+		logger:      config.Logger,
+
+		policy: config.Policy,
+	}
+
+	return s, nil
+}
+
+func (s *ScaleDown) WorkerNodes(ctx context.Context, customObject v1alpha1.KVMConfig, workers []key.WorkerNode) ([]key.WorkerNode, error) {
+	existing, err := s.existingWorkers(customObject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	policy := s.policy
+	if policy == PolicyLeastLoaded && isScaledDown(workers, existing) {
+		err := s.setLoad(ctx, customObject, existing)
+		if err != nil {
+			s.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cannot look up the load of workers, falling back to scale down policy '%s'", PolicyNewest), "stack", fmt.Sprintf("%#v", err))
+			policy = PolicyNewest
+		}
+	}
+
+	sortVictims(policy, existing)
+
+	return selectWorkerNodes(workers, existing), nil
+}
+
+// existingWorkers returns the node pool workers for which worker deployments
+// exist. Deployments already being deleted are ignored. A worker being
+// replaced has two deployments, which is why workers are deduplicated.
+func (s *ScaleDown) existingWorkers(customObject v1alpha1.KVMConfig) ([]worker, error) {
+	n := key.ClusterNamespace(customObject)
+	o := apismetav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", key.WorkerID),
+	}
+
+	list, err := s.k8sClient.Extensions().Deployments(n).List(o)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var workers []worker
+	seen := map[string]bool{}
+	for _, d := range list.Items {
+		if d.GetDeletionTimestamp() != nil {
+			continue
+		}
+		pool := d.GetLabels()[key.NodePoolLabel]
+		if pool == "" {
+			continue
+		}
+		if seen[d.GetLabels()["node"]] {
+			continue
+		}
+		seen[d.GetLabels()["node"]] = true
+
+		w := worker{
+			ID:      d.GetLabels()["node"],
+			Pool:    pool,
+			Created: d.GetCreationTimestamp().Time,
+		}
+
+		workers = append(workers, w)
+	}
+
+	return workers, nil
+}
+
+// setLoad sets the number of pods scheduled on the guest cluster node of every
+// given worker, ignoring pods managed by daemon sets, since these run on every
+// node anyway. Workers of cordoned nodes are marked unschedulable.
+func (s *ScaleDown) setLoad(ctx context.Context, customObject v1alpha1.KVMConfig, workers []worker) error {
+	nodeNames := map[string]string{}
+	{
+		n := key.ClusterNamespace(customObject)
+		o := apismetav1.ListOptions{
+			LabelSelector: fmt.Sprintf("app=%s", key.WorkerID),
+		}
+
+		list, err := s.k8sClient.CoreV1().Pods(n).List(o)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, p := range list.Items {
+			nodeNames[p.GetLabels()["node"]] = p.GetName()
+		}
+	}
+
+	guestK8sClient, err := s.guestClient.NewK8sClient(customObject)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	unschedulable := map[string]bool{}
+	{
+		list, err := guestK8sClient.CoreV1().Nodes().List(apismetav1.ListOptions{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, n := range list.Items {
+			unschedulable[n.GetName()] = n.Spec.Unschedulable
+		}
+	}
+
+	load := map[string]int{}
+	{
+		list, err := guestK8sClient.CoreV1().Pods("").List(apismetav1.ListOptions{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, p := range list.Items {
+			if isDaemonSetPod(p.GetOwnerReferences()) {
+				continue
+			}
+
+			load[p.Spec.NodeName]++
+		}
+	}
+
+	for i, w := range workers {
+		workers[i].Load = load[nodeNames[w.ID]]
+		workers[i].Unschedulable = unschedulable[nodeNames[w.ID]]
+	}
+
+	return nil
+}
+
+// worker is an existing node pool worker considered for removal.
+type worker struct {
+	ID            string
+	Pool          string
+	Created       time.Time
+	Load          int
+	Unschedulable bool
+}
+
+func isDaemonSetPod(references []apismetav1.OwnerReference) bool {
+	for _, r := range references {
+		if r.Kind == "DaemonSet" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isScaledDown returns true in case any node pool has more existing workers
+// than desired.
+func isScaledDown(workers []key.WorkerNode, existing []worker) bool {
+	desired := map[string]int{}
+	for _, w := range workers {
+		desired[w.Pool]++
+	}
+
+	current := map[string]int{}
+	for _, w := range existing {
+		current[w.Pool]++
+	}
+
+	for p, c := range current {
+		if c > desired[p] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectWorkerNodes implements WorkerNodes. The existing workers must be
+// sorted using sortVictims, so the workers to be removed come first.
+func selectWorkerNodes(workers []key.WorkerNode, existing []worker) []key.WorkerNode {
+	var selected []key.WorkerNode
+
+	var pools []string
+	desired := map[string][]key.WorkerNode{}
+	ids := map[string]bool{}
+	for _, w := range workers {
+		if w.Pool == "" {
+			selected = append(selected, w)
+			ids[w.Node.ID] = true
+			continue
+		}
+
+		if _, ok := desired[w.Pool]; !ok {
+			pools = append(pools, w.Pool)
+		}
+		desired[w.Pool] = append(desired[w.Pool], w)
+	}
+
+	for _, p := range pools {
+		var retained []worker
+		for _, w := range existing {
+			if w.Pool == p && !ids[w.ID] {
+				retained = append(retained, w)
+			}
+		}
+
+		replicas := len(desired[p])
+		if len(retained) > replicas {
+			retained = retained[len(retained)-replicas:]
+		}
+
+		used := map[string]bool{}
+		var nodeIDs []string
+		for _, w := range retained {
+			used[w.ID] = true
+			nodeIDs = append(nodeIDs, w.ID)
+		}
+		for i := 0; len(nodeIDs) < replicas; i++ {
+			id := key.NodePoolNodeID(p, i)
+			if used[id] || ids[id] {
+				continue
+			}
+
+			nodeIDs = append(nodeIDs, id)
+		}
+		sortNodeIDs(nodeIDs)
+
+		for _, id := range nodeIDs {
+			w := desired[p][0]
+			w.Node.ID = id
+
+			selected = append(selected, w)
+		}
+	}
+
+	return selected
+}
+
+// sortNodeIDs sorts the given node pool worker IDs by their index.
+func sortNodeIDs(nodeIDs []string) {
+	sort.SliceStable(nodeIDs, func(i, j int) bool {
+		if len(nodeIDs[i]) != len(nodeIDs[j]) {
+			return len(nodeIDs[i]) < len(nodeIDs[j])
+		}
+		return nodeIDs[i] < nodeIDs[j]
+	})
+}
+
+// sortVictims sorts the given workers in the order they should be removed
+// according to the given policy.
+func sortVictims(policy string, workers []worker) {
+	newest := func(i, j int) bool {
+		if workers[i].Created.Equal(workers[j].Created) {
+			return workers[i].ID > workers[j].ID
+		}
+		return workers[i].Created.After(workers[j].Created)
+	}
+
+	switch policy {
+	case PolicyOldest:
+		sort.SliceStable(workers, func(i, j int) bool {
+			if workers[i].Created.Equal(workers[j].Created) {
+				return workers[i].ID < workers[j].ID
+			}
+			return workers[i].Created.Before(workers[j].Created)
+		})
+	case PolicyLeastLoaded:
+		sort.SliceStable(workers, func(i, j int) bool {
+			if workers[i].Unschedulable != workers[j].Unschedulable {
+				return workers[i].Unschedulable
+			}
+			if workers[i].Load != workers[j].Load {
+				return workers[i].Load < workers[j].Load
+			}
+			return newest(i, j)
+		})
+	default:
+		sort.SliceStable(workers, newest)
+	}
+}
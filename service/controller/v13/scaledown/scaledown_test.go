@@ -0,0 +1,189 @@
+package scaledown
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"k8s.io/api/extensions/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/guestclient/guestclienttest"
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_ScaleDown_WorkerNodes(t *testing.T) {
+	customObject := v1alpha1.KVMConfig{
+		Spec: v1alpha1.KVMConfigSpec{
+			Cluster: v1alpha1.Cluster{
+				ID: "al9qy",
+			},
+		},
+	}
+
+	created := time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	newDeployment := func(id, pool string, age int) runtime.Object {
+		labels := map[string]string{
+			"app":  key.WorkerID,
+			"node": id,
+		}
+		if pool != "" {
+			labels[key.NodePoolLabel] = pool
+		}
+
+		return &v1beta1.Deployment{
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name:              key.DeploymentName(key.WorkerID, id),
+				Namespace:         "al9qy",
+				Labels:            labels,
+				CreationTimestamp: apismetav1.NewTime(created.Add(time.Duration(age) * time.Hour)),
+			},
+		}
+	}
+
+	newWorkers := func(pool string, replicas int) []key.WorkerNode {
+		var workers []key.WorkerNode
+		for i := 0; i < replicas; i++ {
+			w := key.WorkerNode{
+				Node: v1alpha1.ClusterNode{
+					ID: key.NodePoolNodeID(pool, i),
+				},
+				Pool: pool,
+			}
+			workers = append(workers, w)
+		}
+		return workers
+	}
+
+	testCases := []struct {
+		Policy      string
+		Deployments []runtime.Object
+		Workers     []key.WorkerNode
+		ExpectedIDs []string
+	}{
+		// Test 1 ensures the workers are returned as they are when there are no
+		// existing workers.
+		{
+			Policy:      PolicyNewest,
+			Deployments: nil,
+			Workers:     newWorkers("gpu", 2),
+			ExpectedIDs: []string{"gpu-0", "gpu-1"},
+		},
+
+		// Test 2 ensures the most recently created worker is removed using the
+		// newest policy.
+		{
+			Policy: PolicyNewest,
+			Deployments: []runtime.Object{
+				newDeployment("gpu-0", "gpu", 3),
+				newDeployment("gpu-1", "gpu", 1),
+				newDeployment("gpu-2", "gpu", 2),
+			},
+			Workers:     newWorkers("gpu", 2),
+			ExpectedIDs: []string{"gpu-1", "gpu-2"},
+		},
+
+		// Test 3 ensures the least recently created worker is removed using the
+		// oldest policy.
+		{
+			Policy: PolicyOldest,
+			Deployments: []runtime.Object{
+				newDeployment("gpu-0", "gpu", 3),
+				newDeployment("gpu-1", "gpu", 1),
+				newDeployment("gpu-2", "gpu", 2),
+			},
+			Workers:     newWorkers("gpu", 2),
+			ExpectedIDs: []string{"gpu-0", "gpu-2"},
+		},
+
+		// Test 4 ensures workers added to a node pool fill the lowest free
+		// indexes and existing workers are kept.
+		{
+			Policy: PolicyNewest,
+			Deployments: []runtime.Object{
+				newDeployment("gpu-1", "gpu", 1),
+				newDeployment("gpu-3", "gpu", 2),
+			},
+			Workers:     newWorkers("gpu", 3),
+			ExpectedIDs: []string{"gpu-0", "gpu-1", "gpu-3"},
+		},
+
+		// Test 5 ensures workers defined in the custom object spec are never
+		// chosen and node pools are handled independently.
+		{
+			Policy: PolicyNewest,
+			Deployments: []runtime.Object{
+				newDeployment("1", "", 5),
+				newDeployment("gpu-0", "gpu", 1),
+				newDeployment("scaled-0", key.ScaledNodePoolName, 2),
+				newDeployment("scaled-1", key.ScaledNodePoolName, 3),
+			},
+			Workers: append(
+				append([]key.WorkerNode{{Node: v1alpha1.ClusterNode{ID: "1"}}}, newWorkers("gpu", 1)...),
+				newWorkers(key.ScaledNodePoolName, 1)...,
+			),
+			ExpectedIDs: []string{"1", "gpu-0", "scaled-0"},
+		},
+	}
+
+	for i, tc := range testCases {
+		var scaleDown *ScaleDown
+		{
+			c := Config{
+				GuestClient: guestclienttest.New(fake.NewSimpleClientset()),
+				K8sClient:   fake.NewSimpleClientset(tc.Deployments...),
+				Logger:      microloggertest.New(),
+
+				Policy: tc.Policy,
+			}
+
+			var err error
+			scaleDown, err = New(c)
+			if err != nil {
+				t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+			}
+		}
+
+		workers, err := scaleDown.WorkerNodes(context.TODO(), customObject, tc.Workers)
+		if err != nil {
+			t.Fatalf("case %d expected %#v got %#v", i+1, nil, err)
+		}
+
+		var ids []string
+		for _, w := range workers {
+			ids = append(ids, w.Node.ID)
+		}
+		if !reflect.DeepEqual(ids, tc.ExpectedIDs) {
+			t.Fatalf("case %d expected %#v got %#v", i+1, tc.ExpectedIDs, ids)
+		}
+	}
+}
+
+func Test_ScaleDown_sortVictims_LeastLoaded(t *testing.T) {
+	created := time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	workers := []worker{
+		{ID: "gpu-0", Created: created, Load: 7},
+		{ID: "gpu-1", Created: created, Load: 2},
+		{ID: "gpu-2", Created: created.Add(time.Hour), Load: 2},
+		{ID: "gpu-3", Created: created, Load: 9, Unschedulable: true},
+	}
+
+	sortVictims(PolicyLeastLoaded, workers)
+
+	var ids []string
+	for _, w := range workers {
+		ids = append(ids, w.ID)
+	}
+
+	expected := []string{"gpu-3", "gpu-2", "gpu-1", "gpu-0"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected %#v got %#v", expected, ids)
+	}
+}
@@ -0,0 +1,32 @@
+package scaledown
+
+import (
+	"context"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+const (
+	// PolicyNewest removes the most recently created workers first.
+	PolicyNewest = "newest"
+	// PolicyOldest removes the least recently created workers first.
+	PolicyOldest = "oldest"
+	// PolicyLeastLoaded removes the workers running the fewest pods according
+	// to the guest cluster API first.
+	PolicyLeastLoaded = "least-loaded"
+)
+
+// Interface describes how workers are chosen when the number of workers of a
+// node pool is reduced.
+type Interface interface {
+	// WorkerNodes returns the given desired workers with the workers of every
+	// node pool replaced by the workers currently existing for it. When a node
+	// pool has more existing workers than desired, the workers to be removed
+	// are chosen according to the configured policy. When a node pool has fewer
+	// existing workers than desired, new workers are added using the lowest
+	// free indexes. Workers defined in the custom object spec are returned as
+	// they are.
+	WorkerNodes(ctx context.Context, customObject v1alpha1.KVMConfig, workers []key.WorkerNode) ([]key.WorkerNode, error)
+}
@@ -8,10 +8,130 @@ func VersionBundle() versionbundle.Bundle {
 	return versionbundle.Bundle{
 		Changelogs: []versionbundle.Changelog{
 			{
-				Component:   "TODO",
-				Description: "TODO.",
+				Component:   "containerlinux",
+				Description: "Added per cluster Container Linux version selection and image pre-pulling on all hosts once per version for the whole installation. The location Container Linux images are downloaded from is configurable.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added registry mirror support, per cluster image overrides and pull secret propagation.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added worker node pools with replica counts, labels and taints.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added optional scale subresource to the KVMConfig CRD to scale guest cluster workers not belonging to node pools. Replicas below the workers defined in the custom object spec are rejected.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Changed worker scale down to choose workers by policy and drain them before removal.",
+				Kind:        versionbundle.KindChanged,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added optional surge mode replacing workers on updates, so capacity does not drop while rolling workers.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added etcd quorum guard checking etcd member health before updating masters or draining their nodes.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added multi master scale up and down managing etcd membership one master at a time, including guest clusters created with a single master, and routing traffic only to ready masters.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Changed deployment updates to roll guest cluster nodes one at a time when their CPUs, memory or disk change.",
+				Kind:        versionbundle.KindChanged,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Changed deployment updates to roll guest cluster nodes one at a time when their rendered cloud-config changes.",
+				Kind:        versionbundle.KindChanged,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added optional storage of guest cluster node cloud-configs in secrets instead of config maps. Existing nodes are rolled to mount secrets when the option is enabled.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added optional gzip compression of cloud-configs, which is passed to k8s-kvm in the cloud-config encoding annotation and environment variable, and a clear error when the user data of a guest cluster node exceeds the size limit of config maps and secrets.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "cloudconfig",
+				Description: "Added extra files and systemd units for masters, workers or both from config maps referenced by the KVMConfig.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "cloudconfig",
+				Description: "Added allowlisted per cluster extra arguments, feature gates and admission plugins for the API server, controller manager and kubelets.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "cloudconfig",
+				Description: "Added per cluster OIDC settings including the issuer CA, replacing the installation wide OIDC configuration. Changing them rolls the master nodes.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "cloudconfig",
+				Description: "Added per cluster and installation wide API server audit policies and optionally store audit logs on a dedicated host volume of master pods.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added certificate expiry metrics and roll out rotated certificates to masters before workers.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added optional CertConfig provisioning for guest clusters, reporting a status condition while waiting for certificates.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added API server encryption key rotation requested using the encryption-key-rotation annotation of KVMConfig.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Changed VM pod containers to run with least-privilege security contexts and seccomp profiles, only the container running the VM is privileged.",
 				Kind:        versionbundle.KindChanged,
 			},
+			{
+				Component:   "kvm-operator",
+				Description: "Changed guest cluster service accounts to be bound to a role in their own namespace and removed their cluster role bindings.",
+				Kind:        versionbundle.KindChanged,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added network policies isolating guest cluster namespaces from each other. The host cluster namespaces they may talk to are labeled by the operator. Egress to addresses outside of the host cluster is only allowed in case its pod network is configured.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added resource quotas and limit ranges to guest cluster namespaces and an optional ceiling holding guest clusters which request more resources.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added quotas limiting the CPUs, memory, disk and number of guest clusters of customers. Deployments of guest clusters of customers over quota are not created or given more resources.",
+				Kind:        versionbundle.KindAdded,
+			},
+			{
+				Component:   "kvm-operator",
+				Description: "Added a host capacity check not creating or updating VM deployments whose pods do not fit on the hosts, and a metric exposing the capacity of the hosts.",
+				Kind:        versionbundle.KindAdded,
+			},
 		},
 		Components: []versionbundle.Component{
 			{
@@ -0,0 +1,31 @@
+// Package workerscontext stores and accesses the desired guest cluster workers
+// in context.Context.
+package workerscontext
+
+import (
+	"context"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+// contextKey is an unexported type for keys defined in this package. This
+// prevents collisions with keys defined in other packages.
+type contextKey string
+
+// workersKey is the key for workers values in context.Context. Clients use
+// workerscontext.NewContext and workerscontext.FromContext instead of using
+// this key directly.
+var workersKey contextKey = "workers"
+
+// NewContext returns a new context.Context that carries value v. The value
+// holds the workers computed once per reconciliation, so all resources agree
+// on the workers to be added and removed.
+func NewContext(ctx context.Context, v []key.WorkerNode) context.Context {
+	return context.WithValue(ctx, workersKey, v)
+}
+
+// FromContext returns the desired workers, if any.
+func FromContext(ctx context.Context) ([]key.WorkerNode, bool) {
+	v, ok := ctx.Value(workersKey).([]key.WorkerNode)
+	return v, ok
+}
@@ -0,0 +1,39 @@
+package workerscontext
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/pkg/apis/provider/v1alpha1"
+
+	"github.com/giantswarm/kvm-operator/service/controller/v13/key"
+)
+
+func Test_WorkersContext(t *testing.T) {
+	ctx := context.Background()
+
+	_, ok := FromContext(ctx)
+	if ok {
+		t.Fatalf("expected %#v got %#v", false, true)
+	}
+
+	expected := []key.WorkerNode{
+		{
+			Node: v1alpha1.ClusterNode{
+				ID: "gpu-0",
+			},
+			Pool: "gpu",
+		},
+	}
+
+	ctx = NewContext(ctx, expected)
+
+	workers, ok := FromContext(ctx)
+	if !ok {
+		t.Fatalf("expected %#v got %#v", true, false)
+	}
+	if !reflect.DeepEqual(workers, expected) {
+		t.Fatalf("expected %#v got %#v", expected, workers)
+	}
+}
@@ -19,6 +19,61 @@ var VersionBundleVersionGauge = prometheus.NewGaugeVec(
 	[]string{"major", "minor", "patch"},
 )
 
+var EtcdQuorumGuardDecisionCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: "etcd_quorum_guard",
+		Name:      "decisions_total",
+		Help:      "A metric counting the decisions of the etcd quorum guard labeled by the guarded action and the decision.",
+	},
+	[]string{"action", "decision"},
+)
+
+var CertsExpiryGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: "certs",
+		Name:      "expiry_timestamp_seconds",
+		Help:      "A metric exposing the expiry of guest cluster certificates as unix timestamp labeled by cluster ID and component.",
+	},
+	[]string{"cluster_id", "component"},
+)
+
+var CustomerQuotaUsageGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: "customer_quota",
+		Name:      "usage",
+		Help:      "A metric exposing the resources guest clusters of a customer are specified with in total labeled by customer ID and resource. CPUs are counted in CPUs, disk and memory in bytes.",
+	},
+	[]string{"customer_id", "resource"},
+)
+
+var CustomerQuotaLimitGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: "customer_quota",
+		Name:      "limit",
+		Help:      "A metric exposing the quota of a customer labeled by customer ID and resource. CPUs are counted in CPUs, disk and memory in bytes.",
+	},
+	[]string{"customer_id", "resource"},
+)
+
+var HostCapacityGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: "host_capacity",
+		Name:      "resources",
+		Help:      "A metric exposing the allocatable and free resources of the hosts guest cluster VMs run on labeled by role, resource and type. CPUs are counted in CPUs, memory in bytes.",
+	},
+	[]string{"role", "resource", "type"},
+)
+
 func init() {
 	prometheus.MustRegister(VersionBundleVersionGauge)
+	prometheus.MustRegister(EtcdQuorumGuardDecisionCounter)
+	prometheus.MustRegister(CertsExpiryGauge)
+	prometheus.MustRegister(CustomerQuotaUsageGauge)
+	prometheus.MustRegister(CustomerQuotaLimitGauge)
+	prometheus.MustRegister(HostCapacityGauge)
 }
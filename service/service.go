@@ -111,7 +111,46 @@ func New(config Config) (*Service, error) {
 			K8sExtClient: k8sExtClient,
 			Logger:       config.Logger,
 
+			Audit: controller.ClusterConfigAudit{
+				HostVolume: config.Viper.GetBool(config.Flag.Service.Installation.Guest.Kubernetes.API.Audit.HostVolume),
+				Policy:     config.Viper.GetString(config.Flag.Service.Installation.Guest.Kubernetes.API.Audit.Policy),
+			},
+			GuestCeiling: controller.ClusterConfigGuestCeiling{
+				CPU:    config.Viper.GetString(config.Flag.Service.Guest.Ceiling.CPU),
+				Memory: config.Viper.GetString(config.Flag.Service.Guest.Ceiling.Memory),
+			},
+			GuestCerts: controller.ClusterConfigGuestCerts{
+				Provision:            config.Viper.GetBool(config.Flag.Service.Guest.Certs.Provision),
+				TTL:                  config.Viper.GetString(config.Flag.Service.Guest.Certs.TTL),
+				VersionBundleVersion: config.Viper.GetString(config.Flag.Service.Guest.Certs.VersionBundleVersion),
+			},
+			GuestCloudConfigCompress: config.Viper.GetBool(config.Flag.Service.Guest.CloudConfig.Compress),
+			GuestCloudConfigSecret:   config.Viper.GetBool(config.Flag.Service.Guest.CloudConfig.Secret),
+			GuestCustomerQuota: controller.ClusterConfigGuestCustomerQuota{
+				ConfigMapName:      config.Viper.GetString(config.Flag.Service.Guest.CustomerQuota.ConfigMap.Name),
+				ConfigMapNamespace: config.Viper.GetString(config.Flag.Service.Guest.CustomerQuota.ConfigMap.Namespace),
+			},
+			GuestImagePrePull: controller.ClusterConfigGuestImagePrePull{
+				BaseURL:        config.Viper.GetString(config.Flag.Service.Guest.ImagePrePull.BaseURL),
+				Namespace:      config.Viper.GetString(config.Flag.Service.Guest.ImagePrePull.Namespace),
+				ServiceAccount: config.Viper.GetString(config.Flag.Service.Guest.ImagePrePull.ServiceAccount),
+			},
+			GuestNetworkPolicy: controller.ClusterConfigGuestNetworkPolicy{
+				DNSNamespace:               config.Viper.GetString(config.Flag.Service.Guest.NetworkPolicy.DNSNamespace),
+				IngressControllerNamespace: config.Viper.GetString(config.Flag.Service.Guest.NetworkPolicy.IngressControllerNamespace),
+				OperatorNamespace:          config.Viper.GetString(config.Flag.Service.Guest.NetworkPolicy.OperatorNamespace),
+				PodCIDR:                    config.Viper.GetString(config.Flag.Service.Guest.NetworkPolicy.PodCIDR),
+			},
+			GuestScaleEnabled: config.Viper.GetBool(config.Flag.Service.Guest.Scale.Enabled),
+			GuestScaleDown: controller.ClusterConfigGuestScaleDown{
+				MaxWorkers: config.Viper.GetInt(config.Flag.Service.Guest.ScaleDown.MaxWorkers),
+				Policy:     config.Viper.GetString(config.Flag.Service.Guest.ScaleDown.Policy),
+			},
+			GuestSecurity: controller.ClusterConfigGuestSecurity{
+				AppArmorProfile: config.Viper.GetString(config.Flag.Service.Guest.Security.AppArmorProfile),
+			},
 			GuestUpdateEnabled: config.Viper.GetBool(config.Flag.Service.Guest.Update.Enabled),
+			GuestUpdateSurge:   config.Viper.GetBool(config.Flag.Service.Guest.Update.Surge),
 			ProjectName:        config.Name,
 
 			OIDC: controller.ClusterConfigOIDC{
@@ -120,6 +159,11 @@ func New(config Config) (*Service, error) {
 				UsernameClaim: config.Viper.GetString(config.Flag.Service.Installation.Guest.Kubernetes.API.Auth.Provider.OIDC.UsernameClaim),
 				GroupsClaim:   config.Viper.GetString(config.Flag.Service.Installation.Guest.Kubernetes.API.Auth.Provider.OIDC.GroupsClaim),
 			},
+			Registry: controller.ClusterConfigRegistry{
+				Mirror:              config.Viper.GetString(config.Flag.Service.Registry.Mirror),
+				PullSecretName:      config.Viper.GetString(config.Flag.Service.Registry.PullSecret.Name),
+				PullSecretNamespace: config.Viper.GetString(config.Flag.Service.Registry.PullSecret.Namespace),
+			},
 		}
 
 		clusterController, err = controller.NewCluster(c)
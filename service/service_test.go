@@ -37,6 +37,15 @@ func Test_Service_New(t *testing.T) {
 				config.Name = "test"
 				config.Source = "test"
 
+				config.Viper.Set(config.Flag.Service.Guest.ImagePrePull.BaseURL, "https://stable.release.core-os.net/amd64-usr")
+				config.Viper.Set(config.Flag.Service.Guest.ImagePrePull.Namespace, "giantswarm")
+				config.Viper.Set(config.Flag.Service.Guest.ImagePrePull.ServiceAccount, "kvm-operator-image-prepull")
+				config.Viper.Set(config.Flag.Service.Guest.NetworkPolicy.DNSNamespace, "kube-system")
+				config.Viper.Set(config.Flag.Service.Guest.NetworkPolicy.IngressControllerNamespace, "kube-system")
+				config.Viper.Set(config.Flag.Service.Guest.NetworkPolicy.OperatorNamespace, "giantswarm")
+				config.Viper.Set(config.Flag.Service.Guest.NetworkPolicy.PodCIDR, "10.2.0.0/16")
+				config.Viper.Set(config.Flag.Service.Guest.ScaleDown.MaxWorkers, 1)
+				config.Viper.Set(config.Flag.Service.Guest.ScaleDown.Policy, "newest")
 				config.Viper.Set(config.Flag.Service.Kubernetes.Address, "http://127.0.0.1:6443")
 				config.Viper.Set(config.Flag.Service.Kubernetes.InCluster, "false")
 